@@ -27,18 +27,18 @@ type Account struct {
 	AccountNumberMasked string `json:"accountNumberMasked,omitempty"`
 	// Routing Transit Number is a nine-digit number assigned by the ABA
 	RoutingNumber string `json:"routingNumber,omitempty"`
-	// Status of the account being created.
+	// Lifecycle status of the account which determines what transactions can be posted against it. New accounts are pending until their opening deposit posts.
 	Status string `json:"status,omitempty"`
-	// Product type of the account
+	// Code of the account's product in the product catalog, such as checking or savings
 	Type      string    `json:"type,omitempty"`
 	CreatedAt time.Time `json:"createdAt,omitempty"`
 	ClosedAt  time.Time `json:"closedAt,omitempty"`
 	// Last time the object was modified except balances
 	LastModified time.Time `json:"lastModified,omitempty"`
-	// Total balance of account in USD cents.
-	Balance int32 `json:"balance,omitempty"`
-	// Balance available in USD cents to be drawn
-	BalanceAvailable int32 `json:"balanceAvailable,omitempty"`
-	// Balance of pending transactions in USD cents
-	BalancePending int32 `json:"balancePending,omitempty"`
+	// Total balance of posted transactions in USD cents.
+	Balance int64 `json:"balance,omitempty"`
+	// Balance available in USD cents to be drawn. This is the total balance less any funds held for pending debits.
+	BalanceAvailable int64 `json:"balanceAvailable,omitempty"`
+	// Net change in USD cents to the total balance once every pending hold is captured. Held debits are negative.
+	BalancePending int64 `json:"balancePending,omitempty"`
 }
@@ -0,0 +1,22 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+// AccountBalanceAsOf struct for AccountBalanceAsOf
+type AccountBalanceAsOf struct {
+	AccountID string `json:"accountID,omitempty"`
+	// Transactions effective before this moment are included
+	AsOf    time.Time `json:"asOf,omitempty"`
+	Balance int64     `json:"balance,omitempty"`
+}
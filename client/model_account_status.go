@@ -0,0 +1,24 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// AccountStatus Lifecycle status of an account which determines what transactions can be posted against it.
+type AccountStatus string
+
+// List of AccountStatus
+const (
+	ACCOUNTSTATUS_PENDING           AccountStatus = "pending"
+	ACCOUNTSTATUS_OPEN              AccountStatus = "open"
+	ACCOUNTSTATUS_FROZEN            AccountStatus = "frozen"
+	ACCOUNTSTATUS_DEBIT_RESTRICTED  AccountStatus = "debit-restricted"
+	ACCOUNTSTATUS_CREDIT_RESTRICTED AccountStatus = "credit-restricted"
+	ACCOUNTSTATUS_DORMANT           AccountStatus = "dormant"
+	ACCOUNTSTATUS_CLOSED            AccountStatus = "closed"
+)
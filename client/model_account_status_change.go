@@ -0,0 +1,27 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+// AccountStatusChange struct for AccountStatusChange
+type AccountStatusChange struct {
+	// Account ID
+	AccountID string        `json:"accountID,omitempty"`
+	Previous  AccountStatus `json:"previous,omitempty"`
+	Status    AccountStatus `json:"status,omitempty"`
+	// Reason for the status change
+	ReasonCode string `json:"reasonCode,omitempty"`
+	// X-User-ID which made the change
+	Actor     string    `json:"actor,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+}
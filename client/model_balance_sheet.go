@@ -0,0 +1,23 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// BalanceSheet struct for BalanceSheet
+type BalanceSheet struct {
+	RoutingNumber string                    `json:"routingNumber,omitempty"`
+	Date          string                    `json:"date,omitempty"`
+	Assets        FinancialStatementSection `json:"assets,omitempty"`
+	Liabilities   FinancialStatementSection `json:"liabilities,omitempty"`
+	Equity        FinancialStatementSection `json:"equity,omitempty"`
+	// Income less expenses which haven't been closed into equity
+	UndistributedIncome int64 `json:"undistributedIncome,omitempty"`
+	// True when assets equal liabilities, equity and undistributed income
+	Balanced bool `json:"balanced,omitempty"`
+}
@@ -0,0 +1,16 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// CaptureHold struct for CaptureHold
+type CaptureHold struct {
+	// Amount to capture in USD cents. Zero or omitted captures the remaining funds of the hold.
+	Amount int64 `json:"amount,omitempty"`
+}
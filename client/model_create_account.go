@@ -13,12 +13,12 @@ package openapi
 type CreateAccount struct {
 	// Customer ID associated with accounts
 	CustomerID string `json:"customerID"`
-	// Initial balance of account in USD cents. This amount is to be deposited from an account at another Financial Institution or in-person (i.e. cash) on account creation.
-	Balance int32 `json:"balance"`
+	// Initial balance of account in USD cents. This amount is to be deposited from an account at another Financial Institution or in-person (i.e. cash) on account creation. It must be at least the minimum opening deposit of the account's product.
+	Balance int64 `json:"balance"`
 	// Caller defined label for this account.
 	Name string `json:"name"`
 	// Random number to be used as unique to distinguish this Account
 	Number string `json:"number,omitempty"`
-	// Product type of the account
+	// Code of the account's product in the product catalog, such as checking or savings
 	Type string `json:"type"`
 }
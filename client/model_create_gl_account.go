@@ -0,0 +1,23 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// CreateGLAccount struct for CreateGLAccount
+type CreateGLAccount struct {
+	// Numeric GL code, call report codes such as RCON2365 are read as their numeric suffix
+	Code string `json:"code"`
+	// Name of the GL account, up to 100 characters. Defaults to the description of call report codes.
+	Name     string     `json:"name,omitempty"`
+	Category GLCategory `json:"category,omitempty"`
+	// Code of an existing GL account in the same category to roll up into
+	ParentCode string `json:"parentCode,omitempty"`
+	// Type of customer account whose balances roll up into this liability account
+	AccountType string `json:"accountType,omitempty"`
+}
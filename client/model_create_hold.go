@@ -0,0 +1,27 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+// CreateHold struct for CreateHold
+type CreateHold struct {
+	// Account ID credited when the hold is captured
+	CreditAccountID string `json:"creditAccountID"`
+	// Purpose of the credited transaction line, defaults to transfer
+	Purpose string `json:"purpose,omitempty"`
+	// Amount to hold in USD cents
+	Amount      int64  `json:"amount"`
+	Description string `json:"description,omitempty"`
+	// When the hold expires, defaults to seven days after it's placed
+	ExpiresAt time.Time `json:"expiresAt,omitempty"`
+}
@@ -0,0 +1,24 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+// CreateReversal struct for CreateReversal
+type CreateReversal struct {
+	// Reverse this much of each line. Only transactions with exactly two lines can be reversed by amount.
+	Amount int64 `json:"amount,omitempty"`
+	// Reverse part or all of specific lines.
+	Lines []ReversalLine `json:"lines,omitempty"`
+	// Backdate the reversal (e.g. an ACH return). Defaults to when it's posted and can't be before the original transaction's effectiveDate.
+	EffectiveDate time.Time `json:"effectiveDate,omitempty"`
+}
@@ -9,7 +9,13 @@
 
 package openapi
 
+import (
+	"time"
+)
+
 // CreateTransaction struct for CreateTransaction
 type CreateTransaction struct {
 	Lines []TransactionLine `json:"lines,omitempty"`
+	// When the transaction takes value. Defaults to when it's posted, and can be backdated but not in the future.
+	EffectiveDate time.Time `json:"effectiveDate,omitempty"`
 }
@@ -0,0 +1,23 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// DailyBalance struct for DailyBalance
+type DailyBalance struct {
+	Date string `json:"date,omitempty"`
+	// Balance at the start of the day
+	Opening int64 `json:"opening,omitempty"`
+	// Balance at the end of the day
+	Closing int64 `json:"closing,omitempty"`
+	// Lowest balance during the day
+	Min int64 `json:"min,omitempty"`
+	// Highest balance during the day
+	Max int64 `json:"max,omitempty"`
+}
@@ -0,0 +1,19 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// FinancialStatementEntry struct for FinancialStatementEntry
+type FinancialStatementEntry struct {
+	Code       string `json:"code,omitempty"`
+	Name       string `json:"name,omitempty"`
+	ParentCode string `json:"parentCode,omitempty"`
+	// Balance including every account below this one, in the normal direction of the category
+	Balance int64 `json:"balance,omitempty"`
+}
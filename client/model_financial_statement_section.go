@@ -0,0 +1,18 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// FinancialStatementSection struct for FinancialStatementSection
+type FinancialStatementSection struct {
+	Category GLCategory                `json:"category,omitempty"`
+	Accounts []FinancialStatementEntry `json:"accounts,omitempty"`
+	// Sum of the accounts which don't roll up into another account
+	Total int64 `json:"total,omitempty"`
+}
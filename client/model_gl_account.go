@@ -0,0 +1,33 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+// GLAccount struct for GLAccount
+type GLAccount struct {
+	// ID transactions are posted against, the routing number and code joined by a dash
+	Id            string `json:"id,omitempty"`
+	RoutingNumber string `json:"routingNumber,omitempty"`
+	// Numeric GL code
+	Code     string     `json:"code,omitempty"`
+	Name     string     `json:"name,omitempty"`
+	Category GLCategory `json:"category,omitempty"`
+	// Code of the GL account this account rolls up into
+	ParentCode string `json:"parentCode,omitempty"`
+	// Type of customer account whose balances roll up into this liability account
+	AccountType string `json:"accountType,omitempty"`
+	// Balance of this account, every account below it and any customer accounts which roll up into it (in USD cents). Reported in the normal direction of the category so debits increase asset and expense balances.
+	Balance      int64     `json:"balance,omitempty"`
+	CreatedAt    time.Time `json:"createdAt,omitempty"`
+	LastModified time.Time `json:"lastModified,omitempty"`
+}
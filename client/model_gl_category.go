@@ -0,0 +1,22 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// GLCategory Section of the chart of accounts. Assets and expenses are increased by debits, liabilities, equity and income by credits.
+type GLCategory string
+
+// List of GLCategory
+const (
+	GLCATEGORY_ASSET     GLCategory = "asset"
+	GLCATEGORY_LIABILITY GLCategory = "liability"
+	GLCATEGORY_EQUITY    GLCategory = "equity"
+	GLCATEGORY_INCOME    GLCategory = "income"
+	GLCATEGORY_EXPENSE   GLCategory = "expense"
+)
@@ -0,0 +1,34 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+// Hold struct for Hold
+type Hold struct {
+	// Hold ID
+	ID string `json:"ID,omitempty"`
+	// Account ID the funds are held in
+	AccountID string `json:"accountID,omitempty"`
+	// Account ID credited when the hold is captured
+	CreditAccountID string `json:"creditAccountID,omitempty"`
+	Purpose         string `json:"purpose,omitempty"`
+	// Amount held in USD cents
+	Amount int64 `json:"amount,omitempty"`
+	// Amount captured so far in USD cents
+	CapturedAmount int64      `json:"capturedAmount,omitempty"`
+	Status         HoldStatus `json:"status,omitempty"`
+	Description    string     `json:"description,omitempty"`
+	ExpiresAt      time.Time  `json:"expiresAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt,omitempty"`
+	LastModified   time.Time  `json:"lastModified,omitempty"`
+}
@@ -0,0 +1,21 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// HoldStatus Status of an authorization hold. Expired holds no longer reduce the available balance.
+type HoldStatus string
+
+// List of HoldStatus
+const (
+	HOLDSTATUS_PENDING  HoldStatus = "pending"
+	HOLDSTATUS_CAPTURED HoldStatus = "captured"
+	HOLDSTATUS_RELEASED HoldStatus = "released"
+	HOLDSTATUS_EXPIRED  HoldStatus = "expired"
+)
@@ -0,0 +1,20 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// IncomeStatement struct for IncomeStatement
+type IncomeStatement struct {
+	RoutingNumber string                    `json:"routingNumber,omitempty"`
+	StartDate     string                    `json:"startDate,omitempty"`
+	EndDate       string                    `json:"endDate,omitempty"`
+	Income        FinancialStatementSection `json:"income,omitempty"`
+	Expenses      FinancialStatementSection `json:"expenses,omitempty"`
+	NetIncome     int64                     `json:"netIncome,omitempty"`
+}
@@ -0,0 +1,28 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+// OverdraftLine struct for OverdraftLine
+type OverdraftLine struct {
+	AccountID string `json:"accountID,omitempty"`
+	// How far below zero the available balance can go in USD cents
+	Limit     int64     `json:"limit,omitempty"`
+	OptedInAt time.Time `json:"optedInAt,omitempty"`
+	// Reference to the overdraft disclosure the customer accepted
+	Disclosure string `json:"disclosure,omitempty"`
+	// Set once the overdraft line is revoked
+	RevokedAt    time.Time `json:"revokedAt,omitempty"`
+	CreatedAt    time.Time `json:"createdAt,omitempty"`
+	LastModified time.Time `json:"lastModified,omitempty"`
+}
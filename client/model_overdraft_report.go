@@ -0,0 +1,23 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+// OverdraftReport struct for OverdraftReport
+type OverdraftReport struct {
+	AsOf          time.Time `json:"asOf,omitempty"`
+	ChargeOffDays int32     `json:"chargeOffDays,omitempty"`
+	// Sum of the negative balances in USD cents
+	TotalOverdrawn int64              `json:"totalOverdrawn,omitempty"`
+	Accounts       []OverdrawnAccount `json:"accounts,omitempty"`
+}
@@ -0,0 +1,28 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+// OverdrawnAccount struct for OverdrawnAccount
+type OverdrawnAccount struct {
+	AccountID string `json:"accountID,omitempty"`
+	Balance   int64  `json:"balance,omitempty"`
+	// Limit of the Account's active overdraft line, zero if it doesn't have one
+	Limit int64 `json:"limit,omitempty"`
+	// Balance is below the negative of the limit
+	OverLimit bool `json:"overLimit,omitempty"`
+	// When the balance last went negative
+	OverdrawnSince     time.Time `json:"overdrawnSince,omitempty"`
+	DaysOverdrawn      int32     `json:"daysOverdrawn,omitempty"`
+	ChargeOffCandidate bool      `json:"chargeOffCandidate,omitempty"`
+}
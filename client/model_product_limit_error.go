@@ -0,0 +1,18 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// ProductLimitError struct for ProductLimitError
+type ProductLimitError struct {
+	// An error message describing the problem intended for humans.
+	Error string `json:"error"`
+	// Which rule of the account's product rejected the transaction
+	Code string `json:"code,omitempty"`
+}
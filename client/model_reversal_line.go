@@ -0,0 +1,18 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// ReversalLine struct for ReversalLine
+type ReversalLine struct {
+	// Account ID of the line to reverse
+	AccountID string `json:"accountID,omitempty"`
+	// Amount of the line to reverse, zero reverses what remains of the line
+	Amount int64 `json:"amount,omitempty"`
+}
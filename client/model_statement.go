@@ -0,0 +1,35 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+// Statement struct for Statement
+type Statement struct {
+	Id          string `json:"id,omitempty"`
+	AccountID   string `json:"accountID,omitempty"`
+	AccountName string `json:"accountName,omitempty"`
+	// Account number with all but the last four digits hidden
+	MaskedAccountNumber string `json:"maskedAccountNumber,omitempty"`
+	RoutingNumber       string `json:"routingNumber,omitempty"`
+	// Month of the statement
+	Period    string `json:"period,omitempty"`
+	StartDate string `json:"startDate,omitempty"`
+	EndDate   string `json:"endDate,omitempty"`
+	// Closing balance of the previous statement, or the balance at startDate on an account's first statement
+	OpeningBalance int64            `json:"openingBalance,omitempty"`
+	ClosingBalance int64            `json:"closingBalance,omitempty"`
+	Summary        StatementSummary `json:"summary,omitempty"`
+	// Lines effective during the period, and lines backdated into earlier statements after they were generated
+	Lines     []StatementLine `json:"lines,omitempty"`
+	CreatedAt time.Time       `json:"createdAt,omitempty"`
+}
@@ -0,0 +1,22 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// StatementLine struct for StatementLine
+type StatementLine struct {
+	TransactionID string `json:"transactionID,omitempty"`
+	// Effective date of the transaction
+	Date      string `json:"date,omitempty"`
+	Purpose   string `json:"purpose,omitempty"`
+	Direction string `json:"direction,omitempty"`
+	Amount    int64  `json:"amount,omitempty"`
+	// Balance of the account after this line
+	RunningBalance int64 `json:"runningBalance,omitempty"`
+}
@@ -0,0 +1,20 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// StatementSummary struct for StatementSummary
+type StatementSummary struct {
+	Credits int64 `json:"credits,omitempty"`
+	Debits  int64 `json:"debits,omitempty"`
+	// Fees charged less fees refunded
+	Fees int64 `json:"fees,omitempty"`
+	// Interest earned less interest reversed
+	Interest int64 `json:"interest,omitempty"`
+}
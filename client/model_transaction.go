@@ -16,7 +16,16 @@ import (
 // Transaction struct for Transaction
 type Transaction struct {
 	// Unique ID of a transaction
-	ID        string            `json:"ID,omitempty"`
-	Timestamp time.Time         `json:"timestamp,omitempty"`
-	Lines     []TransactionLine `json:"lines,omitempty"`
+	ID string `json:"ID,omitempty"`
+	// When the transaction was posted
+	Timestamp time.Time `json:"timestamp,omitempty"`
+	// When the transaction takes value. Balances as of a date include transactions effective before it.
+	EffectiveDate time.Time         `json:"effectiveDate,omitempty"`
+	Lines         []TransactionLine `json:"lines,omitempty"`
+	// Shows if the transaction has been reversed
+	Status string `json:"status,omitempty"`
+	// ID of the transaction this transaction reverses
+	ReversalOf string `json:"reversalOf,omitempty"`
+	// ID of the customer transaction this GL journal entry was posted for. Journal entries are reversed along with their customer transaction.
+	JournalOf string `json:"journalOf,omitempty"`
 }
@@ -14,6 +14,8 @@ type TransactionLine struct {
 	// Account ID
 	AccountID string `json:"accountID,omitempty"`
 	Purpose   string `json:"purpose,omitempty"`
+	// Debits decrease the account balance and credits increase it. Defaults to debit for ACHDebit lines and credit for every other purpose.
+	Direction string `json:"direction,omitempty"`
 	// Change in account balance (in USD cents)
-	Amount float32 `json:"amount,omitempty"`
+	Amount int64 `json:"amount,omitempty"`
 }
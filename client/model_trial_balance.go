@@ -0,0 +1,21 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// TrialBalance struct for TrialBalance
+type TrialBalance struct {
+	RoutingNumber string                `json:"routingNumber,omitempty"`
+	Date          string                `json:"date,omitempty"`
+	Accounts      []TrialBalanceAccount `json:"accounts,omitempty"`
+	TotalDebits   int64                 `json:"totalDebits,omitempty"`
+	TotalCredits  int64                 `json:"totalCredits,omitempty"`
+	// True when total debits equal total credits
+	Balanced bool `json:"balanced,omitempty"`
+}
@@ -0,0 +1,19 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// TrialBalanceAccount struct for TrialBalanceAccount
+type TrialBalanceAccount struct {
+	Code     string     `json:"code,omitempty"`
+	Name     string     `json:"name,omitempty"`
+	Category GLCategory `json:"category,omitempty"`
+	Debit    int64      `json:"debit,omitempty"`
+	Credit   int64      `json:"credit,omitempty"`
+}
@@ -0,0 +1,16 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// UpdateAccount struct for UpdateAccount
+type UpdateAccount struct {
+	// Caller defined label for this account.
+	Name string `json:"name"`
+}
@@ -0,0 +1,19 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+// UpdateGLAccount struct for UpdateGLAccount
+type UpdateGLAccount struct {
+	Name string `json:"name,omitempty"`
+	// Code of the GL account to roll up into, an empty string removes the parent
+	ParentCode string `json:"parentCode,omitempty"`
+	// Type of customer account whose balances roll up into this account, an empty string removes it
+	AccountType string `json:"accountType,omitempty"`
+}
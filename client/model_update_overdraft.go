@@ -0,0 +1,24 @@
+/*
+ * Accounts API
+ *
+ * Moov Accounts is an HTTP service which represents both a general ledger and chart of accounts for customers. The service is designed to abstract over various core systems and provide a uniform API for developers.
+ *
+ * API version: 1.0.0
+ * Generated by: OpenAPI Generator (https://openapi-generator.tech)
+ */
+
+package openapi
+
+import (
+	"time"
+)
+
+// UpdateOverdraft struct for UpdateOverdraft
+type UpdateOverdraft struct {
+	// How far below zero the available balance can go in USD cents
+	Limit int64 `json:"limit"`
+	// When the customer opted in, defaults to now
+	OptedInAt time.Time `json:"optedInAt,omitempty"`
+	// Reference to the overdraft disclosure the customer accepted
+	Disclosure string `json:"disclosure"`
+}
@@ -0,0 +1,147 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+// glcodegen reads a CSV catalog of call report GL codes and writes them as typed Go constants.
+//
+// The catalog has a header row followed by rows of the MDRM code (e.g. RCON2200), the GL category
+// (asset, liability, equity, income or expense) and a description. It's run with go generate from cmd/server.
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+var (
+	flagCatalog = flag.String("catalog", "gl_codes.csv", "CSV catalog of GL codes to read")
+	flagOutput  = flag.String("output", "gl_codes_generated.go", "Go file to write")
+	flagPackage = flag.String("package", "main", "Package name of the generated file")
+)
+
+func main() {
+	flag.Parse()
+
+	fd, err := os.Open(*flagCatalog)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "glcodegen: %v\n", err)
+		os.Exit(1)
+	}
+	defer fd.Close()
+
+	src, err := generate(fd, *flagPackage)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "glcodegen: %s: %v\n", *flagCatalog, err)
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile(*flagOutput, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "glcodegen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+type glCode struct {
+	MDRM        string
+	Category    string
+	Description string
+}
+
+var (
+	mdrmRegex = regexp.MustCompile(`^[A-Z]{4}[0-9]{4}$`)
+
+	// categories maps each GL category to the constant it's declared as in cmd/server
+	categories = map[string]string{
+		"asset":     "GLAsset",
+		"liability": "GLLiability",
+		"equity":    "GLEquity",
+		"income":    "GLIncome",
+		"expense":   "GLExpense",
+	}
+)
+
+// readCatalog parses and validates the GL codes of a catalog in the order they're listed
+func readCatalog(r io.Reader) ([]glCode, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("no GL codes found")
+	}
+	var codes []glCode
+	seen := make(map[string]bool)
+	for i, record := range records[1:] {
+		if len(record) != 3 {
+			return nil, fmt.Errorf("line %d: expected mdrm, category and description", i+2)
+		}
+		code := glCode{
+			MDRM:        strings.ToUpper(strings.TrimSpace(record[0])),
+			Category:    strings.ToLower(strings.TrimSpace(record[1])),
+			Description: strings.TrimSpace(record[2]),
+		}
+		if !mdrmRegex.MatchString(code.MDRM) {
+			return nil, fmt.Errorf("line %d: invalid MDRM code %q", i+2, record[0])
+		}
+		if seen[code.MDRM] {
+			return nil, fmt.Errorf("line %d: duplicate MDRM code %s", i+2, code.MDRM)
+		}
+		seen[code.MDRM] = true
+		if _, exists := categories[code.Category]; !exists {
+			return nil, fmt.Errorf("line %d: unknown category %q", i+2, record[1])
+		}
+		if code.Description == "" {
+			return nil, fmt.Errorf("line %d: %s has no description", i+2, code.MDRM)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+var output = template.Must(template.New("gl_codes").Funcs(template.FuncMap{
+	"category": func(c string) string { return categories[c] },
+}).Parse(`// Code generated by glcodegen from gl_codes.csv. DO NOT EDIT.
+
+package {{ .Package }}
+
+const (
+{{- range .Codes }}
+	// {{ .MDRM }} is {{ .Description }}
+	{{ .MDRM }} GLCode = "{{ .MDRM }}"
+{{- end }}
+)
+
+var glCodes = map[GLCode]glCodeInfo{
+{{- range .Codes }}
+	{{ .MDRM }}: {Description: {{ printf "%q" .Description }}, Category: {{ category .Category }}},
+{{- end }}
+}
+`))
+
+// generate returns the formatted Go source declaring each GL code in the catalog
+func generate(r io.Reader, pkg string) ([]byte, error) {
+	codes, err := readCatalog(r)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = output.Execute(&buf, struct {
+		Package string
+		Codes   []glCode
+	}{
+		Package: pkg,
+		Codes:   codes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
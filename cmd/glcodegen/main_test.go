@@ -0,0 +1,65 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGenerate__stale fails when cmd/server/gl_codes.csv has changed without running go generate
+func TestGenerate__stale(t *testing.T) {
+	fd, err := os.Open(filepath.Join("..", "server", "gl_codes.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+
+	expected, err := generate(fd, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := ioutil.ReadFile(filepath.Join("..", "server", "gl_codes_generated.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expected, found) {
+		t.Error("cmd/server/gl_codes_generated.go is stale, run 'go generate ./cmd/server/'")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	src, err := generate(strings.NewReader("mdrm,category,description\nrcon2200 , Liability,\"Deposits, domestic\"\n"), "reports")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"package reports",
+		`RCON2200 GLCode = "RCON2200"`,
+		`RCON2200: {Description: "Deposits, domestic", Category: GLLiability},`,
+	} {
+		if !bytes.Contains(src, []byte(expected)) {
+			t.Errorf("missing %q in:\n%s", expected, src)
+		}
+	}
+
+	bad := []string{
+		"mdrm,category,description\n",
+		"mdrm,category,description\nRCON2200,liability\n",
+		"mdrm,category,description\n2200,liability,Deposits\n",
+		"mdrm,category,description\nRCON2200,liability,Deposits\nRCON2200,liability,Deposits\n",
+		"mdrm,category,description\nRCON2200,debt,Deposits\n",
+		"mdrm,category,description\nRCON2200,liability,\n",
+	}
+	for i := range bad {
+		if _, err := generate(strings.NewReader(bad[i]), "main"); err == nil {
+			t.Errorf("#%d expected error", i)
+		}
+	}
+}
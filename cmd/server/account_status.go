@@ -0,0 +1,218 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	moovhttp "github.com/moov-io/base/http"
+
+	"github.com/go-kit/kit/log"
+)
+
+// AccountStatus represents where an account is in its lifecycle. Each status determines
+// which transactionLines can be posted against the account.
+type AccountStatus string
+
+var (
+	AccountPending          AccountStatus = "pending"
+	AccountOpen             AccountStatus = "open"
+	AccountFrozen           AccountStatus = "frozen"
+	AccountDebitRestricted  AccountStatus = "debit-restricted"
+	AccountCreditRestricted AccountStatus = "credit-restricted"
+	AccountDormant          AccountStatus = "dormant"
+	AccountClosed           AccountStatus = "closed"
+
+	// accountStatusTransitions lists the statuses an account can move into from its current status.
+	accountStatusTransitions = map[AccountStatus][]AccountStatus{
+		AccountPending:          {AccountOpen, AccountClosed},
+		AccountOpen:             {AccountFrozen, AccountDebitRestricted, AccountCreditRestricted, AccountDormant, AccountClosed},
+		AccountFrozen:           {AccountOpen, AccountDebitRestricted, AccountCreditRestricted},
+		AccountDebitRestricted:  {AccountOpen, AccountFrozen, AccountClosed},
+		AccountCreditRestricted: {AccountOpen, AccountFrozen, AccountClosed},
+		AccountDormant:          {AccountOpen, AccountFrozen, AccountClosed},
+		AccountClosed:           {},
+	}
+)
+
+func (s *AccountStatus) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	*s = AccountStatus(strings.ToLower(str))
+	if err := s.validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s AccountStatus) validate() error {
+	if _, exists := accountStatusTransitions[s]; !exists {
+		return fmt.Errorf("unknown AccountStatus %q", s)
+	}
+	return nil
+}
+
+// readAccountStatus normalizes the status stored on an account. Accounts created before
+// statuses were enforced have an empty status and are treated as open.
+func readAccountStatus(status string) AccountStatus {
+	if status = strings.ToLower(strings.TrimSpace(status)); status == "" {
+		return AccountOpen
+	}
+	return AccountStatus(status)
+}
+
+func (s AccountStatus) canTransitionTo(next AccountStatus) bool {
+	for _, allowed := range accountStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsLine returns an error if the transactionLine can't be posted against an account in this status.
+func (s AccountStatus) allowsLine(line transactionLine, opts createTransactionOpts) error {
+	debit := line.isDebit()
+	switch s {
+	case AccountOpen:
+		return nil
+	case AccountPending:
+		if opts.InitialDeposit && !debit {
+			return nil
+		}
+	case AccountDebitRestricted, AccountDormant:
+		if !debit {
+			return nil
+		}
+	case AccountCreditRestricted:
+		if debit {
+			return nil
+		}
+	}
+	if debit {
+		return fmt.Errorf("account=%q is %s and can't be debited", line.AccountID, s)
+	}
+	return fmt.Errorf("account=%q is %s and can't be credited", line.AccountID, s)
+}
+
+// accountStatusChange is an audit record of an account moving from one status to another.
+type accountStatusChange struct {
+	AccountID  string        `json:"accountId"`
+	Previous   AccountStatus `json:"previous"`
+	Status     AccountStatus `json:"status"`
+	ReasonCode string        `json:"reasonCode"`
+	Actor      string        `json:"actor"`
+	CreatedAt  time.Time     `json:"createdAt"`
+}
+
+type updateAccountStatusRequest struct {
+	Status     AccountStatus `json:"status"`
+	ReasonCode string        `json:"reasonCode"`
+}
+
+func (r updateAccountStatusRequest) validate() error {
+	if err := r.Status.validate(); err != nil {
+		return fmt.Errorf("updateAccountStatusRequest: %w", err)
+	}
+	if code := strings.TrimSpace(r.ReasonCode); code == "" {
+		return errors.New("updateAccountStatusRequest: missing ReasonCode")
+	} else if len(code) > 40 {
+		return errors.New("updateAccountStatusRequest: ReasonCode is longer than 40 characters")
+	}
+	return nil
+}
+
+func updateAccountStatus(logger log.Logger, repo accountRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		accountID, requestID := getAccountID(w, r), moovhttp.GetRequestID(r)
+		if accountID == "" {
+			return
+		}
+
+		var req updateAccountStatusRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.Log("accounts", fmt.Sprintf("error reading JSON request: %v", err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		if err := req.validate(); err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		account, err := readAccount(repo, accountID)
+		if err != nil {
+			logger.Log("accounts", fmt.Sprintf("error reading account=%s: %v", accountID, err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		if account == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		change := accountStatusChange{
+			AccountID:  accountID,
+			Previous:   readAccountStatus(account.Status),
+			Status:     req.Status,
+			ReasonCode: strings.TrimSpace(req.ReasonCode),
+			Actor:      moovhttp.GetUserID(r),
+			CreatedAt:  time.Now(),
+		}
+		if err := repo.UpdateAccountStatus(change); err != nil {
+			logger.Log("accounts", fmt.Sprintf("error updating account=%s status: %v", accountID, err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		logger.Log("accounts", fmt.Sprintf("account=%s moved from %s to %s reason=%s", accountID, change.Previous, change.Status, change.ReasonCode), "requestID", requestID)
+
+		account, err = readAccount(repo, accountID)
+		if err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(account)
+	}
+}
+
+func getAccountStatusHistory(logger log.Logger, repo accountRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		accountID := getAccountID(w, r)
+		if accountID == "" {
+			return
+		}
+
+		changes, err := repo.GetAccountStatusHistory(accountID)
+		if err != nil {
+			logger.Log("accounts", fmt.Sprintf("error reading account=%s status history: %v", accountID, err), "requestID", moovhttp.GetRequestID(r))
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(changes)
+	}
+}
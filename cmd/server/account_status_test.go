@@ -0,0 +1,159 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+func TestAccountStatus(t *testing.T) {
+	if err := AccountStatus("").validate(); err == nil {
+		t.Error("expected error")
+	}
+	if err := AccountStatus("other").validate(); err == nil {
+		t.Error("expected error")
+	}
+
+	var status AccountStatus
+	if err := json.Unmarshal([]byte(`"Debit-Restricted"`), &status); err != nil {
+		t.Fatal(err)
+	}
+	if status != AccountDebitRestricted {
+		t.Errorf("unexpected status: %s", status)
+	}
+	if err := json.Unmarshal([]byte(`"other"`), &status); err == nil {
+		t.Error("expected error")
+	}
+
+	if s := readAccountStatus(""); s != AccountOpen {
+		t.Errorf("unexpected status: %s", s)
+	}
+	if s := readAccountStatus("Frozen"); s != AccountFrozen {
+		t.Errorf("unexpected status: %s", s)
+	}
+}
+
+func TestAccountStatus__transitions(t *testing.T) {
+	if !AccountOpen.canTransitionTo(AccountFrozen) {
+		t.Error("expected open -> frozen")
+	}
+	if !AccountPending.canTransitionTo(AccountOpen) {
+		t.Error("expected pending -> open")
+	}
+	if AccountFrozen.canTransitionTo(AccountClosed) {
+		t.Error("frozen accounts can't be closed")
+	}
+	for status := range accountStatusTransitions {
+		if AccountClosed.canTransitionTo(status) {
+			t.Errorf("closed accounts can't move to %s", status)
+		}
+	}
+}
+
+func TestAccountStatus__allowsLine(t *testing.T) {
+	debit := transactionLine{AccountID: "a", Purpose: ACHDebit, Direction: Debit, Amount: 100}
+	credit := transactionLine{AccountID: "a", Purpose: ACHCredit, Direction: Credit, Amount: 100}
+
+	cases := []struct {
+		status         AccountStatus
+		debit, credit  bool
+		initialDeposit bool
+	}{
+		{AccountOpen, true, true, true},
+		{AccountPending, false, false, true},
+		{AccountFrozen, false, false, false},
+		{AccountDebitRestricted, false, true, true},
+		{AccountCreditRestricted, true, false, false},
+		{AccountDormant, false, true, true},
+		{AccountClosed, false, false, false},
+	}
+	for i := range cases {
+		if err := cases[i].status.allowsLine(debit, createTransactionOpts{}); (err == nil) != cases[i].debit {
+			t.Errorf("%s debit: %v", cases[i].status, err)
+		}
+		if err := cases[i].status.allowsLine(credit, createTransactionOpts{}); (err == nil) != cases[i].credit {
+			t.Errorf("%s credit: %v", cases[i].status, err)
+		}
+		if err := cases[i].status.allowsLine(credit, createTransactionOpts{InitialDeposit: true}); (err == nil) != cases[i].initialDeposit {
+			t.Errorf("%s initial deposit: %v", cases[i].status, err)
+		}
+	}
+}
+
+func TestAccountStatus__updateAccountStatus(t *testing.T) {
+	accountID := base.ID()
+	accountRepo := &testAccountRepository{
+		accounts: []*accounts.Account{
+			{ID: accountID, Status: string(AccountOpen)},
+		},
+	}
+
+	router := mux.NewRouter()
+	addAccountRoutes(log.NewNopLogger(), router, accountRepo, &mockTransactionRepository{}, mockProductRepo)
+
+	body := strings.NewReader(`{"status": "frozen", "reasonCode": "investigation"}`)
+	req := httptest.NewRequest("PUT", fmt.Sprintf("/accounts/%s/status", accountID), body)
+	req.Header.Set("x-user-id", "compliance")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusOK {
+		t.Errorf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	if s := accountRepo.accounts[0].Status; s != string(AccountFrozen) {
+		t.Errorf("unexpected status: %s", s)
+	}
+	if len(accountRepo.statusChanges) != 1 {
+		t.Fatalf("unexpected changes: %#v", accountRepo.statusChanges)
+	}
+	if change := accountRepo.statusChanges[0]; change.Actor != "compliance" || change.Previous != AccountOpen {
+		t.Errorf("unexpected change: %#v", change)
+	}
+
+	// missing reason code
+	body = strings.NewReader(`{"status": "open"}`)
+	req = httptest.NewRequest("PUT", fmt.Sprintf("/accounts/%s/status", accountID), body)
+	req.Header.Set("x-user-id", "compliance")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bogus status code: %d", w.Code)
+	}
+
+	// read the history
+	req = httptest.NewRequest("GET", fmt.Sprintf("/accounts/%s/status/history", accountID), nil)
+	req.Header.Set("x-user-id", "compliance")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusOK {
+		t.Errorf("bogus status code: %d", w.Code)
+	}
+	var changes []accountStatusChange
+	if err := json.NewDecoder(w.Body).Decode(&changes); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Status != AccountFrozen {
+		t.Errorf("unexpected changes: %#v", changes)
+	}
+}
@@ -5,6 +5,8 @@
 package main
 
 import (
+	"time"
+
 	accounts "github.com/moov-io/accounts/client"
 )
 
@@ -13,8 +15,49 @@ type accountRepository interface {
 	Close() error
 
 	GetAccounts(accountIDs []string) ([]*accounts.Account, error)
+	// ListAccounts returns up to limit accounts created before the given time, including closed accounts,
+	// ordered by their ID. Only accounts with an ID after the given one are returned, so the last ID of
+	// each page reads the next.
+	ListAccounts(createdBefore time.Time, after string, limit int) ([]*accounts.Account, error)
 	CreateAccount(customerID string, account *accounts.Account) error // TODO(adam): acctType needs strong type, we can drop customerID as it's on accounts.Account
 
+	// GetAccountTypes returns the lowercase type of every account at routingNumber, including closed accounts,
+	// keyed by account ID. Accounts can be stored in another database than transactions, so ledger reports read
+	// them from here instead of joining tables.
+	GetAccountTypes(routingNumber string) (map[string]string, error)
+
 	SearchAccountsByCustomerID(customerID string) ([]*accounts.Account, error)
 	SearchAccountsByRoutingNumber(accountNumber, routingNumber, acctType string) (*accounts.Account, error)
+
+	RenameAccount(accountID string, name string) error
+
+	// UpdateAccountStatus moves an account into a new status and records the change. Accounts
+	// with a non-zero balance cannot be closed and errAccountHasBalance is returned instead, while
+	// accounts with active holds return errAccountHasHolds.
+	UpdateAccountStatus(change accountStatusChange) error
+	GetAccountStatusHistory(accountID string) ([]accountStatusChange, error)
+}
+
+// listAccountsPageSize is how many accounts forEachAccount reads at a time
+const listAccountsPageSize = 500
+
+// forEachAccount calls fn with every account created before the given time, reading them a page at a time
+// from repo. Iterating stops at the first error fn returns.
+func forEachAccount(repo accountRepository, createdBefore time.Time, fn func(acct *accounts.Account) error) error {
+	after := ""
+	for {
+		page, err := repo.ListAccounts(createdBefore, after, listAccountsPageSize)
+		if err != nil {
+			return err
+		}
+		for i := range page {
+			if err := fn(page[i]); err != nil {
+				return err
+			}
+		}
+		if len(page) < listAccountsPageSize {
+			return nil
+		}
+		after = page[len(page)-1].ID
+	}
 }
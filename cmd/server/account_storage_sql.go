@@ -8,7 +8,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	accounts "github.com/moov-io/accounts/client"
 
@@ -25,7 +27,7 @@ type sqlAccountRepository struct {
 func setupSqlAccountStorage(ctx context.Context, logger log.Logger, db *sql.DB) (*sqlAccountRepository, error) {
 	transactionRepo, err := setupSqlTransactionStorage(ctx, logger, db)
 	if err != nil {
-		return nil, fmt.Errorf("setupSqlTransactionStorage: transactions: %v", err)
+		return nil, fmt.Errorf("setupSqlTransactionStorage: transactions: %w", err)
 	}
 	return &sqlAccountRepository{db, logger, transactionRepo}, nil
 }
@@ -46,14 +48,14 @@ func (r *sqlAccountRepository) GetAccounts(accountIDs []string) ([]*accounts.Acc
 
 	tx, err := r.db.Begin()
 	if err != nil {
-		return nil, fmt.Errorf("GetAccounts: tx.Begin: error=%v rollback=%v", err, tx.Rollback())
+		return nil, fmt.Errorf("GetAccounts: tx.Begin: error=%w rollback=%v", err, tx.Rollback())
 	}
 
 	query := fmt.Sprintf(`select account_id, customer_id, name, account_number, routing_number, status, type, created_at, closed_at, last_modified
 from accounts where account_id in (?%s) and deleted_at is null;`, strings.Repeat(",?", len(accountIDs)-1))
 	stmt, err := tx.Prepare(query)
 	if err != nil {
-		return nil, fmt.Errorf("GetAccounts: tx.Prepare error=%v rollback=%v", err, tx.Rollback())
+		return nil, fmt.Errorf("GetAccounts: tx.Prepare error=%w rollback=%v", err, tx.Rollback())
 	}
 	defer stmt.Close()
 
@@ -63,7 +65,7 @@ from accounts where account_id in (?%s) and deleted_at is null;`, strings.Repeat
 	}
 	rows, err := stmt.Query(ids...)
 	if err != nil {
-		return nil, fmt.Errorf("GetAccounts: stmt query error=%v rollback=%v", err, tx.Rollback())
+		return nil, fmt.Errorf("GetAccounts: stmt query error=%w rollback=%v", err, tx.Rollback())
 	}
 
 	var out []*accounts.Account
@@ -75,30 +77,64 @@ from accounts where account_id in (?%s) and deleted_at is null;`, strings.Repeat
 				continue
 			}
 			rows.Close()
-			return nil, fmt.Errorf("GetAccounts: account=%q error=%v rollback=%v", a.ID, err, tx.Rollback())
+			return nil, fmt.Errorf("GetAccounts: account=%q error=%w rollback=%v", a.ID, err, tx.Rollback())
 		}
 		out = append(out, &a)
 	}
 	rows.Close()
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("GetAccounts: scan error=%v rollback=%v", err, tx.Rollback())
+		return nil, fmt.Errorf("GetAccounts: scan error=%w rollback=%v", err, tx.Rollback())
 	}
 
 	for i := range out {
-		balance, err := r.transactionRepo.getAccountBalance(tx, out[i].ID)
+		balances, err := r.transactionRepo.getAccountBalances(tx, out[i].ID)
 		if err != nil {
-			return nil, fmt.Errorf("GetAccounts: getAccountBalance: account=%q error=%v rollback=%v", out[i].ID, err, tx.Rollback())
+			return nil, fmt.Errorf("GetAccounts: getAccountBalances: account=%q error=%w rollback=%v", out[i].ID, err, tx.Rollback())
 		}
-		// TODO(adam): need Balance, BalanceAvailable, and BalancePending
-		out[i].Balance = balance
+		out[i].Balance = balances.Current
+		out[i].BalanceAvailable = balances.Available
+		out[i].BalancePending = balances.Pending
 	}
 
 	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("GetAccounts: commit error=%v rollback=%v", err, tx.Rollback())
+		return nil, fmt.Errorf("GetAccounts: commit error=%w rollback=%v", err, tx.Rollback())
 	}
 	return out, nil
 }
 
+func (r *sqlAccountRepository) ListAccounts(createdBefore time.Time, after string, limit int) ([]*accounts.Account, error) {
+	query := `select account_id from accounts where created_at < ? and account_id > ? and deleted_at is null order by account_id asc limit ?;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("ListAccounts: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(createdBefore, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ListAccounts: query: %w", err)
+	}
+	defer rows.Close()
+
+	var accountIDs []string
+	for rows.Next() {
+		var accountID string
+		if err := rows.Scan(&accountID); err != nil {
+			return nil, fmt.Errorf("ListAccounts: scan: %w", err)
+		}
+		accountIDs = append(accountIDs, accountID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ListAccounts: rows: %w", err)
+	}
+	out, err := r.GetAccounts(accountIDs)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
 func (r *sqlAccountRepository) CreateAccount(customerID string, a *accounts.Account) error {
 	query := `insert into accounts (account_id, customer_id, name, account_number, routing_number, status, type, created_at, closed_at, last_modified) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`
 	stmt, err := r.db.Prepare(query)
@@ -111,6 +147,34 @@ func (r *sqlAccountRepository) CreateAccount(customerID string, a *accounts.Acco
 	return err
 }
 
+func (r *sqlAccountRepository) GetAccountTypes(routingNumber string) (map[string]string, error) {
+	query := `select account_id, lower(type) from accounts where routing_number = ? and deleted_at is null;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("GetAccountTypes: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(routingNumber)
+	if err != nil {
+		return nil, fmt.Errorf("GetAccountTypes: query: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string]string)
+	for rows.Next() {
+		var accountID, accountType string
+		if err := rows.Scan(&accountID, &accountType); err != nil {
+			return nil, fmt.Errorf("GetAccountTypes: scan: %w", err)
+		}
+		out[accountID] = accountType
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("GetAccountTypes: rows: %w", err)
+	}
+	return out, nil
+}
+
 func (r *sqlAccountRepository) SearchAccountsByRoutingNumber(accountNumber, routingNumber, acctType string) (*accounts.Account, error) {
 	query := `select account_id from accounts where account_number = ? and routing_number = ? and lower(type) = lower(?) and deleted_at is null limit 1;`
 	stmt, err := r.db.Prepare(query)
@@ -125,13 +189,13 @@ func (r *sqlAccountRepository) SearchAccountsByRoutingNumber(accountNumber, rout
 		if err == sql.ErrNoRows {
 			return nil, nil // not found
 		}
-		return nil, fmt.Errorf("SearchAccounts: account=%q: %v", id, err)
+		return nil, fmt.Errorf("SearchAccounts: account=%q: %w", id, err)
 	}
 
 	// Grab out account by its ID
 	accounts, err := r.GetAccounts([]string{id})
 	if err != nil || len(accounts) == 0 {
-		return nil, fmt.Errorf("SearchAccounts: no accounts: %v", err)
+		return nil, fmt.Errorf("SearchAccounts: no accounts: %w", err)
 	}
 	return accounts[0], nil
 }
@@ -157,7 +221,7 @@ func (r *sqlAccountRepository) SearchAccountsByCustomerID(customerID string) ([]
 			if err == sql.ErrNoRows {
 				continue
 			}
-			return nil, fmt.Errorf("SearchAccountsByCustomerID: account=%q: %v", id, err)
+			return nil, fmt.Errorf("SearchAccountsByCustomerID: account=%q: %w", id, err)
 		}
 		accountIDs = append(accountIDs, id)
 	}
@@ -166,3 +230,115 @@ func (r *sqlAccountRepository) SearchAccountsByCustomerID(customerID string) ([]
 	}
 	return r.GetAccounts(accountIDs)
 }
+
+func (r *sqlAccountRepository) RenameAccount(accountID string, name string) error {
+	query := `update accounts set name = ?, last_modified = ? where account_id = ? and deleted_at is null;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("RenameAccount: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(name, time.Now(), accountID); err != nil {
+		return fmt.Errorf("RenameAccount: account=%q: %w", accountID, err)
+	}
+	return nil
+}
+
+func (r *sqlAccountRepository) UpdateAccountStatus(change accountStatusChange) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("UpdateAccountStatus: tx.Begin: %w", err)
+	}
+
+	// Read the current status inside our database transaction so the transition is checked against what we update
+	query := `select status from accounts where account_id = ? and deleted_at is null limit 1;`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("UpdateAccountStatus: prepare: error=%w rollback=%v", err, tx.Rollback())
+	}
+	var status string
+	if err := stmt.QueryRow(change.AccountID).Scan(&status); err != nil {
+		stmt.Close()
+		return fmt.Errorf("UpdateAccountStatus: account=%q status: error=%w rollback=%v", change.AccountID, err, tx.Rollback())
+	}
+	stmt.Close()
+
+	current := readAccountStatus(status)
+	if !current.canTransitionTo(change.Status) {
+		return fmt.Errorf("UpdateAccountStatus: account=%q can't move from %s to %s rollback=%v", change.AccountID, current, change.Status, tx.Rollback())
+	}
+	change.Previous = current
+
+	closedAt := sql.NullTime{}
+	if change.Status == AccountClosed {
+		balance, err := r.transactionRepo.getAccountBalance(tx, change.AccountID)
+		if err != nil {
+			return fmt.Errorf("UpdateAccountStatus: getAccountBalance: account=%q error=%w rollback=%v", change.AccountID, err, tx.Rollback())
+		}
+		if balance != 0 {
+			return fmt.Errorf("UpdateAccountStatus: account=%q balance=%d: %w rollback=%v", change.AccountID, balance, errAccountHasBalance, tx.Rollback())
+		}
+		// Holds would capture into or out of the account after it's closed, so they must be captured or released first
+		holds, err := countActiveHolds(tx, change.AccountID)
+		if err != nil {
+			return fmt.Errorf("UpdateAccountStatus: account=%q error=%w rollback=%v", change.AccountID, err, tx.Rollback())
+		}
+		if holds > 0 {
+			return fmt.Errorf("UpdateAccountStatus: account=%q holds=%d: %w rollback=%v", change.AccountID, holds, errAccountHasHolds, tx.Rollback())
+		}
+		closedAt = sql.NullTime{Time: change.CreatedAt, Valid: true}
+	}
+
+	query = `update accounts set status = ?, closed_at = coalesce(?, closed_at), last_modified = ? where account_id = ? and deleted_at is null;`
+	stmt, err = tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("UpdateAccountStatus: prepare update: error=%w rollback=%v", err, tx.Rollback())
+	}
+	if _, err := stmt.Exec(change.Status, closedAt, change.CreatedAt, change.AccountID); err != nil {
+		stmt.Close()
+		return fmt.Errorf("UpdateAccountStatus: account=%q update: error=%w rollback=%v", change.AccountID, err, tx.Rollback())
+	}
+	stmt.Close()
+
+	query = `insert into account_status_changes (account_id, previous_status, status, reason_code, actor, created_at) values (?, ?, ?, ?, ?, ?);`
+	stmt, err = tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("UpdateAccountStatus: prepare insert: error=%w rollback=%v", err, tx.Rollback())
+	}
+	if _, err := stmt.Exec(change.AccountID, change.Previous, change.Status, change.ReasonCode, change.Actor, change.CreatedAt); err != nil {
+		stmt.Close()
+		return fmt.Errorf("UpdateAccountStatus: account=%q insert: error=%w rollback=%v", change.AccountID, err, tx.Rollback())
+	}
+	stmt.Close()
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("UpdateAccountStatus: commit: %w", err)
+	}
+	return nil
+}
+
+func (r *sqlAccountRepository) GetAccountStatusHistory(accountID string) ([]accountStatusChange, error) {
+	query := `select account_id, previous_status, status, reason_code, actor, created_at from account_status_changes where account_id = ? order by created_at asc;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("GetAccountStatusHistory: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("GetAccountStatusHistory: query: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []accountStatusChange
+	for rows.Next() {
+		var change accountStatusChange
+		if err := rows.Scan(&change.AccountID, &change.Previous, &change.Status, &change.ReasonCode, &change.Actor, &change.CreatedAt); err != nil {
+			return nil, fmt.Errorf("GetAccountStatusHistory: scan account=%q: %w", accountID, err)
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
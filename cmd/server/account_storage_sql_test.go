@@ -7,6 +7,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"strings"
 	"testing"
 	"time"
 
@@ -162,6 +163,49 @@ func TestSqlAccounts__GetAccounts(t *testing.T) {
 	check(t, createTestSqlAccountRepository(t, mysqlDB.DB))
 }
 
+func TestSqlAccountRepository__ListAccounts(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlAccountRepository) {
+		defer repo.Close()
+
+		now := time.Now()
+		acct := &accounts.Account{ID: "b" + base.ID(), CustomerID: base.ID(), Name: "Checking", AccountNumber: "123", RoutingNumber: "121042882", Status: "open", Type: "Checking", CreatedAt: now, LastModified: now}
+		if err := repo.CreateAccount(acct.CustomerID, acct); err != nil {
+			t.Fatal(err)
+		}
+		other := &accounts.Account{ID: "a" + base.ID(), CustomerID: base.ID(), Name: "Savings", AccountNumber: "124", RoutingNumber: "121042882", Status: "open", Type: "Savings", CreatedAt: now, LastModified: now}
+		if err := repo.CreateAccount(other.CustomerID, other); err != nil {
+			t.Fatal(err)
+		}
+		if found, err := repo.ListAccounts(now.Add(time.Second), "", 10); err != nil || len(found) != 2 || found[0].ID != other.ID || found[1].ID != acct.ID {
+			t.Errorf("accounts=%v error=%v", found, err)
+		}
+		if found, err := repo.ListAccounts(now.Add(-1*time.Second), "", 10); err != nil || len(found) != 0 {
+			t.Errorf("accounts=%v error=%v", found, err)
+		}
+
+		// Read one page at a time
+		if found, err := repo.ListAccounts(now.Add(time.Second), "", 1); err != nil || len(found) != 1 || found[0].ID != other.ID {
+			t.Errorf("accounts=%v error=%v", found, err)
+		}
+		if found, err := repo.ListAccounts(now.Add(time.Second), other.ID, 1); err != nil || len(found) != 1 || found[0].ID != acct.ID {
+			t.Errorf("accounts=%v error=%v", found, err)
+		}
+		if found, err := repo.ListAccounts(now.Add(time.Second), acct.ID, 1); err != nil || len(found) != 0 {
+			t.Errorf("accounts=%v error=%v", found, err)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlAccountRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlAccountRepository(t, mysqlDB.DB))
+}
+
 // TestSqlAccountRepository_unique will ensure we can't insert multiple accounts
 // with the same account and routing numbers.
 func TestSqlAccountRepository_unique(t *testing.T) {
@@ -207,3 +251,220 @@ func TestSqlAccountRepository_unique(t *testing.T) {
 	defer mysqlDB.Close()
 	check(t, createTestSqlAccountRepository(t, mysqlDB.DB))
 }
+
+func TestSqlAccountRepository__RenameAndClose(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlAccountRepository) {
+		defer repo.Close()
+
+		account := &accounts.Account{
+			ID:            base.ID(),
+			CustomerID:    base.ID(),
+			Name:          "test account",
+			AccountNumber: "12411",
+			RoutingNumber: defaultRoutingNumber,
+			Status:        "open",
+			Type:          "Checking",
+			CreatedAt:     time.Now(),
+			LastModified:  time.Now(),
+		}
+		if err := repo.CreateAccount(account.CustomerID, account); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := repo.RenameAccount(account.ID, "other name"); err != nil {
+			t.Fatal(err)
+		}
+		accts, err := repo.GetAccounts([]string{account.ID})
+		if err != nil || len(accts) != 1 {
+			t.Fatalf("accounts=%#v error=%v", accts, err)
+		}
+		if accts[0].Name != "other name" {
+			t.Errorf("unexpected name: %q", accts[0].Name)
+		}
+
+		// Add funds so the account can't be closed
+		tx := transaction{
+			ID:        base.ID(),
+			Timestamp: time.Now(),
+			Lines: []transactionLine{
+				{AccountID: account.ID, Purpose: ACHCredit, Direction: Credit, Amount: 1000},
+			},
+		}
+		if err := repo.transactionRepo.createTransaction(tx, createTransactionOpts{InitialDeposit: true}); err != nil {
+			t.Fatal(err)
+		}
+		closeAccount := accountStatusChange{
+			AccountID:  account.ID,
+			Status:     AccountClosed,
+			ReasonCode: "customer-request",
+			Actor:      "test",
+			CreatedAt:  time.Now(),
+		}
+		if err := repo.UpdateAccountStatus(closeAccount); err == nil {
+			t.Error("expected error")
+		} else if !strings.Contains(err.Error(), errAccountHasBalance.Error()) {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		// Drain the account and close it
+		tx = transaction{
+			ID:        base.ID(),
+			Timestamp: time.Now(),
+			Lines: []transactionLine{
+				{AccountID: account.ID, Purpose: ACHDebit, Direction: Debit, Amount: 1000},
+				{AccountID: base.ID(), Purpose: ACHCredit, Direction: Credit, Amount: 1000},
+			},
+		}
+		if err := repo.transactionRepo.createTransaction(tx, createTransactionOpts{AllowOverdraft: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Active holds must be released before the account is closed
+		now := time.Now()
+		h := hold{ID: base.ID(), AccountID: account.ID, CreditAccountID: base.ID(), Purpose: Transfer, Amount: 100, Status: HoldPending, ExpiresAt: now.Add(time.Hour), CreatedAt: now, LastModified: now}
+		if err := setupSqlHoldStorage(log.NewNopLogger(), repo.transactionRepo).placeHold(h, createTransactionOpts{AllowOverdraft: true}); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.UpdateAccountStatus(closeAccount); err == nil {
+			t.Error("expected error")
+		} else if !strings.Contains(err.Error(), errAccountHasHolds.Error()) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if _, err := setupSqlHoldStorage(log.NewNopLogger(), repo.transactionRepo).releaseHold(h.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.UpdateAccountStatus(closeAccount); err != nil {
+			t.Fatal(err)
+		}
+		accts, err = repo.GetAccounts([]string{account.ID})
+		if err != nil || len(accts) != 1 {
+			t.Fatalf("accounts=%#v error=%v", accts, err)
+		}
+		if accts[0].Status != "closed" || accts[0].ClosedAt.IsZero() {
+			t.Errorf("account wasn't closed: %#v", accts[0])
+		}
+
+		// Closed accounts can't have new lines posted against them
+		tx = transaction{
+			ID:        base.ID(),
+			Timestamp: time.Now(),
+			Lines: []transactionLine{
+				{AccountID: account.ID, Purpose: ACHCredit, Direction: Credit, Amount: 1000},
+			},
+		}
+		if err := repo.transactionRepo.createTransaction(tx, createTransactionOpts{InitialDeposit: true}); err == nil {
+			t.Error("expected error")
+		} else if !strings.Contains(err.Error(), "is closed and can't be credited") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlAccountRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlAccountRepository(t, mysqlDB.DB))
+}
+
+func TestSqlAccountRepository__UpdateAccountStatus(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlAccountRepository) {
+		defer repo.Close()
+
+		account := &accounts.Account{
+			ID:            base.ID(),
+			CustomerID:    base.ID(),
+			Name:          "test account",
+			AccountNumber: "12411",
+			RoutingNumber: defaultRoutingNumber,
+			Status:        string(AccountOpen),
+			Type:          "Checking",
+			CreatedAt:     time.Now(),
+			LastModified:  time.Now(),
+		}
+		if err := repo.CreateAccount(account.CustomerID, account); err != nil {
+			t.Fatal(err)
+		}
+
+		// Read the account before it's frozen
+		freeze := accountStatusChange{
+			AccountID:  account.ID,
+			Status:     AccountFrozen,
+			ReasonCode: "investigation",
+			Actor:      "compliance",
+			CreatedAt:  time.Now(),
+		}
+		if err := repo.UpdateAccountStatus(freeze); err != nil {
+			t.Fatal(err)
+		}
+
+		// frozen accounts can't be closed
+		closeAccount := accountStatusChange{
+			AccountID:  account.ID,
+			Status:     AccountClosed,
+			ReasonCode: "customer-request",
+			Actor:      "test",
+			CreatedAt:  time.Now(),
+		}
+		if err := repo.UpdateAccountStatus(closeAccount); err == nil {
+			t.Error("expected error")
+		}
+
+		// neither debits or credits are allowed
+		tx := transaction{
+			ID:        base.ID(),
+			Timestamp: time.Now(),
+			Lines: []transactionLine{
+				{AccountID: account.ID, Purpose: ACHCredit, Direction: Credit, Amount: 1000},
+				{AccountID: base.ID(), Purpose: ACHDebit, Direction: Debit, Amount: 1000},
+			},
+		}
+		if err := repo.transactionRepo.createTransaction(tx, createTransactionOpts{AllowOverdraft: true}); err == nil {
+			t.Error("expected error")
+		} else if !strings.Contains(err.Error(), "is frozen and can't be credited") {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		// Unfreeze with a restriction on debits, so credits are allowed
+		restrict := accountStatusChange{
+			AccountID:  account.ID,
+			Status:     AccountDebitRestricted,
+			ReasonCode: "investigation-cleared",
+			Actor:      "compliance",
+			CreatedAt:  time.Now(),
+		}
+		if err := repo.UpdateAccountStatus(restrict); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.transactionRepo.createTransaction(tx, createTransactionOpts{AllowOverdraft: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		changes, err := repo.GetAccountStatusHistory(account.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != 2 {
+			t.Fatalf("got %d changes: %#v", len(changes), changes)
+		}
+		if changes[0].Previous != AccountOpen || changes[0].Status != AccountFrozen || changes[0].Actor != "compliance" {
+			t.Errorf("unexpected change: %#v", changes[0])
+		}
+		if changes[1].Previous != AccountFrozen || changes[1].Status != AccountDebitRestricted || changes[1].ReasonCode != "investigation-cleared" {
+			t.Errorf("unexpected change: %#v", changes[1])
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlAccountRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlAccountRepository(t, mysqlDB.DB))
+}
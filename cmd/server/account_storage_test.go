@@ -5,13 +5,21 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
 	accounts "github.com/moov-io/accounts/client"
 )
 
 // testAccountRepository represents a mocked accountRepository where accounts or err are
 // returned if set. Tests are fully responsible for managing state.
 type testAccountRepository struct {
-	accounts []*accounts.Account
+	accounts      []*accounts.Account
+	statusChanges []accountStatusChange
 
 	err error
 }
@@ -31,6 +39,23 @@ func (r *testAccountRepository) GetAccounts(accountIDs []string) ([]*accounts.Ac
 	return r.accounts, nil
 }
 
+func (r *testAccountRepository) ListAccounts(createdBefore time.Time, after string, limit int) ([]*accounts.Account, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	var out []*accounts.Account
+	for i := range r.accounts {
+		if r.accounts[i].CreatedAt.Before(createdBefore) && r.accounts[i].ID > after {
+			out = append(out, r.accounts[i])
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
 func (r *testAccountRepository) GetCustomerAccounts(customerID string) ([]*accounts.Account, error) {
 	if r.err != nil {
 		return nil, r.err
@@ -42,6 +67,19 @@ func (r *testAccountRepository) CreateAccount(customerID string, account *accoun
 	return r.err
 }
 
+func (r *testAccountRepository) GetAccountTypes(routingNumber string) (map[string]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	out := make(map[string]string)
+	for i := range r.accounts {
+		if r.accounts[i].RoutingNumber == routingNumber {
+			out[r.accounts[i].ID] = strings.ToLower(r.accounts[i].Type)
+		}
+	}
+	return out, nil
+}
+
 func (r *testAccountRepository) SearchAccountsByRoutingNumber(accountNumber, routingNumber, acctType string) (*accounts.Account, error) {
 	if r.err != nil {
 		return nil, r.err
@@ -58,3 +96,68 @@ func (r *testAccountRepository) SearchAccountsByCustomerID(customerID string) ([
 	}
 	return r.accounts, nil
 }
+
+func (r *testAccountRepository) RenameAccount(accountID string, name string) error {
+	if r.err != nil {
+		return r.err
+	}
+	for i := range r.accounts {
+		if r.accounts[i].ID == accountID {
+			r.accounts[i].Name = name
+		}
+	}
+	return nil
+}
+
+func (r *testAccountRepository) UpdateAccountStatus(change accountStatusChange) error {
+	if r.err != nil {
+		return r.err
+	}
+	for i := range r.accounts {
+		if r.accounts[i].ID == change.AccountID {
+			r.accounts[i].Status = string(change.Status)
+			if change.Status == AccountClosed {
+				r.accounts[i].ClosedAt = change.CreatedAt
+			}
+		}
+	}
+	r.statusChanges = append(r.statusChanges, change)
+	return nil
+}
+
+func (r *testAccountRepository) GetAccountStatusHistory(accountID string) ([]accountStatusChange, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.statusChanges, nil
+}
+
+func TestAccounts__forEachAccount(t *testing.T) {
+	now := time.Now()
+	repo := &testAccountRepository{}
+	for i := 0; i < listAccountsPageSize+1; i++ {
+		repo.accounts = append(repo.accounts, &accounts.Account{ID: fmt.Sprintf("%04d", i), CreatedAt: now})
+	}
+
+	var seen []string
+	err := forEachAccount(repo, now.Add(time.Second), func(acct *accounts.Account) error {
+		seen = append(seen, acct.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != len(repo.accounts) || seen[listAccountsPageSize] != repo.accounts[listAccountsPageSize].ID {
+		t.Errorf("read %d accounts", len(seen))
+	}
+
+	// Errors stop iterating
+	calls := 0
+	err = forEachAccount(repo, now.Add(time.Second), func(acct *accounts.Account) error {
+		calls++
+		return errors.New("bad")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("calls=%d error=%v", calls, err)
+	}
+}
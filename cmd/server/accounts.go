@@ -25,12 +25,29 @@ import (
 
 var (
 	defaultRoutingNumber = os.Getenv("DEFAULT_ROUTING_NUMBER")
+
+	errAccountHasBalance = errors.New("account has a non-zero balance")
+	errAccountClosed     = errors.New("account is closed")
+	errAccountHasHolds   = errors.New("account has active holds")
+
+	// defaultCloseReasonCode is recorded when an account is closed without a reasonCode query parameter.
+	defaultCloseReasonCode = "customer-request"
+
+	// openAccountReasonCode is recorded when a new account is activated after its opening deposit posts.
+	openAccountReasonCode = "account-opened"
 )
 
-func addAccountRoutes(logger log.Logger, r *mux.Router, accountRepo accountRepository, transactionRepo transactionRepository) {
+func addAccountRoutes(logger log.Logger, r *mux.Router, accountRepo accountRepository, transactionRepo transactionRepository, productRepo productRepository) {
 	r.Methods("GET").Path("/accounts/search").HandlerFunc(searchAccounts(logger, accountRepo))
 
-	r.Methods("POST").Path("/accounts").HandlerFunc(createAccount(logger, accountRepo, transactionRepo))
+	r.Methods("POST").Path("/accounts").HandlerFunc(createAccount(logger, accountRepo, transactionRepo, productRepo))
+
+	r.Methods("GET").Path("/accounts/{accountId}").HandlerFunc(getAccount(logger, accountRepo))
+	r.Methods("PATCH").Path("/accounts/{accountId}").HandlerFunc(updateAccount(logger, accountRepo))
+	r.Methods("DELETE").Path("/accounts/{accountId}").HandlerFunc(closeAccount(logger, accountRepo))
+
+	r.Methods("PUT").Path("/accounts/{accountId}/status").HandlerFunc(updateAccountStatus(logger, accountRepo))
+	r.Methods("GET").Path("/accounts/{accountId}/status/history").HandlerFunc(getAccountStatusHistory(logger, accountRepo))
 }
 
 // searchAccounts will attempt to find Accounts which match all query parameters. Searching with an account number will only
@@ -51,7 +68,7 @@ func searchAccounts(logger log.Logger, repo accountRepository) http.HandlerFunc
 			account, err := repo.SearchAccountsByRoutingNumber(reqAcctNumber, reqRoutingNumber, reqAcctType)
 			if err != nil {
 				logger.Log("accounts", fmt.Sprintf("error searching accounts: %v", err), "requestID", moovhttp.GetRequestID(r))
-				moovhttp.Problem(w, fmt.Errorf("account not found, err=%v", err))
+				moovhttp.Problem(w, fmt.Errorf("account not found, err=%w", err))
 				return
 			}
 			w.Header().Set("Content-Type", "application/json; charset=utf-8")
@@ -70,7 +87,7 @@ func searchAccounts(logger log.Logger, repo accountRepository) http.HandlerFunc
 			accounts, err := repo.SearchAccountsByCustomerID(customerID)
 			if err != nil {
 				logger.Log("accounts", fmt.Sprintf("error getting customer accounts: %v", err), "requestID", moovhttp.GetRequestID(r))
-				moovhttp.Problem(w, fmt.Errorf("account not found, err=%v", err))
+				moovhttp.Problem(w, fmt.Errorf("account not found, err=%w", err))
 				return
 			}
 			w.Header().Set("Content-Type", "application/json; charset=utf-8")
@@ -85,37 +102,41 @@ func searchAccounts(logger log.Logger, repo accountRepository) http.HandlerFunc
 
 type createAccountRequest struct {
 	CustomerID string `json:"customerId"`
-	Balance    int    `json:"balance"`
+	Balance    int64  `json:"balance"`
 	Name       string `json:"name"`
 	Number     string `json:"number"`
 	Type       string `json:"type"`
 }
 
-func (r createAccountRequest) validate() error {
+// validate checks the request against product, which is the catalog entry for r.Type or nil if there isn't one
+func (r createAccountRequest) validate(product *accountProduct) error {
 	if r.CustomerID = strings.TrimSpace(r.CustomerID); r.CustomerID == "" {
 		return errors.New("createAccountRequest: empty customerID")
 	}
-	if r.Balance < 100 { // $1
-		return fmt.Errorf("createAccountRequest: invalid initial amount %d USD cents", r.Balance)
-	}
 	if r.Name == "" {
 		return errors.New("createAccountRequest: missing Name")
 	}
-	r.Type = strings.ToLower(r.Type)
-	switch r.Type {
-	case "checking", "savings":
-	default:
+	if product == nil {
 		return fmt.Errorf("createAccountRequest: unknown Type: %q", r.Type)
 	}
+	if r.Balance < 0 || r.Balance < product.MinimumOpeningDeposit {
+		return fmt.Errorf("createAccountRequest: invalid initial amount %d USD cents, %s accounts require %d", r.Balance, product.Code, product.MinimumOpeningDeposit)
+	}
 	return nil
 }
 
+// validCustomerAccountType returns true if v can be the type of customer accounts, which is the code of their
+// account product. Balances of customer accounts roll up into the liability GL account for their type.
+func validCustomerAccountType(v string) bool {
+	return productCodeRegex.MatchString(strings.ToLower(v))
+}
+
 func createAccountNumber() string {
 	n, _ := rand.Int(rand.Reader, big.NewInt(1e9))
 	return fmt.Sprintf("%d", n.Int64())
 }
 
-func createAccount(logger log.Logger, accountRepo accountRepository, transactionRepo transactionRepository) http.HandlerFunc {
+func createAccount(logger log.Logger, accountRepo accountRepository, transactionRepo transactionRepository, productRepo productRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w, err := wrapResponseWriter(logger, w, r)
 		if err != nil {
@@ -132,7 +153,13 @@ func createAccount(logger log.Logger, accountRepo accountRepository, transaction
 			moovhttp.Problem(w, err)
 			return
 		}
-		if err := req.validate(); err != nil {
+		product, err := productRepo.getAccountProduct(req.Type)
+		if err != nil {
+			logger.Log("accounts", fmt.Sprintf("error reading account product: %v", err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		if err := req.validate(product); err != nil {
 			logger.Log("accounts", fmt.Sprintf("error validaing request: %v", err), "requestID", requestID)
 			moovhttp.Problem(w, err)
 			return
@@ -145,7 +172,7 @@ func createAccount(logger log.Logger, accountRepo accountRepository, transaction
 			Name:          req.Name,
 			AccountNumber: req.Number,
 			RoutingNumber: defaultRoutingNumber,
-			Status:        "open",
+			Status:        string(AccountPending),
 			Type:          req.Type,
 			CreatedAt:     now,
 			LastModified:  now,
@@ -167,20 +194,40 @@ func createAccount(logger log.Logger, accountRepo accountRepository, transaction
 		}
 
 		// Submit a transaction of the initial amount (where does the exteranl ABA come from)?
-		tx := (&createTransactionRequest{
-			Lines: []transactionLine{
-				{
-					AccountID: account.ID,
-					Purpose:   ACHCredit,
-					Amount:    req.Balance,
+		// Products without a minimum opening deposit can be opened empty.
+		if req.Balance > 0 {
+			tx := (&createTransactionRequest{
+				Lines: []transactionLine{
+					{
+						AccountID: account.ID,
+						Purpose:   ACHCredit,
+						Direction: Credit,
+						Amount:    req.Balance,
+					},
 				},
-			},
-		}).asTransaction(base.ID())
-		if err := transactionRepo.createTransaction(tx, createTransactionOpts{InitialDeposit: true}); err != nil {
-			logger.Log("accounts", fmt.Errorf("problem creating initial balance transaction: %v", err), "requestID", requestID)
+			}).asTransaction(base.ID())
+			if err := transactionRepo.createTransaction(tx, createTransactionOpts{InitialDeposit: true, IdempotencyKey: readReservedIdempotencyKey(r)}); err != nil {
+				logger.Log("accounts", fmt.Errorf("problem creating initial balance transaction: %w", err), "requestID", requestID)
+				moovhttp.Problem(w, err)
+				return
+			}
+		}
+
+		// Accounts stay pending until their opening deposit has posted, so activate it now
+		change := accountStatusChange{
+			AccountID:  account.ID,
+			Previous:   AccountPending,
+			Status:     AccountOpen,
+			ReasonCode: openAccountReasonCode,
+			Actor:      moovhttp.GetUserID(r),
+			CreatedAt:  time.Now(),
+		}
+		if err := accountRepo.UpdateAccountStatus(change); err != nil {
+			logger.Log("accounts", fmt.Sprintf("error opening account=%s: %v", account.ID, err), "requestID", requestID)
 			moovhttp.Problem(w, err)
 			return
 		}
+		account.Status = string(AccountOpen)
 
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 		w.WriteHeader(http.StatusOK)
@@ -200,3 +247,162 @@ func generateAccountNumber(account *accounts.Account, repo accountRepository) (s
 	}
 	return "", fmt.Errorf("unable to generate account number for account=%s", account.ID)
 }
+
+// readAccount returns the account for accountID or nil if it wasn't found.
+func readAccount(repo accountRepository, accountID string) (*accounts.Account, error) {
+	accts, err := repo.GetAccounts([]string{accountID})
+	if err != nil {
+		return nil, err
+	}
+	for i := range accts {
+		if accts[i].ID == accountID {
+			return accts[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func getAccount(logger log.Logger, repo accountRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		accountID, requestID := getAccountID(w, r), moovhttp.GetRequestID(r)
+		if accountID == "" {
+			return
+		}
+
+		account, err := readAccount(repo, accountID)
+		if err != nil {
+			logger.Log("accounts", fmt.Sprintf("error reading account=%s: %v", accountID, err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		if account == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(account)
+	}
+}
+
+type updateAccountRequest struct {
+	Name string `json:"name"`
+}
+
+func (r updateAccountRequest) validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("updateAccountRequest: missing Name")
+	}
+	return nil
+}
+
+func updateAccount(logger log.Logger, repo accountRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		accountID, requestID := getAccountID(w, r), moovhttp.GetRequestID(r)
+		if accountID == "" {
+			return
+		}
+
+		var req updateAccountRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.Log("accounts", fmt.Sprintf("error reading JSON request: %v", err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		if err := req.validate(); err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		account, err := readAccount(repo, accountID)
+		if err != nil {
+			logger.Log("accounts", fmt.Sprintf("error reading account=%s: %v", accountID, err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		if account == nil {
+			http.NotFound(w, r)
+			return
+		}
+		if readAccountStatus(account.Status) == AccountClosed {
+			moovhttp.Problem(w, errAccountClosed)
+			return
+		}
+
+		if err := repo.RenameAccount(accountID, strings.TrimSpace(req.Name)); err != nil {
+			logger.Log("accounts", fmt.Sprintf("error updating account=%s: %v", accountID, err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		account, err = readAccount(repo, accountID)
+		if err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(account)
+	}
+}
+
+func closeAccount(logger log.Logger, repo accountRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		accountID, requestID := getAccountID(w, r), moovhttp.GetRequestID(r)
+		if accountID == "" {
+			return
+		}
+
+		account, err := readAccount(repo, accountID)
+		if err != nil {
+			logger.Log("accounts", fmt.Sprintf("error reading account=%s: %v", accountID, err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		if account == nil {
+			http.NotFound(w, r)
+			return
+		}
+		if readAccountStatus(account.Status) == AccountClosed {
+			moovhttp.Problem(w, errAccountClosed)
+			return
+		}
+		if account.Balance != 0 {
+			moovhttp.Problem(w, errAccountHasBalance)
+			return
+		}
+
+		change := accountStatusChange{
+			AccountID:  accountID,
+			Previous:   readAccountStatus(account.Status),
+			Status:     AccountClosed,
+			ReasonCode: or(r.URL.Query().Get("reasonCode"), defaultCloseReasonCode),
+			Actor:      moovhttp.GetUserID(r),
+			CreatedAt:  time.Now(),
+		}
+		if err := repo.UpdateAccountStatus(change); err != nil {
+			logger.Log("accounts", fmt.Sprintf("error closing account=%s: %v", accountID, err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		logger.Log("accounts", fmt.Sprintf("closed account=%s", accountID), "requestID", requestID)
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -6,6 +6,7 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -38,30 +39,29 @@ func init() {
 }
 
 func TestAccounts__createAccountRequest(t *testing.T) {
+	checking := &accountProduct{Code: "checking", MinimumOpeningDeposit: 100}
 	req := createAccountRequest{"customerID", 100, "example acct", "", "checking"} // $1
-	if err := req.validate(); err != nil {
+	if err := req.validate(checking); err != nil {
 		t.Error(err)
 	}
 
 	req.CustomerID = ""
-	if err := req.validate(); err == nil {
+	if err := req.validate(checking); err == nil {
 		t.Error("expected error")
 	}
 	req.CustomerID = "customerID"
 
 	req.Balance = 10 // $0.10
-	if err := req.validate(); err == nil {
+	if err := req.validate(checking); err == nil {
 		t.Error("expected error")
 	}
-	req.Balance = 1000
-
-	req.Type = "SavInGs" // valid
-	if err := req.validate(); err != nil {
-		t.Error(err)
+	if err := req.validate(&accountProduct{Code: "basic"}); err != nil {
+		t.Errorf("products without a minimum opening deposit: %v", err)
 	}
+	req.Balance = 1000
 
-	req.Type = "other" // invalid
-	if err := req.validate(); err == nil {
+	req.Type = "other" // not in the catalog
+	if err := req.validate(nil); err == nil {
 		t.Error("expected error")
 	}
 }
@@ -77,7 +77,7 @@ func TestAccounts__CreateAccount(t *testing.T) {
 	transactionRepo := &mockTransactionRepository{}
 
 	router := mux.NewRouter()
-	addAccountRoutes(log.NewNopLogger(), router, accountRepo, transactionRepo)
+	addAccountRoutes(log.NewNopLogger(), router, accountRepo, transactionRepo, mockProductRepo)
 	router.ServeHTTP(w, req)
 	w.Flush()
 
@@ -92,6 +92,44 @@ func TestAccounts__CreateAccount(t *testing.T) {
 	if acct.ID == "" {
 		t.Error("empty Account.ID")
 	}
+
+	// Accounts are created as pending and opened once their deposit posts
+	if acct.Status != string(AccountOpen) {
+		t.Errorf("unexpected status: %s", acct.Status)
+	}
+	if len(accountRepo.statusChanges) != 1 {
+		t.Fatalf("unexpected status changes: %#v", accountRepo.statusChanges)
+	}
+	if change := accountRepo.statusChanges[0]; change.Previous != AccountPending || change.Status != AccountOpen || change.ReasonCode != openAccountReasonCode {
+		t.Errorf("unexpected status change: %#v", change)
+	}
+}
+
+func TestAccounts__CreateAccountProducts(t *testing.T) {
+	productRepo := &testProductRepository{
+		products: []accountProduct{{Code: "student", Name: "Student checking"}},
+	}
+	transactionRepo := &mockTransactionRepository{}
+	router := mux.NewRouter()
+	addAccountRoutes(log.NewNopLogger(), router, &testAccountRepository{}, transactionRepo, productRepo)
+
+	for body, status := range map[string]int{
+		`{"customerID": "customerID", "balance": 1000, "name": "Money", "type": "Savings"}`: http.StatusBadRequest,
+		`{"customerID": "customerID", "balance": 0, "name": "Money", "type": "Student"}`:    http.StatusOK,
+	} {
+		req := httptest.NewRequest("POST", "/accounts", strings.NewReader(body))
+		req.Header.Set("x-user-id", "test")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		w.Flush()
+		if w.Code != status {
+			t.Errorf("%s: bogus status code: %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+	// Accounts opened without a deposit don't post an initial transaction
+	if transactionRepo.created.ID != "" {
+		t.Errorf("unexpected transaction: %#v", transactionRepo.created)
+	}
 }
 
 func TestAccounts__GetCustomerAccounts(t *testing.T) {
@@ -103,7 +141,7 @@ func TestAccounts__GetCustomerAccounts(t *testing.T) {
 	transactionRepo := &mockTransactionRepository{}
 
 	router := mux.NewRouter()
-	addAccountRoutes(log.NewNopLogger(), router, mockAccountRepo, transactionRepo)
+	addAccountRoutes(log.NewNopLogger(), router, mockAccountRepo, transactionRepo, mockProductRepo)
 	router.ServeHTTP(w, req)
 	w.Flush()
 
@@ -132,7 +170,7 @@ func TestAccounts__SearchAccounts(t *testing.T) {
 	transactionRepo := &mockTransactionRepository{}
 
 	router := mux.NewRouter()
-	addAccountRoutes(log.NewNopLogger(), router, mockAccountRepo, transactionRepo)
+	addAccountRoutes(log.NewNopLogger(), router, mockAccountRepo, transactionRepo, mockProductRepo)
 	router.ServeHTTP(w, req)
 	w.Flush()
 
@@ -167,3 +205,148 @@ func TestAccounts__generateAccountNumber(t *testing.T) {
 		t.Fatalf("expected empty account number id=%v error=%v", id, err)
 	}
 }
+
+func TestAccounts__getAccount(t *testing.T) {
+	accountID := base.ID()
+	accountRepo := &testAccountRepository{
+		accounts: []*accounts.Account{
+			{ID: accountID, Name: "example account", Status: "open", Balance: 100},
+		},
+	}
+
+	router := mux.NewRouter()
+	addAccountRoutes(log.NewNopLogger(), router, accountRepo, &mockTransactionRepository{}, mockProductRepo)
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/accounts/%s", accountID), nil)
+	req.Header.Set("x-user-id", "test")
+	req.Header.Set("x-request-id", "request")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusOK {
+		t.Errorf("bogus status code: %d", w.Code)
+	}
+	var acct accounts.Account
+	if err := json.NewDecoder(w.Body).Decode(&acct); err != nil {
+		t.Fatal(err)
+	}
+	if acct.ID != accountID {
+		t.Errorf("unexpected account: %#v", acct)
+	}
+
+	// unknown account
+	req = httptest.NewRequest("GET", "/accounts/other", nil)
+	req.Header.Set("x-user-id", "test")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("bogus status code: %d", w.Code)
+	}
+}
+
+func TestAccounts__updateAccount(t *testing.T) {
+	accountID := base.ID()
+	accountRepo := &testAccountRepository{
+		accounts: []*accounts.Account{
+			{ID: accountID, Name: "example account", Status: "open"},
+		},
+	}
+
+	router := mux.NewRouter()
+	addAccountRoutes(log.NewNopLogger(), router, accountRepo, &mockTransactionRepository{}, mockProductRepo)
+
+	body := strings.NewReader(`{"name": "Rainy Day Fund"}`)
+	req := httptest.NewRequest("PATCH", fmt.Sprintf("/accounts/%s", accountID), body)
+	req.Header.Set("x-user-id", "test")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusOK {
+		t.Errorf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	var acct accounts.Account
+	if err := json.NewDecoder(w.Body).Decode(&acct); err != nil {
+		t.Fatal(err)
+	}
+	if acct.Name != "Rainy Day Fund" {
+		t.Errorf("unexpected name: %q", acct.Name)
+	}
+
+	// empty name
+	req = httptest.NewRequest("PATCH", fmt.Sprintf("/accounts/%s", accountID), strings.NewReader(`{"name": " "}`))
+	req.Header.Set("x-user-id", "test")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bogus status code: %d", w.Code)
+	}
+
+	// closed accounts can't be renamed
+	accountRepo.accounts[0].Status = "closed"
+	req = httptest.NewRequest("PATCH", fmt.Sprintf("/accounts/%s", accountID), strings.NewReader(`{"name": "other"}`))
+	req.Header.Set("x-user-id", "test")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bogus status code: %d", w.Code)
+	}
+}
+
+func TestAccounts__closeAccount(t *testing.T) {
+	accountID := base.ID()
+	accountRepo := &testAccountRepository{
+		accounts: []*accounts.Account{
+			{ID: accountID, Name: "example account", Status: "open", Balance: 100},
+		},
+	}
+
+	router := mux.NewRouter()
+	addAccountRoutes(log.NewNopLogger(), router, accountRepo, &mockTransactionRepository{}, mockProductRepo)
+
+	req := httptest.NewRequest("DELETE", fmt.Sprintf("/accounts/%s", accountID), nil)
+	req.Header.Set("x-user-id", "test")
+
+	// non-zero balance
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bogus status code: %d", w.Code)
+	}
+
+	accountRepo.accounts[0].Balance = 0
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusOK {
+		t.Errorf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	if acct := accountRepo.accounts[0]; acct.Status != "closed" || acct.ClosedAt.IsZero() {
+		t.Errorf("account wasn't closed: %#v", acct)
+	}
+
+	// closing again fails
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bogus status code: %d", w.Code)
+	}
+}
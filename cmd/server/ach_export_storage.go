@@ -0,0 +1,36 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+type achExportRepository interface {
+	// getUnsentACHLines returns the ACHCredit and ACHDebit lines which haven't been exported or skipped yet,
+	// ordered by their effective date. Lines of reversals and reversed transactions aren't included.
+	getUnsentACHLines() ([]achLine, error)
+
+	// getLastACHTraceNumber returns the last trace number exported for the ODFI identification (the first
+	// eight digits of a routing number), or an empty string if none have been. Each ODFI keeps its own sequence
+	// so it continues correctly after wrapping around.
+	getLastACHTraceNumber(odfi string) (string, error)
+	// countACHFiles returns how many files were exported since the given time
+	countACHFiles(since time.Time) (int, error)
+	// achFileExported returns true if lines were marked as sent in fileName
+	achFileExported(fileName string) (bool, error)
+
+	// saveACHExport marks sent lines as exported in fileName with their trace numbers, skipped lines as never
+	// needing to be exported and failed lines as invalid NACHA entries, then advances the trace sequence past the
+	// sent lines. If another export already marked any of the lines errACHLineExported is returned, or if it used
+	// the same trace numbers errACHTraceNumberUsed is, and nothing is saved.
+	saveACHExport(fileName string, sent, skipped, failed []achLine) error
+}
+
+var (
+	errACHLineExported    = errors.New("ACH line was already exported")
+	errACHTraceNumberUsed = errors.New("ACH trace number was already used")
+)
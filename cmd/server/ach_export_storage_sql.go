@@ -0,0 +1,217 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/moov-io/accounts/cmd/server/database"
+
+	"github.com/go-kit/kit/log"
+)
+
+type sqlACHExportRepository struct {
+	db     *sql.DB
+	logger log.Logger
+}
+
+func setupSqlACHExportStorage(logger log.Logger, db *sql.DB) *sqlACHExportRepository {
+	return &sqlACHExportRepository{db: db, logger: logger}
+}
+
+func (r *sqlACHExportRepository) getUnsentACHLines() ([]achLine, error) {
+	query := `select l.transaction_id, l.account_id, l.purpose, l.direction, l.amount, t.effective_date
+from transaction_lines l inner join transactions t on t.transaction_id = l.transaction_id
+left outer join ach_exports e on e.transaction_id = l.transaction_id and e.account_id = l.account_id
+where l.purpose in (?, ?) and e.transaction_id is null
+and t.reversal_of is null and (t.status is null or t.status <> ?)
+and l.deleted_at is null and t.deleted_at is null
+order by t.effective_date asc, l.transaction_id asc, l.account_id asc;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("getUnsentACHLines: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(ACHCredit, ACHDebit, TransactionReversed)
+	if err != nil {
+		return nil, fmt.Errorf("getUnsentACHLines: query: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []achLine
+	for rows.Next() {
+		var line achLine
+		if err := rows.Scan(&line.TransactionID, &line.AccountID, &line.Purpose, &line.Direction, &line.Amount, &line.EffectiveDate); err != nil {
+			return nil, fmt.Errorf("getUnsentACHLines: scan: %w", err)
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+// readACHTraceSequence returns the sequence number of the last trace number used by odfi, or zero if it hasn't
+// used any
+func readACHTraceSequence(tx *sql.Tx, odfi string) (int, error) {
+	stmt, err := tx.Prepare(`select last_sequence from ach_trace_sequences where odfi = ? limit 1;`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var seq int
+	if err := stmt.QueryRow(odfi).Scan(&seq); err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	return seq, nil
+}
+
+// advanceACHTraceSequence moves the trace sequence of the ODFI which sent the lines past their trace numbers, which
+// must follow on from the last one saved. Otherwise another export used them first and errACHTraceNumberUsed is returned.
+func advanceACHTraceSequence(tx *sql.Tx, sent []achLine) error {
+	if len(sent) == 0 {
+		return nil
+	}
+	first, last := sent[0].TraceNumber, sent[len(sent)-1].TraceNumber
+	if len(first) != 15 || len(last) != 15 {
+		return fmt.Errorf("invalid trace numbers %q to %q", first, last)
+	}
+	odfi := first[:8]
+	current, err := readACHTraceSequence(tx, odfi)
+	if err != nil {
+		return fmt.Errorf("odfi=%s: %w", odfi, err)
+	}
+	expected := 1
+	if current > 0 {
+		if expected, err = nextACHTraceNumber(fmt.Sprintf("%s%07d", odfi, current)); err != nil {
+			return err
+		}
+	}
+	if seq, _ := strconv.Atoi(first[8:]); seq != expected {
+		return fmt.Errorf("trace number %s: %w", first, errACHTraceNumberUsed)
+	}
+	next, err := strconv.Atoi(last[8:])
+	if err != nil {
+		return fmt.Errorf("invalid trace number %q: %w", last, err)
+	}
+
+	var query string
+	var args []interface{}
+	if current == 0 {
+		query = `insert into ach_trace_sequences(last_sequence, last_modified, odfi) values (?, ?, ?);`
+		args = []interface{}{next, time.Now(), odfi}
+	} else {
+		query = `update ach_trace_sequences set last_sequence = ?, last_modified = ? where odfi = ? and last_sequence = ?;`
+		args = []interface{}{next, time.Now(), odfi, current}
+	}
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(args...)
+	if err != nil {
+		if database.UniqueViolation(err) {
+			return fmt.Errorf("trace number %s: %w", first, errACHTraceNumberUsed)
+		}
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("trace number %s: %w", first, errACHTraceNumberUsed)
+	}
+	return nil
+}
+
+func (r *sqlACHExportRepository) getLastACHTraceNumber(odfi string) (string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("getLastACHTraceNumber: tx.Begin: %w", err)
+	}
+	seq, err := readACHTraceSequence(tx, odfi)
+	if err != nil {
+		return "", fmt.Errorf("getLastACHTraceNumber: odfi=%s: %w rollback=%v", odfi, err, tx.Rollback())
+	}
+	if seq == 0 {
+		return "", tx.Commit()
+	}
+	return fmt.Sprintf("%s%07d", odfi, seq), tx.Commit()
+}
+
+func (r *sqlACHExportRepository) countACHFiles(since time.Time) (int, error) {
+	query := `select count(distinct file_name) from ach_exports where status = ? and created_at >= ?;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return 0, fmt.Errorf("countACHFiles: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	var n int
+	if err := stmt.QueryRow(achLineSent, since.UTC()).Scan(&n); err != nil {
+		return 0, fmt.Errorf("countACHFiles: %w", err)
+	}
+	return n, nil
+}
+
+func (r *sqlACHExportRepository) achFileExported(fileName string) (bool, error) {
+	stmt, err := r.db.Prepare(`select count(*) from ach_exports where status = ? and file_name = ?;`)
+	if err != nil {
+		return false, fmt.Errorf("achFileExported: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	var n int
+	if err := stmt.QueryRow(achLineSent, fileName).Scan(&n); err != nil {
+		return false, fmt.Errorf("achFileExported: file=%s: %w", fileName, err)
+	}
+	return n > 0, nil
+}
+
+func (r *sqlACHExportRepository) saveACHExport(fileName string, sent, skipped, failed []achLine) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("saveACHExport: begin: %w", err)
+	}
+
+	query := `insert into ach_exports(transaction_id, account_id, status, trace_number, file_name, created_at) values (?, ?, ?, ?, ?, ?);`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("saveACHExport: prepare: %w rollback=%v", err, tx.Rollback())
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	insert := func(line achLine, status string, traceNumber, fileName interface{}) error {
+		if _, err := stmt.Exec(line.TransactionID, line.AccountID, status, traceNumber, fileName, now); err != nil {
+			if database.UniqueViolation(err) {
+				return fmt.Errorf("transaction=%q account=%q %w", line.TransactionID, line.AccountID, errACHLineExported)
+			}
+			return fmt.Errorf("transaction=%q account=%q: %w", line.TransactionID, line.AccountID, err)
+		}
+		return nil
+	}
+	for i := range sent {
+		if err := insert(sent[i], achLineSent, sent[i].TraceNumber, fileName); err != nil {
+			return fmt.Errorf("saveACHExport: %w rollback=%v", err, tx.Rollback())
+		}
+	}
+	for i := range skipped {
+		if err := insert(skipped[i], achLineSkipped, nil, nil); err != nil {
+			return fmt.Errorf("saveACHExport: %w rollback=%v", err, tx.Rollback())
+		}
+	}
+	for i := range failed {
+		if err := insert(failed[i], achLineFailed, nil, nil); err != nil {
+			return fmt.Errorf("saveACHExport: %w rollback=%v", err, tx.Rollback())
+		}
+	}
+	if err := advanceACHTraceSequence(tx, sent); err != nil {
+		return fmt.Errorf("saveACHExport: %w rollback=%v", err, tx.Rollback())
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,189 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/ach"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestSqlACHExportRepository__exportACHFile(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+		achRepo := setupSqlACHExportStorage(log.NewNopLogger(), repo.db)
+
+		dir, err := ioutil.TempDir("", "ach-export")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		cfg := achExportConfig{Dir: dir, ImmediateDestination: "121042882", ImmediateDestinationName: "Federal Reserve Bank", ImmediateOriginName: "Moov"}
+
+		customer := &accounts.Account{ID: base.ID(), Name: "Jane Doe", AccountNumber: "1234", RoutingNumber: defaultRoutingNumber, Status: "open", Type: "Checking"}
+		external := &accounts.Account{ID: base.ID(), Name: "John Doe", AccountNumber: "98765", RoutingNumber: "121042882", Status: "open", Type: "Savings"}
+		accountRepo := &testAccountRepository{accounts: []*accounts.Account{customer, external}}
+		repo.accountRepo = accountRepo
+
+		post := func(debit, credit *accounts.Account, amount int64) transaction {
+			tx := transaction{
+				ID:        base.ID(),
+				Timestamp: time.Now(),
+				Lines: []transactionLine{
+					{AccountID: debit.ID, Purpose: ACHDebit, Direction: Debit, Amount: amount},
+					{AccountID: credit.ID, Purpose: ACHCredit, Direction: Credit, Amount: amount},
+				},
+			}
+			if err := repo.createTransaction(tx, createTransactionOpts{}); err != nil {
+				t.Fatal(err)
+			}
+			return tx
+		}
+		post(external, customer, 5000)
+		post(customer, external, 2000)
+
+		lines, err := achRepo.getUnsentACHLines()
+		if err != nil || len(lines) != 4 {
+			t.Fatalf("lines=%#v error=%v", lines, err)
+		}
+
+		export, err := exportACHFile(log.NewNopLogger(), accountRepo, achRepo, cfg, time.Now().UTC())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if export == nil || export.Entries != 2 || export.Skipped != 2 || export.Debits != 5000 || export.Credits != 2000 {
+			t.Fatalf("unexpected export: %#v", export)
+		}
+
+		fd, err := os.Open(filepath.Join(dir, export.FileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fd.Close()
+		file, err := ach.NewReader(fd).Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries := file.Batches[0].GetEntries()
+		if len(entries) != 2 || entries[0].TransactionCode != ach.SavingsDebit || entries[1].TransactionCode != ach.SavingsCredit {
+			t.Fatalf("unexpected entries: %d %d %d", len(entries), entries[0].TransactionCode, entries[1].TransactionCode)
+		}
+		if entries[0].TraceNumber != "231380100000001" || entries[1].TraceNumber != "231380100000002" || strings.TrimSpace(entries[0].DFIAccountNumber) != "98765" {
+			t.Errorf("unexpected entries: %q %q %q", entries[0].TraceNumber, entries[1].TraceNumber, entries[0].DFIAccountNumber)
+		}
+		if matches, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(matches) != 0 {
+			t.Errorf("temporary files left: %v", matches)
+		}
+
+		// Exports which stopped after marking their lines as sent, but before renaming their file, are finished by
+		// the next export. Temporary files of exports which never marked their lines are removed once they're stale.
+		path := filepath.Join(dir, export.FileName)
+		if err := os.Rename(path, path+".tmp"); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"never.ach.tmp", "writing.ach.tmp"} {
+			if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("partial"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		stale := time.Now().Add(-2 * achTempFileTTL)
+		for _, p := range []string{path + ".tmp", filepath.Join(dir, "never.ach.tmp")} {
+			if err := os.Chtimes(p, stale, stale); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := finishACHFiles(log.NewNopLogger(), achRepo, dir, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("export wasn't finished: %v", err)
+		}
+		if matches, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(matches) != 1 || filepath.Base(matches[0]) != "writing.ach.tmp" {
+			t.Errorf("unexpected temporary files: %v", matches)
+		}
+		os.Remove(filepath.Join(dir, "writing.ach.tmp"))
+
+		// Exported lines aren't exported again
+		if lines, err := achRepo.getUnsentACHLines(); err != nil || len(lines) != 0 {
+			t.Errorf("lines=%#v error=%v", lines, err)
+		}
+		if export, err := exportACHFile(log.NewNopLogger(), accountRepo, achRepo, cfg, time.Now().UTC()); export != nil || err != nil {
+			t.Errorf("export=%#v error=%v", export, err)
+		}
+		if err := achRepo.saveACHExport("other.ach", lines[:1], nil, nil); err == nil || !strings.Contains(err.Error(), errACHLineExported.Error()) {
+			t.Errorf("expected error: %v", err)
+		}
+
+		// Trace numbers and file modifiers continue from the last export
+		if last, err := achRepo.getLastACHTraceNumber("23138010"); err != nil || last != "231380100000002" {
+			t.Errorf("last=%q error=%v", last, err)
+		}
+		if n, err := achRepo.countACHFiles(time.Now().Add(-1 * time.Hour)); err != nil || n != 1 {
+			t.Errorf("n=%d error=%v", n, err)
+		}
+		post(customer, external, 1000)
+		export, err = exportACHFile(log.NewNopLogger(), accountRepo, achRepo, cfg, time.Now().UTC())
+		if err != nil || export == nil || !strings.HasSuffix(export.FileName, "-B.ach") {
+			t.Fatalf("export=%#v error=%v", export, err)
+		}
+		if last, err := achRepo.getLastACHTraceNumber("23138010"); err != nil || last != "231380100000003" {
+			t.Errorf("last=%q error=%v", last, err)
+		}
+
+		// Trace numbers which were already used by another export are rejected
+		used := achLine{TransactionID: base.ID(), AccountID: external.ID, TraceNumber: "231380100000003"}
+		if err := achRepo.saveACHExport("other.ach", []achLine{used}, nil, nil); err == nil || !strings.Contains(err.Error(), errACHTraceNumberUsed.Error()) {
+			t.Errorf("expected error: %v", err)
+		}
+
+		// Sequences wrap around after 9999999 and keep counting from there
+		if _, err := repo.db.Exec(`update ach_trace_sequences set last_sequence = ? where odfi = ?;`, 9999999, "23138010"); err != nil {
+			t.Fatal(err)
+		}
+		post(customer, external, 1000)
+		if export, err := exportACHFile(log.NewNopLogger(), accountRepo, achRepo, cfg, time.Now().UTC()); err != nil || export == nil || export.Entries != 1 {
+			t.Fatalf("export=%#v error=%v", export, err)
+		}
+		if last, err := achRepo.getLastACHTraceNumber("23138010"); err != nil || last != "231380100000001" {
+			t.Errorf("last=%q error=%v", last, err)
+		}
+
+		// Lines which aren't valid entries are marked as failed instead of being retried forever
+		invalid := &accounts.Account{ID: base.ID(), Name: "Invalid", AccountNumber: "555", RoutingNumber: "121042883", Status: "open", Type: "Checking"}
+		accountRepo.accounts = append(accountRepo.accounts, invalid)
+		post(customer, invalid, 1000)
+		export, err = exportACHFile(log.NewNopLogger(), accountRepo, achRepo, cfg, time.Now().UTC())
+		if err != nil || export == nil || export.Entries != 0 || export.Failed != 1 || export.Skipped != 1 {
+			t.Fatalf("export=%#v error=%v", export, err)
+		}
+		var status string
+		if err := repo.db.QueryRow(`select status from ach_exports where account_id = ?;`, invalid.ID).Scan(&status); err != nil || status != achLineFailed {
+			t.Errorf("status=%q error=%v", status, err)
+		}
+		if lines, err := achRepo.getUnsentACHLines(); err != nil || len(lines) != 0 {
+			t.Errorf("lines=%#v error=%v", lines, err)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
@@ -0,0 +1,411 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/ach"
+
+	"github.com/go-kit/kit/log"
+)
+
+const (
+	achLineSent    = "sent"
+	achLineSkipped = "skipped"
+	achLineFailed  = "failed"
+
+	// achFileIDModifiers distinguish the files exported on the same day
+	achFileIDModifiers = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	// achTempFileTTL is how old a temporary file must be before finishACHFiles treats it as left behind by an
+	// export which stopped, rather than one which is still being written
+	achTempFileTTL = 10 * time.Minute
+)
+
+// achLine is an ACHCredit or ACHDebit transaction line and, once it's exported, its trace number
+type achLine struct {
+	TransactionID string
+	AccountID     string
+	Purpose       TransactionPurpose
+	Direction     LineDirection
+	Amount        int64
+	EffectiveDate time.Time
+
+	TraceNumber string
+}
+
+// achExportConfig is where NACHA files are written and who they're sent to
+type achExportConfig struct {
+	Dir                      string
+	ImmediateDestination     string
+	ImmediateDestinationName string
+	ImmediateOriginName      string
+}
+
+func (cfg achExportConfig) validate() error {
+	if cfg.Dir == "" {
+		return errors.New("missing directory")
+	}
+	if len(cfg.ImmediateDestination) != 9 {
+		return fmt.Errorf("invalid immediate destination %q", cfg.ImmediateDestination)
+	}
+	if len(defaultRoutingNumber) != 9 {
+		return fmt.Errorf("invalid immediate origin %q", defaultRoutingNumber)
+	}
+	return nil
+}
+
+// achExport is a NACHA file which was written with the lines it sent
+type achExport struct {
+	FileName string `json:"fileName"`
+	Entries  int    `json:"entries"`
+	Debits   int64  `json:"debits"`
+	Credits  int64  `json:"credits"`
+	Skipped  int    `json:"skipped"`
+	Failed   int    `json:"failed"`
+}
+
+// isOutboundACHLine returns true when line moves money in or out of an account at another financial institution.
+// Like isInternalDebit, accounts are ours when their routing number matches routingNumber.
+func isOutboundACHLine(acct *accounts.Account, line achLine, routingNumber string) bool {
+	if acct == nil || acct.ID != line.AccountID {
+		return false
+	}
+	return acct.RoutingNumber != routingNumber && acct.RoutingNumber != "" && acct.AccountNumber != ""
+}
+
+// nextACHTraceNumber returns the sequence number after traceNumber, wrapping around after 9999999
+func nextACHTraceNumber(traceNumber string) (int, error) {
+	if len(traceNumber) < 7 {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(traceNumber[len(traceNumber)-7:])
+	if err != nil {
+		return 0, fmt.Errorf("invalid trace number %q: %w", traceNumber, err)
+	}
+	if n >= 9999999 {
+		return 1, nil
+	}
+	return n + 1, nil
+}
+
+// achName returns s with only the printable ASCII characters NACHA fields allow
+func achName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' || r > '~' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// buildACHEntry returns the entry which sends line to the external account acct. Credits to the external account
+// send it money and debits pull money from it.
+func buildACHEntry(acct *accounts.Account, line achLine, odfi string, seq int) *ach.EntryDetail {
+	savings := strings.EqualFold(acct.Type, "savings")
+
+	entry := ach.NewEntryDetail()
+	switch {
+	case line.Direction == Credit && savings:
+		entry.TransactionCode = ach.SavingsCredit
+	case line.Direction == Credit:
+		entry.TransactionCode = ach.CheckingCredit
+	case savings:
+		entry.TransactionCode = ach.SavingsDebit
+	default:
+		entry.TransactionCode = ach.CheckingDebit
+	}
+	entry.SetRDFI(acct.RoutingNumber)
+	entry.DFIAccountNumber = acct.AccountNumber
+	entry.Amount = int(line.Amount)
+	entry.IndividualName = achName(acct.Name)
+	entry.SetTraceNumber(odfi, seq)
+	entry.Category = ach.CategoryForward
+	return entry
+}
+
+// buildACHFile returns a NACHA file with one PPD batch of entries, originated from our default routing number
+func buildACHFile(cfg achExportConfig, entries []*ach.EntryDetail, modifier string, now time.Time) (*ach.File, error) {
+	fh := ach.NewFileHeader()
+	fh.ImmediateDestination = cfg.ImmediateDestination
+	fh.ImmediateOrigin = defaultRoutingNumber
+	fh.FileCreationDate = now.Format("060102")
+	fh.FileCreationTime = now.Format("1504")
+	fh.FileIDModifier = modifier
+	fh.ImmediateDestinationName = achName(cfg.ImmediateDestinationName)
+	fh.ImmediateOriginName = achName(cfg.ImmediateOriginName)
+
+	bh := ach.NewBatchHeader()
+	bh.ServiceClassCode = ach.MixedDebitsAndCredits
+	bh.CompanyName = achName(cfg.ImmediateOriginName)
+	bh.CompanyIdentification = defaultRoutingNumber
+	bh.StandardEntryClassCode = ach.PPD
+	bh.CompanyEntryDescription = "TRANSFER"
+	bh.EffectiveEntryDate = now.AddDate(0, 0, 1).Format("060102")
+	bh.ODFIIdentification = defaultRoutingNumber[:8]
+
+	batch := ach.NewBatchPPD(bh)
+	for i := range entries {
+		batch.AddEntry(entries[i])
+	}
+	if err := batch.Create(); err != nil {
+		return nil, fmt.Errorf("batch: %w", err)
+	}
+
+	file := ach.NewFile()
+	file.SetHeader(fh)
+	file.AddBatch(batch)
+	if err := file.Create(); err != nil {
+		return nil, fmt.Errorf("file: %w", err)
+	}
+	return file, file.Validate()
+}
+
+// writeACHFile writes file into dir as name. The file is written and synced under a temporary name first and is
+// only renamed once commit marks its lines as sent, so a file is never left behind for lines which weren't.
+//
+// The export can stop after commit but before the rename, or commit can fail after its lines were marked. Either
+// way the temporary file is kept, and finishACHFiles renames it on a later export if its lines were marked.
+func writeACHFile(dir, name string, file *ach.File, commit func() error) error {
+	path := filepath.Join(dir, name)
+	tmp, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	if err := ach.NewWriter(tmp).Write(file); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := commit(); err != nil {
+		if errors.Is(err, errACHLineExported) || errors.Is(err, errACHTraceNumberUsed) {
+			os.Remove(tmp.Name()) // nothing was marked
+		}
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// syncDir flushes renames in dir to disk
+func syncDir(dir string) error {
+	fd, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	if err := fd.Sync(); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+// finishACHFiles renames the temporary files in dir which were left behind by exports that marked their lines as
+// sent but stopped before renaming them. Temporary files of exports which never marked their lines are removed,
+// since those lines are exported again. Files newer than achTempFileTTL could still be written and are left alone.
+func finishACHFiles(logger log.Logger, repo achExportRepository, dir string, now time.Time) error {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.ach.tmp"))
+	if err != nil {
+		return err
+	}
+	for i := range matches {
+		info, err := os.Stat(matches[i])
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		if now.Sub(info.ModTime()) < achTempFileTTL {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(matches[i]), ".tmp")
+		exported, err := repo.achFileExported(name)
+		if err != nil {
+			return err
+		}
+		if !exported {
+			logger.Log("ach", fmt.Sprintf("removing %s which was never exported", matches[i]))
+			if err := os.Remove(matches[i]); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		logger.Log("ach", fmt.Sprintf("finishing export of %s", name))
+		if err := os.Rename(matches[i], filepath.Join(dir, name)); err != nil {
+			return err
+		}
+		if err := syncDir(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportACHFile builds a NACHA file of every unsent ACH line to an external account, writes it into cfg.Dir and
+// marks the lines as sent with their trace numbers. Lines of our own (or unknown) accounts are marked as skipped and
+// lines which aren't valid NACHA entries are marked as failed. Nil is returned when there was nothing to export.
+func exportACHFile(logger log.Logger, accountRepo accountRepository, repo achExportRepository, cfg achExportConfig, now time.Time) (*achExport, error) {
+	if err := finishACHFiles(logger, repo, cfg.Dir, now); err != nil {
+		return nil, err
+	}
+	lines, err := repo.getUnsentACHLines()
+	if err != nil || len(lines) == 0 {
+		return nil, err
+	}
+	var accountIDs []string
+	for i := range lines {
+		accountIDs = append(accountIDs, lines[i].AccountID)
+	}
+	accts, err := accountRepo.GetAccounts(accountIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*accounts.Account)
+	for i := range accts {
+		byID[accts[i].ID] = accts[i]
+	}
+
+	odfi := defaultRoutingNumber[:8]
+	last, err := repo.getLastACHTraceNumber(odfi)
+	if err != nil {
+		return nil, err
+	}
+	seq, err := nextACHTraceNumber(last)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &achExport{}
+	var sent, skipped, failed []achLine
+	var entries []*ach.EntryDetail
+	for i := range lines {
+		acct := byID[lines[i].AccountID]
+		if !isOutboundACHLine(acct, lines[i], defaultRoutingNumber) {
+			skipped = append(skipped, lines[i])
+			continue
+		}
+		entry := buildACHEntry(acct, lines[i], odfi, seq)
+		if err := entry.Validate(); err != nil {
+			logger.Log("ach", fmt.Sprintf("unable to export transaction=%s account=%s: %v", lines[i].TransactionID, lines[i].AccountID, err))
+			failed = append(failed, lines[i])
+			continue
+		}
+		lines[i].TraceNumber = entry.TraceNumber
+		sent = append(sent, lines[i])
+		entries = append(entries, entry)
+		if lines[i].Direction == Credit {
+			export.Credits += lines[i].Amount
+		} else {
+			export.Debits += lines[i].Amount
+		}
+		if seq, err = nextACHTraceNumber(entry.TraceNumber); err != nil {
+			return nil, err
+		}
+	}
+	export.Entries, export.Skipped, export.Failed = len(sent), len(skipped), len(failed)
+
+	if len(sent) == 0 {
+		if len(skipped) == 0 && len(failed) == 0 {
+			return nil, nil
+		}
+		return export, repo.saveACHExport("", nil, skipped, failed)
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	n, err := repo.countACHFiles(today)
+	if err != nil {
+		return nil, err
+	}
+	modifier := string(achFileIDModifiers[n%len(achFileIDModifiers)])
+
+	file, err := buildACHFile(cfg, entries, modifier, now)
+	if err != nil {
+		return nil, err
+	}
+	export.FileName = fmt.Sprintf("%s-%s-%s.ach", now.Format("20060102-150405"), defaultRoutingNumber, modifier)
+	err = writeACHFile(cfg.Dir, export.FileName, file, func() error {
+		return repo.saveACHExport(export.FileName, sent, skipped, failed)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// exportACHFilesEvery periodically exports unsent ACH lines until ctx is done.
+func exportACHFilesEvery(ctx context.Context, logger log.Logger, accountRepo accountRepository, repo achExportRepository, cfg achExportConfig, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			export, err := exportACHFile(logger, accountRepo, repo, cfg, now.UTC())
+			if err != nil {
+				logger.Log("ach", fmt.Sprintf("problem exporting ACH file: %v", err))
+				continue
+			}
+			if export != nil && export.FileName != "" {
+				logger.Log("ach", fmt.Sprintf("exported %d entries into %s", export.Entries, export.FileName))
+			}
+		}
+	}
+}
+
+// addACHExportRoutes registers the admin endpoint for exporting ACH files
+func addACHExportRoutes(logger log.Logger, handle func(string, http.HandlerFunc), accountRepo accountRepository, repo achExportRepository, cfg achExportConfig) {
+	handle("/ach/export", exportACHFiles(logger, accountRepo, repo, cfg))
+}
+
+// exportACHFiles writes a NACHA file of the unsent ACH lines now instead of waiting for the next export
+func exportACHFiles(logger log.Logger, accountRepo accountRepository, repo achExportRepository, cfg achExportConfig) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, fmt.Sprintf("unsupported HTTP verb %s", r.Method), http.StatusBadRequest)
+			return
+		}
+		if err := cfg.validate(); err != nil {
+			http.Error(w, fmt.Sprintf("ACH exports aren't configured: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		export, err := exportACHFile(logger, accountRepo, repo, cfg, time.Now().UTC())
+		if err != nil {
+			logger.Log("ach", fmt.Sprintf("problem exporting ACH file: %v", err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if export == nil {
+			export = &achExport{}
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(export)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/ach"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+func TestACHExports__nextACHTraceNumber(t *testing.T) {
+	for trace, expected := range map[string]int{
+		"":                1,
+		"231380100000001": 2,
+		"231380109999999": 1,
+	} {
+		if n, err := nextACHTraceNumber(trace); err != nil || n != expected {
+			t.Errorf("%q: n=%d error=%v", trace, n, err)
+		}
+	}
+	if _, err := nextACHTraceNumber("23138010000000x"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestACHExports__isOutboundACHLine(t *testing.T) {
+	line := achLine{AccountID: "a", Direction: Credit}
+	if !isOutboundACHLine(&accounts.Account{ID: "a", AccountNumber: "123", RoutingNumber: "121042882"}, line, defaultRoutingNumber) {
+		t.Error("expected outbound line")
+	}
+	for _, acct := range []*accounts.Account{
+		nil,
+		{ID: "a", AccountNumber: "123", RoutingNumber: defaultRoutingNumber},
+		{ID: "a", RoutingNumber: "121042882"},
+		{ID: "b", AccountNumber: "123", RoutingNumber: "121042882"},
+	} {
+		if isOutboundACHLine(acct, line, defaultRoutingNumber) {
+			t.Errorf("unexpected outbound line: %#v", acct)
+		}
+	}
+}
+
+func TestACHExports__buildACHEntry(t *testing.T) {
+	acct := &accounts.Account{ID: "a", Name: "Jöhn Doe", AccountNumber: "123", RoutingNumber: "121042882", Type: "Checking"}
+	entry := buildACHEntry(acct, achLine{AccountID: "a", Direction: Debit, Amount: 1250}, "23138010", 12)
+	if entry.TransactionCode != ach.CheckingDebit || entry.Amount != 1250 || entry.TraceNumber != "231380100000012" || entry.IndividualName != "Jhn Doe" {
+		t.Errorf("unexpected entry: %#v", entry)
+	}
+	if err := entry.Validate(); err != nil {
+		t.Error(err)
+	}
+	if entry := buildACHEntry(acct, achLine{AccountID: "a", Direction: Credit, Amount: 1250}, "23138010", 13); entry.TransactionCode != ach.CheckingCredit {
+		t.Errorf("unexpected transaction code: %d", entry.TransactionCode)
+	}
+}
+
+func TestACHExports__writeACHFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ach-write")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	acct := &accounts.Account{ID: "a", Name: "John Doe", AccountNumber: "123", RoutingNumber: "121042882", Type: "Checking"}
+	cfg := achExportConfig{Dir: dir, ImmediateDestination: "121042882", ImmediateDestinationName: "Federal Reserve Bank", ImmediateOriginName: "Moov"}
+	file, err := buildACHFile(cfg, []*ach.EntryDetail{buildACHEntry(acct, achLine{AccountID: "a", Direction: Credit, Amount: 100}, "23138010", 1)}, "A", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Files of lines another export marked are removed, but when marking failed for any other reason the lines
+	// could have been marked, so the file is kept for finishACHFiles
+	if err := writeACHFile(dir, "a.ach", file, func() error { return errACHLineExported }); !errors.Is(err, errACHLineExported) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.ach.tmp")); !os.IsNotExist(err) {
+		t.Errorf("expected temporary file to be removed: %v", err)
+	}
+	if err := writeACHFile(dir, "b.ach", file, func() error { return errors.New("connection reset") }); err == nil {
+		t.Error("expected error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "b.ach.tmp")); err != nil {
+		t.Errorf("expected temporary file to be kept: %v", err)
+	}
+	if err := writeACHFile(dir, "c.ach", file, func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "c.ach")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestACHExports__routes(t *testing.T) {
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	repo := setupSqlACHExportStorage(log.NewNopLogger(), createTestSqlTransactionRepository(t, sqliteDB.DB).db)
+
+	handle := func(router *mux.Router) func(string, http.HandlerFunc) {
+		return func(path string, hf http.HandlerFunc) {
+			router.HandleFunc(path, hf)
+		}
+	}
+
+	// Not configured
+	router := mux.NewRouter()
+	addACHExportRoutes(log.NewNopLogger(), handle(router), &testAccountRepository{}, repo, achExportConfig{})
+	for _, method := range []string{"GET", "POST"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/ach/export", nil))
+		w.Flush()
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: bogus HTTP status: %d", method, w.Code)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "ach-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	router = mux.NewRouter()
+	addACHExportRoutes(log.NewNopLogger(), handle(router), &testAccountRepository{}, repo, achExportConfig{Dir: dir, ImmediateDestination: "121042882"})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/ach/export", nil))
+	w.Flush()
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"entries":0`) {
+		t.Errorf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+}
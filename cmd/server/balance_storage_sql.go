@@ -0,0 +1,295 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/moov-io/accounts/cmd/server/database"
+)
+
+// getAccountBalance returns the checkpointed balance of an account. Balances are kept up to date
+// as each transactionLine is posted, so reading them doesn't scan every line.
+func (r *sqlTransactionRepository) getAccountBalance(tx *sql.Tx, accountID string) (int64, error) {
+	if accountID == "" {
+		return 0, nil
+	}
+
+	query := `select balance from account_balances where account_id = ? limit 1;`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var balance int64
+	if err := stmt.QueryRow(accountID).Scan(&balance); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil // no lines posted yet
+		}
+		return 0, fmt.Errorf("problem reading account=%s balance: %w", accountID, err)
+	}
+	return balance, nil
+}
+
+// getAccountBalances returns the current, available and pending balances of an account. Holds which
+// have expired are ignored even if expireHolds hasn't marked them yet.
+func (r *sqlTransactionRepository) getAccountBalances(tx *sql.Tx, accountID string) (accountBalances, error) {
+	var out accountBalances
+
+	current, err := r.getAccountBalance(tx, accountID)
+	if err != nil {
+		return out, err
+	}
+	out.Current = current
+
+	query := `select coalesce(sum(case when account_id = ? then amount - captured_amount else 0 end), 0), coalesce(sum(case when credit_account_id = ? then amount - captured_amount else 0 end), 0)
+from account_holds where (account_id = ? or credit_account_id = ?) and status = ? and expires_at > ?;`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return out, fmt.Errorf("getAccountBalances: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	var debits, credits int64
+	if err := stmt.QueryRow(accountID, accountID, accountID, accountID, HoldPending, time.Now()).Scan(&debits, &credits); err != nil {
+		return out, fmt.Errorf("getAccountBalances: account=%q holds: %w", accountID, err)
+	}
+
+	// Held credits aren't available until they're captured
+	out.Available = out.Current - debits
+	out.Pending = credits - debits
+	return out, nil
+}
+
+var (
+	errBalanceConflict = errors.New("account balance was modified concurrently")
+)
+
+// applyBalanceChange adds change to the checkpointed balance of accountID and returns the new balance. It needs to be
+// called in the same database transaction which inserts the transactionLine.
+//
+// Each balance carries a version which is incremented on every write. If another database transaction updated the balance
+// after we read it errBalanceConflict is returned and the caller should retry with a new database transaction.
+func (r *sqlTransactionRepository) applyBalanceChange(tx *sql.Tx, accountID string, change int64) (int64, error) {
+	query := `select balance, version from account_balances where account_id = ? limit 1;`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return 0, fmt.Errorf("applyBalanceChange: prepare: %w", err)
+	}
+	exists := true
+	var balance, version int64
+	if err := stmt.QueryRow(accountID).Scan(&balance, &version); err != nil {
+		if err != sql.ErrNoRows {
+			stmt.Close()
+			return 0, fmt.Errorf("applyBalanceChange: account=%q read: %w", accountID, err)
+		}
+		exists = false
+	}
+	stmt.Close()
+
+	balance, err = addAmounts(balance, change)
+	if err != nil {
+		return 0, fmt.Errorf("applyBalanceChange: account=%q: %w", accountID, err)
+	}
+	if !exists {
+		// This is the first line posted against the account
+		query = `insert into account_balances (account_id, balance, version, last_modified) values (?, ?, 1, ?);`
+		stmt, err = tx.Prepare(query)
+		if err != nil {
+			return 0, fmt.Errorf("applyBalanceChange: prepare insert: %w", err)
+		}
+		defer stmt.Close()
+
+		if _, err := stmt.Exec(accountID, balance, time.Now()); err != nil {
+			if database.UniqueViolation(err) {
+				return 0, fmt.Errorf("applyBalanceChange: account=%q: %w", accountID, errBalanceConflict)
+			}
+			return 0, fmt.Errorf("applyBalanceChange: account=%q insert: %w", accountID, err)
+		}
+		return balance, nil
+	}
+
+	query = `update account_balances set balance = ?, version = version + 1, last_modified = ? where account_id = ? and version = ?;`
+	stmt, err = tx.Prepare(query)
+	if err != nil {
+		return 0, fmt.Errorf("applyBalanceChange: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(balance, time.Now(), accountID, version)
+	if err != nil {
+		return 0, fmt.Errorf("applyBalanceChange: account=%q update: %w", accountID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return 0, fmt.Errorf("applyBalanceChange: account=%q: %w", accountID, errBalanceConflict)
+	}
+	return balance, nil
+}
+
+// reconcileBalances recomputes every account balance from its transactionLines and returns each account
+// whose checkpointed balance differs. When repair is set the checkpointed balances are overwritten.
+func (r *sqlTransactionRepository) reconcileBalances(repair bool) ([]balanceDrift, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("reconcileBalances: tx.Begin: %w", err)
+	}
+
+	computed, err := readBalances(tx, `select account_id, sum(case when direction = 'debit' then -amount else amount end) from transaction_lines where deleted_at is null group by account_id;`)
+	if err != nil {
+		return nil, fmt.Errorf("reconcileBalances: computed: error=%w rollback=%v", err, tx.Rollback())
+	}
+	checkpointed, err := readBalances(tx, `select account_id, balance from account_balances;`)
+	if err != nil {
+		return nil, fmt.Errorf("reconcileBalances: checkpointed: error=%w rollback=%v", err, tx.Rollback())
+	}
+
+	var drifts []balanceDrift
+	for accountID, balance := range computed {
+		if checkpointed[accountID] != balance {
+			drifts = append(drifts, balanceDrift{AccountID: accountID, Checkpointed: checkpointed[accountID], Computed: balance})
+		}
+	}
+	for accountID, balance := range checkpointed {
+		if _, exists := computed[accountID]; !exists && balance != 0 {
+			drifts = append(drifts, balanceDrift{AccountID: accountID, Checkpointed: balance, Computed: 0})
+		}
+	}
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].AccountID < drifts[j].AccountID })
+
+	if repair {
+		for i := range drifts {
+			change := drifts[i].Computed - drifts[i].Checkpointed
+			if _, err := r.applyBalanceChange(tx, drifts[i].AccountID, change); err != nil {
+				return nil, fmt.Errorf("reconcileBalances: repair: error=%w rollback=%v", err, tx.Rollback())
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("reconcileBalances: commit: %w", err)
+	}
+	return drifts, nil
+}
+
+func readBalances(tx *sql.Tx, query string, args ...interface{}) (map[string]int64, error) {
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := make(map[string]int64)
+	for rows.Next() {
+		var accountID string
+		var balance int64
+		if err := rows.Scan(&accountID, &balance); err != nil {
+			return nil, err
+		}
+		balances[accountID] = balance
+	}
+	return balances, rows.Err()
+}
+
+// getAccountBalanceAsOf sums the lines of accountID effective before asOf. Unlike getAccountBalance this reads every
+// line of the account, so it's only used for historical balances.
+func (r *sqlTransactionRepository) getAccountBalanceAsOf(accountID string, asOf time.Time) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("getAccountBalanceAsOf: tx.Begin: %w", err)
+	}
+	balance, err := readBalanceAsOf(tx, accountID, asOf)
+	if err != nil {
+		return 0, fmt.Errorf("getAccountBalanceAsOf: account=%s: %w rollback=%v", accountID, err, tx.Rollback())
+	}
+	return balance, tx.Commit()
+}
+
+func readBalanceAsOf(tx *sql.Tx, accountID string, asOf time.Time) (int64, error) {
+	query := `select coalesce(sum(case when l.direction = 'debit' then -l.amount else l.amount end), 0)
+from transaction_lines l inner join transactions t on t.transaction_id = l.transaction_id
+where l.account_id = ? and t.effective_date < ? and l.deleted_at is null and t.deleted_at is null;`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var balance int64
+	if err := stmt.QueryRow(accountID, asOf.UTC()).Scan(&balance); err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
+
+// getDailyBalances reads the opening balance at from and then walks the account's transactions effective
+// in [from, to) in order, so each day's intraday minimum and maximum can be found.
+func (r *sqlTransactionRepository) getDailyBalances(accountID string, from, to time.Time) ([]dailyBalance, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("getDailyBalances: tx.Begin: %w", err)
+	}
+	opening, err := readBalanceAsOf(tx, accountID, from)
+	if err != nil {
+		return nil, fmt.Errorf("getDailyBalances: account=%s opening balance: %w rollback=%v", accountID, err, tx.Rollback())
+	}
+
+	changes, err := readBalanceChanges(tx, accountID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("getDailyBalances: account=%s: %w rollback=%v", accountID, err, tx.Rollback())
+	}
+	return buildDailyBalances(from, to, opening, changes), tx.Commit()
+}
+
+// readBalanceChanges returns the net change of each transaction to the balance of accountID effective in [from, to),
+// ordered by effective date. Lines with an excluded purpose aren't included.
+func readBalanceChanges(tx *sql.Tx, accountID string, from, to time.Time, excluded ...TransactionPurpose) ([]effectiveBalanceChange, error) {
+	args := []interface{}{accountID, from.UTC(), to.UTC()}
+	purposes := ""
+	if len(excluded) > 0 {
+		purposes = fmt.Sprintf(" and l.purpose not in (?%s)", strings.Repeat(",?", len(excluded)-1))
+		for i := range excluded {
+			args = append(args, excluded[i])
+		}
+	}
+	query := fmt.Sprintf(`select t.transaction_id, t.effective_date, sum(case when l.direction = 'debit' then -l.amount else l.amount end)
+from transaction_lines l inner join transactions t on t.transaction_id = l.transaction_id
+where l.account_id = ? and t.effective_date >= ? and t.effective_date < ? and l.deleted_at is null and t.deleted_at is null%s
+group by t.transaction_id, t.effective_date
+order by t.effective_date asc, t.transaction_id asc;`, purposes)
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []effectiveBalanceChange
+	for rows.Next() {
+		var transactionID string
+		var change effectiveBalanceChange
+		if err := rows.Scan(&transactionID, &change.EffectiveDate, &change.Amount); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
@@ -0,0 +1,300 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+)
+
+func TestSqlTransactionRepository__reconcileBalances(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+
+		account1, account2 := base.ID(), base.ID()
+		repo.accountRepo = &testAccountRepository{}
+
+		tx := transaction{
+			ID:        base.ID(),
+			Timestamp: time.Now(),
+			Lines: []transactionLine{
+				{AccountID: account1, Purpose: ACHDebit, Direction: Debit, Amount: 500},
+				{AccountID: account2, Purpose: ACHCredit, Direction: Credit, Amount: 500},
+			},
+		}
+		if err := repo.createTransaction(tx, createTransactionOpts{AllowOverdraft: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		drifts, err := repo.reconcileBalances(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(drifts) != 0 {
+			t.Fatalf("unexpected drifts: %#v", drifts)
+		}
+
+		// Corrupt a checkpointed balance
+		if _, err := repo.db.Exec(`update account_balances set balance = 123 where account_id = ?`, account2); err != nil {
+			t.Fatal(err)
+		}
+		drifts, err = repo.reconcileBalances(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(drifts) != 1 || drifts[0].AccountID != account2 || drifts[0].Checkpointed != 123 || drifts[0].Computed != 500 {
+			t.Fatalf("unexpected drifts: %#v", drifts)
+		}
+
+		// Repair and verify
+		if _, err := repo.reconcileBalances(true); err != nil {
+			t.Fatal(err)
+		}
+		drifts, err = repo.reconcileBalances(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(drifts) != 0 {
+			t.Fatalf("unexpected drifts: %#v", drifts)
+		}
+
+		dbtx, _ := repo.db.Begin()
+		defer dbtx.Rollback()
+
+		if bal, err := repo.getAccountBalance(dbtx, account1); err != nil || bal != -500 {
+			t.Errorf("got balance of %d: %v", bal, err)
+		}
+		if bal, err := repo.getAccountBalance(dbtx, account2); err != nil || bal != 500 {
+			t.Errorf("got balance of %d: %v", bal, err)
+		}
+		if bal, err := repo.getAccountBalance(dbtx, base.ID()); err != nil || bal != 0 {
+			t.Errorf("got balance of %d: %v", bal, err)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
+
+// TestSqlTransactionRepository__concurrentDebits fires parallel debits at one account and
+// verifies the balance checks hold so the account is never overdrawn.
+func TestSqlTransactionRepository__concurrentDebits(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+
+		account1, account2 := base.ID(), base.ID()
+		repo.accountRepo = &testAccountRepository{
+			accounts: []*accounts.Account{
+				{ID: account1, AccountNumber: "123", RoutingNumber: defaultRoutingNumber},
+				{ID: account2, AccountNumber: "432", RoutingNumber: "121042882"},
+			},
+		}
+
+		deposit := transaction{
+			ID:        base.ID(),
+			Timestamp: time.Now(),
+			Lines: []transactionLine{
+				{AccountID: account1, Purpose: ACHCredit, Direction: Credit, Amount: 1000},
+			},
+		}
+		if err := repo.createTransaction(deposit, createTransactionOpts{InitialDeposit: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		posted := 0
+		for i := 0; i < 25; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				tx := transaction{
+					ID:        base.ID(),
+					Timestamp: time.Now(),
+					Lines: []transactionLine{
+						{AccountID: account1, Purpose: ACHDebit, Direction: Debit, Amount: 100},
+						{AccountID: account2, Purpose: ACHCredit, Direction: Credit, Amount: 100},
+					},
+				}
+				err := repo.createTransaction(tx, createTransactionOpts{})
+
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err == nil:
+					posted++
+				case strings.Contains(err.Error(), errInsufficientFunds.Error()):
+				default:
+					// Conflicting debits are retried, so the only rejections should be for a lack of funds
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// The deposit covers exactly ten debits
+		if posted != 10 {
+			t.Fatalf("posted %d of 25 debits, expected 10", posted)
+		}
+
+		dbtx, _ := repo.db.Begin()
+		defer dbtx.Rollback()
+
+		balance, err := repo.getAccountBalance(dbtx, account1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if balance < 0 {
+			t.Errorf("account was overdrawn: balance=%d", balance)
+		}
+		if expected := int64(1000 - 100*posted); balance != expected {
+			t.Errorf("balance=%d expected %d after %d debits", balance, expected, posted)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
+
+func TestSqlTransactionRepository__largeBalances(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+
+		account1, account2 := base.ID(), base.ID()
+		repo.accountRepo = &testAccountRepository{}
+
+		// $50 billion is well past what fits in an int32 of cents
+		tx := transaction{
+			ID:        base.ID(),
+			Timestamp: time.Now(),
+			Lines: []transactionLine{
+				{AccountID: account1, Purpose: ACHDebit, Direction: Debit, Amount: 5000000000000},
+				{AccountID: account2, Purpose: ACHCredit, Direction: Credit, Amount: 5000000000000},
+			},
+		}
+		if err := repo.createTransaction(tx, createTransactionOpts{AllowOverdraft: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		dbtx, _ := repo.db.Begin()
+		if bal, err := repo.getAccountBalance(dbtx, account2); err != nil || bal != 5000000000000 {
+			t.Errorf("got balance of %d: %v", bal, err)
+		}
+		dbtx.Rollback()
+
+		// Push account2 past the int64 limit
+		tx = transaction{
+			ID:        base.ID(),
+			Timestamp: time.Now(),
+			Lines: []transactionLine{
+				{AccountID: base.ID(), Purpose: ACHDebit, Direction: Debit, Amount: math.MaxInt64},
+				{AccountID: account2, Purpose: ACHCredit, Direction: Credit, Amount: math.MaxInt64},
+			},
+		}
+		if err := repo.createTransaction(tx, createTransactionOpts{AllowOverdraft: true}); err == nil {
+			t.Error("expected error")
+		} else if !strings.Contains(err.Error(), errAmountOverflow.Error()) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
+
+func TestSqlTransactionRepository__balanceHistory(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+
+		customer, other := base.ID(), base.ID()
+		repo.accountRepo = &testAccountRepository{}
+
+		post := func(effective time.Time, amount int64) {
+			t.Helper()
+			direction, opposite := Credit, Debit
+			if amount < 0 {
+				direction, opposite, amount = Debit, Credit, -amount
+			}
+			err := repo.createTransaction(transaction{
+				ID:            base.ID(),
+				Timestamp:     time.Now(),
+				EffectiveDate: effective,
+				Lines: []transactionLine{
+					{AccountID: customer, Purpose: Transfer, Direction: direction, Amount: amount},
+					{AccountID: other, Purpose: Transfer, Direction: opposite, Amount: amount},
+				},
+			}, createTransactionOpts{AllowOverdraft: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		post(time.Date(2020, time.March, 1, 9, 0, 0, 0, time.UTC), 1000)
+		post(time.Date(2020, time.March, 2, 9, 0, 0, 0, time.UTC), -1500)
+		post(time.Date(2020, time.March, 2, 17, 0, 0, 0, time.UTC), 2000)
+		post(time.Date(2020, time.March, 4, 12, 0, 0, 0, time.UTC), -100)
+
+		if balance, err := repo.getAccountBalanceAsOf(customer, time.Date(2020, time.March, 2, 12, 0, 0, 0, time.UTC)); err != nil || balance != -500 {
+			t.Errorf("balance=%d error=%v", balance, err)
+		}
+		if balance, err := repo.getAccountBalanceAsOf(customer, time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC)); err != nil || balance != 0 {
+			t.Errorf("balance=%d error=%v", balance, err)
+		}
+
+		days, err := repo.getDailyBalances(customer, time.Date(2020, time.March, 2, 0, 0, 0, 0, time.UTC), time.Date(2020, time.March, 5, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(days) != 3 {
+			t.Fatalf("unexpected days: %#v", days)
+		}
+		if d := days[0]; d.Date != "2020-03-02" || d.Opening != 1000 || d.Closing != 1500 || d.Min != -500 || d.Max != 1500 {
+			t.Errorf("unexpected day: %#v", d)
+		}
+		if d := days[1]; d.Opening != 1500 || d.Closing != 1500 || d.Min != 1500 || d.Max != 1500 {
+			t.Errorf("unexpected day: %#v", d)
+		}
+		if d := days[2]; d.Date != "2020-03-04" || d.Closing != 1400 || d.Min != 1400 {
+			t.Errorf("unexpected day: %#v", d)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
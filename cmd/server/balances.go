@@ -0,0 +1,244 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	moovhttp "github.com/moov-io/base/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+// balanceDrift is an account whose checkpointed balance doesn't match the sum of its transactionLines.
+type balanceDrift struct {
+	AccountID    string `json:"accountId"`
+	Checkpointed int64  `json:"checkpointed"`
+	Computed     int64  `json:"computed"`
+}
+
+type balanceReconciliation struct {
+	Drifts   []balanceDrift `json:"drifts"`
+	Repaired bool           `json:"repaired"`
+}
+
+// reconcileBalances is an admin endpoint which recomputes every account balance from posted lines and
+// responds with each account whose checkpointed balance has drifted. Calling with ?repair=true will
+// overwrite drifted balances with the computed values.
+func reconcileBalances(logger log.Logger, repo transactionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, fmt.Sprintf("unsupported HTTP verb %s", r.Method), http.StatusBadRequest)
+			return
+		}
+		repair, _ := strconv.ParseBool(r.URL.Query().Get("repair"))
+
+		drifts, err := repo.reconcileBalances(repair)
+		if err != nil {
+			logger.Log("balances", fmt.Sprintf("problem reconciling balances: %v", err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for i := range drifts {
+			logger.Log("balances", fmt.Sprintf("account=%s checkpointed balance %d drifted from computed %d", drifts[i].AccountID, drifts[i].Checkpointed, drifts[i].Computed), "repaired", repair)
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(balanceReconciliation{
+			Drifts:   drifts,
+			Repaired: repair && len(drifts) > 0,
+		})
+	}
+}
+
+// accountBalanceAsOf is the balance of an account from transactions effective before AsOf
+type accountBalanceAsOf struct {
+	AccountID string    `json:"accountId"`
+	AsOf      time.Time `json:"asOf"`
+	Balance   int64     `json:"balance"`
+}
+
+// dailyBalance is the balance history of an account for one day (UTC). Min and Max are the lowest and
+// highest balances of the day, including its opening balance.
+type dailyBalance struct {
+	Date    string `json:"date"`
+	Opening int64  `json:"opening"`
+	Closing int64  `json:"closing"`
+	Min     int64  `json:"min"`
+	Max     int64  `json:"max"`
+}
+
+// effectiveBalanceChange is the net change to an account's balance from one transaction
+type effectiveBalanceChange struct {
+	EffectiveDate time.Time
+	Amount        int64
+}
+
+// buildDailyBalances returns a dailyBalance for each day in [from, to) starting at opening. changes must be
+// ordered by EffectiveDate and within [from, to). Days without any changes keep the prior day's closing balance.
+func buildDailyBalances(from, to time.Time, opening int64, changes []effectiveBalanceChange) []dailyBalance {
+	var out []dailyBalance
+	balance := opening
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		current := dailyBalance{Date: day.Format("2006-01-02"), Opening: balance, Min: balance, Max: balance}
+		for len(changes) > 0 && changes[0].EffectiveDate.Before(next) {
+			balance += changes[0].Amount
+			if balance < current.Min {
+				current.Min = balance
+			}
+			if balance > current.Max {
+				current.Max = balance
+			}
+			changes = changes[1:]
+		}
+		current.Closing = balance
+		out = append(out, current)
+	}
+	return out
+}
+
+// maxDailyBalanceDays limits how many days of balance history are returned at once
+const maxDailyBalanceDays = 366
+
+func addBalanceRoutes(logger log.Logger, r *mux.Router, accountRepo accountRepository, transactionRepo transactionRepository) {
+	r.Methods("GET").Path("/accounts/{accountId}/balance").HandlerFunc(getAccountBalanceAsOf(logger, accountRepo, transactionRepo))
+	r.Methods("GET").Path("/accounts/{accountId}/balances/daily").HandlerFunc(getDailyBalances(logger, accountRepo, transactionRepo))
+}
+
+// readBalanceAccountID returns the accountId of the request if the account exists, otherwise a response is written.
+func readBalanceAccountID(logger log.Logger, w http.ResponseWriter, r *http.Request, accountRepo accountRepository) string {
+	accountID, requestID := getAccountID(w, r), moovhttp.GetRequestID(r)
+	if accountID == "" {
+		return ""
+	}
+	account, err := readAccount(accountRepo, accountID)
+	if err != nil {
+		logger.Log("balances", fmt.Sprintf("error reading account=%s: %v", accountID, err), "requestID", requestID)
+		moovhttp.Problem(w, err)
+		return ""
+	}
+	if account == nil {
+		http.NotFound(w, r)
+		return ""
+	}
+	return accountID
+}
+
+// readAsOfParam reads the asOf query parameter, which includes the entire day for dates and defaults to now.
+func readAsOfParam(v string) (time.Time, error) {
+	if v == "" {
+		return time.Now(), nil
+	}
+	asOf, err := readDateParam(v, true)
+	if err != nil {
+		return asOf, fmt.Errorf("invalid asOf: %w", err)
+	}
+	if _, err := time.Parse("2006-01-02", v); err != nil {
+		// Include transactions effective at the given moment
+		asOf = asOf.Add(time.Nanosecond)
+	}
+	return asOf, nil
+}
+
+func getAccountBalanceAsOf(logger log.Logger, accountRepo accountRepository, transactionRepo transactionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		accountID := readBalanceAccountID(logger, w, r, accountRepo)
+		if accountID == "" {
+			return
+		}
+		asOf, err := readAsOfParam(r.URL.Query().Get("asOf"))
+		if err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		balance, err := transactionRepo.getAccountBalanceAsOf(accountID, asOf)
+		if err != nil {
+			logger.Log("balances", fmt.Sprintf("problem reading account=%s balance: %v", accountID, err), "requestID", moovhttp.GetRequestID(r))
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(accountBalanceAsOf{
+			AccountID: accountID,
+			AsOf:      asOf,
+			Balance:   balance,
+		})
+	}
+}
+
+// readDailyBalanceParams reads the from and to dates, which are both included. The last 30 days are returned by default.
+func readDailyBalanceParams(r *http.Request) (time.Time, time.Time, error) {
+	q := r.URL.Query()
+	var from, to time.Time
+	var err error
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			return from, to, fmt.Errorf("invalid to %q", v)
+		}
+	} else {
+		to = time.Now().UTC().Truncate(24 * time.Hour)
+	}
+	to = to.AddDate(0, 0, 1)
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			return from, to, fmt.Errorf("invalid from %q", v)
+		}
+	} else {
+		from = to.AddDate(0, 0, -30)
+	}
+	if !from.Before(to) {
+		return from, to, errors.New("from must not be after to")
+	}
+	if to.Sub(from) > maxDailyBalanceDays*24*time.Hour {
+		return from, to, fmt.Errorf("at most %d days of balances can be read", maxDailyBalanceDays)
+	}
+	return from, to, nil
+}
+
+func getDailyBalances(logger log.Logger, accountRepo accountRepository, transactionRepo transactionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		accountID := readBalanceAccountID(logger, w, r, accountRepo)
+		if accountID == "" {
+			return
+		}
+		from, to, err := readDailyBalanceParams(r)
+		if err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		balances, err := transactionRepo.getDailyBalances(accountID, from, to)
+		if err != nil {
+			logger.Log("balances", fmt.Sprintf("problem reading account=%s daily balances: %v", accountID, err), "requestID", moovhttp.GetRequestID(r))
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(balances)
+	}
+}
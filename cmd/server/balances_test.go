@@ -0,0 +1,182 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+func TestBalances__reconcileBalances(t *testing.T) {
+	repo := &mockTransactionRepository{
+		drifts: []balanceDrift{
+			{AccountID: "account", Checkpointed: 100, Computed: 200},
+		},
+	}
+	handler := reconcileBalances(log.NewNopLogger(), repo)
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/balances/reconcile?repair=true", nil))
+	w.Flush()
+
+	if w.Code != http.StatusOK {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	var resp balanceReconciliation
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Drifts) != 1 || !resp.Repaired {
+		t.Errorf("unexpected response: %#v", resp)
+	}
+
+	// wrong method
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/balances/reconcile", nil))
+	w.Flush()
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+
+	// error
+	repo.err = errors.New("bad error")
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/balances/reconcile", nil))
+	w.Flush()
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+}
+
+func TestBalances__buildDailyBalances(t *testing.T) {
+	from := time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC)
+	days := buildDailyBalances(from, from.AddDate(0, 0, 3), 100, []effectiveBalanceChange{
+		{EffectiveDate: from.Add(2 * time.Hour), Amount: 50},
+		{EffectiveDate: from.Add(26 * time.Hour), Amount: -200},
+		{EffectiveDate: from.Add(27 * time.Hour), Amount: 75},
+	})
+	if len(days) != 3 {
+		t.Fatalf("unexpected days: %#v", days)
+	}
+	if d := days[0]; d.Date != "2020-03-01" || d.Opening != 100 || d.Closing != 150 || d.Min != 100 || d.Max != 150 {
+		t.Errorf("unexpected day: %#v", d)
+	}
+	if d := days[1]; d.Opening != 150 || d.Closing != 25 || d.Min != -50 || d.Max != 150 {
+		t.Errorf("unexpected day: %#v", d)
+	}
+	if d := days[2]; d.Opening != 25 || d.Closing != 25 || d.Min != 25 || d.Max != 25 {
+		t.Errorf("unexpected day: %#v", d)
+	}
+}
+
+func TestBalances__readDailyBalanceParams(t *testing.T) {
+	from, to, err := readDailyBalanceParams(httptest.NewRequest("GET", "/accounts/foo/balances/daily?from=2020-03-01&to=2020-03-31", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !from.Equal(time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2020, time.April, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("from=%v to=%v", from, to)
+	}
+	if from, to, err = readDailyBalanceParams(httptest.NewRequest("GET", "/accounts/foo/balances/daily", nil)); err != nil || to.Sub(from) != 30*24*time.Hour {
+		t.Errorf("from=%v to=%v error=%v", from, to, err)
+	}
+
+	for _, query := range []string{"from=03/01/2020", "to=2020-03", "from=2020-04-01&to=2020-03-01", "from=2019-01-01&to=2020-03-01"} {
+		if _, _, err := readDailyBalanceParams(httptest.NewRequest("GET", "/accounts/foo/balances/daily?"+query, nil)); err == nil {
+			t.Errorf("%s: expected error", query)
+		}
+	}
+
+	if asOf, err := readAsOfParam("2020-03-31"); err != nil || !asOf.Equal(time.Date(2020, time.April, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("asOf=%v error=%v", asOf, err)
+	}
+	if _, err := readAsOfParam("yesterday"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestBalances__routes(t *testing.T) {
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+
+	repo := createTestSqlTransactionRepository(t, sqliteDB.DB)
+	now := time.Now()
+	customer := &accounts.Account{ID: base.ID(), CustomerID: base.ID(), Name: "Checking", AccountNumber: "123", RoutingNumber: "121042882", Status: "open", Type: "Checking", CreatedAt: now, LastModified: now}
+	if err := repo.accountRepo.CreateAccount(customer.CustomerID, customer); err != nil {
+		t.Fatal(err)
+	}
+	err := repo.createTransaction(transaction{
+		ID:            base.ID(),
+		Timestamp:     now,
+		EffectiveDate: time.Date(2020, time.March, 10, 12, 0, 0, 0, time.UTC),
+		Lines: []transactionLine{
+			{AccountID: customer.ID, Purpose: ACHCredit, Direction: Credit, Amount: 2500},
+			{AccountID: base.ID(), Purpose: ACHDebit, Direction: Debit, Amount: 2500},
+		},
+	}, createTransactionOpts{AllowOverdraft: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := mux.NewRouter()
+	addBalanceRoutes(log.NewNopLogger(), router, repo.accountRepo, repo)
+	call := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("x-user-id", "test")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		w.Flush()
+		return w
+	}
+
+	w := call("/accounts/" + customer.ID + "/balance?asOf=2020-03-10T12:00:00Z")
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+	var asOf accountBalanceAsOf
+	if err := json.NewDecoder(w.Body).Decode(&asOf); err != nil {
+		t.Fatal(err)
+	}
+	if asOf.Balance != 2500 || asOf.AccountID != customer.ID {
+		t.Errorf("unexpected balance: %#v", asOf)
+	}
+	if w := call("/accounts/" + customer.ID + "/balance?asOf=2020-03-09"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"balance":0`) {
+		t.Errorf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+
+	w = call("/accounts/" + customer.ID + "/balances/daily?from=2020-03-09&to=2020-03-11")
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+	var days []dailyBalance
+	if err := json.NewDecoder(w.Body).Decode(&days); err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 3 || days[1].Opening != 0 || days[1].Closing != 2500 || days[2].Opening != 2500 {
+		t.Errorf("unexpected days: %#v", days)
+	}
+
+	if w := call("/accounts/" + base.ID() + "/balance"); w.Code != http.StatusNotFound {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	if w := call("/accounts/" + customer.ID + "/balances/daily?from=bad"); w.Code != http.StatusBadRequest {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+}
@@ -0,0 +1,408 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moov-io/accounts/cmd/server/database"
+
+	"github.com/go-kit/kit/log"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// callReport is an FFIEC 051 Consolidated Report of Condition and Income (call report) for a routing number.
+//
+// Amounts are read from the GL account for each line item's GLCode (e.g. RCON2200 from GL account 2200)
+// and are reported in both cents and thousands of dollars. The PDF reports them in thousands of dollars.
+type callReport struct {
+	Form          string               `json:"form"`
+	RoutingNumber string               `json:"routingNumber"`
+	ReportDate    string               `json:"reportDate"`
+	Schedules     []callReportSchedule `json:"schedules"`
+}
+
+type callReportSchedule struct {
+	Schedule string           `json:"schedule"`
+	Title    string           `json:"title"`
+	Items    []callReportItem `json:"items"`
+}
+
+type callReportItem struct {
+	Item        string `json:"item"`
+	Caption     string `json:"caption"`
+	MDRM        string `json:"mdrm"`
+	AmountCents int64  `json:"amountCents"`
+
+	// AmountThousands is rounded to thousands of dollars. Totals are the sum of their rounded line items,
+	// as the FFIEC instructions require, so they can differ from AmountCents rounded.
+	AmountThousands int64 `json:"amountThousands"`
+}
+
+// callReportLine describes a line item of a schedule. Lines with a sum are totals of the earlier lines listed,
+// less any lines in less, instead of being read from a GL account.
+type callReportLine struct {
+	item      string
+	code      GLCode
+	sum, less []GLCode
+
+	// retainedEarnings lines include income and expenses which haven't been closed into equity yet
+	retainedEarnings bool
+}
+
+type callReportTemplate struct {
+	schedule, title string
+
+	// incomeStatement schedules report activity from the start of the calendar year rather than balances
+	incomeStatement bool
+
+	lines []callReportLine
+}
+
+var callReportTemplates = []callReportTemplate{
+	{
+		schedule: "RC",
+		title:    "Balance Sheet",
+		lines: []callReportLine{
+			{item: "1.a", code: RCON0081},
+			{item: "1.b", code: RCON0071},
+			{item: "2.a", code: RCON1754},
+			{item: "2.b", code: RCON1773},
+			{item: "4.d", code: RCON2122},
+			{item: "6", code: RCON2145},
+			{item: "11", code: RCON2160},
+			{item: "12", code: RCON2170, sum: []GLCode{RCON0081, RCON0071, RCON1754, RCON1773, RCON2122, RCON2145, RCON2160}},
+			{item: "13.a", code: RCON2200},
+			{item: "16", code: RCON3190},
+			{item: "20", code: RCON2930},
+			{item: "21", code: RCON2948, sum: []GLCode{RCON2200, RCON3190, RCON2930}},
+			{item: "24", code: RCON3230},
+			{item: "25", code: RCON3839},
+			{item: "26.a", code: RCON3632, retainedEarnings: true},
+			{item: "27.a", code: RCON3210, sum: []GLCode{RCON3230, RCON3839, RCON3632}},
+			{item: "29", code: RCON3300, sum: []GLCode{RCON2948, RCON3210}},
+		},
+	},
+	{
+		schedule: "RC-E",
+		title:    "Deposit Liabilities",
+		lines: []callReportLine{
+			{item: "7.A", code: RCON2215},
+			{item: "7.C", code: RCON2385},
+			{item: "M.2.a.1", code: RCON6810},
+			{item: "M.2.a.2", code: RCON0352},
+			{item: "M.2.b", code: RCON6648},
+		},
+	},
+	{
+		schedule:        "RI",
+		title:           "Income Statement",
+		incomeStatement: true,
+		lines: []callReportLine{
+			{item: "1.a", code: RIAD4010},
+			{item: "1.c", code: RIAD4115},
+			{item: "1.h", code: RIAD4107, sum: []GLCode{RIAD4010, RIAD4115}},
+			{item: "2.a.1.a", code: RIAD4508},
+			{item: "2.a.1.b", code: RIAD0093},
+			{item: "2.e", code: RIAD4073, sum: []GLCode{RIAD4508, RIAD0093}},
+			{item: "3", code: RIAD4074, sum: []GLCode{RIAD4107}, less: []GLCode{RIAD4073}},
+			{item: "4", code: RIAD4230},
+			{item: "5.b", code: RIAD4080},
+			{item: "5.m", code: RIAD4079, sum: []GLCode{RIAD4080}},
+			{item: "7.a", code: RIAD4135},
+			{item: "7.b", code: RIAD4217},
+			{item: "7.e", code: RIAD4093, sum: []GLCode{RIAD4135, RIAD4217}},
+			{item: "8", code: RIAD4301, sum: []GLCode{RIAD4074, RIAD4079}, less: []GLCode{RIAD4230, RIAD4093}},
+			{item: "9", code: RIAD4302},
+			{item: "14", code: RIAD4340, sum: []GLCode{RIAD4301}, less: []GLCode{RIAD4302}},
+		},
+	},
+}
+
+// readQuarterEnd parses a YYYY-MM-DD date which must be the last day of a calendar quarter
+func readQuarterEnd(v string) (time.Time, error) {
+	date, err := time.Parse("2006-01-02", strings.TrimSpace(v))
+	if err != nil {
+		return date, fmt.Errorf("invalid report date %q: %w", v, err)
+	}
+	if date.Month()%3 != 0 || date.AddDate(0, 0, 1).Day() != 1 {
+		return date, fmt.Errorf("report date %s is not a quarter-end", date.Format("2006-01-02"))
+	}
+	return date, nil
+}
+
+// writeCallReportFromLedger generates the call report for a quarter-end date from the accounts and transactions
+// databases and writes it into dir.
+func writeCallReportFromLedger(ctx context.Context, logger log.Logger, routingNumber, date, dir string) error {
+	reportDate, err := readQuarterEnd(date)
+	if err != nil {
+		return err
+	}
+	accountsDB, err := database.New(ctx, logger, or(os.Getenv("ACCOUNT_STORAGE_TYPE"), "sqlite"))
+	if err != nil {
+		return fmt.Errorf("error connecting to accounts database: %w", err)
+	}
+	accountRepo, err := setupSqlAccountStorage(ctx, logger, accountsDB)
+	if err != nil {
+		return fmt.Errorf("account storage: %w", err)
+	}
+	defer accountRepo.Close()
+
+	db, err := database.New(ctx, logger, or(os.Getenv("TRANSACTION_STORAGE_TYPE"), "sqlite"))
+	if err != nil {
+		return fmt.Errorf("error connecting to transactions database: %w", err)
+	}
+	defer db.Close()
+
+	report, err := generateCallReport(setupSqlGLAccountStorage(logger, db, accountRepo), routingNumber, reportDate)
+	if err != nil {
+		return err
+	}
+	paths, err := writeCallReport(dir, report)
+	if err != nil {
+		return err
+	}
+	logger.Log("call-report", fmt.Sprintf("wrote %s call report as of %s to %s", routingNumber, report.ReportDate, strings.Join(paths, ", ")))
+	return nil
+}
+
+// generateCallReport reads GL balances as of the end of reportDate and builds the call report from them
+func generateCallReport(glRepo glAccountRepository, routingNumber string, reportDate time.Time) (*callReport, error) {
+	end := reportDate.AddDate(0, 0, 1)
+	balances, err := glRepo.getGLActivity(routingNumber, time.Time{}, end)
+	if err != nil {
+		return nil, err
+	}
+	income, err := glRepo.getGLActivity(routingNumber, time.Date(reportDate.Year(), time.January, 1, 0, 0, 0, 0, time.UTC), end)
+	if err != nil {
+		return nil, err
+	}
+	return buildCallReport(routingNumber, reportDate, balances, income)
+}
+
+// buildCallReport fills in each schedule from a chart of accounts with balances as of the report date and one
+// with the activity since the start of the year.
+func buildCallReport(routingNumber string, reportDate time.Time, balances, income []glAccount) (*callReport, error) {
+	report := &callReport{
+		Form:          "FFIEC 051",
+		RoutingNumber: routingNumber,
+		ReportDate:    reportDate.Format("2006-01-02"),
+	}
+	for _, tmpl := range callReportTemplates {
+		chart := balances
+		if tmpl.incomeStatement {
+			chart = income
+		}
+		byCode := make(map[string]int64)
+		for i := range chart {
+			byCode[chart[i].Code] = chart[i].Balance
+		}
+
+		schedule := callReportSchedule{Schedule: tmpl.schedule, Title: tmpl.title}
+		amounts, thousands := make(map[GLCode]int64), make(map[GLCode]int64)
+		for _, line := range tmpl.lines {
+			amount, err := line.amount(byCode, amounts)
+			if err != nil {
+				return nil, fmt.Errorf("call report: schedule %s item %s: %w", tmpl.schedule, line.item, err)
+			}
+			if line.retainedEarnings {
+				amount += undistributedIncome(chart)
+			}
+			rounded := roundThousands(amount)
+			if len(line.sum) > 0 {
+				if rounded, err = line.total(thousands); err != nil {
+					return nil, fmt.Errorf("call report: schedule %s item %s: %w", tmpl.schedule, line.item, err)
+				}
+			}
+			amounts[line.code] = amount
+			thousands[line.code] = rounded
+			schedule.Items = append(schedule.Items, callReportItem{
+				Item:            line.item,
+				Caption:         line.code.Description(),
+				MDRM:            string(line.code),
+				AmountCents:     amount,
+				AmountThousands: rounded,
+			})
+		}
+		report.Schedules = append(report.Schedules, schedule)
+	}
+	return report, nil
+}
+
+func (line callReportLine) amount(byCode map[string]int64, amounts map[GLCode]int64) (int64, error) {
+	if len(line.sum) == 0 {
+		return byCode[line.code.Code()], nil
+	}
+	return line.total(amounts)
+}
+
+// total adds up the earlier lines in sum less the lines in less
+func (line callReportLine) total(amounts map[GLCode]int64) (int64, error) {
+	var total int64
+	add := func(codes []GLCode, sign int64) error {
+		for _, code := range codes {
+			amount, exists := amounts[code]
+			if !exists {
+				return fmt.Errorf("%s isn't an earlier line", code)
+			}
+			total += sign * amount
+		}
+		return nil
+	}
+	if err := add(line.sum, 1); err != nil {
+		return 0, err
+	}
+	if err := add(line.less, -1); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// undistributedIncome returns income less expenses posted to the chart which haven't been closed into equity
+func undistributedIncome(chart []glAccount) int64 {
+	var total int64
+	for i := range chart {
+		if chart[i].ParentCode != "" {
+			continue // balances are already rolled up
+		}
+		switch chart[i].Category {
+		case GLIncome:
+			total += chart[i].Balance
+		case GLExpense:
+			total -= chart[i].Balance
+		}
+	}
+	return total
+}
+
+// writeCallReport writes the report as PDF, JSON and CSV files into dir and returns their paths
+func writeCallReport(dir string, report *callReport) ([]string, error) {
+	name := filepath.Join(dir, fmt.Sprintf("call-report-%s-%s", report.RoutingNumber, report.ReportDate))
+	writers := []struct {
+		ext   string
+		write func(io.Writer, *callReport) error
+	}{
+		{".pdf", renderCallReportPDF},
+		{".json", renderCallReportJSON},
+		{".csv", renderCallReportCSV},
+	}
+	var paths []string
+	for _, w := range writers {
+		fd, err := os.Create(name + w.ext)
+		if err != nil {
+			return paths, err
+		}
+		if err := w.write(fd, report); err != nil {
+			fd.Close()
+			return paths, fmt.Errorf("writing %s: %w", fd.Name(), err)
+		}
+		if err := fd.Close(); err != nil {
+			return paths, err
+		}
+		paths = append(paths, fd.Name())
+	}
+	return paths, nil
+}
+
+func renderCallReportJSON(w io.Writer, report *callReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func renderCallReportCSV(w io.Writer, report *callReport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"schedule", "item", "mdrm", "caption", "amountCents", "amountThousands"})
+	for _, schedule := range report.Schedules {
+		for _, item := range schedule.Items {
+			cw.Write([]string{schedule.Schedule, item.Item, item.MDRM, item.Caption, strconv.FormatInt(item.AmountCents, 10), strconv.FormatInt(item.AmountThousands, 10)})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// renderCallReportPDF lays out each schedule on its own page like the FFIEC 051 forms. The report date is used
+// as the creation date so rendering the same ledger always produces the same file.
+func renderCallReportPDF(w io.Writer, report *callReport) error {
+	reportDate, err := time.Parse("2006-01-02", report.ReportDate)
+	if err != nil {
+		return err
+	}
+	pdf := gofpdf.New("P", "pt", "Letter", "")
+	pdf.SetCreationDate(reportDate)
+	pdf.SetModificationDate(reportDate)
+	pdf.SetCatalogSort(true)
+	pdf.SetTitle(fmt.Sprintf("%s call report for %s as of %s", report.Form, report.RoutingNumber, report.ReportDate), false)
+	pdf.SetMargins(36, 36, 36)
+
+	const (
+		itemWidth    = 50.0
+		captionWidth = 320.0
+		mdrmWidth    = 70.0
+		amountWidth  = 100.0
+		rowHeight    = 16.0
+	)
+	for _, schedule := range report.Schedules {
+		pdf.AddPage()
+
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(270, 12, report.Form, "", 0, "L", false, 0, "")
+		pdf.CellFormat(270, 12, fmt.Sprintf("%s-1", schedule.Schedule), "", 1, "R", false, 0, "")
+		pdf.CellFormat(540, 12, fmt.Sprintf("Routing number %s, report date %s", report.RoutingNumber, reportDate.Format("01/02/2006")), "", 1, "L", false, 0, "")
+		pdf.Ln(6)
+
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(540, 18, fmt.Sprintf("Schedule %s - %s", schedule.Schedule, schedule.Title), "", 1, "C", false, 0, "")
+		pdf.Ln(6)
+
+		pdf.SetFont("Helvetica", "B", 8)
+		pdf.SetFillColor(230, 230, 230)
+		pdf.CellFormat(itemWidth+captionWidth, rowHeight, "", "1", 0, "L", true, 0, "")
+		pdf.CellFormat(mdrmWidth+amountWidth, rowHeight, "Dollar Amounts in Thousands", "1", 1, "C", true, 0, "")
+
+		pdf.SetFont("Helvetica", "", 8)
+		for _, item := range schedule.Items {
+			pdf.CellFormat(itemWidth, rowHeight, item.Item+".", "1", 0, "L", false, 0, "")
+			pdf.CellFormat(captionWidth, rowHeight, item.Caption, "1", 0, "L", false, 0, "")
+			pdf.CellFormat(mdrmWidth, rowHeight, item.MDRM, "1", 0, "C", true, 0, "")
+			pdf.CellFormat(amountWidth, rowHeight, formatThousands(item.AmountThousands), "1", 1, "R", false, 0, "")
+		}
+	}
+	return pdf.Output(w)
+}
+
+// roundThousands rounds cents to the nearest thousand dollars (e.g. 123456789 is 1235)
+func roundThousands(cents int64) int64 {
+	if cents < 0 {
+		return -1 * roundThousands(-1*cents)
+	}
+	return (cents + 50000) / 100000
+}
+
+// formatThousands adds separators to an amount in thousands of dollars (e.g. 1235 is 1,235)
+func formatThousands(thousands int64) string {
+	neg := thousands < 0
+	if neg {
+		thousands = -1 * thousands
+	}
+	s := strconv.FormatInt(thousands, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	if neg && s != "0" {
+		return "-" + s
+	}
+	return s
+}
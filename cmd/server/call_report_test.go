@@ -0,0 +1,255 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestCallReport__readQuarterEnd(t *testing.T) {
+	for _, v := range []string{"2020-03-31", "2020-06-30", "2020-09-30", "2019-12-31"} {
+		if _, err := readQuarterEnd(v); err != nil {
+			t.Errorf("%s: %v", v, err)
+		}
+	}
+	for _, v := range []string{"", "2020-03-30", "2020-04-30", "03/31/2020"} {
+		if _, err := readQuarterEnd(v); err == nil {
+			t.Errorf("%s: expected error", v)
+		}
+	}
+}
+
+func TestCallReport__formatThousands(t *testing.T) {
+	cases := map[int64]string{
+		0:             "0",
+		49999:         "0",
+		50000:         "1",
+		123456789:     "1,235",
+		-123456789:    "-1,235",
+		-49999:        "0",
+		1000000000000: "10,000,000",
+	}
+	for cents, expected := range cases {
+		if v := formatThousands(roundThousands(cents)); v != expected {
+			t.Errorf("%d: got %s", cents, v)
+		}
+	}
+}
+
+func findCallReportItem(t *testing.T, report *callReport, schedule, mdrm string) int64 {
+	t.Helper()
+	for _, s := range report.Schedules {
+		for _, item := range s.Items {
+			if s.Schedule == schedule && item.MDRM == mdrm {
+				return item.AmountCents
+			}
+		}
+	}
+	t.Fatalf("schedule %s item %s not found", schedule, mdrm)
+	return 0
+}
+
+func TestCallReport__build(t *testing.T) {
+	balances := []glAccount{
+		{Code: "0081", Category: GLAsset, Balance: 10000},
+		{Code: "2122", Category: GLAsset, Balance: 5000},
+		{Code: "2200", Category: GLLiability, Balance: 12000},
+		{Code: "2215", Category: GLLiability, ParentCode: "2200", Balance: 12000},
+		{Code: "3230", Category: GLEquity, Balance: 2500},
+		{Code: "3632", Category: GLEquity, Balance: 200},
+		{Code: "4000", Category: GLIncome, Balance: 500},
+		{Code: "4080", Category: GLIncome, ParentCode: "4000", Balance: 500},
+		{Code: "4135", Category: GLExpense, Balance: 200},
+	}
+	income := []glAccount{
+		{Code: "4080", Category: GLIncome, ParentCode: "4000", Balance: 300},
+		{Code: "4135", Category: GLExpense, Balance: 100},
+	}
+	report, err := buildCallReport("121042882", time.Date(2020, time.March, 31, 0, 0, 0, 0, time.UTC), balances, income)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Form != "FFIEC 051" || report.ReportDate != "2020-03-31" || len(report.Schedules) != 3 {
+		t.Errorf("unexpected report: %#v", report)
+	}
+	expected := []struct {
+		schedule, mdrm string
+		amount         int64
+	}{
+		{"RC", "RCON2170", 15000},
+		{"RC", "RCON2948", 12000},
+		{"RC", "RCON3632", 500}, // 200 of retained earnings plus 300 undistributed income
+		{"RC", "RCON3210", 3000},
+		{"RC", "RCON3300", 15000},
+		{"RC-E", "RCON2215", 12000},
+		{"RI", "RIAD4079", 300},
+		{"RI", "RIAD4093", 100},
+		{"RI", "RIAD4340", 200},
+	}
+	for _, e := range expected {
+		if v := findCallReportItem(t, report, e.schedule, e.mdrm); v != e.amount {
+			t.Errorf("%s %s: %d", e.schedule, e.mdrm, v)
+		}
+	}
+
+	// Totals in thousands are the sum of their rounded line items rather than their rounded sum
+	rounding, err := buildCallReport("121042882", time.Date(2020, time.March, 31, 0, 0, 0, 0, time.UTC), []glAccount{
+		{Code: "0081", Category: GLAsset, Balance: 60000},
+		{Code: "2122", Category: GLAsset, Balance: 60000},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range rounding.Schedules[0].Items {
+		switch item.MDRM {
+		case "RCON0081", "RCON2122":
+			if item.AmountCents != 60000 || item.AmountThousands != 1 {
+				t.Errorf("unexpected item: %#v", item)
+			}
+		case "RCON2170":
+			if item.AmountCents != 120000 || item.AmountThousands != 2 {
+				t.Errorf("unexpected total: %#v", item)
+			}
+		}
+	}
+
+	// Each format is written and the PDF is the same every time it's rendered
+	var first, second bytes.Buffer
+	if err := renderCallReportPDF(&first, report); err != nil {
+		t.Fatal(err)
+	}
+	if err := renderCallReportPDF(&second, report); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(first.Bytes(), []byte("%PDF")) || !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Error("PDF isn't reproducible")
+	}
+
+	var buf bytes.Buffer
+	if err := renderCallReportJSON(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	var decoded callReport
+	if err := json.NewDecoder(&buf).Decode(&decoded); err != nil || len(decoded.Schedules) != 3 {
+		t.Errorf("report=%#v error=%v", decoded, err)
+	}
+	if err := renderCallReportCSV(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1+17+5+16 || records[18][2] != "RCON2215" {
+		t.Errorf("unexpected CSV: %v", records)
+	}
+}
+
+func TestCallReport__generate(t *testing.T) {
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+
+	repo := createTestSqlTransactionRepository(t, sqliteDB.DB)
+	glRepo := setupSqlGLAccountStorage(log.NewNopLogger(), sqliteDB.DB, repo.accountRepo)
+	routingNumber := "121042882"
+
+	now := time.Now()
+	for _, req := range []createGLAccountRequest{
+		{Code: "RCON0081", Name: "Cash", Category: GLAsset},
+		{Code: "RCON2200", Name: "Deposits", Category: GLLiability},
+		{Code: "RCON2215", Name: "Transaction accounts", Category: GLLiability, ParentCode: "2200", AccountType: "checking"},
+		{Code: "RIAD4080", Name: "Service charges", Category: GLIncome},
+	} {
+		acct, err := req.asGLAccount(routingNumber, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := glRepo.createGLAccount(acct); err != nil {
+			t.Fatal(err)
+		}
+	}
+	customer := &accounts.Account{ID: base.ID(), CustomerID: base.ID(), Name: "Checking", AccountNumber: "123", RoutingNumber: routingNumber, Status: "open", Type: "Checking", CreatedAt: now, LastModified: now}
+	if err := repo.accountRepo.CreateAccount(customer.CustomerID, customer); err != nil {
+		t.Fatal(err)
+	}
+
+	// A deposit last year, a fee during the quarter and a deposit after the quarter ended
+	post := func(when time.Time, lines ...transactionLine) {
+		t.Helper()
+		if err := repo.createTransaction(transaction{ID: base.ID(), Timestamp: when, Lines: lines}, createTransactionOpts{AllowGLDebits: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cash := glAccountID(routingNumber, "0081")
+	post(time.Date(2019, time.December, 20, 12, 0, 0, 0, time.UTC),
+		transactionLine{AccountID: cash, Purpose: ACHCredit, Direction: Debit, Amount: 100000},
+		transactionLine{AccountID: customer.ID, Purpose: ACHCredit, Direction: Credit, Amount: 100000})
+	post(time.Date(2020, time.February, 1, 12, 0, 0, 0, time.UTC),
+		transactionLine{AccountID: customer.ID, Purpose: Fee, Direction: Debit, Amount: 2500},
+		transactionLine{AccountID: glAccountID(routingNumber, "4080"), Purpose: Fee, Direction: Credit, Amount: 2500})
+	post(time.Date(2020, time.April, 1, 0, 0, 0, 0, time.UTC),
+		transactionLine{AccountID: cash, Purpose: ACHCredit, Direction: Debit, Amount: 5000},
+		transactionLine{AccountID: customer.ID, Purpose: ACHCredit, Direction: Credit, Amount: 5000})
+
+	report, err := generateCallReport(glRepo, routingNumber, time.Date(2020, time.March, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]int64{
+		"RCON2170": 100000,
+		"RCON2200": 97500,
+		"RCON3632": 2500,
+		"RCON3300": 100000,
+		"RIAD4080": 2500,
+	}
+	for mdrm, amount := range expected {
+		schedule := "RC"
+		if mdrm[:4] == "RIAD" {
+			schedule = "RI"
+		}
+		if v := findCallReportItem(t, report, schedule, mdrm); v != amount {
+			t.Errorf("%s: %d", mdrm, v)
+		}
+	}
+	if v := findCallReportItem(t, report, "RC-E", "RCON2215"); v != 97500 {
+		t.Errorf("RCON2215: %d", v)
+	}
+
+	// Next year the fee is no longer in the income statement
+	report, err = generateCallReport(glRepo, routingNumber, time.Date(2021, time.March, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := findCallReportItem(t, report, "RI", "RIAD4340"); v != 0 {
+		t.Errorf("RIAD4340: %d", v)
+	}
+
+	dir, err := ioutil.TempDir("", "call-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	paths, err := writeCallReport(dir, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 3 || paths[0] != filepath.Join(dir, "call-report-121042882-2021-03-31.pdf") {
+		t.Errorf("unexpected paths: %v", paths)
+	}
+}
@@ -49,3 +49,9 @@ func recordStatus(metric *kitprom.Gauge, db *sql.DB) {
 	metric.With("state", "inuse").Set(float64(stats.InUse))
 	metric.With("state", "open").Set(float64(stats.OpenConnections))
 }
+
+// LockConflict returns true when the provided error is from a database transaction which couldn't
+// acquire a lock (or was chosen as a deadlock victim). These operations can be retried.
+func LockConflict(err error) bool {
+	return MySQLLockConflict(err) || SqliteLockConflict(err)
+}
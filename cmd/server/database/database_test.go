@@ -6,6 +6,9 @@ package database
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/go-kit/kit/log"
@@ -28,6 +31,15 @@ func TestDatabase(t *testing.T) {
 		t.Error("expected error")
 	}
 
+	// Write the sqlite database somewhere temporary rather than the working directory
+	dir, err := ioutil.TempDir("", "accounts-database")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("SQLITE_DB_PATH", filepath.Join(dir, "accounts.db"))
+	defer os.Unsetenv("SQLITE_DB_PATH")
+
 	if db, err := New(ctx, logger, "sqlite"); err != nil {
 		t.Fatal(err)
 	} else {
@@ -34,6 +34,11 @@ var (
 	// https://dev.mysql.com/doc/refman/8.0/en/server-error-reference.html#error_er_dup_entry
 	mySQLErrDuplicateKey uint16 = 1062
 
+	// mySQLErrLockWaitTimeout and mySQLErrLockDeadlock are returned when a transaction can't acquire a row lock
+	// https://dev.mysql.com/doc/refman/8.0/en/server-error-reference.html#error_er_lock_wait_timeout
+	mySQLErrLockWaitTimeout uint16 = 1205
+	mySQLErrLockDeadlock    uint16 = 1213
+
 	maxActiveMySQLConnections = func() int {
 		if v := os.Getenv("MYSQL_MAX_CONNECTIONS"); v != "" {
 			if n, _ := strconv.ParseInt(v, 10, 32); n > 0 {
@@ -72,6 +77,226 @@ var (
 			"create_transaction_lines_account_index",
 			`create index transaction_lines_account_index on transaction_lines(account_id);`,
 		),
+		execsql(
+			"widen_accounts_status",
+			`alter table accounts modify status varchar(20);`,
+		),
+		execsql(
+			"create_account_status_changes",
+			`create table if not exists account_status_changes(account_id varchar(40), previous_status varchar(20), status varchar(20), reason_code varchar(40), actor varchar(40), created_at datetime);`,
+		),
+		execsql(
+			"create_account_status_changes_account_index",
+			`create index account_status_changes_account_index on account_status_changes(account_id);`,
+		),
+		execsql(
+			"create_account_balances",
+			`create table if not exists account_balances(account_id varchar(40) primary key, balance integer, last_modified datetime);`,
+		),
+		execsql(
+			"backfill_account_balances",
+			`insert into account_balances(account_id, balance, last_modified) select account_id, sum(case when lower(purpose) = 'achdebit' then -amount else amount end), current_timestamp from transaction_lines where deleted_at is null group by account_id;`,
+		),
+		execsql(
+			"add_account_balances_version",
+			`alter table account_balances add column version integer not null default 0;`,
+		),
+		execsql(
+			"bigint_transaction_lines_amount",
+			`alter table transaction_lines modify amount bigint;`,
+		),
+		execsql(
+			"bigint_account_balances_balance",
+			`alter table account_balances modify balance bigint;`,
+		),
+		execsql(
+			"create_account_holds",
+			`create table if not exists account_holds(hold_id varchar(40) primary key, account_id varchar(40), credit_account_id varchar(40), purpose varchar(12), amount bigint, captured_amount bigint, status varchar(10), description varchar(200), expires_at datetime, created_at datetime, last_modified datetime);`,
+		),
+		execsql(
+			"create_account_holds_account_index",
+			`create index account_holds_account_index on account_holds(account_id);`,
+		),
+		execsql(
+			"create_account_holds_credit_account_index",
+			`create index account_holds_credit_account_index on account_holds(credit_account_id);`,
+		),
+		execsql(
+			"create_idempotency_keys",
+			`create table if not exists idempotency_keys(user_id varchar(40), idempotency_key varchar(64), request_hash varchar(64), status_code integer, content_type varchar(100), response_body mediumblob, created_at datetime, primary key(user_id, idempotency_key));`,
+		),
+		execsql(
+			"add_transactions_reversal_of",
+			`alter table transactions add column reversal_of varchar(40);`,
+		),
+		execsql(
+			"add_transactions_status",
+			`alter table transactions add column status varchar(20);`,
+		),
+		execsql(
+			"add_transactions_reversed_amount",
+			`alter table transactions add column reversed_amount bigint not null default 0;`,
+		),
+		execsql(
+			"create_transactions_reversal_of_index",
+			`create index transactions_reversal_of_index on transactions(reversal_of);`,
+		),
+		execsql(
+			"add_transaction_lines_direction",
+			`alter table transaction_lines add column direction varchar(6) not null default 'credit';`,
+		),
+		execsql(
+			"backfill_transaction_lines_direction",
+			`update transaction_lines set direction = 'debit' where lower(purpose) = 'achdebit';`,
+		),
+		execsql(
+			"create_gl_accounts",
+			`create table if not exists gl_accounts(account_id varchar(20) primary key, routing_number varchar(9), code varchar(10), name varchar(100), category varchar(10), parent_code varchar(10), account_type varchar(10), created_at datetime, last_modified datetime, unique(routing_number, code));`,
+		),
+		execsql(
+			"add_transactions_journal_of",
+			`alter table transactions add column journal_of varchar(40);`,
+		),
+		execsql(
+			"create_transactions_journal_of_index",
+			`create index transactions_journal_of_index on transactions(journal_of);`,
+		),
+		execsql(
+			"create_accounting_periods",
+			`create table if not exists accounting_periods(period_id varchar(20) primary key, period_type varchar(10), start_date datetime, end_date datetime, status varchar(10), closed_by varchar(40), closed_at datetime, created_at datetime);`,
+		),
+		execsql(
+			"create_accounting_period_balances",
+			`create table if not exists accounting_period_balances(period_id varchar(20), account_id varchar(40), balance bigint, unique(period_id, account_id));`,
+		),
+		execsql(
+			"create_accounting_period_events",
+			`create table if not exists accounting_period_events(period_id varchar(20), action varchar(10), actor varchar(40), reason varchar(200), created_at datetime);`,
+		),
+		execsql(
+			"create_accounting_period_events_period_index",
+			`create index accounting_period_events_period_index on accounting_period_events(period_id);`,
+		),
+		execsql(
+			"add_transactions_effective_date",
+			`alter table transactions add column effective_date datetime;`,
+		),
+		execsql(
+			"backfill_transactions_effective_date",
+			`update transactions set effective_date = timestamp where effective_date is null;`,
+		),
+		execsql(
+			"create_transactions_effective_date_index",
+			`create index transactions_effective_date_index on transactions(effective_date);`,
+		),
+		execsql(
+			"create_account_statements",
+			`create table if not exists account_statements(statement_id varchar(40) primary key, account_id varchar(40), period varchar(7), start_date datetime, end_date datetime, opening_balance bigint, closing_balance bigint, statement mediumtext, pdf mediumblob, created_at datetime, unique(account_id, period));`,
+		),
+		execsql(
+			"create_interest_accruals",
+			`create table if not exists interest_accruals(account_id varchar(40), accrual_date datetime, revision integer, balance bigint, apy double, amount_micros bigint, transaction_id varchar(40), created_at datetime, unique(account_id, accrual_date, revision));`,
+		),
+		execsql(
+			"create_fee_assessments",
+			`create table if not exists fee_assessments(account_id varchar(40), fee_type varchar(12), reference varchar(40), amount bigint, transaction_id varchar(40), created_at datetime, unique(account_id, fee_type, reference));`,
+		),
+		execsql(
+			"create_nsf_events",
+			`create table if not exists nsf_events(transaction_id varchar(40), account_id varchar(40), amount bigint, created_at datetime, unique(transaction_id, account_id));`,
+		),
+		execsql(
+			"create_account_overdrafts",
+			`create table if not exists account_overdrafts(account_id varchar(40) primary key, overdraft_limit bigint, opted_in_at datetime, disclosure varchar(200), created_at datetime, last_modified datetime, revoked_at datetime);`,
+		),
+		execsql(
+			"create_account_products",
+			`create table if not exists account_products(product_code varchar(40) primary key, name varchar(100), minimum_opening_deposit bigint, allowed_purposes varchar(200), interest_plan varchar(100), fee_plan varchar(100), max_withdrawals integer, created_at datetime, last_modified datetime);`,
+		),
+		execsql(
+			"seed_account_products",
+			`insert into account_products(product_code, name, minimum_opening_deposit, allowed_purposes, interest_plan, fee_plan, max_withdrawals, created_at, last_modified) values ('checking', 'Checking', 100, '', '', '', 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP), ('savings', 'Savings', 100, '', '', '', 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);`,
+		),
+		execsql(
+			"add_account_products_daily_debit_limit",
+			`alter table account_products add column daily_debit_limit bigint not null default 0;`,
+		),
+		execsql(
+			"add_account_products_monthly_debit_limit",
+			`alter table account_products add column monthly_debit_limit bigint not null default 0;`,
+		),
+		execsql(
+			"add_account_products_purpose_limits",
+			`alter table account_products add column purpose_limits varchar(200) not null default '';`,
+		),
+		execsql(
+			"create_withdrawal_counters",
+			`create table if not exists withdrawal_counters(account_id varchar(40), period varchar(10), purpose varchar(20), withdrawals integer, amount bigint, version bigint, last_modified datetime, primary key(account_id, period, purpose));`,
+		),
+		execsql(
+			"backfill_withdrawal_counters_monthly",
+			`insert into withdrawal_counters(account_id, period, purpose, withdrawals, amount, version, last_modified) select al.account_id, date_format(t.effective_date, '%Y-%m'), '', count(*), sum(al.amount), 1, current_timestamp from transaction_lines al inner join transactions t on al.transaction_id = t.transaction_id where al.direction = 'debit' and al.purpose <> 'fee' and al.deleted_at is null and t.reversal_of is null and t.deleted_at is null group by al.account_id, date_format(t.effective_date, '%Y-%m');`,
+		),
+		execsql(
+			"backfill_withdrawal_counters_daily",
+			`insert into withdrawal_counters(account_id, period, purpose, withdrawals, amount, version, last_modified) select al.account_id, date_format(t.effective_date, '%Y-%m-%d'), '', count(*), sum(al.amount), 1, current_timestamp from transaction_lines al inner join transactions t on al.transaction_id = t.transaction_id where al.direction = 'debit' and al.purpose <> 'fee' and al.deleted_at is null and t.reversal_of is null and t.deleted_at is null group by al.account_id, date_format(t.effective_date, '%Y-%m-%d');`,
+		),
+		execsql(
+			"backfill_withdrawal_counters_purposes",
+			`insert into withdrawal_counters(account_id, period, purpose, withdrawals, amount, version, last_modified) select al.account_id, date_format(t.effective_date, '%Y-%m'), al.purpose, count(*), sum(al.amount), 1, current_timestamp from transaction_lines al inner join transactions t on al.transaction_id = t.transaction_id where al.direction = 'debit' and al.purpose <> 'fee' and al.deleted_at is null and t.reversal_of is null and t.deleted_at is null group by al.account_id, date_format(t.effective_date, '%Y-%m'), al.purpose;`,
+		),
+		execsql(
+			"create_ach_exports",
+			`create table if not exists ach_exports(transaction_id varchar(40), account_id varchar(40), status varchar(10), trace_number varchar(15), file_name varchar(100), created_at datetime, unique(transaction_id, account_id), unique(trace_number));`,
+		),
+		execsql(
+			"backfill_ach_exports",
+			`insert into ach_exports(transaction_id, account_id, status, created_at) select transaction_id, account_id, 'skipped', current_timestamp from transaction_lines where lower(purpose) in ('achcredit', 'achdebit');`,
+		),
+		execsql(
+			"create_idempotency_keys_created_at_index",
+			`create index idempotency_keys_created_at_index on idempotency_keys(created_at);`,
+		),
+		execsql(
+			"add_fee_assessments_status",
+			`alter table fee_assessments add column status varchar(12);`,
+		),
+		execsql(
+			"create_ach_trace_sequences",
+			`create table if not exists ach_trace_sequences(odfi varchar(8) primary key, last_sequence int not null, last_modified datetime);`,
+		),
+		execsql(
+			"backfill_ach_trace_sequences",
+			`insert into ach_trace_sequences(odfi, last_sequence, last_modified) select substring(trace_number, 1, 8), max(cast(substring(trace_number, 9) as unsigned)), current_timestamp from ach_exports where trace_number is not null group by substring(trace_number, 1, 8);`,
+		),
+		execsql(
+			"drop_ach_exports_trace_number_index",
+			`alter table ach_exports drop index trace_number;`,
+		),
+		execsql(
+			"add_idempotency_keys_transaction_id",
+			`alter table idempotency_keys add column transaction_id varchar(40);`,
+		),
+		execsql(
+			"add_transaction_lines_statement_id",
+			`alter table transaction_lines add column statement_id varchar(40);`,
+		),
+		execsql(
+			"add_transaction_lines_interest_accrued_at",
+			`alter table transaction_lines add column interest_accrued_at datetime;`,
+		),
+		execsql(
+			"backfill_transaction_lines_interest_accrued_at",
+			`update transaction_lines set interest_accrued_at = current_timestamp where account_id in (select account_id from interest_accruals);`,
+		),
+		execsql(
+			"create_accounting_period_version",
+			`create table if not exists accounting_period_version(lock_id varchar(10) primary key, version bigint, postings bigint);`,
+		),
+		execsql(
+			"insert_accounting_period_version",
+			`insert into accounting_period_version(lock_id, version, postings) values ('periods', 0, 0);`,
+		),
 	)
 )
 
@@ -235,3 +460,17 @@ func MySQLUniqueViolation(err error) bool {
 	}
 	return match
 }
+
+// MySQLLockConflict returns true when the provided error matches the MySQL codes for
+// lock wait timeouts or deadlocks.
+func MySQLLockConflict(err error) bool {
+	if e, ok := err.(*gomysql.MySQLError); ok {
+		return e.Number == mySQLErrLockWaitTimeout || e.Number == mySQLErrLockDeadlock
+	}
+	for _, code := range []uint16{mySQLErrLockWaitTimeout, mySQLErrLockDeadlock} {
+		if strings.Contains(err.Error(), fmt.Sprintf("Error %d:", code)) {
+			return true
+		}
+	}
+	return false
+}
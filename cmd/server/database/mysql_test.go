@@ -44,3 +44,13 @@ func TestMySQLUniqueViolation(t *testing.T) {
 		t.Error("should have matched unique violation")
 	}
 }
+
+func TestMySQLLockConflict(t *testing.T) {
+	err := errors.New(`createTransaction: insert: Error 1213: Deadlock found when trying to get lock; try restarting transaction`)
+	if !MySQLLockConflict(err) {
+		t.Error("should have matched lock conflict")
+	}
+	if MySQLLockConflict(errors.New("Error 1062: Duplicate entry")) {
+		t.Error("shouldn't have matched lock conflict")
+	}
+}
@@ -52,6 +52,218 @@ var (
 			"create_transaction_lines_account_index",
 			`create index transaction_lines_account_index on transaction_lines(account_id);`,
 		),
+		execsql(
+			"create_account_status_changes",
+			`create table if not exists account_status_changes(account_id, previous_status, status, reason_code, actor, created_at datetime);`,
+		),
+		execsql(
+			"create_account_status_changes_account_index",
+			`create index account_status_changes_account_index on account_status_changes(account_id);`,
+		),
+		execsql(
+			"create_account_balances",
+			`create table if not exists account_balances(account_id primary key, balance integer, last_modified datetime);`,
+		),
+		execsql(
+			"backfill_account_balances",
+			`insert into account_balances(account_id, balance, last_modified) select account_id, sum(case when lower(purpose) = 'achdebit' then -amount else amount end), current_timestamp from transaction_lines where deleted_at is null group by account_id;`,
+		),
+		execsql(
+			"add_account_balances_version",
+			`alter table account_balances add column version integer not null default 0;`,
+		),
+		execsql(
+			"create_account_holds",
+			`create table if not exists account_holds(hold_id primary key, account_id, credit_account_id, purpose, amount integer, captured_amount integer, status, description, expires_at datetime, created_at datetime, last_modified datetime);`,
+		),
+		execsql(
+			"create_account_holds_account_index",
+			`create index account_holds_account_index on account_holds(account_id);`,
+		),
+		execsql(
+			"create_account_holds_credit_account_index",
+			`create index account_holds_credit_account_index on account_holds(credit_account_id);`,
+		),
+		execsql(
+			"create_idempotency_keys",
+			`create table if not exists idempotency_keys(user_id, idempotency_key, request_hash, status_code integer, content_type, response_body blob, created_at datetime, primary key(user_id, idempotency_key));`,
+		),
+		execsql(
+			"add_transactions_reversal_of",
+			`alter table transactions add column reversal_of;`,
+		),
+		execsql(
+			"add_transactions_status",
+			`alter table transactions add column status;`,
+		),
+		execsql(
+			"add_transactions_reversed_amount",
+			`alter table transactions add column reversed_amount integer not null default 0;`,
+		),
+		execsql(
+			"create_transactions_reversal_of_index",
+			`create index transactions_reversal_of_index on transactions(reversal_of);`,
+		),
+		execsql(
+			"add_transaction_lines_direction",
+			`alter table transaction_lines add column direction text not null default 'credit';`,
+		),
+		execsql(
+			"backfill_transaction_lines_direction",
+			`update transaction_lines set direction = 'debit' where lower(purpose) = 'achdebit';`,
+		),
+		execsql(
+			"create_gl_accounts",
+			`create table if not exists gl_accounts(account_id primary key, routing_number, code, name, category, parent_code, account_type, created_at datetime, last_modified datetime, unique(routing_number, code));`,
+		),
+		execsql(
+			"add_transactions_journal_of",
+			`alter table transactions add column journal_of;`,
+		),
+		execsql(
+			"create_transactions_journal_of_index",
+			`create index transactions_journal_of_index on transactions(journal_of);`,
+		),
+		execsql(
+			"create_accounting_periods",
+			`create table if not exists accounting_periods(period_id primary key, period_type, start_date datetime, end_date datetime, status, closed_by, closed_at datetime, created_at datetime);`,
+		),
+		execsql(
+			"create_accounting_period_balances",
+			`create table if not exists accounting_period_balances(period_id, account_id, balance integer, unique(period_id, account_id));`,
+		),
+		execsql(
+			"create_accounting_period_events",
+			`create table if not exists accounting_period_events(period_id, action, actor, reason, created_at datetime);`,
+		),
+		execsql(
+			"create_accounting_period_events_period_index",
+			`create index accounting_period_events_period_index on accounting_period_events(period_id);`,
+		),
+		execsql(
+			"add_transactions_effective_date",
+			`alter table transactions add column effective_date datetime;`,
+		),
+		execsql(
+			"backfill_transactions_effective_date",
+			`update transactions set effective_date = timestamp where effective_date is null;`,
+		),
+		execsql(
+			"create_transactions_effective_date_index",
+			`create index transactions_effective_date_index on transactions(effective_date);`,
+		),
+		execsql(
+			"create_account_statements",
+			`create table if not exists account_statements(statement_id primary key, account_id, period, start_date datetime, end_date datetime, opening_balance integer, closing_balance integer, statement, pdf blob, created_at datetime, unique(account_id, period));`,
+		),
+		execsql(
+			"create_interest_accruals",
+			`create table if not exists interest_accruals(account_id, accrual_date datetime, revision integer, balance integer, apy double, amount_micros integer, transaction_id, created_at datetime, unique(account_id, accrual_date, revision));`,
+		),
+		execsql(
+			"create_fee_assessments",
+			`create table if not exists fee_assessments(account_id, fee_type, reference, amount integer, transaction_id, created_at datetime, unique(account_id, fee_type, reference));`,
+		),
+		execsql(
+			"create_nsf_events",
+			`create table if not exists nsf_events(transaction_id, account_id, amount integer, created_at datetime, unique(transaction_id, account_id));`,
+		),
+		execsql(
+			"create_account_overdrafts",
+			`create table if not exists account_overdrafts(account_id primary key, overdraft_limit integer, opted_in_at datetime, disclosure, created_at datetime, last_modified datetime, revoked_at datetime);`,
+		),
+		execsql(
+			"create_account_products",
+			`create table if not exists account_products(product_code primary key, name, minimum_opening_deposit integer, allowed_purposes, interest_plan, fee_plan, max_withdrawals integer, created_at datetime, last_modified datetime);`,
+		),
+		execsql(
+			"seed_account_products",
+			`insert into account_products(product_code, name, minimum_opening_deposit, allowed_purposes, interest_plan, fee_plan, max_withdrawals, created_at, last_modified) values ('checking', 'Checking', 100, '', '', '', 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP), ('savings', 'Savings', 100, '', '', '', 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);`,
+		),
+		execsql(
+			"add_account_products_daily_debit_limit",
+			`alter table account_products add column daily_debit_limit integer not null default 0;`,
+		),
+		execsql(
+			"add_account_products_monthly_debit_limit",
+			`alter table account_products add column monthly_debit_limit integer not null default 0;`,
+		),
+		execsql(
+			"add_account_products_purpose_limits",
+			`alter table account_products add column purpose_limits text not null default '';`,
+		),
+		execsql(
+			"create_withdrawal_counters",
+			`create table if not exists withdrawal_counters(account_id, period, purpose, withdrawals integer, amount integer, version integer, last_modified datetime, primary key(account_id, period, purpose));`,
+		),
+		execsql(
+			"backfill_withdrawal_counters_monthly",
+			`insert into withdrawal_counters(account_id, period, purpose, withdrawals, amount, version, last_modified) select al.account_id, strftime('%Y-%m', t.effective_date), '', count(*), sum(al.amount), 1, current_timestamp from transaction_lines al inner join transactions t on al.transaction_id = t.transaction_id where al.direction = 'debit' and al.purpose <> 'fee' and al.deleted_at is null and t.reversal_of is null and t.deleted_at is null group by al.account_id, strftime('%Y-%m', t.effective_date);`,
+		),
+		execsql(
+			"backfill_withdrawal_counters_daily",
+			`insert into withdrawal_counters(account_id, period, purpose, withdrawals, amount, version, last_modified) select al.account_id, strftime('%Y-%m-%d', t.effective_date), '', count(*), sum(al.amount), 1, current_timestamp from transaction_lines al inner join transactions t on al.transaction_id = t.transaction_id where al.direction = 'debit' and al.purpose <> 'fee' and al.deleted_at is null and t.reversal_of is null and t.deleted_at is null group by al.account_id, strftime('%Y-%m-%d', t.effective_date);`,
+		),
+		execsql(
+			"backfill_withdrawal_counters_purposes",
+			`insert into withdrawal_counters(account_id, period, purpose, withdrawals, amount, version, last_modified) select al.account_id, strftime('%Y-%m', t.effective_date), al.purpose, count(*), sum(al.amount), 1, current_timestamp from transaction_lines al inner join transactions t on al.transaction_id = t.transaction_id where al.direction = 'debit' and al.purpose <> 'fee' and al.deleted_at is null and t.reversal_of is null and t.deleted_at is null group by al.account_id, strftime('%Y-%m', t.effective_date), al.purpose;`,
+		),
+		execsql(
+			"create_ach_exports",
+			`create table if not exists ach_exports(transaction_id, account_id, status, trace_number, file_name, created_at datetime, unique(transaction_id, account_id));`,
+		),
+		execsql(
+			"create_ach_exports_trace_number_index",
+			`create unique index ach_exports_trace_number_index on ach_exports(trace_number);`,
+		),
+		execsql(
+			"backfill_ach_exports",
+			`insert into ach_exports(transaction_id, account_id, status, created_at) select transaction_id, account_id, 'skipped', current_timestamp from transaction_lines where lower(purpose) in ('achcredit', 'achdebit');`,
+		),
+		execsql(
+			"create_idempotency_keys_created_at_index",
+			`create index idempotency_keys_created_at_index on idempotency_keys(created_at);`,
+		),
+		execsql(
+			"add_fee_assessments_status",
+			`alter table fee_assessments add column status;`,
+		),
+		execsql(
+			"create_ach_trace_sequences",
+			`create table if not exists ach_trace_sequences(odfi primary key, last_sequence integer not null, last_modified datetime);`,
+		),
+		execsql(
+			"backfill_ach_trace_sequences",
+			`insert into ach_trace_sequences(odfi, last_sequence, last_modified) select substr(trace_number, 1, 8), max(cast(substr(trace_number, 9) as integer)), current_timestamp from ach_exports where trace_number is not null group by substr(trace_number, 1, 8);`,
+		),
+		execsql(
+			"drop_ach_exports_trace_number_index",
+			`drop index ach_exports_trace_number_index;`,
+		),
+		execsql(
+			"add_idempotency_keys_transaction_id",
+			`alter table idempotency_keys add column transaction_id;`,
+		),
+		execsql(
+			"add_transaction_lines_statement_id",
+			`alter table transaction_lines add column statement_id;`,
+		),
+		execsql(
+			"add_transaction_lines_interest_accrued_at",
+			`alter table transaction_lines add column interest_accrued_at datetime;`,
+		),
+		execsql(
+			"backfill_transaction_lines_interest_accrued_at",
+			`update transaction_lines set interest_accrued_at = current_timestamp where account_id in (select account_id from interest_accruals);`,
+		),
+		execsql(
+			"create_accounting_period_version",
+			`create table if not exists accounting_period_version(lock_id primary key, version integer, postings integer);`,
+		),
+		execsql(
+			"insert_accounting_period_version",
+			`insert into accounting_period_version(lock_id, version, postings) values ('periods', 0, 0);`,
+		),
 	)
 )
 
@@ -66,7 +278,7 @@ type sqlite struct {
 
 func (s *sqlite) Connect(ctx context.Context) (*sql.DB, error) {
 	if s.err != nil {
-		return nil, fmt.Errorf("sqlite had error %v", s.err)
+		return nil, fmt.Errorf("sqlite had error %w", s.err)
 	}
 
 	sqliteVersionLogOnce.Do(func() {
@@ -182,3 +394,12 @@ func SqliteUniqueViolation(err error) bool {
 	}
 	return match
 }
+
+// SqliteLockConflict returns true when the provided error matches the SQLite errors for
+// a locked or busy database.
+func SqliteLockConflict(err error) bool {
+	if e, ok := err.(sqlite3.Error); ok {
+		return e.Code == sqlite3.ErrBusy || e.Code == sqlite3.ErrLocked
+	}
+	return strings.Contains(err.Error(), "database is locked") || strings.Contains(err.Error(), "database table is locked")
+}
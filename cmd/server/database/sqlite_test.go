@@ -52,3 +52,13 @@ func TestSqliteUniqueViolation(t *testing.T) {
 		t.Error("should have matched unique violation")
 	}
 }
+
+func TestSqliteLockConflict(t *testing.T) {
+	err := errors.New(`createTransaction: insert: database is locked`)
+	if !LockConflict(err) {
+		t.Error("should have matched lock conflict")
+	}
+	if SqliteLockConflict(errors.New("UNIQUE constraint failed: accounts.account_id")) {
+		t.Error("shouldn't have matched lock conflict")
+	}
+}
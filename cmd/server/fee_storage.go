@@ -0,0 +1,33 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+)
+
+type feeRepository interface {
+	// getDailyBalances returns the balance history of an account for each day in [from, to)
+	getDailyBalances(accountID string, from, to time.Time) ([]dailyBalance, error)
+	// getAccountProducts returns every product in the catalog so accounts can use their product's fee plan
+	getAccountProducts() ([]accountProduct, error)
+
+	// getFeeableLines returns the lines of accountID from transactions effective in [from, to) which fees
+	// can be charged for. Fees, reversals, reversed transactions and GL journal entries aren't included.
+	getFeeableLines(accountID string, from, to time.Time) ([]feeableLine, error)
+	// getBalanceChanges returns the net change of each transaction effective in [from, to) to the balance of
+	// accountID, ordered by effective date and leaving out lines with an excluded purpose
+	getBalanceChanges(accountID string, from, to time.Time, excluded ...TransactionPurpose) ([]effectiveBalanceChange, error)
+	// getNSFEvents returns the debits to accountID which were rejected for insufficient funds in [from, to)
+	getNSFEvents(accountID string, from, to time.Time) ([]nsfEvent, error)
+
+	// assessFee debits fee from account and credits the incomeAccountID GL account. Each fee is assessed
+	// once per account, type and reference, so a nil transaction is returned if it was already assessed.
+	// Fees which don't fit within the account's available balance and overdraft limit are recorded as
+	// uncollected and errFeeUncollected is returned.
+	assessFee(account *accounts.Account, incomeAccountID string, fee feeAssessment) (*transaction, error)
+}
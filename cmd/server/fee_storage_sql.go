@@ -0,0 +1,229 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+)
+
+type sqlFeeRepository struct {
+	db     *sql.DB
+	logger log.Logger
+
+	transactionRepo *sqlTransactionRepository
+	productRepo     *sqlProductRepository
+}
+
+func setupSqlFeeStorage(logger log.Logger, transactionRepo *sqlTransactionRepository) *sqlFeeRepository {
+	return &sqlFeeRepository{
+		db:              transactionRepo.db,
+		logger:          logger,
+		transactionRepo: transactionRepo,
+		productRepo:     setupSqlProductStorage(logger, transactionRepo.db),
+	}
+}
+
+func (r *sqlFeeRepository) getDailyBalances(accountID string, from, to time.Time) ([]dailyBalance, error) {
+	return r.transactionRepo.getDailyBalances(accountID, from, to)
+}
+
+func (r *sqlFeeRepository) getAccountProducts() ([]accountProduct, error) {
+	return r.productRepo.getAccountProducts()
+}
+
+func (r *sqlFeeRepository) getFeeableLines(accountID string, from, to time.Time) ([]feeableLine, error) {
+	query := `select t.transaction_id, t.effective_date, l.purpose, l.direction, l.amount
+from transaction_lines l inner join transactions t on t.transaction_id = l.transaction_id
+where l.account_id = ? and t.effective_date >= ? and t.effective_date < ? and l.purpose <> ?
+and t.reversal_of is null and t.journal_of is null and (t.status is null or t.status <> ?)
+and l.deleted_at is null and t.deleted_at is null
+order by t.effective_date asc, t.transaction_id asc;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("getFeeableLines: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(accountID, from.UTC(), to.UTC(), Fee, TransactionReversed)
+	if err != nil {
+		return nil, fmt.Errorf("getFeeableLines: query: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []feeableLine
+	for rows.Next() {
+		var line feeableLine
+		if err := rows.Scan(&line.TransactionID, &line.EffectiveDate, &line.Purpose, &line.Direction, &line.Amount); err != nil {
+			return nil, fmt.Errorf("getFeeableLines: scan account=%s: %w", accountID, err)
+		}
+		line.AccountID = accountID
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+// recordNSFEvents stores each debit of t to an account we have a record of, after t was rejected for insufficient funds
+func (r *sqlTransactionRepository) recordNSFEvents(t transaction, accounts []*accounts.Account) error {
+	stmt, err := r.db.Prepare(`insert into nsf_events(transaction_id, account_id, amount, created_at) values (?, ?, ?, ?);`)
+	if err != nil {
+		return fmt.Errorf("recordNSFEvents: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	for i := range accounts {
+		for j := range t.Lines {
+			if accounts[i].ID != t.Lines[j].AccountID || !t.Lines[j].isDebit() {
+				continue
+			}
+			if _, err := stmt.Exec(t.ID, t.Lines[j].AccountID, t.Lines[j].Amount, time.Now()); err != nil && !database.UniqueViolation(err) {
+				return fmt.Errorf("recordNSFEvents: transaction=%q account=%q: %w", t.ID, t.Lines[j].AccountID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *sqlFeeRepository) getBalanceChanges(accountID string, from, to time.Time, excluded ...TransactionPurpose) ([]effectiveBalanceChange, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("getBalanceChanges: tx.Begin: %w", err)
+	}
+	changes, err := readBalanceChanges(tx, accountID, from, to, excluded...)
+	if err != nil {
+		return nil, fmt.Errorf("getBalanceChanges: account=%s: %w rollback=%v", accountID, err, tx.Rollback())
+	}
+	return changes, tx.Commit()
+}
+
+func (r *sqlFeeRepository) getNSFEvents(accountID string, from, to time.Time) ([]nsfEvent, error) {
+	query := `select transaction_id, account_id, amount, created_at from nsf_events
+where account_id = ? and created_at >= ? and created_at < ? order by created_at asc;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("getNSFEvents: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(accountID, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("getNSFEvents: query: %w", err)
+	}
+	defer rows.Close()
+
+	var events []nsfEvent
+	for rows.Next() {
+		var e nsfEvent
+		if err := rows.Scan(&e.TransactionID, &e.AccountID, &e.Amount, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("getNSFEvents: scan account=%s: %w", accountID, err)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// assessFee records the assessment before posting its transaction, so the unique key on fee_assessments
+// keeps concurrent assessors from charging a fee twice. Fees are checked for sufficient funds like any other
+// debit, and when that fails the assessment is recorded as uncollected instead.
+func (r *sqlFeeRepository) assessFee(account *accounts.Account, incomeAccountID string, fee feeAssessment) (*transaction, error) {
+	opts := createTransactionOpts{}
+	var out *transaction
+	err := withPostingRetries(func() error {
+		out = nil
+
+		tx, err := r.db.Begin()
+		if err != nil {
+			return fmt.Errorf("assessFee: tx.Begin: %w", err)
+		}
+		stmt, err := tx.Prepare(`insert into fee_assessments(account_id, fee_type, reference, amount, created_at) values (?, ?, ?, ?, ?);`)
+		if err != nil {
+			return fmt.Errorf("assessFee: prepare: error=%w rollback=%v", err, tx.Rollback())
+		}
+		_, err = stmt.Exec(account.ID, fee.Type, fee.Reference, fee.Amount, time.Now())
+		stmt.Close()
+		if err != nil {
+			if database.UniqueViolation(err) {
+				return tx.Rollback() // already assessed
+			}
+			return fmt.Errorf("assessFee: account=%s %s fee=%s: error=%w rollback=%v", account.ID, fee.Type, fee.Reference, err, tx.Rollback())
+		}
+		if err := checkJournalGLAccount(tx, incomeAccountID); err != nil {
+			return fmt.Errorf("assessFee: account=%s: error=%w rollback=%v", account.ID, err, tx.Rollback())
+		}
+
+		now := time.Now()
+		effective, err := openPostingDate(tx, fee.EffectiveDate, now)
+		if err != nil {
+			return fmt.Errorf("assessFee: account=%s: error=%w rollback=%v", account.ID, err, tx.Rollback())
+		}
+		t := transaction{
+			ID:            base.ID(),
+			Timestamp:     now,
+			EffectiveDate: effective,
+			Lines: []transactionLine{
+				{AccountID: account.ID, Purpose: Fee, Direction: Debit, Amount: fee.Amount},
+				{AccountID: incomeAccountID, Purpose: Fee, Direction: Credit, Amount: fee.Amount},
+			},
+		}
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("assessFee: account=%s: error=%w rollback=%v", account.ID, err, tx.Rollback())
+		}
+		if err := checkAccountStatuses([]*accounts.Account{account}, t.Lines, opts); err != nil {
+			return fmt.Errorf("assessFee: account=%s: error=%w rollback=%v", account.ID, err, tx.Rollback())
+		}
+		if err := r.transactionRepo.insertTransaction(tx, t, opts, []*accounts.Account{account}); err != nil {
+			if errors.Is(err, errInsufficientFunds) {
+				if rollback := tx.Rollback(); rollback != nil {
+					return fmt.Errorf("assessFee: account=%s: %w rollback=%v", account.ID, err, rollback)
+				}
+				return r.recordUncollectedFee(account, fee)
+			}
+			return fmt.Errorf("assessFee: account=%s: %w rollback=%v", account.ID, err, tx.Rollback())
+		}
+
+		stmt, err = tx.Prepare(`update fee_assessments set transaction_id = ?, status = ? where account_id = ? and fee_type = ? and reference = ?;`)
+		if err != nil {
+			return fmt.Errorf("assessFee: prepare update: error=%w rollback=%v", err, tx.Rollback())
+		}
+		_, err = stmt.Exec(t.ID, feeCollected, account.ID, fee.Type, fee.Reference)
+		stmt.Close()
+		if err != nil {
+			return fmt.Errorf("assessFee: account=%s update: error=%w rollback=%v", account.ID, err, tx.Rollback())
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("assessFee: commit: %w", err)
+		}
+		out = &t
+		return nil
+	})
+	return out, err
+}
+
+// recordUncollectedFee saves the assessment of a fee which wasn't posted so it isn't assessed again, and returns
+// errFeeUncollected unless the fee was already assessed.
+func (r *sqlFeeRepository) recordUncollectedFee(account *accounts.Account, fee feeAssessment) error {
+	stmt, err := r.db.Prepare(`insert into fee_assessments(account_id, fee_type, reference, amount, status, created_at) values (?, ?, ?, ?, ?, ?);`)
+	if err != nil {
+		return fmt.Errorf("recordUncollectedFee: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(account.ID, fee.Type, fee.Reference, fee.Amount, feeUncollected, time.Now()); err != nil {
+		if database.UniqueViolation(err) {
+			return nil // already assessed
+		}
+		return fmt.Errorf("recordUncollectedFee: account=%s %s fee=%s: %w", account.ID, fee.Type, fee.Reference, err)
+	}
+	return fmt.Errorf("recordUncollectedFee: account=%s %s fee=%s: %w", account.ID, fee.Type, fee.Reference, errFeeUncollected)
+}
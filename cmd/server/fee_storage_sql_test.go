@@ -0,0 +1,144 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestSqlFeeRepository__fees(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, db *sql.DB) {
+		repo := createTestSqlTransactionRepository(t, db)
+		glRepo := setupSqlGLAccountStorage(log.NewNopLogger(), db, repo.accountRepo)
+		defer repo.Close()
+		feeRepo := setupSqlFeeStorage(log.NewNopLogger(), repo)
+
+		income, err := createGLAccountRequest{Code: "4080", Name: "Service charges", Category: GLIncome}.asGLAccount(defaultRoutingNumber, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := glRepo.createGLAccount(income); err != nil {
+			t.Fatal(err)
+		}
+
+		now := time.Now().UTC()
+		checking := &accounts.Account{ID: base.ID(), Name: "Checking", AccountNumber: "123", RoutingNumber: defaultRoutingNumber, Status: "open", Type: "Checking", CreatedAt: now.Add(-1 * time.Minute)}
+		accountRepo := &testAccountRepository{accounts: []*accounts.Account{checking}}
+		repo.accountRepo = accountRepo
+
+		createTestGLAccount(t, db, defaultRoutingNumber, createGLAccountRequest{Code: "0010", Name: "Cash", Category: GLAsset})
+		cash := glAccountID(defaultRoutingNumber, "0010")
+		post := func(purpose TransactionPurpose, direction LineDirection, amount int64, opts createTransactionOpts) (string, error) {
+			id := base.ID()
+			opts.AllowGLDebits = true
+			return id, repo.createTransaction(transaction{
+				ID:        id,
+				Timestamp: time.Now(),
+				Lines: []transactionLine{
+					{AccountID: checking.ID, Purpose: purpose, Direction: direction, Amount: amount},
+					{AccountID: cash, Purpose: purpose, Direction: direction.opposite(), Amount: amount},
+				},
+			}, opts)
+		}
+		if _, err := post(ACHCredit, Credit, 10000, createTransactionOpts{AllowOverdraft: true}); err != nil {
+			t.Fatal(err)
+		}
+		wireID, err := post(Wire, Debit, 2000, createTransactionOpts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := post(ACHDebit, Debit, 9000, createTransactionOpts{AllowOverdraft: true}); err != nil {
+			t.Fatal(err)
+		}
+		rejectedID, err := post(ACHDebit, Debit, 5000, createTransactionOpts{})
+		if err == nil || !strings.Contains(err.Error(), errInsufficientFunds.Error()) {
+			t.Fatalf("expected insufficient funds: %v", err)
+		}
+
+		start := startOfDay(checking.CreatedAt)
+		events, err := feeRepo.getNSFEvents(checking.ID, start, now.Add(time.Hour))
+		if err != nil || len(events) != 1 || events[0].TransactionID != rejectedID || events[0].Amount != 5000 {
+			t.Fatalf("unexpected NSF events=%#v: %v", events, err)
+		}
+		lines, err := feeRepo.getFeeableLines(checking.ID, start, now.Add(time.Hour))
+		if err != nil || len(lines) != 3 || lines[1].TransactionID != wireID {
+			t.Fatalf("unexpected lines=%#v: %v", lines, err)
+		}
+
+		schedules := feeSchedules{
+			defaultRoutingNumber: []feeSchedule{{
+				Name:        "Checking",
+				AccountType: "checking",
+				Income:      "4080",
+				Maintenance: &maintenanceFee{Amount: 500, WaiveMinBalance: 100000},
+				Transaction: []transactionFee{{Purpose: Wire, Direction: Debit, Amount: 2500}},
+				Overdraft:   3500,
+				NSF:         3000,
+			}},
+		}
+		// Fees are limited by the overdraft line, so the NSF fee which doesn't fit is left uncollected
+		overdraftRepo := setupSqlOverdraftStorage(log.NewNopLogger(), db, repo.accountRepo)
+		if err := overdraftRepo.saveOverdraft(overdraftLine{AccountID: checking.ID, Limit: 10000, OptedInAt: now, CreatedAt: now, LastModified: now}); err != nil {
+			t.Fatal(err)
+		}
+		period := newAccountingPeriod(MonthlyPeriod, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC))
+		n, err := assessFees(log.NewNopLogger(), accountRepo, feeRepo, schedules, "", period, period.End)
+		if err != nil || n != 3 {
+			t.Fatalf("assessed %d fees: %v", n, err)
+		}
+		if n, err := assessFees(log.NewNopLogger(), accountRepo, feeRepo, schedules, "", period, period.End); err != nil || n != 0 {
+			t.Errorf("assessed %d fees again: %v", n, err)
+		}
+		var status sql.NullString
+		if err := db.QueryRow(`select status from fee_assessments where account_id = ? and fee_type = ?;`, checking.ID, NSFFee).Scan(&status); err != nil || status.String != feeUncollected {
+			t.Errorf("NSF fee status=%q: %v", status.String, err)
+		}
+		if balance, err := repo.getAccountBalanceAsOf(checking.ID, now.Add(time.Hour)); err != nil || balance != -1000-500-2500-3500 {
+			t.Errorf("checking balance=%d: %v", balance, err)
+		}
+
+		fees := int64(500 + 2500 + 3500)
+		if balance, err := repo.getAccountBalanceAsOf(glAccountID(defaultRoutingNumber, "4080"), now.Add(time.Hour)); err != nil || balance != fees {
+			t.Errorf("income balance=%d: %v", balance, err)
+		}
+
+		// Fees are refunded by reversing them, and aren't charged again
+		if _, err := post(ACHCredit, Credit, 50000, createTransactionOpts{AllowOverdraft: true}); err != nil {
+			t.Fatal(err)
+		}
+		txs, _, err := repo.getAccountTransactions(checking.ID, transactionSearchParams{Limit: 10, Purposes: []TransactionPurpose{Fee}})
+		if err != nil || len(txs) != 3 {
+			t.Fatalf("fee transactions=%d: %v", len(txs), err)
+		}
+		if _, err := repo.reverseTransaction(txs[0].ID, reversalRequest{}, createTransactionOpts{}); err != nil {
+			t.Fatal(err)
+		}
+		if n, err := assessFees(log.NewNopLogger(), accountRepo, feeRepo, schedules, "", period, period.End); err != nil || n != 0 {
+			t.Errorf("assessed %d fees after refund: %v", n, err)
+		}
+		if balance, err := repo.getAccountBalanceAsOf(glAccountID(defaultRoutingNumber, "4080"), now.Add(time.Hour)); err != nil || balance != fees-txs[0].Lines[0].Amount {
+			t.Errorf("income balance=%d after refund: %v", balance, err)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, sqliteDB.DB)
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, mysqlDB.DB)
+}
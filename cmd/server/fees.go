@@ -0,0 +1,458 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+
+	"github.com/go-kit/kit/log"
+	"gopkg.in/yaml.v2"
+)
+
+// FeeType is the reason a fee was charged
+type FeeType string
+
+var (
+	MaintenanceFee FeeType = "maintenance"
+	TransactionFee FeeType = "transaction"
+	OverdraftFee   FeeType = "overdraft"
+	NSFFee         FeeType = "nsf"
+)
+
+// Statuses of fee assessments. Uncollected fees didn't fit within the account's available balance and overdraft
+// limit, so they were recorded without debiting the account.
+const (
+	feeCollected   = "collected"
+	feeUncollected = "uncollected"
+)
+
+var errFeeUncollected = errors.New("fee exceeds available balance and overdraft limit")
+
+// maintenanceFee is charged for each month an account is open, unless its balance stayed at or above
+// WaiveMinBalance for the whole month. A zero WaiveMinBalance is never waived.
+type maintenanceFee struct {
+	Amount          int64 `yaml:"amount"`
+	WaiveMinBalance int64 `yaml:"waiveMinBalance"`
+}
+
+// transactionFee is charged for each transaction with a line for the account of Purpose. Fees without
+// a Direction are charged for both credits and debits.
+type transactionFee struct {
+	Purpose   TransactionPurpose `yaml:"purpose"`
+	Direction LineDirection      `yaml:"direction"`
+	Amount    int64              `yaml:"amount"`
+}
+
+func (fee transactionFee) matches(line feeableLine) bool {
+	return fee.Purpose == line.Purpose && (fee.Direction == "" || fee.Direction == line.Direction)
+}
+
+// feeSchedule is the fees charged to customer accounts of AccountType, which are credited to the Income GL account.
+//
+// Overdraft is charged once each day an account goes from a non-negative to a negative balance, and NSF is
+// charged for each debit rejected due to insufficient funds.
+type feeSchedule struct {
+	Name        string           `yaml:"name"`
+	AccountType string           `yaml:"accountType"`
+	Income      string           `yaml:"income"`
+	Maintenance *maintenanceFee  `yaml:"maintenance"`
+	Transaction []transactionFee `yaml:"transactions"`
+	Overdraft   int64            `yaml:"overdraft"`
+	NSF         int64            `yaml:"nsf"`
+}
+
+// normalize lowercases and trims values read from YAML and reads the GL code
+func (s *feeSchedule) normalize() error {
+	s.Name = strings.TrimSpace(s.Name)
+	s.AccountType = strings.ToLower(strings.TrimSpace(s.AccountType))
+	for i := range s.Transaction {
+		s.Transaction[i].Purpose = TransactionPurpose(strings.ToLower(strings.TrimSpace(string(s.Transaction[i].Purpose))))
+		s.Transaction[i].Direction = LineDirection(strings.ToLower(strings.TrimSpace(string(s.Transaction[i].Direction))))
+	}
+	var err error
+	if s.Income, err = readGLCode(s.Income); err != nil {
+		return fmt.Errorf("income: %w", err)
+	}
+	return nil
+}
+
+func (s feeSchedule) validate() error {
+	if s.Name == "" {
+		return errors.New("missing name")
+	}
+	if !validCustomerAccountType(s.AccountType) {
+		return fmt.Errorf("unknown accountType %q", s.AccountType)
+	}
+	if s.Maintenance != nil && (s.Maintenance.Amount <= 0 || s.Maintenance.WaiveMinBalance < 0) {
+		return errors.New("maintenance amount must be positive and waiveMinBalance can't be negative")
+	}
+	for i, fee := range s.Transaction {
+		if err := fee.Purpose.validate(); err != nil {
+			return fmt.Errorf("transactions[%d]: %w", i, err)
+		}
+		if fee.Purpose == Fee {
+			return fmt.Errorf("transactions[%d]: fees can't be charged for fees", i)
+		}
+		if fee.Direction != "" {
+			if err := fee.Direction.validate(); err != nil {
+				return fmt.Errorf("transactions[%d]: %w", i, err)
+			}
+		}
+		if fee.Amount <= 0 {
+			return fmt.Errorf("transactions[%d]: amount must be positive", i)
+		}
+	}
+	if s.Overdraft < 0 || s.NSF < 0 {
+		return errors.New("overdraft and nsf fees can't be negative")
+	}
+	return nil
+}
+
+// feeSchedules are the fee schedules for each routing number. The first schedule for an account's type is applied.
+type feeSchedules map[string][]feeSchedule
+
+// readFeeSchedules parses and validates fee schedules in YAML keyed by routing number:
+//
+//	"121042882":
+//	  - name: Basic checking
+//	    accountType: checking
+//	    income: RIAD4080
+//	    maintenance:
+//	      amount: 500
+//	      waiveMinBalance: 150000
+//	    transactions:
+//	      - purpose: wire
+//	        direction: debit
+//	        amount: 2500
+//	    overdraft: 3500
+//	    nsf: 3000
+func readFeeSchedules(r io.Reader) (feeSchedules, error) {
+	bs, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var schedules feeSchedules
+	if err := yaml.UnmarshalStrict(bs, &schedules); err != nil {
+		return nil, fmt.Errorf("fee schedules: %w", err)
+	}
+	for routingNumber := range schedules {
+		if !routingNumberRegex.MatchString(routingNumber) {
+			return nil, fmt.Errorf("fee schedules: invalid routing number %q", routingNumber)
+		}
+		for i := range schedules[routingNumber] {
+			if err := schedules[routingNumber][i].normalize(); err != nil {
+				return nil, fmt.Errorf("fee schedules: routingNumber=%s schedule[%d]: %w", routingNumber, i, err)
+			}
+			if err := schedules[routingNumber][i].validate(); err != nil {
+				return nil, fmt.Errorf("fee schedules: routingNumber=%s schedule[%d]: %w", routingNumber, i, err)
+			}
+		}
+	}
+	return schedules, nil
+}
+
+// readFeeSchedulesFile returns the fee schedules from a YAML file, or no schedules when path is empty
+func readFeeSchedulesFile(path string) (feeSchedules, error) {
+	if path == "" {
+		return nil, nil
+	}
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return readFeeSchedules(bytes.NewReader(bs))
+}
+
+// scheduleFor returns the fee schedule which applies to acct, or nil if it isn't charged fees. When plan is
+// set the fee schedule with that name applies, otherwise the one for the account's type.
+func (schedules feeSchedules) scheduleFor(acct *accounts.Account, plan string) *feeSchedule {
+	for i := range schedules[acct.RoutingNumber] {
+		s := &schedules[acct.RoutingNumber][i]
+		if plan != "" && strings.EqualFold(s.Name, plan) || plan == "" && strings.EqualFold(s.AccountType, acct.Type) {
+			return s
+		}
+	}
+	return nil
+}
+
+// feeableLine is a line of a customer transaction which fees can be charged for
+type feeableLine struct {
+	TransactionID string
+	EffectiveDate time.Time
+	transactionLine
+}
+
+// nsfEvent is a debit which was rejected because the account had insufficient funds
+type nsfEvent struct {
+	TransactionID string
+	AccountID     string
+	Amount        int64
+	CreatedAt     time.Time
+}
+
+// feeAssessment is a fee to charge an account. Reference identifies what the fee is for (e.g. the month of
+// a maintenance fee or the transaction a transaction fee was charged for) so it's only charged once.
+type feeAssessment struct {
+	AccountID     string
+	Type          FeeType
+	Reference     string
+	Amount        int64
+	EffectiveDate time.Time
+}
+
+// feeActivity is what happened to an account over a window of days that fees are charged on
+type feeActivity struct {
+	Balances []dailyBalance
+	Lines    []feeableLine
+	NSF      []nsfEvent
+
+	// Changes are the net changes to the balance of each transaction without their Fee lines
+	Changes []effectiveBalanceChange
+}
+
+// fees returns the fees the schedule charges acct for activity. maintenance is the month a maintenance
+// fee is due for, which is nil when the month hasn't ended.
+func (s feeSchedule) fees(acct *accounts.Account, activity feeActivity, maintenance *accountingPeriod) []feeAssessment {
+	var out []feeAssessment
+	if s.Maintenance != nil && maintenance != nil {
+		waived := s.Maintenance.WaiveMinBalance > 0 && len(activity.Balances) > 0
+		for i := range activity.Balances {
+			if activity.Balances[i].Min < s.Maintenance.WaiveMinBalance {
+				waived = false
+			}
+		}
+		if !waived {
+			out = append(out, feeAssessment{
+				AccountID:     acct.ID,
+				Type:          MaintenanceFee,
+				Reference:     maintenance.Start.Format("2006-01"),
+				Amount:        s.Maintenance.Amount,
+				EffectiveDate: maintenance.End.Add(-1 * time.Second),
+			})
+		}
+	}
+	for _, line := range activity.Lines {
+		for _, fee := range s.Transaction {
+			if fee.matches(line) {
+				out = append(out, feeAssessment{
+					AccountID:     acct.ID,
+					Type:          TransactionFee,
+					Reference:     line.TransactionID,
+					Amount:        fee.Amount,
+					EffectiveDate: line.EffectiveDate,
+				})
+				break
+			}
+		}
+	}
+	if s.Overdraft > 0 {
+		for _, day := range overdrawnDays(activity.Balances, activity.Changes) {
+			start, _ := time.Parse("2006-01-02", day)
+			out = append(out, feeAssessment{
+				AccountID:     acct.ID,
+				Type:          OverdraftFee,
+				Reference:     day,
+				Amount:        s.Overdraft,
+				EffectiveDate: start.AddDate(0, 0, 1).Add(-1 * time.Second),
+			})
+		}
+	}
+	if s.NSF > 0 {
+		for _, event := range activity.NSF {
+			out = append(out, feeAssessment{
+				AccountID:     acct.ID,
+				Type:          NSFFee,
+				Reference:     event.TransactionID,
+				Amount:        s.NSF,
+				EffectiveDate: event.CreatedAt,
+			})
+		}
+	}
+	return out
+}
+
+// overdrawnDays returns the days which opened with a non-negative balance and went negative. Fees aren't counted
+// towards going negative, so charging a fee (like an overdraft fee) never overdraws an account by itself.
+func overdrawnDays(balances []dailyBalance, changes []effectiveBalanceChange) []string {
+	var out []string
+	for _, day := range balances {
+		balance := day.Opening
+		overdrawn := false
+		for len(changes) > 0 && changes[0].EffectiveDate.UTC().Format("2006-01-02") <= day.Date {
+			if changes[0].EffectiveDate.UTC().Format("2006-01-02") == day.Date {
+				balance += changes[0].Amount
+				overdrawn = overdrawn || (day.Opening >= 0 && balance < 0)
+			}
+			changes = changes[1:]
+		}
+		if overdrawn {
+			out = append(out, day.Date)
+		}
+	}
+	return out
+}
+
+// readFeeActivity reads the activity of acct in [from, to), limited to the days it was open
+func readFeeActivity(repo feeRepository, acct *accounts.Account, from, to time.Time) (feeActivity, error) {
+	var activity feeActivity
+	if created := startOfDay(acct.CreatedAt); from.Before(created) {
+		from = created
+	}
+	if !acct.ClosedAt.IsZero() {
+		if closed := startOfDay(acct.ClosedAt).AddDate(0, 0, 1); to.After(closed) {
+			to = closed
+		}
+	}
+	if !from.Before(to) {
+		return activity, nil
+	}
+	var err error
+	if activity.Balances, err = repo.getDailyBalances(acct.ID, from, to); err != nil {
+		return activity, err
+	}
+	if activity.Lines, err = repo.getFeeableLines(acct.ID, from, to); err != nil {
+		return activity, err
+	}
+	if activity.NSF, err = repo.getNSFEvents(acct.ID, from, to); err != nil {
+		return activity, err
+	}
+	if activity.Changes, err = repo.getBalanceChanges(acct.ID, from, to, Fee); err != nil {
+		return activity, err
+	}
+	return activity, nil
+}
+
+// assessFees charges the fees of each account with a fee schedule for activity in period before through.
+// Maintenance fees are charged once period has ended. accountID, when set, limits fees to that account.
+func assessFees(logger log.Logger, accountRepo accountRepository, repo feeRepository, schedules feeSchedules, accountID string, period accountingPeriod, through time.Time) (int, error) {
+	to := period.End
+	var maintenance *accountingPeriod
+	if !through.Before(period.End) {
+		maintenance = &period
+	} else {
+		to = through
+	}
+
+	products, err := repo.getAccountProducts()
+	if err != nil {
+		return 0, err
+	}
+	catalog := newProductCatalog(products)
+	assessed := 0
+	err = forEachAccount(accountRepo, to, func(acct *accounts.Account) error {
+		if accountID != "" && acct.ID != accountID {
+			return nil
+		}
+		schedule := schedules.scheduleFor(acct, catalog.feePlan(acct.Type))
+		if schedule == nil {
+			return nil
+		}
+		activity, err := readFeeActivity(repo, acct, period.Start, to)
+		if err != nil {
+			logger.Log("fees", fmt.Sprintf("problem reading account=%s activity: %v", acct.ID, err))
+			return nil
+		}
+		due := maintenance
+		if !acct.ClosedAt.IsZero() && acct.ClosedAt.Before(period.End) {
+			due = nil // closed accounts aren't charged maintenance
+		}
+		income := glAccountID(acct.RoutingNumber, schedule.Income)
+		for _, fee := range schedule.fees(acct, activity, due) {
+			t, err := repo.assessFee(acct, income, fee)
+			if err != nil && errors.Is(err, errFeeUncollected) {
+				logger.Log("fees", fmt.Sprintf("recorded %s fee=%s for account=%s as uncollected", fee.Type, fee.Reference, acct.ID))
+				continue
+			}
+			if err != nil {
+				logger.Log("fees", fmt.Sprintf("problem assessing %s fee=%s for account=%s: %v", fee.Type, fee.Reference, acct.ID, err))
+				continue
+			}
+			if t != nil {
+				logger.Log("fees", fmt.Sprintf("assessed %s fee=%s as transaction=%s for account=%s", fee.Type, fee.Reference, t.ID, acct.ID))
+				assessed++
+			}
+		}
+		return nil
+	})
+	return assessed, err
+}
+
+// assessFeesEvery periodically charges fees for last month and this month through yesterday until ctx is done.
+func assessFeesEvery(ctx context.Context, logger log.Logger, accountRepo accountRepository, repo feeRepository, schedules feeSchedules, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			today := startOfDay(now)
+			thisMonth := newAccountingPeriod(MonthlyPeriod, time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC))
+			lastMonth := newAccountingPeriod(MonthlyPeriod, thisMonth.Start.AddDate(0, -1, 0))
+			for _, period := range []accountingPeriod{lastMonth, thisMonth} {
+				n, err := assessFees(logger, accountRepo, repo, schedules, "", period, today)
+				if err != nil {
+					logger.Log("fees", fmt.Sprintf("problem assessing fees for %s: %v", period.ID, err))
+					continue
+				}
+				if n > 0 {
+					logger.Log("fees", fmt.Sprintf("assessed %d fees for %s", n, period.ID))
+				}
+			}
+		}
+	}
+}
+
+// addFeeRoutes registers the admin endpoint for assessing fees
+func addFeeRoutes(logger log.Logger, handle func(string, http.HandlerFunc), accountRepo accountRepository, repo feeRepository, schedules feeSchedules) {
+	handle("/fees/assess", assessMonthlyFees(logger, accountRepo, repo, schedules))
+}
+
+type feeRun struct {
+	Assessed int `json:"assessed"`
+}
+
+// assessMonthlyFees charges the fees due for month (YYYY-MM) through yesterday. Fees which have already
+// been assessed aren't charged again.
+func assessMonthlyFees(logger log.Logger, accountRepo accountRepository, repo feeRepository, schedules feeSchedules) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, fmt.Sprintf("unsupported HTTP verb %s", r.Method), http.StatusBadRequest)
+			return
+		}
+		q := r.URL.Query()
+		period, err := readAccountingPeriod(MonthlyPeriod, q.Get("month"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		today := startOfDay(time.Now())
+		if !period.Start.Before(today) {
+			http.Error(w, fmt.Sprintf("%s hasn't started", period.ID), http.StatusBadRequest)
+			return
+		}
+
+		n, err := assessFees(logger, accountRepo, repo, schedules, q.Get("accountId"), period, today)
+		if err != nil {
+			logger.Log("fees", fmt.Sprintf("problem assessing fees for %s: %v", period.ID, err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(feeRun{Assessed: n})
+	}
+}
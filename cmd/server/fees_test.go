@@ -0,0 +1,158 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+func TestFees__readFeeSchedules(t *testing.T) {
+	schedules, err := readFeeSchedules(strings.NewReader(`
+"121042882":
+  - name: Basic checking
+    accountType: Checking
+    income: RIAD4080
+    maintenance:
+      amount: 500
+      waiveMinBalance: 150000
+    transactions:
+      - purpose: Wire
+        direction: debit
+        amount: 2500
+    overdraft: 3500
+    nsf: 3000
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedule := schedules.scheduleFor(&accounts.Account{RoutingNumber: "121042882", Type: "checking"}, "")
+	if schedule == nil || schedule.Income != "4080" || schedule.Transaction[0].Purpose != Wire || schedule.Maintenance.WaiveMinBalance != 150000 {
+		t.Fatalf("unexpected schedule: %#v", schedule)
+	}
+	if s := schedules.scheduleFor(&accounts.Account{RoutingNumber: "231380104", Type: "checking"}, ""); s != nil {
+		t.Errorf("unexpected schedule: %#v", s)
+	}
+	// Account products can reference a fee schedule by name
+	if s := schedules.scheduleFor(&accounts.Account{RoutingNumber: "121042882", Type: "student"}, "Basic Checking"); s != schedule {
+		t.Errorf("unexpected schedule: %#v", s)
+	}
+
+	for _, raw := range []string{
+		`"1234": []`,
+		`"121042882": [{accountType: checking, income: "4080"}]`,
+		`"121042882": [{name: Checking, accountType: "home loan", income: "4080"}]`,
+		`"121042882": [{name: Checking, accountType: checking, income: "4080", maintenance: {amount: 0}}]`,
+		`"121042882": [{name: Checking, accountType: checking, income: "4080", transactions: [{purpose: fee, amount: 100}]}]`,
+		`"121042882": [{name: Checking, accountType: checking, income: "4080", transactions: [{purpose: wire, direction: sideways, amount: 100}]}]`,
+		`"121042882": [{name: Checking, accountType: checking, income: "4080", transactions: [{purpose: wire}]}]`,
+		`"121042882": [{name: Checking, accountType: checking, income: "4080", overdraft: -1}]`,
+		`"121042882": [{name: Checking, accountType: checking, income: "4080", monthly: 500}]`,
+	} {
+		if _, err := readFeeSchedules(strings.NewReader(raw)); err == nil {
+			t.Errorf("expected error: %s", raw)
+		}
+	}
+	if schedules, err := readFeeSchedulesFile(""); schedules != nil || err != nil {
+		t.Errorf("schedules=%v error=%v", schedules, err)
+	}
+}
+
+func TestFees__fees(t *testing.T) {
+	schedule := feeSchedule{
+		Maintenance: &maintenanceFee{Amount: 500, WaiveMinBalance: 1000},
+		Transaction: []transactionFee{{Purpose: Wire, Direction: Debit, Amount: 2500}, {Purpose: Wire, Amount: 1000}},
+		Overdraft:   3500,
+	}
+	acct := &accounts.Account{ID: "acct"}
+	period, _ := readAccountingPeriod(MonthlyPeriod, "2020-03")
+	activity := feeActivity{
+		Balances: []dailyBalance{
+			{Date: "2020-03-01", Opening: 2000, Closing: 1500, Min: 1500, Max: 2000},
+			{Date: "2020-03-02", Opening: 1500, Closing: -100, Min: -100, Max: 1500},
+			{Date: "2020-03-03", Opening: -100, Closing: -200, Min: -200, Max: -100},
+		},
+		Lines: []feeableLine{
+			{TransactionID: "debit", transactionLine: transactionLine{Purpose: Wire, Direction: Debit, Amount: 100}},
+			{TransactionID: "credit", transactionLine: transactionLine{Purpose: Wire, Direction: Credit, Amount: 100}},
+			{TransactionID: "ach", transactionLine: transactionLine{Purpose: ACHDebit, Direction: Debit, Amount: 100}},
+		},
+		NSF: []nsfEvent{{TransactionID: "rejected"}},
+		Changes: []effectiveBalanceChange{
+			{EffectiveDate: time.Date(2020, time.March, 1, 12, 0, 0, 0, time.UTC), Amount: -500},
+			{EffectiveDate: time.Date(2020, time.March, 2, 12, 0, 0, 0, time.UTC), Amount: -1600},
+			{EffectiveDate: time.Date(2020, time.March, 3, 12, 0, 0, 0, time.UTC), Amount: -100},
+		},
+	}
+
+	fees := schedule.fees(acct, activity, &period)
+	if len(fees) != 4 {
+		t.Fatalf("unexpected fees: %#v", fees)
+	}
+	if fees[0].Type != MaintenanceFee || fees[0].Reference != "2020-03" || !fees[0].EffectiveDate.Equal(period.End.Add(-1*time.Second)) {
+		t.Errorf("unexpected maintenance fee: %#v", fees[0])
+	}
+	if fees[1].Reference != "debit" || fees[1].Amount != 2500 || fees[2].Reference != "credit" || fees[2].Amount != 1000 {
+		t.Errorf("unexpected transaction fees: %#v", fees[1:3])
+	}
+	if fees[3].Type != OverdraftFee || fees[3].Reference != "2020-03-02" {
+		t.Errorf("unexpected overdraft fee: %#v", fees[3])
+	}
+
+	// Days which only went negative from fees aren't overdrawn
+	activity.Changes[1].Amount = -1400
+	if fees := schedule.fees(acct, activity, &period); len(fees) != 3 || fees[2].Type != TransactionFee {
+		t.Errorf("unexpected fees: %#v", fees)
+	}
+
+	// Maintenance is waived above the minimum balance and only charged after the month
+	activity.Balances = activity.Balances[:1]
+	if fees := schedule.fees(acct, activity, &period); len(fees) != 2 || fees[0].Type != TransactionFee {
+		t.Errorf("unexpected fees: %#v", fees)
+	}
+	schedule.NSF = 3000
+	if fees := schedule.fees(acct, activity, nil); len(fees) != 3 || fees[2].Type != NSFFee || fees[2].Reference != "rejected" {
+		t.Errorf("unexpected fees: %#v", fees)
+	}
+}
+
+func TestFees__routes(t *testing.T) {
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+
+	router := mux.NewRouter()
+	addFeeRoutes(log.NewNopLogger(), func(path string, hf http.HandlerFunc) {
+		router.HandleFunc(path, hf)
+	}, &testAccountRepository{}, setupSqlFeeStorage(log.NewNopLogger(), createTestSqlTransactionRepository(t, sqliteDB.DB)), nil)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/fees/assess?month=2020-03", nil),
+		httptest.NewRequest("POST", "/fees/assess?month=March", nil),
+		httptest.NewRequest("POST", "/fees/assess?month="+time.Now().AddDate(0, 2, 0).Format("2006-01"), nil),
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		w.Flush()
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: bogus HTTP status: %d", req.Method, req.URL, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/fees/assess?month=2020-03", nil))
+	w.Flush()
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"assessed":0`) {
+		t.Errorf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+}
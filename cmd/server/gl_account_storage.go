@@ -0,0 +1,35 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+type glAccountRepository interface {
+	createGLAccount(acct glAccount) error
+
+	// getChartOfAccounts returns every GL account for the routing number ordered by code with their balances rolled up
+	getChartOfAccounts(routingNumber string) ([]glAccount, error)
+	getGLAccount(routingNumber, code string) (*glAccount, error)
+
+	// getGLActivity returns every GL account for the routing number with the net amount posted to it by
+	// transactions timestamped within [start, end) rolled up. A zero start includes everything before end.
+	getGLActivity(routingNumber string, start, end time.Time) ([]glAccount, error)
+
+	// updateGLAccount saves the name, parentCode and accountType of an existing GL account
+	updateGLAccount(acct glAccount) error
+
+	// deleteGLAccount removes a GL account which has no accounts below it and has never been posted against
+	deleteGLAccount(routingNumber, code string) error
+}
+
+var (
+	errGLAccountNotFound        = errors.New("GL account not found")
+	errGLAccountExists          = errors.New("GL account already exists")
+	errGLAccountHasChildren     = errors.New("GL account has accounts which roll up into it")
+	errGLAccountHasTransactions = errors.New("GL account has posted transactions")
+)
@@ -0,0 +1,266 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moov-io/accounts/cmd/server/database"
+
+	"github.com/go-kit/kit/log"
+)
+
+type sqlGLAccountRepository struct {
+	db     *sql.DB
+	logger log.Logger
+
+	// accountRepo reads the types of customer accounts, which roll up into GL accounts
+	accountRepo accountRepository
+}
+
+func setupSqlGLAccountStorage(logger log.Logger, db *sql.DB, accountRepo accountRepository) *sqlGLAccountRepository {
+	return &sqlGLAccountRepository{db: db, logger: logger, accountRepo: accountRepo}
+}
+
+const glAccountColumns = `account_id, routing_number, code, name, category, parent_code, account_type, created_at, last_modified`
+
+func scanGLAccount(row rowScanner) (*glAccount, error) {
+	var acct glAccount
+	var parentCode, accountType sql.NullString
+	err := row.Scan(&acct.ID, &acct.RoutingNumber, &acct.Code, &acct.Name, &acct.Category, &parentCode, &accountType, &acct.CreatedAt, &acct.LastModified)
+	if err != nil {
+		return nil, err
+	}
+	acct.ParentCode, acct.AccountType = parentCode.String, accountType.String
+	return &acct, nil
+}
+
+// readGLAccounts returns the GL accounts for a routing number ordered by code, without balances
+func readGLAccounts(tx *sql.Tx, routingNumber string) ([]glAccount, error) {
+	query := fmt.Sprintf(`select %s from gl_accounts where routing_number = ? order by length(code), code;`, glAccountColumns)
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("readGLAccounts: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(routingNumber)
+	if err != nil {
+		return nil, fmt.Errorf("readGLAccounts: query: %w", err)
+	}
+	defer rows.Close()
+
+	var out []glAccount
+	for rows.Next() {
+		acct, err := scanGLAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("readGLAccounts: scan: %w", err)
+		}
+		out = append(out, *acct)
+	}
+	return out, rows.Err()
+}
+
+func nullString(v string) sql.NullString {
+	return sql.NullString{String: v, Valid: v != ""}
+}
+
+func (r *sqlGLAccountRepository) createGLAccount(acct glAccount) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("createGLAccount: tx.Begin: %w", err)
+	}
+	chart, err := readGLAccounts(tx, acct.RoutingNumber)
+	if err != nil {
+		return fmt.Errorf("createGLAccount: %w rollback=%v", err, tx.Rollback())
+	}
+	if err := checkGLHierarchy(chart, acct); err != nil {
+		return fmt.Errorf("createGLAccount: %w rollback=%v", err, tx.Rollback())
+	}
+
+	query := fmt.Sprintf(`insert into gl_accounts (%s) values (?, ?, ?, ?, ?, ?, ?, ?, ?);`, glAccountColumns)
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("createGLAccount: prepare: error=%w rollback=%v", err, tx.Rollback())
+	}
+	_, err = stmt.Exec(acct.ID, acct.RoutingNumber, acct.Code, acct.Name, acct.Category, nullString(acct.ParentCode), nullString(acct.AccountType), acct.CreatedAt, acct.LastModified)
+	stmt.Close()
+	if err != nil {
+		tx.Rollback()
+		if database.UniqueViolation(err) {
+			return errGLAccountExists
+		}
+		return fmt.Errorf("createGLAccount: insert account=%q: %w", acct.ID, err)
+	}
+	return tx.Commit()
+}
+
+func (r *sqlGLAccountRepository) getChartOfAccounts(routingNumber string) ([]glAccount, error) {
+	types, err := r.accountRepo.GetAccountTypes(routingNumber)
+	if err != nil {
+		return nil, fmt.Errorf("getChartOfAccounts: routingNumber=%s account types: %w", routingNumber, err)
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("getChartOfAccounts: tx.Begin: %w", err)
+	}
+	chart, err := readGLAccounts(tx, routingNumber)
+	if err != nil {
+		return nil, fmt.Errorf("getChartOfAccounts: %w rollback=%v", err, tx.Rollback())
+	}
+	balances, err := readGLBalances(tx, routingNumber)
+	if err != nil {
+		return nil, fmt.Errorf("getChartOfAccounts: routingNumber=%s: %w rollback=%v", routingNumber, err, tx.Rollback())
+	}
+
+	customers, err := readBalances(tx, `select account_id, balance from account_balances;`)
+	if err != nil {
+		return nil, fmt.Errorf("getChartOfAccounts: routingNumber=%s customer balances: %w rollback=%v", routingNumber, err, tx.Rollback())
+	}
+	rollUpCustomerBalances(chart, balances, sumByAccountType(customers, types))
+
+	return chart, tx.Commit()
+}
+
+// sumByAccountType adds up balances keyed by account ID into the lowercase account type from types. Accounts missing
+// from types, like GL accounts and accounts at other routing numbers, are left out.
+func sumByAccountType(balances map[string]int64, types map[string]string) map[string]int64 {
+	out := make(map[string]int64)
+	for accountID, balance := range balances {
+		if accountType, exists := types[accountID]; exists {
+			out[accountType] += balance
+		}
+	}
+	return out
+}
+
+// rollUpCustomerBalances adds the balances of customer accounts, keyed by lowercase account type, into the GL
+// account for their type and then rolls every balance up the chart.
+func rollUpCustomerBalances(chart []glAccount, balances map[string]int64, customers map[string]int64) {
+	for i := range chart {
+		if chart[i].AccountType != "" {
+			balances[chart[i].ID] += customers[strings.ToLower(chart[i].AccountType)]
+		}
+	}
+	rollUpGLBalances(chart, balances)
+}
+
+func (r *sqlGLAccountRepository) getGLActivity(routingNumber string, start, end time.Time) ([]glAccount, error) {
+	types, err := r.accountRepo.GetAccountTypes(routingNumber)
+	if err != nil {
+		return nil, fmt.Errorf("getGLActivity: routingNumber=%s account types: %w", routingNumber, err)
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("getGLActivity: tx.Begin: %w", err)
+	}
+	chart, err := readGLAccounts(tx, routingNumber)
+	if err != nil {
+		return nil, fmt.Errorf("getGLActivity: %w rollback=%v", err, tx.Rollback())
+	}
+
+	// Sum lines instead of reading account_balances since those are only current
+	balances, err := readBalances(tx, `select l.account_id, sum(case when l.direction = 'debit' then -l.amount else l.amount end)
+from transaction_lines l inner join transactions t on t.transaction_id = l.transaction_id
+inner join gl_accounts g on g.account_id = l.account_id
+where g.routing_number = ? and t.effective_date >= ? and t.effective_date < ? and l.deleted_at is null and t.deleted_at is null
+group by l.account_id;`, routingNumber, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("getGLActivity: routingNumber=%s balances: %w rollback=%v", routingNumber, err, tx.Rollback())
+	}
+	customers, err := readBalances(tx, `select l.account_id, sum(case when l.direction = 'debit' then -l.amount else l.amount end)
+from transaction_lines l inner join transactions t on t.transaction_id = l.transaction_id
+where t.effective_date >= ? and t.effective_date < ? and l.deleted_at is null and t.deleted_at is null
+group by l.account_id;`, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("getGLActivity: routingNumber=%s customer balances: %w rollback=%v", routingNumber, err, tx.Rollback())
+	}
+	rollUpCustomerBalances(chart, balances, sumByAccountType(customers, types))
+
+	return chart, tx.Commit()
+}
+
+// readGLBalances returns the balance posted against each GL account for a routing number, keyed by account ID
+func readGLBalances(tx *sql.Tx, routingNumber string) (map[string]int64, error) {
+	return readBalances(tx, `select g.account_id, b.balance from gl_accounts g inner join account_balances b on b.account_id = g.account_id where g.routing_number = ?;`, routingNumber)
+}
+
+func (r *sqlGLAccountRepository) getGLAccount(routingNumber, code string) (*glAccount, error) {
+	chart, err := r.getChartOfAccounts(routingNumber)
+	if err != nil {
+		return nil, err
+	}
+	for i := range chart {
+		if chart[i].Code == code {
+			return &chart[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *sqlGLAccountRepository) updateGLAccount(acct glAccount) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("updateGLAccount: tx.Begin: %w", err)
+	}
+	chart, err := readGLAccounts(tx, acct.RoutingNumber)
+	if err != nil {
+		return fmt.Errorf("updateGLAccount: %w rollback=%v", err, tx.Rollback())
+	}
+	if err := checkGLHierarchy(chart, acct); err != nil {
+		return fmt.Errorf("updateGLAccount: %w rollback=%v", err, tx.Rollback())
+	}
+
+	query := `update gl_accounts set name = ?, parent_code = ?, account_type = ?, last_modified = ? where account_id = ?;`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("updateGLAccount: prepare: error=%w rollback=%v", err, tx.Rollback())
+	}
+	res, err := stmt.Exec(acct.Name, nullString(acct.ParentCode), nullString(acct.AccountType), acct.LastModified, acct.ID)
+	stmt.Close()
+	if err != nil {
+		return fmt.Errorf("updateGLAccount: account=%q update: error=%w rollback=%v", acct.ID, err, tx.Rollback())
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("updateGLAccount: account=%q: %w rollback=%v", acct.ID, errGLAccountNotFound, tx.Rollback())
+	}
+	return tx.Commit()
+}
+
+func (r *sqlGLAccountRepository) deleteGLAccount(routingNumber, code string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("deleteGLAccount: tx.Begin: %w", err)
+	}
+	accountID := glAccountID(routingNumber, code)
+
+	var children, lines int
+	query := `select count(*) from gl_accounts where routing_number = ? and parent_code = ?;`
+	if err := tx.QueryRow(query, routingNumber, code).Scan(&children); err != nil {
+		return fmt.Errorf("deleteGLAccount: account=%q children: error=%w rollback=%v", accountID, err, tx.Rollback())
+	}
+	if children > 0 {
+		return fmt.Errorf("deleteGLAccount: account=%q: %w rollback=%v", accountID, errGLAccountHasChildren, tx.Rollback())
+	}
+	query = `select count(*) from transaction_lines where account_id = ?;`
+	if err := tx.QueryRow(query, accountID).Scan(&lines); err != nil {
+		return fmt.Errorf("deleteGLAccount: account=%q lines: error=%w rollback=%v", accountID, err, tx.Rollback())
+	}
+	if lines > 0 {
+		return fmt.Errorf("deleteGLAccount: account=%q: %w rollback=%v", accountID, errGLAccountHasTransactions, tx.Rollback())
+	}
+
+	res, err := tx.Exec(`delete from gl_accounts where account_id = ?;`, accountID)
+	if err != nil {
+		return fmt.Errorf("deleteGLAccount: account=%q delete: error=%w rollback=%v", accountID, err, tx.Rollback())
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("deleteGLAccount: account=%q: %w rollback=%v", accountID, errGLAccountNotFound, tx.Rollback())
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,196 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+)
+
+// createTestGLAccount adds an account to the chart of accounts for routingNumber
+func createTestGLAccount(t *testing.T, db *sql.DB, routingNumber string, req createGLAccountRequest) {
+	t.Helper()
+
+	acct, err := req.asGLAccount(routingNumber, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := setupSqlGLAccountStorage(log.NewNopLogger(), db, &testAccountRepository{}).createGLAccount(acct); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSqlGLAccountRepository(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, db *sql.DB) {
+		transactionRepo := createTestSqlTransactionRepository(t, db)
+		repo := setupSqlGLAccountStorage(log.NewNopLogger(), db, transactionRepo.accountRepo)
+		routingNumber := "121042882"
+
+		now := time.Now()
+		create := func(req createGLAccountRequest) glAccount {
+			t.Helper()
+			acct, err := req.asGLAccount(routingNumber, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.createGLAccount(acct); err != nil {
+				t.Fatal(err)
+			}
+			return acct
+		}
+		cash := create(createGLAccountRequest{Code: "0010", Name: "Cash", Category: GLAsset})
+		deposits := create(createGLAccountRequest{Code: "2200", Name: "Deposits", Category: GLLiability})
+		checking := create(createGLAccountRequest{Code: "2210", Name: "Checking", Category: GLLiability, ParentCode: "2200", AccountType: "checking"})
+
+		if err := repo.createGLAccount(cash); err != errGLAccountExists {
+			t.Errorf("unexpected error: %v", err)
+		}
+		orphan, _ := createGLAccountRequest{Code: "2220", Name: "Savings", Category: GLLiability, ParentCode: "2299"}.asGLAccount(routingNumber, now)
+		if err := repo.createGLAccount(orphan); err == nil {
+			t.Error("expected error")
+		}
+
+		// A customer deposits cash into their checking account
+		customer := &accounts.Account{ID: base.ID(), CustomerID: base.ID(), Name: "Checking", AccountNumber: "123", RoutingNumber: routingNumber, Status: "open", Type: "Checking", CreatedAt: now, LastModified: now}
+		if err := transactionRepo.accountRepo.CreateAccount(customer.CustomerID, customer); err != nil {
+			t.Fatal(err)
+		}
+		tx := transaction{
+			ID:        base.ID(),
+			Timestamp: now,
+			Lines: []transactionLine{
+				{AccountID: cash.ID, Purpose: ACHCredit, Direction: Debit, Amount: 1500},
+				{AccountID: customer.ID, Purpose: ACHCredit, Direction: Credit, Amount: 1500},
+			},
+		}
+		if err := transactionRepo.createTransaction(tx, createTransactionOpts{AllowGLDebits: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		chart, err := repo.getChartOfAccounts(routingNumber)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chart) != 3 {
+			t.Fatalf("unexpected chart: %#v", chart)
+		}
+		for _, acct := range chart {
+			if acct.Balance != 1500 {
+				t.Errorf("GL account %s balance=%d", acct.Code, acct.Balance)
+			}
+		}
+
+		// Move checking accounts out from under deposits
+		checking.ParentCode = ""
+		if err := repo.updateGLAccount(checking); err != nil {
+			t.Fatal(err)
+		}
+		found, err := repo.getGLAccount(routingNumber, deposits.Code)
+		if err != nil || found == nil || found.Balance != 0 {
+			t.Errorf("account=%#v error=%v", found, err)
+		}
+		checking.ParentCode = "0010"
+		if err := repo.updateGLAccount(checking); err == nil {
+			t.Error("expected error")
+		}
+
+		// Accounts with children or posted transactions can't be deleted
+		checking.ParentCode = deposits.Code
+		if err := repo.updateGLAccount(checking); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.deleteGLAccount(routingNumber, deposits.Code); err == nil || !strings.Contains(err.Error(), errGLAccountHasChildren.Error()) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := repo.deleteGLAccount(routingNumber, cash.Code); err == nil || !strings.Contains(err.Error(), errGLAccountHasTransactions.Error()) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := repo.deleteGLAccount(routingNumber, checking.Code); err != nil {
+			t.Fatal(err)
+		}
+		if found, err := repo.getGLAccount(routingNumber, checking.Code); err != nil || found != nil {
+			t.Errorf("account=%#v error=%v", found, err)
+		}
+		if err := repo.deleteGLAccount(routingNumber, checking.Code); err == nil {
+			t.Error("expected error")
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, sqliteDB.DB)
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, mysqlDB.DB)
+}
+
+func TestSqlGLAccountRepository__separateAccountsDatabase(t *testing.T) {
+	accountsDB := database.CreateTestSqliteDB(t)
+	defer accountsDB.Close()
+	transactionsDB := database.CreateTestSqliteDB(t)
+	defer transactionsDB.Close()
+
+	accountRepo, err := setupSqlAccountStorage(context.Background(), log.NewNopLogger(), accountsDB.DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transactionRepo := createTestSqlTransactionRepository(t, transactionsDB.DB)
+	transactionRepo.accountRepo = accountRepo
+	repo := setupSqlGLAccountStorage(log.NewNopLogger(), transactionsDB.DB, accountRepo)
+
+	routingNumber := "121042882"
+	createTestGLAccount(t, transactionsDB.DB, routingNumber, createGLAccountRequest{Code: "0010", Name: "Cash", Category: GLAsset})
+	createTestGLAccount(t, transactionsDB.DB, routingNumber, createGLAccountRequest{Code: "2210", Name: "Checking", Category: GLLiability, AccountType: "checking"})
+
+	// Customer accounts only exist in the accounts database, including one at another financial institution
+	now := time.Now()
+	customer := &accounts.Account{ID: base.ID(), CustomerID: base.ID(), Name: "Checking", AccountNumber: "123", RoutingNumber: routingNumber, Status: "open", Type: "Checking", CreatedAt: now, LastModified: now}
+	external := &accounts.Account{ID: base.ID(), CustomerID: base.ID(), Name: "External", AccountNumber: "456", RoutingNumber: "231380104", Status: "open", Type: "Checking", CreatedAt: now, LastModified: now}
+	for _, acct := range []*accounts.Account{customer, external} {
+		if err := accountRepo.CreateAccount(acct.CustomerID, acct); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, acct := range []*accounts.Account{customer, external} {
+		tx := transaction{
+			ID:        base.ID(),
+			Timestamp: now,
+			Lines: []transactionLine{
+				{AccountID: glAccountID(routingNumber, "0010"), Purpose: ACHCredit, Direction: Debit, Amount: 1500},
+				{AccountID: acct.ID, Purpose: ACHCredit, Direction: Credit, Amount: 1500},
+			},
+		}
+		if err := transactionRepo.createTransaction(tx, createTransactionOpts{AllowGLDebits: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	chart, err := repo.getChartOfAccounts(routingNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chart) != 2 || chart[0].Balance != 3000 || chart[1].Balance != 1500 {
+		t.Errorf("unexpected chart: %#v", chart)
+	}
+	activity, err := repo.getGLActivity(routingNumber, time.Time{}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(activity) != 2 || activity[0].Balance != 3000 || activity[1].Balance != 1500 {
+		t.Errorf("unexpected activity: %#v", activity)
+	}
+}
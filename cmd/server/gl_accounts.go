@@ -0,0 +1,463 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	moovhttp "github.com/moov-io/base/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+// GLCategory is the section of the chart of accounts a general ledger (GL) account belongs to.
+type GLCategory string
+
+var (
+	GLAsset     GLCategory = "asset"
+	GLLiability GLCategory = "liability"
+	GLEquity    GLCategory = "equity"
+	GLIncome    GLCategory = "income"
+	GLExpense   GLCategory = "expense"
+)
+
+func (c *GLCategory) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*c = GLCategory(strings.ToLower(strings.TrimSpace(s)))
+	if *c == "" {
+		return nil // call report codes have a default category
+	}
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c GLCategory) validate() error {
+	switch c {
+	case GLAsset, GLLiability, GLEquity, GLIncome, GLExpense:
+		return nil
+	default:
+		return fmt.Errorf("unknown GLCategory %q", c)
+	}
+}
+
+// normalDirection returns the direction which increases balances of accounts in the category. Assets and
+// expenses are increased by debits while liabilities, equity and income are increased by credits.
+func (c GLCategory) normalDirection() LineDirection {
+	if c == GLAsset || c == GLExpense {
+		return Debit
+	}
+	return Credit
+}
+
+// glAccount is an account in the chart of accounts for a routing number. Transactions are posted against
+// GL accounts with their ID, which is the routing number and code joined by a dash (e.g. 121042882-2365).
+type glAccount struct {
+	ID            string     `json:"id"`
+	RoutingNumber string     `json:"routingNumber"`
+	Code          string     `json:"code"`
+	Name          string     `json:"name"`
+	Category      GLCategory `json:"category"`
+
+	// ParentCode is the code of the GL account this account rolls up into
+	ParentCode string `json:"parentCode,omitempty"`
+
+	// AccountType is the type of customer account (the code of its account product) whose balances roll up into this account
+	AccountType string `json:"accountType,omitempty"`
+
+	// Balance is the balance posted to this account, every account below it and any customer accounts which
+	// roll up into it. Balances are reported in the normal direction of the category, so an asset with more
+	// debits than credits has a positive balance.
+	Balance int64 `json:"balance"`
+
+	CreatedAt    time.Time `json:"createdAt"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+const maxGLAccountNameLength = 100
+
+func (a glAccount) validate() error {
+	if a.ID != glAccountID(a.RoutingNumber, a.Code) {
+		return fmt.Errorf("glAccount: ID %q doesn't match routingNumber and code", a.ID)
+	}
+	if _, err := readGLCode(a.Code); err != nil {
+		return err
+	}
+	if a.Name == "" || len(a.Name) > maxGLAccountNameLength {
+		return fmt.Errorf("glAccount: name must be between 1 and %d characters", maxGLAccountNameLength)
+	}
+	if err := a.Category.validate(); err != nil {
+		return err
+	}
+	if a.ParentCode != "" && a.ParentCode == a.Code {
+		return errors.New("glAccount: account can't be its own parent")
+	}
+	if a.AccountType != "" {
+		if !validCustomerAccountType(a.AccountType) {
+			return fmt.Errorf("glAccount: unknown accountType %q", a.AccountType)
+		}
+		if a.Category != GLLiability {
+			return errors.New("glAccount: customer accounts can only roll up into a liability account")
+		}
+	}
+	return nil
+}
+
+// glAccountID returns the ID transactions are posted against for a GL account
+func glAccountID(routingNumber, code string) string {
+	return fmt.Sprintf("%s-%s", routingNumber, code)
+}
+
+var (
+	glAccountIDRegex   = regexp.MustCompile(`^[0-9]{9}-[0-9]{1,10}$`)
+	glCodeRegex        = regexp.MustCompile(`^[A-Za-z]*([0-9]{1,10})$`)
+	routingNumberRegex = regexp.MustCompile(`^[0-9]{9}$`)
+)
+
+// isGLAccountID returns true if accountID belongs to a GL account rather than a customer account.
+func isGLAccountID(accountID string) bool {
+	return glAccountIDRegex.MatchString(accountID)
+}
+
+// readGLCode returns the numeric GL code from v. Call report codes are prefixed with their schedule
+// (e.g. RCON2365) which is dropped, leaving the numeric suffix (2365).
+func readGLCode(v string) (string, error) {
+	m := glCodeRegex.FindStringSubmatch(strings.TrimSpace(v))
+	if len(m) != 2 {
+		return "", fmt.Errorf("invalid GL code %q", v)
+	}
+	return m[1], nil
+}
+
+// checkGLHierarchy returns an error if acct can't be saved in the chart of accounts for its routing number.
+// Parents must exist with the same category, the hierarchy can't loop and each customer account type rolls
+// up into at most one GL account.
+func checkGLHierarchy(chart []glAccount, acct glAccount) error {
+	byCode := make(map[string]glAccount)
+	for i := range chart {
+		byCode[chart[i].Code] = chart[i]
+		if acct.AccountType != "" && chart[i].Code != acct.Code && strings.EqualFold(chart[i].AccountType, acct.AccountType) {
+			return fmt.Errorf("%s accounts already roll up into GL account %s", acct.AccountType, chart[i].Code)
+		}
+	}
+	byCode[acct.Code] = acct
+
+	for code, seen := acct.ParentCode, map[string]bool{acct.Code: true}; code != ""; code = byCode[code].ParentCode {
+		parent, exists := byCode[code]
+		if !exists {
+			return fmt.Errorf("parent GL account %s not found", code)
+		}
+		if parent.Category != acct.Category {
+			return fmt.Errorf("parent GL account %s is %s, not %s", code, parent.Category, acct.Category)
+		}
+		if seen[code] {
+			return fmt.Errorf("GL account %s can't roll up into itself", acct.Code)
+		}
+		seen[code] = true
+	}
+	return nil
+}
+
+// rollUpGLBalances sets the Balance of each account to the balance posted against it plus the Balance of
+// every account below it. balances are keyed by account ID and are credits less debits, as with
+// every other account, and Balance is set in the normal direction of the account's category.
+func rollUpGLBalances(chart []glAccount, balances map[string]int64) {
+	children := make(map[string][]int)
+	for i := range chart {
+		if chart[i].ParentCode != "" {
+			children[chart[i].ParentCode] = append(children[chart[i].ParentCode], i)
+		}
+	}
+	totals := make(map[int]int64)
+	var total func(i int, depth int) int64
+	total = func(i int, depth int) int64 {
+		if n, exists := totals[i]; exists || depth > len(chart) {
+			return n // depth guards against loops, which checkGLHierarchy prevents
+		}
+		n := balances[chart[i].ID]
+		for _, child := range children[chart[i].Code] {
+			n += total(child, depth+1)
+		}
+		totals[i] = n
+		return n
+	}
+	for i := range chart {
+		chart[i].Balance = total(i, 0)
+		if chart[i].Category.normalDirection() == Debit {
+			chart[i].Balance = -1 * chart[i].Balance
+		}
+	}
+}
+
+type createGLAccountRequest struct {
+	Code        string     `json:"code"`
+	Name        string     `json:"name"`
+	Category    GLCategory `json:"category"`
+	ParentCode  string     `json:"parentCode"`
+	AccountType string     `json:"accountType"`
+}
+
+func (req createGLAccountRequest) asGLAccount(routingNumber string, now time.Time) (glAccount, error) {
+	code, err := readGLCode(req.Code)
+	if err != nil {
+		return glAccount{}, err
+	}
+	acct := glAccount{
+		ID:            glAccountID(routingNumber, code),
+		RoutingNumber: routingNumber,
+		Code:          code,
+		Name:          strings.TrimSpace(req.Name),
+		Category:      req.Category,
+		AccountType:   strings.ToLower(strings.TrimSpace(req.AccountType)),
+		CreatedAt:     now,
+		LastModified:  now,
+	}
+	if req.ParentCode != "" {
+		if acct.ParentCode, err = readGLCode(req.ParentCode); err != nil {
+			return acct, err
+		}
+	}
+
+	// Call report line items we know about fill in their name and category
+	if c, exists := lookupGLCode(req.Code); exists {
+		if acct.Name == "" {
+			acct.Name = c.Description()
+		}
+		if acct.Category == "" {
+			acct.Category = c.Category()
+		}
+		if acct.Category != c.Category() {
+			return acct, fmt.Errorf("glAccount: %s is reported as %s, not %s", c, c.Category(), acct.Category)
+		}
+	}
+	return acct, acct.validate()
+}
+
+// updateGLAccountRequest changes the fields which are set. Empty strings remove the parentCode or accountType.
+type updateGLAccountRequest struct {
+	Name        *string `json:"name"`
+	ParentCode  *string `json:"parentCode"`
+	AccountType *string `json:"accountType"`
+}
+
+func (req updateGLAccountRequest) apply(acct glAccount, now time.Time) (glAccount, error) {
+	if req.Name != nil {
+		acct.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.ParentCode != nil {
+		acct.ParentCode = ""
+		if *req.ParentCode != "" {
+			code, err := readGLCode(*req.ParentCode)
+			if err != nil {
+				return acct, err
+			}
+			acct.ParentCode = code
+		}
+	}
+	if req.AccountType != nil {
+		acct.AccountType = strings.ToLower(strings.TrimSpace(*req.AccountType))
+	}
+	acct.LastModified = now
+	return acct, acct.validate()
+}
+
+func addGLAccountRoutes(logger log.Logger, r *mux.Router, glRepo glAccountRepository) {
+	r.Methods("GET").Path("/gl/{routingNumber}/accounts").HandlerFunc(getChartOfAccounts(logger, glRepo))
+	r.Methods("POST").Path("/gl/{routingNumber}/accounts").HandlerFunc(createGLAccount(logger, glRepo))
+	r.Methods("GET").Path("/gl/{routingNumber}/accounts/{code}").HandlerFunc(getGLAccount(logger, glRepo))
+	r.Methods("PATCH").Path("/gl/{routingNumber}/accounts/{code}").HandlerFunc(updateGLAccount(logger, glRepo))
+	r.Methods("DELETE").Path("/gl/{routingNumber}/accounts/{code}").HandlerFunc(deleteGLAccount(logger, glRepo))
+}
+
+func getRoutingNumber(w http.ResponseWriter, r *http.Request) string {
+	v := mux.Vars(r)["routingNumber"]
+	if !routingNumberRegex.MatchString(v) {
+		moovhttp.Problem(w, fmt.Errorf("invalid routing number %q", v))
+		return ""
+	}
+	return v
+}
+
+// readGLAccount returns the GL account from the request path. Problems are written to w and nil is returned.
+func readGLAccount(w http.ResponseWriter, r *http.Request, glRepo glAccountRepository) *glAccount {
+	routingNumber := getRoutingNumber(w, r)
+	if routingNumber == "" {
+		return nil
+	}
+	code, err := readGLCode(mux.Vars(r)["code"])
+	if err != nil {
+		moovhttp.Problem(w, err)
+		return nil
+	}
+	acct, err := glRepo.getGLAccount(routingNumber, code)
+	if err != nil {
+		moovhttp.Problem(w, err)
+		return nil
+	}
+	if acct == nil {
+		http.NotFound(w, r)
+		return nil
+	}
+	return acct
+}
+
+func getChartOfAccounts(logger log.Logger, glRepo glAccountRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		routingNumber := getRoutingNumber(w, r)
+		if routingNumber == "" {
+			return
+		}
+		chart, err := glRepo.getChartOfAccounts(routingNumber)
+		if err != nil {
+			logger.Log("gl", fmt.Sprintf("problem reading chart of accounts for routingNumber=%s: %v", routingNumber, err), "requestID", moovhttp.GetRequestID(r))
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(chart)
+	}
+}
+
+func createGLAccount(logger log.Logger, glRepo glAccountRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		routingNumber := getRoutingNumber(w, r)
+		if routingNumber == "" {
+			return
+		}
+
+		var req createGLAccountRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+		acct, err := req.asGLAccount(routingNumber, time.Now())
+		if err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		requestID := moovhttp.GetRequestID(r)
+		if err := glRepo.createGLAccount(acct); err != nil {
+			logger.Log("gl", fmt.Sprintf("problem creating GL account=%s: %v", acct.ID, err), "requestID", requestID)
+			if errors.Is(err, errGLAccountExists) {
+				writeConflict(w, err)
+			} else {
+				moovhttp.Problem(w, err)
+			}
+			return
+		}
+		logger.Log("gl", fmt.Sprintf("created %s GL account=%s", acct.Category, acct.ID), "requestID", requestID)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(acct)
+	}
+}
+
+func getGLAccount(logger log.Logger, glRepo glAccountRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		acct := readGLAccount(w, r, glRepo)
+		if acct == nil {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(acct)
+	}
+}
+
+func updateGLAccount(logger log.Logger, glRepo glAccountRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		acct := readGLAccount(w, r, glRepo)
+		if acct == nil {
+			return
+		}
+
+		var req updateGLAccountRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+		updated, err := req.apply(*acct, time.Now())
+		if err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		requestID := moovhttp.GetRequestID(r)
+		if err := glRepo.updateGLAccount(updated); err != nil {
+			logger.Log("gl", fmt.Sprintf("problem updating GL account=%s: %v", acct.ID, err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		acct, err = glRepo.getGLAccount(acct.RoutingNumber, acct.Code)
+		if err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(acct)
+	}
+}
+
+func deleteGLAccount(logger log.Logger, glRepo glAccountRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		acct := readGLAccount(w, r, glRepo)
+		if acct == nil {
+			return
+		}
+
+		requestID := moovhttp.GetRequestID(r)
+		if err := glRepo.deleteGLAccount(acct.RoutingNumber, acct.Code); err != nil {
+			logger.Log("gl", fmt.Sprintf("problem deleting GL account=%s: %v", acct.ID, err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		logger.Log("gl", fmt.Sprintf("deleted GL account=%s", acct.ID), "requestID", requestID)
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moov-io/accounts/cmd/server/database"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+func TestGL__readGLCode(t *testing.T) {
+	cases := map[string]string{
+		"2365":     "2365",
+		"RCON2365": "2365",
+		" 0111 ":   "0111",
+		"rcfd3123": "3123",
+	}
+	for input, expected := range cases {
+		if code, err := readGLCode(input); err != nil || code != expected {
+			t.Errorf("%q: code=%q error=%v", input, code, err)
+		}
+	}
+	for _, input := range []string{"", "RCON", "23a5", "12345678901", "2365-1"} {
+		if _, err := readGLCode(input); err == nil {
+			t.Errorf("%q: expected error", input)
+		}
+	}
+}
+
+func TestGL__isGLAccountID(t *testing.T) {
+	if !isGLAccountID(glAccountID("121042882", "2365")) {
+		t.Error("expected GL account")
+	}
+	if isGLAccountID("e0e4c8a4ff3bb3b1c04fe3e18b4a1b14e91a6a5c") || isGLAccountID("1210-2365") {
+		t.Error("expected customer account")
+	}
+}
+
+func TestGL__GLCategory(t *testing.T) {
+	var category GLCategory
+	if err := json.Unmarshal([]byte(`"Liability"`), &category); err != nil || category != GLLiability {
+		t.Errorf("category=%q error=%v", category, err)
+	}
+	if err := json.Unmarshal([]byte(`"other"`), &category); err == nil {
+		t.Error("expected error")
+	}
+	if GLAsset.normalDirection() != Debit || GLExpense.normalDirection() != Debit || GLIncome.normalDirection() != Credit {
+		t.Error("unexpected normal directions")
+	}
+}
+
+func TestGL__createGLAccountRequest(t *testing.T) {
+	now := time.Now()
+	req := createGLAccountRequest{Code: "RCON2200", Name: " Deposits ", Category: GLLiability, AccountType: "Checking"}
+	acct, err := req.asGLAccount("121042882", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acct.ID != "121042882-2200" || acct.Code != "2200" || acct.Name != "Deposits" || acct.AccountType != "checking" {
+		t.Errorf("unexpected account: %#v", acct)
+	}
+
+	// Only liabilities hold customer accounts
+	req.Category = GLAsset
+	if _, err := req.asGLAccount("121042882", now); err == nil {
+		t.Error("expected error")
+	}
+	req.Category, req.AccountType = GLLiability, "brokerage account"
+	if _, err := req.asGLAccount("121042882", now); err == nil {
+		t.Error("expected error")
+	}
+	req.AccountType, req.ParentCode = "", "2200"
+	if _, err := req.asGLAccount("121042882", now); err == nil {
+		t.Error("expected error")
+	}
+	req.Code, req.ParentCode, req.Name = "2299", "", ""
+	if _, err := req.asGLAccount("121042882", now); err == nil {
+		t.Error("expected error")
+	}
+
+	// Call report codes fill in their name and category
+	acct, err = createGLAccountRequest{Code: "rcon2215", AccountType: "checking"}.asGLAccount("121042882", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acct.Code != RCON2215.Code() || acct.Name != RCON2215.Description() || acct.Category != GLLiability {
+		t.Errorf("unexpected account: %#v", acct)
+	}
+	if _, err := (createGLAccountRequest{Code: "RIAD4080", Category: GLExpense}).asGLAccount("121042882", now); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGL__checkGLHierarchy(t *testing.T) {
+	chart := []glAccount{
+		{Code: "1000", Category: GLAsset},
+		{Code: "1100", Category: GLAsset, ParentCode: "1000"},
+		{Code: "2000", Category: GLLiability, AccountType: "checking"},
+	}
+	if err := checkGLHierarchy(chart, glAccount{Code: "1110", Category: GLAsset, ParentCode: "1100"}); err != nil {
+		t.Error(err)
+	}
+
+	bad := []glAccount{
+		{Code: "1110", Category: GLAsset, ParentCode: "9999"},          // missing parent
+		{Code: "1110", Category: GLLiability, ParentCode: "1100"},      // parent category differs
+		{Code: "1000", Category: GLAsset, ParentCode: "1100"},          // loop
+		{Code: "2100", Category: GLLiability, AccountType: "Checking"}, // checking already rolls up
+	}
+	for i := range bad {
+		if err := checkGLHierarchy(chart, bad[i]); err == nil {
+			t.Errorf("#%d expected error", i)
+		}
+	}
+
+	// Updating the account which holds checking accounts is fine
+	if err := checkGLHierarchy(chart, glAccount{Code: "2000", Category: GLLiability, AccountType: "checking", Name: "Deposits"}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGL__rollUpGLBalances(t *testing.T) {
+	chart := []glAccount{
+		{ID: "a-1000", Code: "1000", Category: GLAsset},
+		{ID: "a-1100", Code: "1100", Category: GLAsset, ParentCode: "1000"},
+		{ID: "a-1110", Code: "1110", Category: GLAsset, ParentCode: "1100"},
+		{ID: "a-2000", Code: "2000", Category: GLLiability},
+	}
+	rollUpGLBalances(chart, map[string]int64{
+		"a-1000": -10,
+		"a-1100": -100,
+		"a-1110": -1000,
+		"a-2000": 500,
+	})
+	expected := []int64{1110, 1100, 1000, 500}
+	for i := range chart {
+		if chart[i].Balance != expected[i] {
+			t.Errorf("%s balance=%d", chart[i].Code, chart[i].Balance)
+		}
+	}
+}
+
+func TestGL__routes(t *testing.T) {
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+
+	router := mux.NewRouter()
+	addGLAccountRoutes(log.NewNopLogger(), router, setupSqlGLAccountStorage(log.NewNopLogger(), sqliteDB.DB, &testAccountRepository{}))
+
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("x-user-id", "test")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		w.Flush()
+		return w
+	}
+
+	if w := call("POST", "/gl/121042882/accounts", `{"code": "RCON2200", "name": "Deposits", "category": "liability"}`); w.Code != http.StatusOK {
+		t.Fatalf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	if w := call("POST", "/gl/121042882/accounts", `{"code": "2200", "name": "Deposits", "category": "liability"}`); w.Code != http.StatusConflict {
+		t.Errorf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	if w := call("POST", "/gl/121042882/accounts", `{"code": "2210", "name": "Checking", "category": "liability", "parentCode": "2200", "accountType": "checking"}`); w.Code != http.StatusOK {
+		t.Fatalf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	if w := call("POST", "/gl/1210/accounts", `{"code": "2210", "name": "Checking", "category": "liability"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+
+	w := call("GET", "/gl/121042882/accounts", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	var chart []glAccount
+	if err := json.NewDecoder(w.Body).Decode(&chart); err != nil {
+		t.Fatal(err)
+	}
+	if len(chart) != 2 || chart[0].ID != "121042882-2200" || chart[1].ParentCode != "2200" {
+		t.Errorf("unexpected chart: %#v", chart)
+	}
+
+	w = call("PATCH", "/gl/121042882/accounts/2210", `{"name": "Demand deposits", "parentCode": ""}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	var acct glAccount
+	if err := json.NewDecoder(w.Body).Decode(&acct); err != nil {
+		t.Fatal(err)
+	}
+	if acct.Name != "Demand deposits" || acct.ParentCode != "" || acct.AccountType != "checking" {
+		t.Errorf("unexpected account: %#v", acct)
+	}
+
+	if w := call("DELETE", "/gl/121042882/accounts/2210", ""); w.Code != http.StatusOK {
+		t.Errorf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	if w := call("GET", "/gl/121042882/accounts/2210", ""); w.Code != http.StatusNotFound {
+		t.Errorf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+}
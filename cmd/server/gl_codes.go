@@ -0,0 +1,43 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+)
+
+//go:generate go run ../glcodegen -catalog gl_codes.csv -output gl_codes_generated.go
+
+// GLCode is a call report line item identified by its MDRM code (e.g. RCON2200). Each is posted to
+// the GL account with the numeric part of the code, so RCON2200 is GL account 2200.
+//
+// GL codes are generated from gl_codes.csv, which is the catalog of line items we report on.
+type GLCode string
+
+type glCodeInfo struct {
+	Description string
+	Category    GLCategory
+}
+
+// Code returns the GL account code the line item is posted to
+func (c GLCode) Code() string {
+	code, _ := readGLCode(string(c))
+	return code
+}
+
+func (c GLCode) Description() string {
+	return glCodes[c].Description
+}
+
+func (c GLCode) Category() GLCategory {
+	return glCodes[c].Category
+}
+
+// lookupGLCode returns the catalog entry for an MDRM code, if v is one
+func lookupGLCode(v string) (GLCode, bool) {
+	c := GLCode(strings.ToUpper(strings.TrimSpace(v)))
+	_, exists := glCodes[c]
+	return c, exists
+}
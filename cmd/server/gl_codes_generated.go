@@ -0,0 +1,123 @@
+// Code generated by glcodegen from gl_codes.csv. DO NOT EDIT.
+
+package main
+
+const (
+	// RCON0081 is Noninterest-bearing balances and currency and coin
+	RCON0081 GLCode = "RCON0081"
+	// RCON0071 is Interest-bearing balances
+	RCON0071 GLCode = "RCON0071"
+	// RCON1754 is Held-to-maturity securities
+	RCON1754 GLCode = "RCON1754"
+	// RCON1773 is Available-for-sale debt securities
+	RCON1773 GLCode = "RCON1773"
+	// RCON2122 is Loans and leases held for investment, net of allowance
+	RCON2122 GLCode = "RCON2122"
+	// RCON2145 is Premises and fixed assets
+	RCON2145 GLCode = "RCON2145"
+	// RCON2160 is Other assets
+	RCON2160 GLCode = "RCON2160"
+	// RCON2170 is Total assets
+	RCON2170 GLCode = "RCON2170"
+	// RCON2200 is Deposits in domestic offices
+	RCON2200 GLCode = "RCON2200"
+	// RCON2215 is Total transaction accounts
+	RCON2215 GLCode = "RCON2215"
+	// RCON2385 is Total nontransaction accounts
+	RCON2385 GLCode = "RCON2385"
+	// RCON6810 is Money market deposit accounts (MMDAs)
+	RCON6810 GLCode = "RCON6810"
+	// RCON0352 is Other savings deposits (excludes MMDAs)
+	RCON0352 GLCode = "RCON0352"
+	// RCON6648 is Total time deposits of less than $100,000
+	RCON6648 GLCode = "RCON6648"
+	// RCON3190 is Other borrowed money
+	RCON3190 GLCode = "RCON3190"
+	// RCON2930 is Other liabilities
+	RCON2930 GLCode = "RCON2930"
+	// RCON2948 is Total liabilities
+	RCON2948 GLCode = "RCON2948"
+	// RCON3230 is Common stock
+	RCON3230 GLCode = "RCON3230"
+	// RCON3839 is Surplus
+	RCON3839 GLCode = "RCON3839"
+	// RCON3632 is Retained earnings
+	RCON3632 GLCode = "RCON3632"
+	// RCON3210 is Total bank equity capital
+	RCON3210 GLCode = "RCON3210"
+	// RCON3300 is Total liabilities and equity capital
+	RCON3300 GLCode = "RCON3300"
+	// RIAD4010 is Interest and fee income on loans
+	RIAD4010 GLCode = "RIAD4010"
+	// RIAD4115 is Interest income from balances due from depository institutions
+	RIAD4115 GLCode = "RIAD4115"
+	// RIAD4107 is Total interest income
+	RIAD4107 GLCode = "RIAD4107"
+	// RIAD4508 is Interest on transaction accounts
+	RIAD4508 GLCode = "RIAD4508"
+	// RIAD0093 is Interest on savings deposits
+	RIAD0093 GLCode = "RIAD0093"
+	// RIAD4073 is Total interest expense
+	RIAD4073 GLCode = "RIAD4073"
+	// RIAD4074 is Net interest income
+	RIAD4074 GLCode = "RIAD4074"
+	// RIAD4230 is Provision for loan and lease losses
+	RIAD4230 GLCode = "RIAD4230"
+	// RIAD4080 is Service charges on deposit accounts
+	RIAD4080 GLCode = "RIAD4080"
+	// RIAD4079 is Total noninterest income
+	RIAD4079 GLCode = "RIAD4079"
+	// RIAD4135 is Salaries and employee benefits
+	RIAD4135 GLCode = "RIAD4135"
+	// RIAD4217 is Expenses of premises and fixed assets
+	RIAD4217 GLCode = "RIAD4217"
+	// RIAD4093 is Total noninterest expense
+	RIAD4093 GLCode = "RIAD4093"
+	// RIAD4301 is Income before income taxes
+	RIAD4301 GLCode = "RIAD4301"
+	// RIAD4302 is Applicable income taxes
+	RIAD4302 GLCode = "RIAD4302"
+	// RIAD4340 is Net income
+	RIAD4340 GLCode = "RIAD4340"
+)
+
+var glCodes = map[GLCode]glCodeInfo{
+	RCON0081: {Description: "Noninterest-bearing balances and currency and coin", Category: GLAsset},
+	RCON0071: {Description: "Interest-bearing balances", Category: GLAsset},
+	RCON1754: {Description: "Held-to-maturity securities", Category: GLAsset},
+	RCON1773: {Description: "Available-for-sale debt securities", Category: GLAsset},
+	RCON2122: {Description: "Loans and leases held for investment, net of allowance", Category: GLAsset},
+	RCON2145: {Description: "Premises and fixed assets", Category: GLAsset},
+	RCON2160: {Description: "Other assets", Category: GLAsset},
+	RCON2170: {Description: "Total assets", Category: GLAsset},
+	RCON2200: {Description: "Deposits in domestic offices", Category: GLLiability},
+	RCON2215: {Description: "Total transaction accounts", Category: GLLiability},
+	RCON2385: {Description: "Total nontransaction accounts", Category: GLLiability},
+	RCON6810: {Description: "Money market deposit accounts (MMDAs)", Category: GLLiability},
+	RCON0352: {Description: "Other savings deposits (excludes MMDAs)", Category: GLLiability},
+	RCON6648: {Description: "Total time deposits of less than $100,000", Category: GLLiability},
+	RCON3190: {Description: "Other borrowed money", Category: GLLiability},
+	RCON2930: {Description: "Other liabilities", Category: GLLiability},
+	RCON2948: {Description: "Total liabilities", Category: GLLiability},
+	RCON3230: {Description: "Common stock", Category: GLEquity},
+	RCON3839: {Description: "Surplus", Category: GLEquity},
+	RCON3632: {Description: "Retained earnings", Category: GLEquity},
+	RCON3210: {Description: "Total bank equity capital", Category: GLEquity},
+	RCON3300: {Description: "Total liabilities and equity capital", Category: GLLiability},
+	RIAD4010: {Description: "Interest and fee income on loans", Category: GLIncome},
+	RIAD4115: {Description: "Interest income from balances due from depository institutions", Category: GLIncome},
+	RIAD4107: {Description: "Total interest income", Category: GLIncome},
+	RIAD4508: {Description: "Interest on transaction accounts", Category: GLExpense},
+	RIAD0093: {Description: "Interest on savings deposits", Category: GLExpense},
+	RIAD4073: {Description: "Total interest expense", Category: GLExpense},
+	RIAD4074: {Description: "Net interest income", Category: GLIncome},
+	RIAD4230: {Description: "Provision for loan and lease losses", Category: GLExpense},
+	RIAD4080: {Description: "Service charges on deposit accounts", Category: GLIncome},
+	RIAD4079: {Description: "Total noninterest income", Category: GLIncome},
+	RIAD4135: {Description: "Salaries and employee benefits", Category: GLExpense},
+	RIAD4217: {Description: "Expenses of premises and fixed assets", Category: GLExpense},
+	RIAD4093: {Description: "Total noninterest expense", Category: GLExpense},
+	RIAD4301: {Description: "Income before income taxes", Category: GLIncome},
+	RIAD4302: {Description: "Applicable income taxes", Category: GLExpense},
+	RIAD4340: {Description: "Net income", Category: GLIncome},
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/base"
+)
+
+// insertGLJournal posts the GL journal entry for the customer lines of t inside of tx. The entry is its own
+// transaction linked to t, so GL accounts have the same history and balances as customer accounts.
+func (r *sqlTransactionRepository) insertGLJournal(tx *sql.Tx, t transaction, accounts []*accounts.Account) error {
+	if t.JournalOf != "" {
+		return nil
+	}
+	lines, err := r.glRules.journalLines(t, accounts)
+	if err != nil {
+		return fmt.Errorf("createTransaction: transaction=%q GL journal: %w", t.ID, err)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	journal := transaction{
+		ID:            base.ID(),
+		Timestamp:     t.Timestamp,
+		EffectiveDate: t.EffectiveDate,
+		Lines:         lines,
+		Status:        TransactionPosted,
+		JournalOf:     t.ID,
+	}
+	if err := journal.validate(); err != nil {
+		return fmt.Errorf("createTransaction: transaction=%q GL journal: %w", t.ID, err)
+	}
+	if err := r.insertTransaction(tx, journal, createTransactionOpts{AllowOverdraft: true, AllowGLDebits: true}, nil); err != nil {
+		return fmt.Errorf("createTransaction: transaction=%q GL journal: %w", t.ID, err)
+	}
+	return nil
+}
+
+// checkJournalGLAccount returns an error if accountID isn't in the chart of accounts or holds customer accounts.
+// Customer balances already roll up into the GL account for their type, so journaling to it would count them twice.
+func checkJournalGLAccount(tx *sql.Tx, accountID string) error {
+	stmt, err := tx.Prepare(`select account_type from gl_accounts where account_id = ?;`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	var accountType sql.NullString
+	if err := stmt.QueryRow(accountID).Scan(&accountType); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("GL account=%q: %w", accountID, errGLAccountNotFound)
+		}
+		return err
+	}
+	if accountType.String != "" {
+		return fmt.Errorf("GL account=%q holds %s accounts", accountID, accountType.String)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestSqlTransactionRepository__GLJournal(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, db *sql.DB) {
+		repo := createTestSqlTransactionRepository(t, db)
+		glRepo := setupSqlGLAccountStorage(log.NewNopLogger(), db, repo.accountRepo)
+		routingNumber := "121042882"
+
+		now := time.Now()
+		for _, req := range []createGLAccountRequest{
+			{Code: "0010", Name: "Cash", Category: GLAsset},
+			{Code: "0020", Name: "ACH settlement", Category: GLAsset},
+			{Code: "2210", Name: "Checking", Category: GLLiability, AccountType: "checking"},
+			{Code: "2950", Name: "ACH clearing", Category: GLLiability},
+		} {
+			acct, err := req.asGLAccount(routingNumber, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := glRepo.createGLAccount(acct); err != nil {
+				t.Fatal(err)
+			}
+		}
+		repo.glRules = glRules{
+			routingNumber: []glRule{{Purpose: ACHCredit, Debit: "0020", Credit: "2950"}},
+		}
+
+		customer := &accounts.Account{ID: base.ID(), CustomerID: base.ID(), Name: "Checking", AccountNumber: "123", RoutingNumber: routingNumber, Status: "open", Type: "Checking", CreatedAt: now, LastModified: now}
+		if err := repo.accountRepo.CreateAccount(customer.CustomerID, customer); err != nil {
+			t.Fatal(err)
+		}
+		deposit := transaction{
+			ID:        base.ID(),
+			Timestamp: now,
+			Lines: []transactionLine{
+				{AccountID: glAccountID(routingNumber, "0010"), Purpose: ACHCredit, Direction: Debit, Amount: 1500},
+				{AccountID: customer.ID, Purpose: ACHCredit, Direction: Credit, Amount: 1500},
+			},
+		}
+		if err := repo.createTransaction(deposit, createTransactionOpts{AllowGLDebits: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		balances := func(expected map[string]int64) {
+			t.Helper()
+			chart, err := glRepo.getChartOfAccounts(routingNumber)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range chart {
+				if chart[i].Balance != expected[chart[i].Code] {
+					t.Errorf("GL account %s balance=%d", chart[i].Code, chart[i].Balance)
+				}
+			}
+		}
+		balances(map[string]int64{"0010": 1500, "0020": 1500, "2210": 1500, "2950": 1500})
+
+		// The journal is linked to the deposit and can't be reversed on its own
+		journals, _, err := repo.getAccountTransactions(glAccountID(routingNumber, "2950"), transactionSearchParams{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(journals) != 1 || journals[0].JournalOf != deposit.ID {
+			t.Fatalf("unexpected journals: %#v", journals)
+		}
+		if _, err := repo.reverseTransaction(journals[0].ID, reversalRequest{}, createTransactionOpts{}); err == nil || !strings.Contains(err.Error(), errReverseJournal.Error()) {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		// Reversing the deposit unwinds its journal
+		if _, err := repo.reverseTransaction(deposit.ID, reversalRequest{Amount: 500}, createTransactionOpts{}); err != nil {
+			t.Fatal(err)
+		}
+		balances(map[string]int64{"0010": 1000, "0020": 1000, "2210": 1000, "2950": 1000})
+
+		// Rules posting to missing GL accounts, or the account holding customer balances, reject the transaction
+		for _, code := range []string{"2999", "2210"} {
+			repo.glRules[routingNumber][0].Credit = code
+			err := repo.createTransaction(transaction{
+				ID:        base.ID(),
+				Timestamp: now,
+				Lines: []transactionLine{
+					{AccountID: glAccountID(routingNumber, "0010"), Purpose: ACHCredit, Direction: Debit, Amount: 100},
+					{AccountID: customer.ID, Purpose: ACHCredit, Direction: Credit, Amount: 100},
+				},
+			}, createTransactionOpts{AllowGLDebits: true})
+			if err == nil || !strings.Contains(err.Error(), "GL journal") {
+				t.Errorf("%s: unexpected error: %v", code, err)
+			}
+		}
+		balances(map[string]int64{"0010": 1000, "0020": 1000, "2210": 1000, "2950": 1000})
+
+		// Transactions from the HTTP API can't credit customers from the FI's books or post to GL accounts we don't have
+		repo.glRules[routingNumber][0].Credit = "2950"
+		for code, expected := range map[string]error{"0010": errInsufficientFunds, "0099": errGLAccountNotFound} {
+			err := repo.createTransaction(transaction{
+				ID:        base.ID(),
+				Timestamp: now,
+				Lines: []transactionLine{
+					{AccountID: glAccountID(routingNumber, code), Purpose: ACHCredit, Direction: Debit, Amount: 100},
+					{AccountID: customer.ID, Purpose: ACHCredit, Direction: Credit, Amount: 100},
+				},
+			}, createTransactionOpts{})
+			if err == nil || !strings.Contains(err.Error(), expected.Error()) {
+				t.Errorf("%s: unexpected error: %v", code, err)
+			}
+		}
+		balances(map[string]int64{"0010": 1000, "0020": 1000, "2210": 1000, "2950": 1000})
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, sqliteDB.DB)
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, mysqlDB.DB)
+}
@@ -0,0 +1,220 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	accounts "github.com/moov-io/accounts/client"
+
+	"gopkg.in/yaml.v2"
+)
+
+// glRule posts a GL journal entry for each customer transactionLine it matches. The entry debits the Debit GL
+// account and credits the Credit GL account with the amount of the customer line.
+//
+// Rules without a Direction match both credits and debits to customer accounts. Debit and Credit describe the
+// entry for credits, and are swapped for debits so that reversals unwind the original entry.
+type glRule struct {
+	Purpose     TransactionPurpose `yaml:"purpose"`
+	AccountType string             `yaml:"accountType"`
+	Direction   LineDirection      `yaml:"direction"`
+
+	Debit  string `yaml:"debit"`
+	Credit string `yaml:"credit"`
+}
+
+// normalize lowercases and trims values read from YAML and reads the GL codes
+func (rule *glRule) normalize() error {
+	rule.Purpose = TransactionPurpose(strings.ToLower(strings.TrimSpace(string(rule.Purpose))))
+	rule.AccountType = strings.ToLower(strings.TrimSpace(rule.AccountType))
+	rule.Direction = LineDirection(strings.ToLower(strings.TrimSpace(string(rule.Direction))))
+
+	var err error
+	if rule.Debit, err = readGLCode(rule.Debit); err != nil {
+		return fmt.Errorf("debit: %w", err)
+	}
+	if rule.Credit, err = readGLCode(rule.Credit); err != nil {
+		return fmt.Errorf("credit: %w", err)
+	}
+	return nil
+}
+
+func (rule glRule) validate() error {
+	if err := rule.Purpose.validate(); err != nil {
+		return err
+	}
+	if rule.AccountType != "" && !validCustomerAccountType(rule.AccountType) {
+		return fmt.Errorf("unknown accountType %q", rule.AccountType)
+	}
+	if rule.Direction != "" {
+		if err := rule.Direction.validate(); err != nil {
+			return err
+		}
+	}
+	if rule.Debit == rule.Credit {
+		return fmt.Errorf("debit and credit are both GL account %s", rule.Debit)
+	}
+	return nil
+}
+
+func (rule glRule) matches(line transactionLine, acct *accounts.Account) bool {
+	if rule.Purpose != line.Purpose {
+		return false
+	}
+	if rule.AccountType != "" && !strings.EqualFold(rule.AccountType, acct.Type) {
+		return false
+	}
+	return rule.Direction == "" || rule.Direction == line.Direction
+}
+
+// glRules are the GL posting rules for each routing number. The first rule which matches a line is applied.
+type glRules map[string][]glRule
+
+// readGLRules parses and validates GL posting rules in YAML keyed by routing number:
+//
+//	"121042882":
+//	  - purpose: achcredit
+//	    accountType: checking
+//	    debit: "0010"
+//	    credit: "2950"
+func readGLRules(r io.Reader) (glRules, error) {
+	bs, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var rules glRules
+	if err := yaml.UnmarshalStrict(bs, &rules); err != nil {
+		return nil, fmt.Errorf("GL rules: %w", err)
+	}
+	for routingNumber := range rules {
+		if !routingNumberRegex.MatchString(routingNumber) {
+			return nil, fmt.Errorf("GL rules: invalid routing number %q", routingNumber)
+		}
+		for i := range rules[routingNumber] {
+			if err := rules[routingNumber][i].normalize(); err != nil {
+				return nil, fmt.Errorf("GL rules: routingNumber=%s rule[%d]: %w", routingNumber, i, err)
+			}
+			if err := rules[routingNumber][i].validate(); err != nil {
+				return nil, fmt.Errorf("GL rules: routingNumber=%s rule[%d]: %w", routingNumber, i, err)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// readGLRulesFile returns the GL posting rules from a YAML file, or no rules when path is empty
+func readGLRulesFile(path string) (glRules, error) {
+	if path == "" {
+		return nil, nil
+	}
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return readGLRules(bytes.NewReader(bs))
+}
+
+// checkGLAccounts returns an error if any rule posts to a GL account which isn't in the chart of accounts of its
+// routing number. Journals for those rules would fail every customer transaction they match.
+func (rules glRules) checkGLAccounts(repo glAccountRepository) error {
+	for routingNumber := range rules {
+		chart, err := repo.getChartOfAccounts(routingNumber)
+		if err != nil {
+			return fmt.Errorf("GL rules: routingNumber=%s: %w", routingNumber, err)
+		}
+		codes := make(map[string]bool)
+		for i := range chart {
+			codes[chart[i].Code] = true
+		}
+		for i, rule := range rules[routingNumber] {
+			for _, code := range []string{rule.Debit, rule.Credit} {
+				if !codes[code] {
+					return fmt.Errorf("GL rules: routingNumber=%s rule[%d]: %s %w", routingNumber, i, code, errGLAccountNotFound)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+var errGLJournalUnbalanced = errors.New("GL journal entry is unbalanced")
+
+// journalLines returns the lines posted to GL accounts for the customer lines of t. Entries against the same
+// GL account are netted so each GL account has one line. Lines for GL accounts, accounts we don't have
+// a record of and lines which no rule matches aren't journaled.
+func (rules glRules) journalLines(t transaction, accts []*accounts.Account) ([]transactionLine, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	var order []string
+	net := make(map[string]int64)
+	purposes := make(map[string]TransactionPurpose)
+	post := func(accountID string, purpose TransactionPurpose, change int64) error {
+		if _, exists := net[accountID]; !exists {
+			order = append(order, accountID)
+			purposes[accountID] = purpose
+		}
+		n, err := addAmounts(net[accountID], change)
+		if err != nil {
+			return err
+		}
+		net[accountID] = n
+		return nil
+	}
+
+	for _, line := range t.Lines {
+		if isGLAccountID(line.AccountID) {
+			continue
+		}
+		var acct *accounts.Account
+		for i := range accts {
+			if accts[i].ID == line.AccountID {
+				acct = accts[i]
+			}
+		}
+		if acct == nil {
+			continue
+		}
+		for _, rule := range rules[acct.RoutingNumber] {
+			if !rule.matches(line, acct) {
+				continue
+			}
+			debit, credit := rule.Debit, rule.Credit
+			if rule.Direction == "" && line.isDebit() {
+				debit, credit = credit, debit
+			}
+			if err := post(glAccountID(acct.RoutingNumber, debit), line.Purpose, -1*line.Amount); err != nil {
+				return nil, err
+			}
+			if err := post(glAccountID(acct.RoutingNumber, credit), line.Purpose, line.Amount); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	var lines []transactionLine
+	var sum int64
+	for _, accountID := range order {
+		n := net[accountID]
+		sum += n
+		switch {
+		case n > 0:
+			lines = append(lines, transactionLine{AccountID: accountID, Purpose: purposes[accountID], Direction: Credit, Amount: n})
+		case n < 0:
+			lines = append(lines, transactionLine{AccountID: accountID, Purpose: purposes[accountID], Direction: Debit, Amount: -1 * n})
+		}
+	}
+	if sum != 0 {
+		return nil, errGLJournalUnbalanced
+	}
+	return lines, nil
+}
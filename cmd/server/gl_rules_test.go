@@ -0,0 +1,133 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestGLRules__read(t *testing.T) {
+	rules, err := readGLRules(strings.NewReader(`
+"121042882":
+  - purpose: ACHCredit
+    accountType: Checking
+    debit: RCON0010
+    credit: "2210"
+  - purpose: fee
+    direction: debit
+    debit: "2210"
+    credit: "4080"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs := rules["121042882"]
+	if len(rs) != 2 {
+		t.Fatalf("unexpected rules: %#v", rules)
+	}
+	if rs[0].Purpose != ACHCredit || rs[0].AccountType != "checking" || rs[0].Debit != "0010" || rs[1].Direction != Debit {
+		t.Errorf("unexpected rules: %#v", rs)
+	}
+
+	bad := []string{
+		`"1210": [{purpose: achcredit, debit: "0010", credit: "2210"}]`,
+		`"121042882": [{purpose: other, debit: "0010", credit: "2210"}]`,
+		`"121042882": [{purpose: achcredit, accountType: "brokerage account", debit: "0010", credit: "2210"}]`,
+		`"121042882": [{purpose: achcredit, direction: sideways, debit: "0010", credit: "2210"}]`,
+		`"121042882": [{purpose: achcredit, debit: "0010", credit: "0010"}]`,
+		`"121042882": [{purpose: achcredit, debit: "cash", credit: "2210"}]`,
+		`"121042882": [{purpose: achcredit, debit: "0010", credit: "2210", amount: 10}]`,
+	}
+	for i := range bad {
+		if _, err := readGLRules(strings.NewReader(bad[i])); err == nil {
+			t.Errorf("#%d expected error", i)
+		}
+	}
+
+	if rules, err := readGLRulesFile(""); err != nil || rules != nil {
+		t.Errorf("rules=%#v error=%v", rules, err)
+	}
+}
+
+func TestGLRules__checkGLAccounts(t *testing.T) {
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+
+	routingNumber := "121042882"
+	createTestGLAccount(t, sqliteDB.DB, routingNumber, createGLAccountRequest{Code: "0010", Name: "Cash", Category: GLAsset})
+	createTestGLAccount(t, sqliteDB.DB, routingNumber, createGLAccountRequest{Code: "2210", Name: "Deposits", Category: GLLiability})
+	repo := setupSqlGLAccountStorage(log.NewNopLogger(), sqliteDB.DB, &testAccountRepository{})
+
+	rules, err := readGLRules(strings.NewReader(`"121042882": [{purpose: achcredit, debit: "0010", credit: "2210"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rules.checkGLAccounts(repo); err != nil {
+		t.Error(err)
+	}
+
+	rules[routingNumber] = append(rules[routingNumber], glRule{Purpose: Fee, Debit: "2210", Credit: "4080"})
+	if err := rules.checkGLAccounts(repo); err == nil || !strings.Contains(err.Error(), errGLAccountNotFound.Error()) {
+		t.Errorf("expected error: %v", err)
+	}
+}
+
+func TestGLRules__journalLines(t *testing.T) {
+	rules := glRules{
+		"121042882": []glRule{
+			{Purpose: ACHCredit, AccountType: "checking", Debit: "0010", Credit: "2210"},
+			{Purpose: ACHDebit, Debit: "2210", Credit: "0010"},
+			{Purpose: Fee, Direction: Debit, Debit: "2210", Credit: "4080"},
+		},
+	}
+	checking := &accounts.Account{ID: "a", RoutingNumber: "121042882", Type: "Checking"}
+	savings := &accounts.Account{ID: "b", RoutingNumber: "121042882", Type: "Savings"}
+	other := &accounts.Account{ID: "c", RoutingNumber: "231380104", Type: "Checking"}
+	accts := []*accounts.Account{checking, savings, other}
+
+	// Deposit into checking, and pay a fee out of it
+	lines, err := rules.journalLines(transaction{Lines: []transactionLine{
+		{AccountID: "a", Purpose: ACHCredit, Direction: Credit, Amount: 1000},
+		{AccountID: "a", Purpose: Fee, Direction: Debit, Amount: 25},
+		{AccountID: "121042882-4080", Purpose: Fee, Direction: Credit, Amount: 25},
+		{AccountID: "b", Purpose: ACHCredit, Direction: Credit, Amount: 500}, // savings has no rules
+		{AccountID: "c", Purpose: ACHCredit, Direction: Credit, Amount: 500}, // other routing number
+	}}, accts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []transactionLine{
+		{AccountID: "121042882-0010", Purpose: ACHCredit, Direction: Debit, Amount: 1000},
+		{AccountID: "121042882-2210", Purpose: ACHCredit, Direction: Credit, Amount: 975},
+		{AccountID: "121042882-4080", Purpose: Fee, Direction: Credit, Amount: 25},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("unexpected lines: %#v", lines)
+	}
+	for i := range expected {
+		if lines[i] != expected[i] {
+			t.Errorf("line #%d: %#v", i, lines[i])
+		}
+	}
+
+	// Reversing the deposit swaps debit and credit
+	lines, err = rules.journalLines(transaction{Lines: []transactionLine{
+		{AccountID: "a", Purpose: ACHCredit, Direction: Debit, Amount: 1000},
+	}}, accts)
+	if err != nil || len(lines) != 2 || lines[0].AccountID != "121042882-2210" || lines[0].Direction != Debit {
+		t.Errorf("lines=%#v error=%v", lines, err)
+	}
+
+	// No rules, no journal
+	if lines, err := glRules(nil).journalLines(transaction{Lines: expected}, accts); err != nil || lines != nil {
+		t.Errorf("lines=%#v error=%v", lines, err)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+type holdRepository interface {
+	placeHold(h hold, opts createTransactionOpts) error
+	getHold(holdID string) (*hold, error)
+	getAccountHolds(accountID string) ([]hold, error)
+
+	// captureHold posts a transaction for amount of the hold's remaining funds. An amount of zero captures
+	// everything which remains. Holds stay pending until all of their funds are captured.
+	captureHold(holdID string, amount int64) (*transaction, error)
+	releaseHold(holdID string) (*hold, error)
+
+	// expireHolds marks every pending hold which expired before now and returns how many were expired
+	expireHolds(now time.Time) (int, error)
+}
+
+var (
+	errHoldNotFound   = errors.New("hold not found")
+	errHoldNotPending = errors.New("hold is no longer pending")
+	errHoldExpired    = errors.New("hold has expired")
+)
+
+// accountBalances are the balances of an account once active holds are considered.
+type accountBalances struct {
+	// Current is the balance of all posted transactionLines
+	Current int64
+
+	// Available is the Current balance less any funds held for pending debits
+	Available int64
+
+	// Pending is the net change to the Current balance once every active hold is captured
+	Pending int64
+}
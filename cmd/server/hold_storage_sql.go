@@ -0,0 +1,291 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+)
+
+type sqlHoldRepository struct {
+	db     *sql.DB
+	logger log.Logger
+
+	transactionRepo *sqlTransactionRepository
+}
+
+func setupSqlHoldStorage(logger log.Logger, transactionRepo *sqlTransactionRepository) *sqlHoldRepository {
+	return &sqlHoldRepository{db: transactionRepo.db, logger: logger, transactionRepo: transactionRepo}
+}
+
+func (r *sqlHoldRepository) placeHold(h hold, opts createTransactionOpts) error {
+	lines := h.captureLines(h.Amount)
+	return withPostingRetries(func() error {
+		accounts, err := r.transactionRepo.readPostingAccounts(lines, opts)
+		if err != nil {
+			return fmt.Errorf("placeHold: hold=%q: %w", h.ID, err)
+		}
+		found := false
+		for i := range accounts {
+			found = found || accounts[i].ID == h.AccountID
+		}
+		if !found {
+			return fmt.Errorf("placeHold: account=%q not found", h.AccountID)
+		}
+
+		tx, err := r.db.Begin()
+		if err != nil {
+			return fmt.Errorf("placeHold: tx.Begin: %w", err)
+		}
+
+		// Bump the version of the account balance so holds and debits placed concurrently conflict with each other
+		if _, err := r.transactionRepo.applyBalanceChange(tx, h.AccountID, 0); err != nil {
+			return fmt.Errorf("placeHold: hold=%q: error=%w rollback=%v", h.ID, err, tx.Rollback())
+		}
+
+		query := `insert into account_holds (hold_id, account_id, credit_account_id, purpose, amount, captured_amount, status, description, expires_at, created_at, last_modified) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`
+		stmt, err := tx.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("placeHold: prepare: error=%w rollback=%v", err, tx.Rollback())
+		}
+		_, err = stmt.Exec(h.ID, h.AccountID, h.CreditAccountID, h.Purpose, h.Amount, h.CapturedAmount, h.Status, h.Description, h.ExpiresAt, h.CreatedAt, h.LastModified)
+		stmt.Close()
+		if err != nil {
+			return fmt.Errorf("placeHold: hold=%q insert: error=%w rollback=%v", h.ID, err, tx.Rollback())
+		}
+
+		if !opts.AllowOverdraft && isInternalDebit(accounts, lines, defaultRoutingNumber) {
+			balances, err := r.transactionRepo.getAccountBalances(tx, h.AccountID)
+			if err != nil {
+				return fmt.Errorf("placeHold: hold=%q: error=%w rollback=%v", h.ID, err, tx.Rollback())
+			}
+			limit, err := readOverdraftLimit(tx, h.AccountID)
+			if err != nil {
+				return fmt.Errorf("placeHold: hold=%q: error=%w rollback=%v", h.ID, err, tx.Rollback())
+			}
+			if !hasSufficientFunds(balances.Available, limit, lines[0]) {
+				return fmt.Errorf("account=%q %w: rollback=%v", h.AccountID, errInsufficientFunds, tx.Rollback())
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("placeHold: commit: %w", err)
+		}
+		return nil
+	})
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+const holdColumns = `hold_id, account_id, credit_account_id, purpose, amount, captured_amount, status, description, expires_at, created_at, last_modified`
+
+func scanHold(row rowScanner) (*hold, error) {
+	var h hold
+	err := row.Scan(&h.ID, &h.AccountID, &h.CreditAccountID, &h.Purpose, &h.Amount, &h.CapturedAmount, &h.Status, &h.Description, &h.ExpiresAt, &h.CreatedAt, &h.LastModified)
+	if err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func (r *sqlHoldRepository) getHold(holdID string) (*hold, error) {
+	query := fmt.Sprintf(`select %s from account_holds where hold_id = ? limit 1;`, holdColumns)
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("getHold: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	h, err := scanHold(stmt.QueryRow(holdID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getHold: hold=%q: %w", holdID, err)
+	}
+	return h, nil
+}
+
+func (r *sqlHoldRepository) getAccountHolds(accountID string) ([]hold, error) {
+	query := fmt.Sprintf(`select %s from account_holds where account_id = ? order by created_at desc;`, holdColumns)
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("getAccountHolds: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("getAccountHolds: query: %w", err)
+	}
+	defer rows.Close()
+
+	var holds []hold
+	for rows.Next() {
+		h, err := scanHold(rows)
+		if err != nil {
+			return nil, fmt.Errorf("getAccountHolds: scan account=%q: %w", accountID, err)
+		}
+		holds = append(holds, *h)
+	}
+	return holds, rows.Err()
+}
+
+func (r *sqlHoldRepository) captureHold(holdID string, amount int64) (*transaction, error) {
+	h, err := r.getHold(holdID)
+	if err != nil {
+		return nil, fmt.Errorf("captureHold: %w", err)
+	}
+	if h == nil {
+		return nil, errHoldNotFound
+	}
+	opts := createTransactionOpts{AllowOverdraft: false}
+
+	var out *transaction
+	err = withPostingRetries(func() error {
+		accounts, err := r.transactionRepo.readPostingAccounts(h.captureLines(h.remaining()), opts)
+		if err != nil {
+			return fmt.Errorf("captureHold: hold=%q: %w", h.ID, err)
+		}
+
+		tx, err := r.db.Begin()
+		if err != nil {
+			return fmt.Errorf("captureHold: tx.Begin: %w", err)
+		}
+
+		// Re-read the hold inside our database transaction as it could have been captured or released
+		stmt, err := tx.Prepare(fmt.Sprintf(`select %s from account_holds where hold_id = ? limit 1;`, holdColumns))
+		if err != nil {
+			return fmt.Errorf("captureHold: prepare: error=%w rollback=%v", err, tx.Rollback())
+		}
+		h, err := scanHold(stmt.QueryRow(holdID))
+		stmt.Close()
+		if err != nil {
+			return fmt.Errorf("captureHold: hold=%q: error=%w rollback=%v", holdID, err, tx.Rollback())
+		}
+
+		now := time.Now()
+		if h.Status != HoldPending {
+			return fmt.Errorf("captureHold: hold=%q is %s: %w rollback=%v", holdID, h.Status, errHoldNotPending, tx.Rollback())
+		}
+		if !h.ExpiresAt.After(now) {
+			return fmt.Errorf("captureHold: hold=%q: %w rollback=%v", holdID, errHoldExpired, tx.Rollback())
+		}
+		capture := amount
+		if capture == 0 {
+			capture = h.remaining()
+		}
+		if capture < 0 || capture > h.remaining() {
+			return fmt.Errorf("captureHold: hold=%q can't capture %d of %d remaining rollback=%v", holdID, capture, h.remaining(), tx.Rollback())
+		}
+		status := HoldPending
+		if capture == h.remaining() {
+			status = HoldCaptured
+		}
+
+		// Release the captured funds from the hold before posting so they aren't counted against the available balance twice
+		query := `update account_holds set captured_amount = ?, status = ?, last_modified = ? where hold_id = ? and status = ? and captured_amount = ?;`
+		stmt, err = tx.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("captureHold: prepare update: error=%w rollback=%v", err, tx.Rollback())
+		}
+		res, err := stmt.Exec(h.CapturedAmount+capture, status, now, holdID, HoldPending, h.CapturedAmount)
+		stmt.Close()
+		if err != nil {
+			return fmt.Errorf("captureHold: hold=%q update: error=%w rollback=%v", holdID, err, tx.Rollback())
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("captureHold: hold=%q: %w rollback=%v", holdID, errBalanceConflict, tx.Rollback())
+		}
+
+		t := transaction{
+			ID:            base.ID(),
+			Timestamp:     now,
+			EffectiveDate: now,
+			Lines:         h.captureLines(capture),
+		}
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("captureHold: hold=%q: error=%w rollback=%v", holdID, err, tx.Rollback())
+		}
+		if err := checkAccountProducts(tx, t, accounts); err != nil {
+			return fmt.Errorf("captureHold: hold=%q: %w rollback=%v", holdID, err, tx.Rollback())
+		}
+		if err := r.transactionRepo.insertTransaction(tx, t, opts, accounts); err != nil {
+			return fmt.Errorf("captureHold: hold=%q: %w rollback=%v", holdID, err, tx.Rollback())
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("captureHold: commit: %w", err)
+		}
+		out = &t
+		return nil
+	})
+	return out, err
+}
+
+func (r *sqlHoldRepository) releaseHold(holdID string) (*hold, error) {
+	query := `update account_holds set status = ?, last_modified = ? where hold_id = ? and status = ?;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("releaseHold: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(HoldReleased, time.Now(), holdID, HoldPending)
+	if err != nil {
+		return nil, fmt.Errorf("releaseHold: hold=%q: %w", holdID, err)
+	}
+	h, err := r.getHold(holdID)
+	if err != nil {
+		return nil, fmt.Errorf("releaseHold: %w", err)
+	}
+	if h == nil {
+		return nil, errHoldNotFound
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("releaseHold: hold=%q is %s: %w", holdID, h.Status, errHoldNotPending)
+	}
+	return h, nil
+}
+
+// countActiveHolds returns how many holds which haven't expired are still pending against accountID, either as the
+// account being debited or the account being credited.
+func countActiveHolds(tx *sql.Tx, accountID string) (int, error) {
+	query := `select count(*) from account_holds where (account_id = ? or credit_account_id = ?) and status = ? and expires_at > ?;`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return 0, fmt.Errorf("countActiveHolds: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	var n int
+	if err := stmt.QueryRow(accountID, accountID, HoldPending, time.Now()).Scan(&n); err != nil {
+		return 0, fmt.Errorf("countActiveHolds: account=%q: %w", accountID, err)
+	}
+	return n, nil
+}
+
+func (r *sqlHoldRepository) expireHolds(now time.Time) (int, error) {
+	query := `update account_holds set status = ?, last_modified = ? where status = ? and expires_at <= ?;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return 0, fmt.Errorf("expireHolds: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(HoldExpired, now, HoldPending, now)
+	if err != nil {
+		return 0, fmt.Errorf("expireHolds: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,198 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestSqlHoldRepository__holds(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+		holdRepo := setupSqlHoldStorage(log.NewNopLogger(), repo)
+
+		account1, account2 := base.ID(), base.ID()
+		repo.accountRepo = &testAccountRepository{
+			accounts: []*accounts.Account{
+				{ID: account1, Status: "open", RoutingNumber: defaultRoutingNumber},
+				{ID: account2, Status: "open", RoutingNumber: defaultRoutingNumber},
+			},
+		}
+		deposit := transaction{
+			ID:        base.ID(),
+			Timestamp: time.Now(),
+			Lines:     []transactionLine{{AccountID: account1, Purpose: ACHCredit, Direction: Credit, Amount: 1000}},
+		}
+		if err := repo.createTransaction(deposit, createTransactionOpts{InitialDeposit: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		balancesOf := func(accountID string) accountBalances {
+			t.Helper()
+			dbtx, _ := repo.db.Begin()
+			defer dbtx.Rollback()
+			balances, err := repo.getAccountBalances(dbtx, accountID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return balances
+		}
+		now := time.Now()
+
+		h := hold{ID: base.ID(), AccountID: account1, CreditAccountID: account2, Purpose: Transfer, Amount: 400, Status: HoldPending, ExpiresAt: now.Add(time.Hour), CreatedAt: now, LastModified: now}
+		if err := holdRepo.placeHold(h, createTransactionOpts{}); err != nil {
+			t.Fatal(err)
+		}
+		if b := balancesOf(account1); b.Current != 1000 || b.Available != 600 || b.Pending != -400 {
+			t.Errorf("account1 balances: %#v", b)
+		}
+		if b := balancesOf(account2); b.Current != 0 || b.Available != 0 || b.Pending != 400 {
+			t.Errorf("account2 balances: %#v", b)
+		}
+
+		// Holds can't be placed beyond the available balance
+		other := h
+		other.ID, other.Amount = base.ID(), 700
+		if err := holdRepo.placeHold(other, createTransactionOpts{}); err == nil || !strings.Contains(err.Error(), "insufficient funds") {
+			t.Errorf("expected insufficient funds: %v", err)
+		}
+
+		// Debits are checked against the available balance
+		debit := transaction{
+			ID:        base.ID(),
+			Timestamp: time.Now(),
+			Lines: []transactionLine{
+				{AccountID: account1, Purpose: ACHDebit, Direction: Debit, Amount: 700},
+				{AccountID: account2, Purpose: ACHCredit, Direction: Credit, Amount: 700},
+			},
+		}
+		if err := repo.createTransaction(debit, createTransactionOpts{}); err == nil || !strings.Contains(err.Error(), "insufficient funds") {
+			t.Errorf("expected insufficient funds: %v", err)
+		}
+
+		// Partial capture
+		tx, err := holdRepo.captureHold(h.ID, 150)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tx.Lines) != 2 || tx.Lines[0].Amount != 150 || tx.Lines[1].AccountID != account2 {
+			t.Errorf("unexpected transaction: %#v", tx)
+		}
+		if b := balancesOf(account1); b.Current != 850 || b.Available != 600 || b.Pending != -250 {
+			t.Errorf("account1 balances: %#v", b)
+		}
+		if found, err := holdRepo.getHold(h.ID); err != nil || found.Status != HoldPending || found.CapturedAmount != 150 {
+			t.Errorf("unexpected hold=%#v error=%v", found, err)
+		}
+		if _, err := holdRepo.captureHold(h.ID, 251); err == nil {
+			t.Error("expected error")
+		}
+
+		// Capture the rest
+		if _, err := holdRepo.captureHold(h.ID, 0); err != nil {
+			t.Fatal(err)
+		}
+		if b := balancesOf(account1); b.Current != 600 || b.Available != 600 || b.Pending != 0 {
+			t.Errorf("account1 balances: %#v", b)
+		}
+		if b := balancesOf(account2); b.Current != 400 || b.Available != 400 || b.Pending != 0 {
+			t.Errorf("account2 balances: %#v", b)
+		}
+		if found, err := holdRepo.getHold(h.ID); err != nil || found.Status != HoldCaptured {
+			t.Errorf("unexpected hold=%#v error=%v", found, err)
+		}
+		if _, err := holdRepo.captureHold(h.ID, 0); err == nil || !strings.Contains(err.Error(), errHoldNotPending.Error()) {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		// Release
+		released := h
+		released.ID, released.Amount = base.ID(), 100
+		if err := holdRepo.placeHold(released, createTransactionOpts{}); err != nil {
+			t.Fatal(err)
+		}
+		if found, err := holdRepo.releaseHold(released.ID); err != nil || found.Status != HoldReleased {
+			t.Errorf("unexpected hold=%#v error=%v", found, err)
+		}
+		if _, err := holdRepo.releaseHold(released.ID); err == nil {
+			t.Error("expected error")
+		}
+		if _, err := holdRepo.releaseHold(base.ID()); err != errHoldNotFound {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		// Expired holds don't count against the available balance
+		expired := h
+		expired.ID, expired.Amount, expired.ExpiresAt = base.ID(), 200, now.Add(-1*time.Minute)
+		if err := holdRepo.placeHold(expired, createTransactionOpts{}); err != nil {
+			t.Fatal(err)
+		}
+		if b := balancesOf(account1); b.Available != 600 {
+			t.Errorf("account1 balances: %#v", b)
+		}
+		if _, err := holdRepo.captureHold(expired.ID, 0); err == nil || !strings.Contains(err.Error(), errHoldExpired.Error()) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if n, err := holdRepo.expireHolds(time.Now()); err != nil || n != 1 {
+			t.Errorf("expired %d holds: %v", n, err)
+		}
+		if found, err := holdRepo.getHold(expired.ID); err != nil || found.Status != HoldExpired {
+			t.Errorf("unexpected hold=%#v error=%v", found, err)
+		}
+
+		holds, err := holdRepo.getAccountHolds(account1)
+		if err != nil || len(holds) != 3 {
+			t.Errorf("got %d holds: %v", len(holds), err)
+		}
+		if holds, err := holdRepo.getAccountHolds(account2); err != nil || len(holds) != 0 {
+			t.Errorf("got %d holds: %v", len(holds), err)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
+
+func TestSqlHoldRepository__placeHoldFrozen(t *testing.T) {
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+
+	repo := createTestSqlTransactionRepository(t, sqliteDB.DB)
+	defer repo.Close()
+	holdRepo := setupSqlHoldStorage(log.NewNopLogger(), repo)
+
+	accountID := base.ID()
+	repo.accountRepo = &testAccountRepository{
+		accounts: []*accounts.Account{{ID: accountID, Status: "frozen"}},
+	}
+	h, err := placeHoldRequest{CreditAccountID: base.ID(), Amount: 100}.asHold(accountID, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := holdRepo.placeHold(h, createTransactionOpts{}); err == nil {
+		t.Error("expected error")
+	}
+
+	// unknown account
+	repo.accountRepo = &testAccountRepository{}
+	if err := holdRepo.placeHold(h, createTransactionOpts{}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/moov-io/base"
+	moovhttp "github.com/moov-io/base/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+type holdStatus string
+
+var (
+	HoldPending  holdStatus = "pending"
+	HoldCaptured holdStatus = "captured"
+	HoldReleased holdStatus = "released"
+	HoldExpired  holdStatus = "expired"
+)
+
+// defaultHoldExpiration is how long a hold lasts when it's placed without an expiration.
+var defaultHoldExpiration = 7 * 24 * time.Hour
+
+// hold is an authorization which sets aside funds in AccountID until they are captured into
+// CreditAccountID or the hold is released. Funds which are held reduce the available balance of
+// AccountID, but are kept out of posted transactionLines until captured.
+type hold struct {
+	ID              string             `json:"id"`
+	AccountID       string             `json:"accountId"`
+	CreditAccountID string             `json:"creditAccountId"`
+	Purpose         TransactionPurpose `json:"purpose"`
+	Amount          int64              `json:"amount"`
+	CapturedAmount  int64              `json:"capturedAmount"`
+	Status          holdStatus         `json:"status"`
+	Description     string             `json:"description,omitempty"`
+	ExpiresAt       time.Time          `json:"expiresAt"`
+	CreatedAt       time.Time          `json:"createdAt"`
+	LastModified    time.Time          `json:"lastModified"`
+}
+
+// remaining returns the amount of the hold which has not been captured.
+func (h hold) remaining() int64 {
+	return h.Amount - h.CapturedAmount
+}
+
+// captureLines returns the transactionLines which are posted when amount of the hold is captured.
+func (h hold) captureLines(amount int64) []transactionLine {
+	return []transactionLine{
+		{AccountID: h.AccountID, Purpose: h.Purpose, Direction: Debit, Amount: amount},
+		{AccountID: h.CreditAccountID, Purpose: h.Purpose, Direction: Credit, Amount: amount},
+	}
+}
+
+func (h hold) validate() error {
+	if h.AccountID == "" || h.CreditAccountID == "" {
+		return errors.New("hold: missing accountId or creditAccountId")
+	}
+	if h.AccountID == h.CreditAccountID {
+		return errors.New("hold: accountId and creditAccountId must differ")
+	}
+	if h.Amount <= 0 {
+		return fmt.Errorf("hold: invalid amount %d", h.Amount)
+	}
+	if h.Purpose == ACHDebit {
+		return errors.New("hold: purpose must credit creditAccountId")
+	}
+	return h.Purpose.validate()
+}
+
+type placeHoldRequest struct {
+	CreditAccountID string             `json:"creditAccountId"`
+	Purpose         TransactionPurpose `json:"purpose"`
+	Amount          int64              `json:"amount"`
+	Description     string             `json:"description"`
+	ExpiresAt       time.Time          `json:"expiresAt"`
+}
+
+func (req placeHoldRequest) asHold(accountID string, now time.Time) (hold, error) {
+	h := hold{
+		ID:              base.ID(),
+		AccountID:       accountID,
+		CreditAccountID: req.CreditAccountID,
+		Purpose:         req.Purpose,
+		Amount:          req.Amount,
+		Status:          HoldPending,
+		Description:     strings.TrimSpace(req.Description),
+		ExpiresAt:       req.ExpiresAt,
+		CreatedAt:       now,
+		LastModified:    now,
+	}
+	if h.Purpose == "" {
+		h.Purpose = Transfer
+	}
+	if h.ExpiresAt.IsZero() {
+		h.ExpiresAt = now.Add(defaultHoldExpiration)
+	}
+	if !h.ExpiresAt.After(now) {
+		return h, fmt.Errorf("hold: expiresAt %v is in the past", req.ExpiresAt.Format(time.RFC3339))
+	}
+	if len(h.Description) > 200 {
+		return h, errors.New("hold: description is longer than 200 characters")
+	}
+	return h, h.validate()
+}
+
+type captureHoldRequest struct {
+	// Amount to capture, zero captures the remaining funds of the hold
+	Amount int64 `json:"amount"`
+}
+
+func addHoldRoutes(logger log.Logger, r *mux.Router, holdRepo holdRepository) {
+	r.Methods("GET").Path("/accounts/{accountId}/holds").HandlerFunc(getAccountHolds(logger, holdRepo))
+	r.Methods("POST").Path("/accounts/{accountId}/holds").HandlerFunc(placeHold(logger, holdRepo))
+	r.Methods("GET").Path("/accounts/{accountId}/holds/{holdId}").HandlerFunc(getHold(logger, holdRepo))
+	r.Methods("POST").Path("/accounts/{accountId}/holds/{holdId}/capture").HandlerFunc(captureHold(logger, holdRepo))
+	r.Methods("POST").Path("/accounts/{accountId}/holds/{holdId}/release").HandlerFunc(releaseHold(logger, holdRepo))
+}
+
+func getHoldID(w http.ResponseWriter, r *http.Request) string {
+	v := mux.Vars(r)["holdId"]
+	if v == "" {
+		moovhttp.Problem(w, errHoldNotFound)
+		return ""
+	}
+	return v
+}
+
+// readAccountHold returns the hold from the request path after checking it belongs to the account in the path.
+// Problems are written to w and nil is returned.
+func readAccountHold(w http.ResponseWriter, r *http.Request, holdRepo holdRepository) *hold {
+	accountID, holdID := getAccountID(w, r), getHoldID(w, r)
+	if accountID == "" || holdID == "" {
+		return nil
+	}
+	h, err := holdRepo.getHold(holdID)
+	if err != nil {
+		moovhttp.Problem(w, err)
+		return nil
+	}
+	if h == nil || h.AccountID != accountID {
+		http.NotFound(w, r)
+		return nil
+	}
+	return h
+}
+
+func placeHold(logger log.Logger, holdRepo holdRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		accountID := getAccountID(w, r)
+		if accountID == "" {
+			return
+		}
+
+		var req placeHoldRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+		h, err := req.asHold(accountID, time.Now())
+		if err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		requestID := moovhttp.GetRequestID(r)
+		if err := holdRepo.placeHold(h, createTransactionOpts{AllowOverdraft: false}); err != nil {
+			logger.Log("holds", fmt.Sprintf("problem placing hold on account=%s: %v", accountID, err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		logger.Log("holds", fmt.Sprintf("placed hold=%s of %d on account=%s", h.ID, h.Amount, accountID), "requestID", requestID)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(h)
+	}
+}
+
+func getAccountHolds(logger log.Logger, holdRepo holdRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		accountID := getAccountID(w, r)
+		if accountID == "" {
+			return
+		}
+		holds, err := holdRepo.getAccountHolds(accountID)
+		if err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(holds)
+	}
+}
+
+func getHold(logger log.Logger, holdRepo holdRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		h := readAccountHold(w, r, holdRepo)
+		if h == nil {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(h)
+	}
+}
+
+func captureHold(logger log.Logger, holdRepo holdRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		h := readAccountHold(w, r, holdRepo)
+		if h == nil {
+			return
+		}
+
+		var req captureHoldRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			moovhttp.Problem(w, err)
+			return
+		}
+		if req.Amount < 0 || req.Amount > h.remaining() {
+			moovhttp.Problem(w, fmt.Errorf("invalid capture amount %d of %d remaining", req.Amount, h.remaining()))
+			return
+		}
+
+		requestID := moovhttp.GetRequestID(r)
+		tx, err := holdRepo.captureHold(h.ID, req.Amount)
+		if err != nil {
+			logger.Log("holds", fmt.Sprintf("problem capturing hold=%s: %v", h.ID, err), "requestID", requestID)
+			if code := readProductLimitCode(err); code != "" {
+				writeProductLimitProblem(w, code, err)
+				return
+			}
+			moovhttp.Problem(w, err)
+			return
+		}
+		logger.Log("holds", fmt.Sprintf("captured hold=%s in transaction=%s", h.ID, tx.ID), "requestID", requestID)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(tx)
+	}
+}
+
+func releaseHold(logger log.Logger, holdRepo holdRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		h := readAccountHold(w, r, holdRepo)
+		if h == nil {
+			return
+		}
+
+		requestID := moovhttp.GetRequestID(r)
+		h, err = holdRepo.releaseHold(h.ID)
+		if err != nil {
+			logger.Log("holds", fmt.Sprintf("problem releasing hold: %v", err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		logger.Log("holds", fmt.Sprintf("released hold=%s", h.ID), "requestID", requestID)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(h)
+	}
+}
+
+// expireHoldsEvery periodically marks holds which have passed their expiration until ctx is done.
+// Expired holds no longer count against available balances even before they're marked.
+func expireHoldsEvery(ctx context.Context, logger log.Logger, holdRepo holdRepository, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			n, err := holdRepo.expireHolds(now)
+			if err != nil {
+				logger.Log("holds", fmt.Sprintf("problem expiring holds: %v", err))
+				continue
+			}
+			if n > 0 {
+				logger.Log("holds", fmt.Sprintf("expired %d holds", n))
+			}
+		}
+	}
+}
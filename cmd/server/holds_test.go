@@ -0,0 +1,265 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+type mockHoldRepository struct {
+	holds    []hold
+	captured int64
+
+	err error
+}
+
+func (r *mockHoldRepository) placeHold(h hold, opts createTransactionOpts) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.holds = append(r.holds, h)
+	return nil
+}
+
+func (r *mockHoldRepository) getHold(holdID string) (*hold, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for i := range r.holds {
+		if r.holds[i].ID == holdID {
+			return &r.holds[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *mockHoldRepository) getAccountHolds(accountID string) ([]hold, error) {
+	return r.holds, r.err
+}
+
+func (r *mockHoldRepository) captureHold(holdID string, amount int64) (*transaction, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	h, _ := r.getHold(holdID)
+	if amount == 0 {
+		amount = h.remaining()
+	}
+	r.captured = amount
+	return &transaction{ID: base.ID(), Timestamp: time.Now(), Lines: h.captureLines(amount)}, nil
+}
+
+func (r *mockHoldRepository) releaseHold(holdID string) (*hold, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	h, _ := r.getHold(holdID)
+	h.Status = HoldReleased
+	return h, nil
+}
+
+func (r *mockHoldRepository) expireHolds(now time.Time) (int, error) {
+	return 0, r.err
+}
+
+func TestHolds__placeHoldRequest(t *testing.T) {
+	now := time.Now()
+	h, err := placeHoldRequest{CreditAccountID: "credit", Amount: 100}.asHold("debit", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.ID == "" || h.Status != HoldPending || h.Purpose != Transfer || !h.ExpiresAt.Equal(now.Add(defaultHoldExpiration)) {
+		t.Errorf("unexpected hold: %#v", h)
+	}
+
+	cases := []placeHoldRequest{
+		{CreditAccountID: "credit", Amount: 0},
+		{CreditAccountID: "debit", Amount: 100},
+		{CreditAccountID: "", Amount: 100},
+		{CreditAccountID: "credit", Amount: 100, Purpose: ACHDebit},
+		{CreditAccountID: "credit", Amount: 100, ExpiresAt: now.Add(-1 * time.Hour)},
+		{CreditAccountID: "credit", Amount: 100, Description: strings.Repeat("a", 201)},
+	}
+	for i := range cases {
+		if _, err := cases[i].asHold("debit", now); err == nil {
+			t.Errorf("#%d expected error", i)
+		}
+	}
+}
+
+func TestHolds__captureLines(t *testing.T) {
+	h := hold{AccountID: "debit", CreditAccountID: "credit", Purpose: Transfer, Amount: 500, CapturedAmount: 200}
+	if n := h.remaining(); n != 300 {
+		t.Errorf("remaining=%d", n)
+	}
+	tx := transaction{ID: base.ID(), Timestamp: time.Now(), Lines: h.captureLines(h.remaining())}
+	if err := tx.validate(); err != nil {
+		t.Error(err)
+	}
+	for _, line := range tx.Lines {
+		if line.Purpose != Transfer {
+			t.Errorf("unexpected purpose: %#v", line)
+		}
+	}
+}
+
+func TestHolds__placeHold(t *testing.T) {
+	repo := &mockHoldRepository{}
+	router := mux.NewRouter()
+	addHoldRoutes(log.NewNopLogger(), router, repo)
+
+	body := strings.NewReader(`{"creditAccountId": "credit", "amount": 1250, "description": "card authorization"}`)
+	req := httptest.NewRequest("POST", "/accounts/debit/holds", body)
+	req.Header.Set("x-user-id", "test")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	var h hold
+	if err := json.NewDecoder(w.Body).Decode(&h); err != nil {
+		t.Fatal(err)
+	}
+	if h.AccountID != "debit" || h.Amount != 1250 || len(repo.holds) != 1 {
+		t.Errorf("unexpected hold: %#v", h)
+	}
+
+	// repository error
+	repo.err = fmt.Errorf("insufficient funds")
+	req = httptest.NewRequest("POST", "/accounts/debit/holds", strings.NewReader(`{"creditAccountId": "credit", "amount": 1250}`))
+	req.Header.Set("x-user-id", "test")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bogus status code: %d", w.Code)
+	}
+}
+
+func TestHolds__captureAndRelease(t *testing.T) {
+	holdID := base.ID()
+	repo := &mockHoldRepository{
+		holds: []hold{
+			{ID: holdID, AccountID: "debit", CreditAccountID: "credit", Purpose: Transfer, Amount: 500, Status: HoldPending},
+		},
+	}
+	router := mux.NewRouter()
+	addHoldRoutes(log.NewNopLogger(), router, repo)
+
+	// partial capture
+	req := httptest.NewRequest("POST", fmt.Sprintf("/accounts/debit/holds/%s/capture", holdID), strings.NewReader(`{"amount": 200}`))
+	req.Header.Set("x-user-id", "test")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	if repo.captured != 200 {
+		t.Errorf("captured %d", repo.captured)
+	}
+
+	// capture everything with an empty body
+	req = httptest.NewRequest("POST", fmt.Sprintf("/accounts/debit/holds/%s/capture", holdID), nil)
+	req.Header.Set("x-user-id", "test")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	if repo.captured != 500 {
+		t.Errorf("captured %d", repo.captured)
+	}
+
+	// capture too much
+	req = httptest.NewRequest("POST", fmt.Sprintf("/accounts/debit/holds/%s/capture", holdID), strings.NewReader(`{"amount": 501}`))
+	req.Header.Set("x-user-id", "test")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bogus status code: %d", w.Code)
+	}
+
+	// hold belongs to another account
+	req = httptest.NewRequest("GET", fmt.Sprintf("/accounts/other/holds/%s", holdID), nil)
+	req.Header.Set("x-user-id", "test")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("bogus status code: %d", w.Code)
+	}
+
+	// release
+	req = httptest.NewRequest("POST", fmt.Sprintf("/accounts/debit/holds/%s/release", holdID), nil)
+	req.Header.Set("x-user-id", "test")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	var h hold
+	if err := json.NewDecoder(w.Body).Decode(&h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Status != HoldReleased {
+		t.Errorf("unexpected hold: %#v", h)
+	}
+}
+
+func TestHolds__getAccountHolds(t *testing.T) {
+	repo := &mockHoldRepository{
+		holds: []hold{{ID: base.ID(), AccountID: "debit", CreditAccountID: "credit", Purpose: Transfer, Amount: 500, Status: HoldPending}},
+	}
+	router := mux.NewRouter()
+	addHoldRoutes(log.NewNopLogger(), router, repo)
+
+	req := httptest.NewRequest("GET", "/accounts/debit/holds", nil)
+	req.Header.Set("x-user-id", "test")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	var holds []hold
+	if err := json.NewDecoder(w.Body).Decode(&holds); err != nil {
+		t.Fatal(err)
+	}
+	if len(holds) != 1 {
+		t.Errorf("unexpected holds: %#v", holds)
+	}
+}
@@ -5,11 +5,14 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/moov-io/base"
 	moovhttp "github.com/moov-io/base/http"
 	"github.com/moov-io/base/idempotent/lru"
 
@@ -32,6 +35,15 @@ func wrapResponseWriter(logger log.Logger, w http.ResponseWriter, r *http.Reques
 	return moovhttp.EnsureHeaders(logger, routeHistogram.With("route", route), inmemIdempotentRecorder, w, r)
 }
 
+// writeConflict writes err to w as a '409 Conflict' response in the same format as moovhttp.Problem
+func writeConflict(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": err.Error(),
+	})
+}
+
 var baseIdRegex = regexp.MustCompile(`([a-f0-9]{40})`)
 
 // cleanMetricsPath takes a URL path and formats it for Prometheus metrics
@@ -48,3 +60,19 @@ func cleanMetricsPath(path string) string {
 	}
 	return strings.Join(out, "-")
 }
+
+// readDateParam parses a query parameter as either a date (2006-01-02) or ISO 8601 timestamp.
+// Dates are read as midnight UTC, or the following midnight if endOfDay is set so they can be used
+// as an exclusive upper bound. An empty value returns the zero time.
+func readDateParam(v string, endOfDay bool) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Parse(base.ISO8601Format, v)
+}
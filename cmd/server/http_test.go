@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/moov-io/base"
 
@@ -89,3 +90,21 @@ func TestHTTP__cleanMetricsPath(t *testing.T) {
 		t.Errorf("got %q", v)
 	}
 }
+
+func TestHTTP__readDateParam(t *testing.T) {
+	if v, err := readDateParam("", false); err != nil || !v.IsZero() {
+		t.Errorf("v=%v error=%v", v, err)
+	}
+	if v, err := readDateParam("2020-04-30", false); err != nil || !v.Equal(time.Date(2020, time.April, 30, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("v=%v error=%v", v, err)
+	}
+	if v, err := readDateParam("2020-04-30", true); err != nil || !v.Equal(time.Date(2020, time.May, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("v=%v error=%v", v, err)
+	}
+	if v, err := readDateParam("2020-04-30T15:04:05-07:00", true); err != nil || v.Hour() != 15 {
+		t.Errorf("v=%v error=%v", v, err)
+	}
+	if _, err := readDateParam("04/30/2020", false); err == nil {
+		t.Error("expected error")
+	}
+}
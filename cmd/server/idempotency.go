@@ -0,0 +1,213 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	moovhttp "github.com/moov-io/base/http"
+	"github.com/moov-io/base/idempotent"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+
+	// maxIdempotencyKeyLength is the longest Idempotency-Key we accept
+	maxIdempotencyKeyLength = 64
+)
+
+// idempotentRoutes are the routes which post money, so retrying them must not post twice.
+// Routes are identified by their method and path template.
+var idempotentRoutes = map[string]bool{
+	"POST /accounts":                                       true,
+	"POST /accounts/transactions":                          true,
+	"POST /accounts/transactions/{transactionID}/reversal": true,
+}
+
+// addIdempotencyMiddleware saves the response of each idempotentRoutes request made with an Idempotency-Key header
+// in the database. Retries with the same key and request are sent the original response, instead of being processed
+// again, and retries with the same key but a different request are rejected with '409 Conflict'.
+//
+// Keys are scoped to the X-User-ID which sent them. Requests which don't succeed release their key so they can be retried.
+// Handlers link the transaction they post to the reserved key (see readReservedIdempotencyKey) in the same database
+// transaction, so a request whose response was lost after posting is rejected instead of posting again.
+// Keys are kept until deleteIdempotencyKeysEvery removes them.
+func addIdempotencyMiddleware(logger log.Logger, router *mux.Router, repo idempotencyRepository) {
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := mux.CurrentRoute(r)
+			if route == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			tmpl, _ := route.GetPathTemplate()
+			if !idempotentRoutes[fmt.Sprintf("%s %s", r.Method, tmpl)] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			idempotentRequest(logger, repo, w, r, next)
+		})
+	})
+}
+
+// readIdempotencyKey returns the Idempotency-Key header, or the older X-Idempotency-Key header if it's missing
+func readIdempotencyKey(r *http.Request) string {
+	key := strings.TrimSpace(r.Header.Get(idempotencyKeyHeader))
+	if key == "" {
+		key = strings.TrimSpace(r.Header.Get(idempotent.HeaderKey))
+	}
+	return key
+}
+
+type idempotencyKeyContextKey struct{}
+
+// readReservedIdempotencyKey returns the key reserved by the middleware for r, or nil if r wasn't sent with one
+func readReservedIdempotencyKey(r *http.Request) *idempotencyKey {
+	key, _ := r.Context().Value(idempotencyKeyContextKey{}).(*idempotencyKey)
+	return key
+}
+
+// idempotentRequestHash returns a hash of the request method, path and body to detect reused keys
+func idempotentRequestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.Path)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func idempotentRequest(logger log.Logger, repo idempotencyRepository, w http.ResponseWriter, r *http.Request, next http.Handler) {
+	key, userID := readIdempotencyKey(r), moovhttp.GetUserID(r)
+	if key == "" || userID == "" {
+		next.ServeHTTP(w, r) // handlers will reject requests without X-User-ID
+		return
+	}
+	requestID := moovhttp.GetRequestID(r)
+	if utf8.RuneCountInString(key) > maxIdempotencyKeyLength {
+		moovhttp.Problem(w, fmt.Errorf("%s is longer than %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength))
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		moovhttp.Problem(w, err)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	saved, err := repo.reserveIdempotencyKey(userID, key, idempotentRequestHash(r, body))
+	if err != nil {
+		logger.Log("idempotency", fmt.Sprintf("problem with idempotency key %q: %v", key, err), "requestID", requestID)
+		if errors.Is(err, errIdempotencyKeyReused) || errors.Is(err, errIdempotencyKeyInFlight) || errors.Is(err, errIdempotencyKeyPosted) {
+			writeConflict(w, err)
+		} else {
+			moovhttp.Problem(w, err)
+		}
+		return
+	}
+	if saved != nil {
+		logger.Log("idempotency", fmt.Sprintf("replaying response for idempotency key %q", key), "requestID", requestID)
+		w.Header().Set("Content-Type", saved.ContentType)
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(saved.StatusCode)
+		w.Write(saved.Body)
+		return
+	}
+
+	// The database tracks this key now, so drop the older header which the in-memory recorder would reject
+	r.Header.Del(idempotent.HeaderKey)
+	r = r.WithContext(context.WithValue(r.Context(), idempotencyKeyContextKey{}, &idempotencyKey{UserID: userID, Key: key}))
+
+	// Release the key unless the request succeeded, even when the handler panics, so the request can be retried.
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			if err := repo.releaseIdempotencyKey(userID, key); err != nil {
+				logger.Log("idempotency", fmt.Sprintf("problem releasing idempotency key %q: %v", key, err), "requestID", requestID)
+			}
+		}
+	}()
+
+	rec := &responseRecorder{ResponseWriter: w}
+	next.ServeHTTP(rec, r)
+
+	if rec.statusCode() >= 200 && rec.statusCode() < 300 {
+		// Keep the key reserved if saving the response fails, the request succeeded and mustn't be processed again
+		// until the reservation is stale.
+		succeeded = true
+		resp := idempotentResponse{
+			StatusCode:  rec.statusCode(),
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		}
+		if err := repo.completeIdempotencyKey(userID, key, resp); err != nil {
+			logger.Log("idempotency", fmt.Sprintf("problem saving response for idempotency key %q: %v", key, err), "requestID", requestID)
+		}
+	}
+}
+
+// deleteIdempotencyKeysEvery periodically removes idempotency keys older than retention until ctx is done.
+// Retries after a key is removed are processed again.
+func deleteIdempotencyKeysEvery(ctx context.Context, logger log.Logger, repo idempotencyRepository, retention, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			n, err := repo.deleteIdempotencyKeys(now.Add(-1 * retention))
+			if err != nil {
+				logger.Log("idempotency", fmt.Sprintf("problem deleting idempotency keys: %v", err))
+				continue
+			}
+			if n > 0 {
+				logger.Log("idempotency", fmt.Sprintf("deleted %d idempotency keys", n))
+			}
+		}
+	}
+}
+
+// responseRecorder copies the status code and body written to an http.ResponseWriter
+type responseRecorder struct {
+	http.ResponseWriter
+
+	code int
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) statusCode() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
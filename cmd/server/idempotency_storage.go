@@ -0,0 +1,56 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+type idempotencyRepository interface {
+	// reserveIdempotencyKey records key for userID before the request is processed. If the key was reserved
+	// before by an identical request and its response was saved then the saved response is returned.
+	//
+	// errIdempotencyKeyReused is returned if the key was used for a different request and
+	// errIdempotencyKeyInFlight is returned if the original request hasn't completed.
+	reserveIdempotencyKey(userID, key, requestHash string) (*idempotentResponse, error)
+
+	// completeIdempotencyKey saves the response of a reserved key so it can be replayed.
+	completeIdempotencyKey(userID, key string, resp idempotentResponse) error
+
+	// releaseIdempotencyKey removes a reserved key, which allows the request to be retried. Keys linked to a
+	// posted transaction are kept since retrying them would post again.
+	releaseIdempotencyKey(userID, key string) error
+
+	// deleteIdempotencyKeys removes every key created before the given time, whether or not its response
+	// was saved, and returns how many were removed.
+	deleteIdempotencyKeys(createdBefore time.Time) (int, error)
+}
+
+// idempotencyReservationTTL is how long a key can stay reserved without its response being saved. Older reservations
+// were left behind by requests which never finished, such as when the server stopped, and are taken over by the next
+// identical request with the key. Reservations linked to a posted transaction are never taken over, the request
+// finished posting and only its response was lost.
+var idempotencyReservationTTL = 5 * time.Minute
+
+var (
+	errIdempotencyKeyReused   = errors.New("idempotency key was used with a different request")
+	errIdempotencyKeyInFlight = errors.New("request with idempotency key is still being processed")
+	errIdempotencyKeyPosted   = errors.New("request with idempotency key was already posted")
+)
+
+// idempotencyKey is a key reserved by the request being handled. Transactions posted by the request are linked to
+// it inside the same database transaction, see createTransactionOpts.
+type idempotencyKey struct {
+	UserID string
+	Key    string
+}
+
+// idempotentResponse is the saved response of a request made with an idempotency key
+type idempotentResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
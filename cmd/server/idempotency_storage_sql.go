@@ -0,0 +1,158 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/moov-io/accounts/cmd/server/database"
+
+	"github.com/go-kit/kit/log"
+)
+
+type sqlIdempotencyRepository struct {
+	db     *sql.DB
+	logger log.Logger
+}
+
+func setupSqlIdempotencyStorage(logger log.Logger, db *sql.DB) *sqlIdempotencyRepository {
+	return &sqlIdempotencyRepository{db: db, logger: logger}
+}
+
+func (r *sqlIdempotencyRepository) reserveIdempotencyKey(userID, key, requestHash string) (*idempotentResponse, error) {
+	query := `insert into idempotency_keys (user_id, idempotency_key, request_hash, status_code, created_at) values (?, ?, ?, 0, ?);`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("reserveIdempotencyKey: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(userID, key, requestHash, time.Now())
+	if err == nil {
+		return nil, nil // first time we've seen this key
+	}
+	if !database.UniqueViolation(err) {
+		return nil, fmt.Errorf("reserveIdempotencyKey: insert: %w", err)
+	}
+
+	// The key was reserved before, so compare the requests and return the saved response
+	query = `select request_hash, status_code, content_type, response_body, transaction_id from idempotency_keys where user_id = ? and idempotency_key = ? limit 1;`
+	stmt, err = r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("reserveIdempotencyKey: prepare select: %w", err)
+	}
+	defer stmt.Close()
+
+	var hash string
+	var contentType, transactionID sql.NullString
+	var resp idempotentResponse
+	if err := stmt.QueryRow(userID, key).Scan(&hash, &resp.StatusCode, &contentType, &resp.Body, &transactionID); err != nil {
+		if err == sql.ErrNoRows {
+			// The original request failed and released the key between our insert and select
+			return nil, errIdempotencyKeyInFlight
+		}
+		return nil, fmt.Errorf("reserveIdempotencyKey: select: %w", err)
+	}
+	if hash != requestHash {
+		return nil, errIdempotencyKeyReused
+	}
+	if resp.StatusCode == 0 {
+		if transactionID.Valid {
+			return nil, fmt.Errorf("transaction=%s: %w", transactionID.String, errIdempotencyKeyPosted)
+		}
+		return nil, r.takeoverIdempotencyKey(userID, key)
+	}
+	resp.ContentType = contentType.String
+	return &resp, nil
+}
+
+// takeoverIdempotencyKey reserves a key again if its reservation is older than idempotencyReservationTTL and no
+// transaction was posted with it. Only one request can take over a stale reservation, others are returned
+// errIdempotencyKeyInFlight.
+func (r *sqlIdempotencyRepository) takeoverIdempotencyKey(userID, key string) error {
+	query := `update idempotency_keys set created_at = ? where user_id = ? and idempotency_key = ? and status_code = 0 and transaction_id is null and created_at < ?;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("takeoverIdempotencyKey: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	res, err := stmt.Exec(now, userID, key, now.Add(-1*idempotencyReservationTTL))
+	if err != nil {
+		return fmt.Errorf("takeoverIdempotencyKey: update: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errIdempotencyKeyInFlight
+	}
+	return nil
+}
+
+func (r *sqlIdempotencyRepository) completeIdempotencyKey(userID, key string, resp idempotentResponse) error {
+	query := `update idempotency_keys set status_code = ?, content_type = ?, response_body = ? where user_id = ? and idempotency_key = ?;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("completeIdempotencyKey: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(resp.StatusCode, resp.ContentType, resp.Body, userID, key); err != nil {
+		return fmt.Errorf("completeIdempotencyKey: update: %w", err)
+	}
+	return nil
+}
+
+func (r *sqlIdempotencyRepository) releaseIdempotencyKey(userID, key string) error {
+	query := `delete from idempotency_keys where user_id = ? and idempotency_key = ? and status_code = 0 and transaction_id is null;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("releaseIdempotencyKey: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(userID, key); err != nil {
+		return fmt.Errorf("releaseIdempotencyKey: delete: %w", err)
+	}
+	return nil
+}
+
+// linkIdempotencyKey records that transactionID was posted with a reserved key inside tx, the database transaction
+// posting it. Retries can't take over the key once tx commits, so they can't post again. errIdempotencyKeyInFlight
+// is returned if the reservation was taken over or released in the meantime.
+func linkIdempotencyKey(tx *sql.Tx, key *idempotencyKey, transactionID string) error {
+	query := `update idempotency_keys set transaction_id = ? where user_id = ? and idempotency_key = ? and status_code = 0 and transaction_id is null;`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("linkIdempotencyKey: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(transactionID, key.UserID, key.Key)
+	if err != nil {
+		return fmt.Errorf("linkIdempotencyKey: transaction=%q: %w", transactionID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("linkIdempotencyKey: transaction=%q: %w", transactionID, errIdempotencyKeyInFlight)
+	}
+	return nil
+}
+
+func (r *sqlIdempotencyRepository) deleteIdempotencyKeys(createdBefore time.Time) (int, error) {
+	query := `delete from idempotency_keys where created_at < ?;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return 0, fmt.Errorf("deleteIdempotencyKeys: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(createdBefore)
+	if err != nil {
+		return 0, fmt.Errorf("deleteIdempotencyKeys: delete: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
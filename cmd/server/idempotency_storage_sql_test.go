@@ -0,0 +1,167 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestSqlIdempotencyRepository(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, db *sql.DB) {
+		repo := setupSqlIdempotencyStorage(log.NewNopLogger(), db)
+		userID, key := base.ID(), base.ID()
+
+		if resp, err := repo.reserveIdempotencyKey(userID, key, "hash"); err != nil || resp != nil {
+			t.Fatalf("resp=%#v error=%v", resp, err)
+		}
+
+		// In progress
+		if _, err := repo.reserveIdempotencyKey(userID, key, "hash"); err != errIdempotencyKeyInFlight {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if _, err := repo.reserveIdempotencyKey(userID, key, "other"); err != errIdempotencyKeyReused {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		// Other users can use the same key
+		if resp, err := repo.reserveIdempotencyKey(base.ID(), key, "other"); err != nil || resp != nil {
+			t.Errorf("resp=%#v error=%v", resp, err)
+		}
+
+		saved := idempotentResponse{StatusCode: 200, ContentType: "application/json", Body: []byte(`{"id":"foo"}`)}
+		if err := repo.completeIdempotencyKey(userID, key, saved); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := repo.reserveIdempotencyKey(userID, key, "hash")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp == nil || resp.StatusCode != 200 || resp.ContentType != saved.ContentType || string(resp.Body) != string(saved.Body) {
+			t.Errorf("unexpected response: %#v", resp)
+		}
+
+		// Completed keys aren't released
+		if err := repo.releaseIdempotencyKey(userID, key); err != nil {
+			t.Fatal(err)
+		}
+		if resp, err := repo.reserveIdempotencyKey(userID, key, "hash"); err != nil || resp == nil {
+			t.Errorf("resp=%#v error=%v", resp, err)
+		}
+
+		// Released keys can be reserved again
+		other := base.ID()
+		if _, err := repo.reserveIdempotencyKey(userID, other, "hash"); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.releaseIdempotencyKey(userID, other); err != nil {
+			t.Fatal(err)
+		}
+		if resp, err := repo.reserveIdempotencyKey(userID, other, "hash2"); err != nil || resp != nil {
+			t.Errorf("resp=%#v error=%v", resp, err)
+		}
+
+		// Reservations which were never completed or released are taken over once they're stale
+		if _, err := db.Exec(`update idempotency_keys set created_at = ? where user_id = ? and idempotency_key = ?;`, time.Now().Add(-2*idempotencyReservationTTL), userID, other); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.reserveIdempotencyKey(userID, other, "hash"); err != errIdempotencyKeyReused {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if resp, err := repo.reserveIdempotencyKey(userID, other, "hash2"); err != nil || resp != nil {
+			t.Errorf("resp=%#v error=%v", resp, err)
+		}
+		if _, err := repo.reserveIdempotencyKey(userID, other, "hash2"); err != errIdempotencyKeyInFlight {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		// Old keys are deleted, completed or not
+		if n, err := repo.deleteIdempotencyKeys(time.Now().Add(time.Minute)); err != nil || n < 3 {
+			t.Errorf("deleted %d keys: %v", n, err)
+		}
+		if resp, err := repo.reserveIdempotencyKey(userID, key, "other"); err != nil || resp != nil {
+			t.Errorf("resp=%#v error=%v", resp, err)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, sqliteDB.DB)
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, mysqlDB.DB)
+}
+
+func TestSqlIdempotencyRepository__postedTransaction(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+		account := &accounts.Account{ID: base.ID(), AccountNumber: "123", Status: "open", RoutingNumber: defaultRoutingNumber}
+		repo.accountRepo = &testAccountRepository{accounts: []*accounts.Account{account}}
+		createTestGLAccount(t, repo.db, defaultRoutingNumber, createGLAccountRequest{Code: "0010", Name: "Cash", Category: GLAsset})
+		keyRepo := setupSqlIdempotencyStorage(log.NewNopLogger(), repo.db)
+
+		key := &idempotencyKey{UserID: base.ID(), Key: base.ID()}
+		if _, err := keyRepo.reserveIdempotencyKey(key.UserID, key.Key, "hash"); err != nil {
+			t.Fatal(err)
+		}
+		post := func() (transaction, error) {
+			tx := transaction{
+				ID:        base.ID(),
+				Timestamp: time.Now(),
+				Lines: []transactionLine{
+					{AccountID: account.ID, Purpose: ACHCredit, Direction: Credit, Amount: 100},
+					{AccountID: glAccountID(defaultRoutingNumber, "0010"), Purpose: ACHCredit, Direction: Debit, Amount: 100},
+				},
+			}
+			return tx, repo.createTransaction(tx, createTransactionOpts{AllowGLDebits: true, IdempotencyKey: key})
+		}
+		posted, err := post()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The response was never saved, as if the server stopped after posting. Only one transaction is posted.
+		if _, err := post(); !errors.Is(err, errIdempotencyKeyInFlight) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := keyRepo.releaseIdempotencyKey(key.UserID, key.Key); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.db.Exec(`update idempotency_keys set created_at = ? where user_id = ? and idempotency_key = ?;`, time.Now().Add(-2*idempotencyReservationTTL), key.UserID, key.Key); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := keyRepo.reserveIdempotencyKey(key.UserID, key.Key, "hash"); !errors.Is(err, errIdempotencyKeyPosted) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		txs, _, err := repo.getAccountTransactions(account.ID, transactionSearchParams{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(txs) != 1 || txs[0].ID != posted.ID {
+			t.Errorf("unexpected transactions: %#v", txs)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
@@ -0,0 +1,213 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+func TestIdempotency__readIdempotencyKey(t *testing.T) {
+	req := httptest.NewRequest("POST", "/accounts/transactions", nil)
+	if key := readIdempotencyKey(req); key != "" {
+		t.Errorf("unexpected key: %q", key)
+	}
+	req.Header.Set("X-Idempotency-Key", "older")
+	if key := readIdempotencyKey(req); key != "older" {
+		t.Errorf("unexpected key: %q", key)
+	}
+	req.Header.Set("Idempotency-Key", " newer ")
+	if key := readIdempotencyKey(req); key != "newer" {
+		t.Errorf("unexpected key: %q", key)
+	}
+}
+
+func TestIdempotency__createTransaction(t *testing.T) {
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+
+	transactionRepo := &mockTransactionRepository{}
+	accountRepo := &testAccountRepository{
+		accounts: []*accounts.Account{{ID: "a", Status: "open"}, {ID: "b", Status: "open"}},
+	}
+
+	router := mux.NewRouter()
+	addIdempotencyMiddleware(log.NewNopLogger(), router, setupSqlIdempotencyStorage(log.NewNopLogger(), sqliteDB.DB))
+	addTransactionRoutes(log.NewNopLogger(), router, accountRepo, transactionRepo)
+
+	post := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/accounts/transactions", strings.NewReader(body))
+		req.Header.Set("x-user-id", "test")
+		req.Header.Set("Idempotency-Key", key)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		w.Flush()
+		return w
+	}
+
+	key := base.ID()
+	body := `{"lines": [{"accountId": "a", "purpose": "ACHDebit", "amount": 1000}, {"accountId": "b", "purpose": "ACHCredit", "amount": 1000}]}`
+
+	w := post(key, body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	var first transaction
+	if err := json.NewDecoder(w.Body).Decode(&first); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replay returns the original response without posting again
+	transactionRepo.created = transaction{}
+	w = post(key, body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("expected replayed response")
+	}
+	var second transaction
+	if err := json.NewDecoder(w.Body).Decode(&second); err != nil {
+		t.Fatal(err)
+	}
+	if first.ID != second.ID {
+		t.Errorf("first=%s second=%s", first.ID, second.ID)
+	}
+	if transactionRepo.created.ID != "" {
+		t.Errorf("transaction was posted again: %#v", transactionRepo.created)
+	}
+
+	// Different request with the same key
+	w = post(key, strings.Replace(body, "1000", "2000", -1))
+	if w.Code != http.StatusConflict {
+		t.Errorf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+
+	// Failed requests can be retried with their key
+	other := base.ID()
+	if w := post(other, `{"lines": []}`); w.Code != http.StatusBadRequest {
+		t.Errorf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	if w := post(other, body); w.Code != http.StatusOK {
+		t.Errorf("bogus status code: %d: %s", w.Code, w.Body.String())
+	}
+	if transactionRepo.created.ID == "" {
+		t.Error("expected posted transaction")
+	}
+}
+
+func TestIdempotency__otherRoutes(t *testing.T) {
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+
+	transactionRepo := &mockTransactionRepository{
+		transactions: []transaction{
+			{
+				ID:        base.ID(),
+				Timestamp: time.Now(),
+				Lines: []transactionLine{
+					{AccountID: "a", Purpose: ACHDebit, Direction: Debit, Amount: 1000},
+					{AccountID: "b", Purpose: ACHCredit, Direction: Credit, Amount: 1000},
+				},
+			},
+		},
+	}
+
+	router := mux.NewRouter()
+	addIdempotencyMiddleware(log.NewNopLogger(), router, setupSqlIdempotencyStorage(log.NewNopLogger(), sqliteDB.DB))
+	addTransactionRoutes(log.NewNopLogger(), router, &testAccountRepository{}, transactionRepo)
+
+	// Transaction searches are not idempotent routes, so they're never replayed
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "/accounts/a/transactions", nil)
+		req.Header.Set("x-user-id", "test")
+		req.Header.Set("Idempotency-Key", "key")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		w.Flush()
+
+		if w.Code != http.StatusOK || w.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("bogus status code: %d", w.Code)
+		}
+	}
+
+	// Reversals with the same key are only posted once
+	var reversals []string
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("POST", "/accounts/transactions/foo/reversal", nil)
+		req.Header.Set("x-user-id", "test")
+		req.Header.Set("Idempotency-Key", "key")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		w.Flush()
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("bogus status code: %d: %s", w.Code, w.Body.String())
+		}
+		var tx transaction
+		if err := json.NewDecoder(w.Body).Decode(&tx); err != nil {
+			t.Fatal(err)
+		}
+		reversals = append(reversals, tx.ID)
+	}
+	if reversals[0] != reversals[1] {
+		t.Errorf("reversals: %v", reversals)
+	}
+}
+
+func TestIdempotency__panic(t *testing.T) {
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+
+	panics := true
+	router := mux.NewRouter()
+	addIdempotencyMiddleware(log.NewNopLogger(), router, setupSqlIdempotencyStorage(log.NewNopLogger(), sqliteDB.DB))
+	router.Methods("POST").Path("/accounts").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if panics {
+			panic("boom")
+		}
+		// Handlers can link what they post to the reserved key
+		if key := readReservedIdempotencyKey(r); key == nil || key.UserID != "test" || key.Key != "key" {
+			t.Errorf("unexpected reserved key: %#v", key)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	post := func() (w *httptest.ResponseRecorder, recovered interface{}) {
+		defer func() {
+			recovered = recover()
+		}()
+		req := httptest.NewRequest("POST", "/accounts", strings.NewReader(`{}`))
+		req.Header.Set("x-user-id", "test")
+		req.Header.Set("Idempotency-Key", "key")
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w, nil
+	}
+
+	// Keys of requests which panic are released so they can be retried
+	if _, recovered := post(); recovered == nil {
+		t.Fatal("expected panic")
+	}
+	panics = false
+	if w, _ := post(); w.Code != http.StatusOK || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("bogus status code: %d", w.Code)
+	}
+}
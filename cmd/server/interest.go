@@ -0,0 +1,501 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+
+	"github.com/go-kit/kit/log"
+	"gopkg.in/yaml.v2"
+)
+
+// DayCountConvention decides how much of a year each day's interest accrues for
+type DayCountConvention string
+
+var (
+	// Actual365 accrues 1/365th of a year every day, including leap days
+	Actual365 DayCountConvention = "actual/365"
+	// Actual360 accrues 1/360th of a year every day
+	Actual360 DayCountConvention = "actual/360"
+	// Thirty360 treats every month as 30 days of a 360 day year. The 31st accrues nothing and the
+	// last day of February accrues for the rest of the month.
+	Thirty360 DayCountConvention = "30/360"
+)
+
+func (dc DayCountConvention) validate() error {
+	switch dc {
+	case Actual365, Actual360, Thirty360:
+		return nil
+	default:
+		return fmt.Errorf("unknown dayCount %q", dc)
+	}
+}
+
+// yearFraction returns the fraction of a year which interest accrues for on day
+func (dc DayCountConvention) yearFraction(day time.Time) float64 {
+	switch dc {
+	case Actual360:
+		return 1.0 / 360
+	case Thirty360:
+		if day.Day() == 31 {
+			return 0
+		}
+		if day.Month() == time.February && day.AddDate(0, 0, 1).Month() != time.February {
+			return float64(30-day.Day()+1) / 360
+		}
+		return 1.0 / 360
+	default:
+		return 1.0 / 365
+	}
+}
+
+// interestTier is the APY paid on balances of at least MinBalance (in cents). APYs are fractions, so 0.015 is 1.5%.
+type interestTier struct {
+	MinBalance int64   `yaml:"minBalance"`
+	APY        float64 `yaml:"apy"`
+}
+
+// interestProduct pays interest on customer accounts of AccountType. Interest accrues daily on each day's closing
+// balance and is posted monthly from the Expense GL account. The highest tier the balance qualifies for applies
+// to the whole balance.
+type interestProduct struct {
+	Name        string             `yaml:"name"`
+	AccountType string             `yaml:"accountType"`
+	DayCount    DayCountConvention `yaml:"dayCount"`
+	Expense     string             `yaml:"expense"`
+	Tiers       []interestTier     `yaml:"tiers"`
+}
+
+// normalize lowercases and trims values read from YAML and reads the GL code
+func (p *interestProduct) normalize() error {
+	p.Name = strings.TrimSpace(p.Name)
+	p.AccountType = strings.ToLower(strings.TrimSpace(p.AccountType))
+	p.DayCount = DayCountConvention(strings.ToLower(strings.TrimSpace(string(p.DayCount))))
+	if p.DayCount == "" {
+		p.DayCount = Actual365
+	}
+	var err error
+	if p.Expense, err = readGLCode(p.Expense); err != nil {
+		return fmt.Errorf("expense: %w", err)
+	}
+	return nil
+}
+
+func (p interestProduct) validate() error {
+	if p.Name == "" {
+		return errors.New("missing name")
+	}
+	if !validCustomerAccountType(p.AccountType) {
+		return fmt.Errorf("unknown accountType %q", p.AccountType)
+	}
+	if err := p.DayCount.validate(); err != nil {
+		return err
+	}
+	if len(p.Tiers) == 0 {
+		return errors.New("no tiers")
+	}
+	for i := range p.Tiers {
+		if p.Tiers[i].APY < 0 || p.Tiers[i].APY >= 1 {
+			return fmt.Errorf("tier[%d] APY %v must be in [0, 1)", i, p.Tiers[i].APY)
+		}
+		if i > 0 && p.Tiers[i].MinBalance <= p.Tiers[i-1].MinBalance {
+			return fmt.Errorf("tier[%d] minBalance must be greater than the tier before it", i)
+		}
+	}
+	return nil
+}
+
+// apy returns the APY paid on balance, which is zero for balances below every tier
+func (p interestProduct) apy(balance int64) float64 {
+	if balance <= 0 {
+		return 0
+	}
+	var apy float64
+	for i := range p.Tiers {
+		if balance >= p.Tiers[i].MinBalance {
+			apy = p.Tiers[i].APY
+		}
+	}
+	return apy
+}
+
+// accrue returns the interest, in millionths of a cent, earned by balance for day. The APY is converted to a
+// daily rate, so a balance which compounds with each posting earns close to the APY.
+func (p interestProduct) accrue(balance int64, day time.Time) interestAccrual {
+	apy := p.apy(balance)
+	accrual := interestAccrual{
+		Date:    day,
+		Balance: balance,
+		APY:     apy,
+	}
+	if apy > 0 {
+		rate := math.Pow(1+apy, p.DayCount.yearFraction(day)) - 1
+		accrual.AmountMicros = int64(math.Round(float64(balance) * rate * 1e6))
+	}
+	return accrual
+}
+
+// interestProducts are the interest products offered under each routing number. The first product
+// for an account's type is applied.
+type interestProducts map[string][]interestProduct
+
+// readInterestProducts parses and validates interest products in YAML keyed by routing number:
+//
+//	"121042882":
+//	  - name: High yield savings
+//	    accountType: savings
+//	    dayCount: actual/365
+//	    expense: RIAD0093
+//	    tiers:
+//	      - minBalance: 0
+//	        apy: 0.005
+//	      - minBalance: 1000000
+//	        apy: 0.015
+func readInterestProducts(r io.Reader) (interestProducts, error) {
+	bs, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var products interestProducts
+	if err := yaml.UnmarshalStrict(bs, &products); err != nil {
+		return nil, fmt.Errorf("interest products: %w", err)
+	}
+	for routingNumber := range products {
+		if !routingNumberRegex.MatchString(routingNumber) {
+			return nil, fmt.Errorf("interest products: invalid routing number %q", routingNumber)
+		}
+		for i := range products[routingNumber] {
+			if err := products[routingNumber][i].normalize(); err != nil {
+				return nil, fmt.Errorf("interest products: routingNumber=%s product[%d]: %w", routingNumber, i, err)
+			}
+			if err := products[routingNumber][i].validate(); err != nil {
+				return nil, fmt.Errorf("interest products: routingNumber=%s product[%d]: %w", routingNumber, i, err)
+			}
+		}
+	}
+	return products, nil
+}
+
+// readInterestProductsFile returns the interest products from a YAML file, or no products when path is empty
+func readInterestProductsFile(path string) (interestProducts, error) {
+	if path == "" {
+		return nil, nil
+	}
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return readInterestProducts(bytes.NewReader(bs))
+}
+
+// productFor returns the interest product which applies to acct, or nil if it doesn't earn interest. When plan
+// is set the interest product with that name applies, otherwise the one for the account's type.
+func (products interestProducts) productFor(acct *accounts.Account, plan string) *interestProduct {
+	for i := range products[acct.RoutingNumber] {
+		p := &products[acct.RoutingNumber][i]
+		if plan != "" && strings.EqualFold(p.Name, plan) || plan == "" && strings.EqualFold(p.AccountType, acct.Type) {
+			return p
+		}
+	}
+	return nil
+}
+
+// interestAccrual is the interest an account earned for one day (UTC) on its closing balance. Amounts are in
+// millionths of a cent so that small daily accruals add up without rounding. TransactionID is set once
+// the accrual has been posted. Days whose balance changed after being accrued get an adjusting accrual of the
+// difference on the new balance, which posts with the next interest posting.
+type interestAccrual struct {
+	AccountID     string    `json:"accountId"`
+	Date          time.Time `json:"date"`
+	Balance       int64     `json:"balance"`
+	APY           float64   `json:"apy"`
+	AmountMicros  int64     `json:"amountMicros"`
+	TransactionID string    `json:"transactionId,omitempty"`
+}
+
+// microsToCents rounds an amount in millionths of a cent to the nearest cent
+func microsToCents(micros int64) int64 {
+	if micros < 0 {
+		return -microsToCents(-micros)
+	}
+	return (micros + 500000) / 1000000
+}
+
+func startOfDay(when time.Time) time.Time {
+	when = when.UTC()
+	return time.Date(when.Year(), when.Month(), when.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// accrueAccountInterest stores an accrual for each day of [from, to) the account was open. Days which
+// were already accrued are adjusted if their balance changed, and skipped otherwise.
+func accrueAccountInterest(repo interestRepository, product *interestProduct, acct *accounts.Account, from, to time.Time) (int, error) {
+	if created := startOfDay(acct.CreatedAt); from.Before(created) {
+		from = created
+	}
+	if !acct.ClosedAt.IsZero() {
+		if closed := startOfDay(acct.ClosedAt).AddDate(0, 0, 1); to.After(closed) {
+			to = closed
+		}
+	}
+	accrued := 0
+	for from.Before(to) {
+		end := from.AddDate(0, 0, maxDailyBalanceDays)
+		if end.After(to) {
+			end = to
+		}
+		balances, err := repo.getDailyBalances(acct.ID, from, end)
+		if err != nil {
+			return accrued, err
+		}
+		accruals := make([]interestAccrual, len(balances))
+		for i := range balances {
+			day, _ := time.Parse("2006-01-02", balances[i].Date)
+			accruals[i] = product.accrue(balances[i].Closing, day)
+			accruals[i].AccountID = acct.ID
+		}
+		n, err := repo.saveInterestAccruals(accruals)
+		if err != nil {
+			return accrued, err
+		}
+		accrued += n
+		from = end
+	}
+	return accrued, nil
+}
+
+// accrueInterest accrues interest for every day before to of each account which earns interest. When from
+// is zero each account resumes after its last accrual, or from the earliest day changed by a line posted after
+// it was accrued, otherwise days from from are backfilled.
+// accountID, when set, limits accruals to that account.
+func accrueInterest(logger log.Logger, accountRepo accountRepository, repo interestRepository, products interestProducts, accountID string, from, to time.Time) (int, error) {
+	accountProducts, err := repo.getAccountProducts()
+	if err != nil {
+		return 0, err
+	}
+	catalog := newProductCatalog(accountProducts)
+	accrued := 0
+	err = forEachAccount(accountRepo, to, func(acct *accounts.Account) error {
+		if accountID != "" && acct.ID != accountID {
+			return nil
+		}
+		product := products.productFor(acct, catalog.interestPlan(acct.Type))
+		if product == nil {
+			return nil
+		}
+		start := from
+		if start.IsZero() {
+			last, err := repo.lastInterestAccrual(acct.ID)
+			if err != nil {
+				return err
+			}
+			if !last.IsZero() {
+				start = last.AddDate(0, 0, 1)
+
+				// Days which changed after being accrued, such as from backdated postings, are accrued again
+				changed, err := repo.earliestUnaccruedLine(acct.ID, start)
+				if err != nil {
+					return err
+				}
+				if !changed.IsZero() {
+					start = startOfDay(changed)
+				}
+			}
+		}
+		n, err := accrueAccountInterest(repo, product, acct, start, to)
+		if err != nil {
+			logger.Log("interest", fmt.Sprintf("problem accruing interest for account=%s: %v", acct.ID, err))
+			return nil
+		}
+		accrued += n
+		return nil
+	})
+	return accrued, err
+}
+
+// postInterestBefore posts the unposted accruals from before end of each account which earns interest.
+// accountID, when set, limits posting to that account.
+func postInterestBefore(logger log.Logger, accountRepo accountRepository, repo interestRepository, products interestProducts, accountID string, end time.Time) (int, error) {
+	accountProducts, err := repo.getAccountProducts()
+	if err != nil {
+		return 0, err
+	}
+	catalog := newProductCatalog(accountProducts)
+	posted := 0
+	err = forEachAccount(accountRepo, end, func(acct *accounts.Account) error {
+		if accountID != "" && acct.ID != accountID {
+			return nil
+		}
+		product := products.productFor(acct, catalog.interestPlan(acct.Type))
+		if product == nil {
+			return nil
+		}
+		t, err := repo.postInterest(acct, glAccountID(acct.RoutingNumber, product.Expense), end)
+		if err != nil {
+			logger.Log("interest", fmt.Sprintf("problem posting interest for account=%s: %v", acct.ID, err))
+			return nil
+		}
+		if t != nil {
+			logger.Log("interest", fmt.Sprintf("posted interest transaction=%s for account=%s", t.ID, acct.ID))
+			posted++
+		}
+		return nil
+	})
+	return posted, err
+}
+
+// accrueInterestEvery periodically accrues interest through yesterday and posts the accruals of every
+// month which has ended until ctx is done.
+func accrueInterestEvery(ctx context.Context, logger log.Logger, accountRepo accountRepository, repo interestRepository, products interestProducts, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			today := startOfDay(now)
+			if n, err := accrueInterest(logger, accountRepo, repo, products, "", time.Time{}, today); err != nil {
+				logger.Log("interest", fmt.Sprintf("problem accruing interest: %v", err))
+			} else if n > 0 {
+				logger.Log("interest", fmt.Sprintf("accrued %d days of interest", n))
+			}
+			thisMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+			if n, err := postInterestBefore(logger, accountRepo, repo, products, "", thisMonth); err != nil {
+				logger.Log("interest", fmt.Sprintf("problem posting interest: %v", err))
+			} else if n > 0 {
+				logger.Log("interest", fmt.Sprintf("posted interest to %d accounts", n))
+			}
+		}
+	}
+}
+
+type interestRun struct {
+	Accrued int `json:"accrued"`
+	Posted  int `json:"posted"`
+}
+
+// addInterestRoutes registers the admin endpoints for backfilling interest accruals and postings
+func addInterestRoutes(logger log.Logger, handle func(string, http.HandlerFunc), accountRepo accountRepository, repo interestRepository, products interestProducts) {
+	handle("/interest/accrue", backfillInterestAccruals(logger, accountRepo, repo, products))
+	handle("/interest/post", postMonthlyInterest(logger, accountRepo, repo, products))
+	handle("/interest/accruals", getInterestAccruals(logger, repo))
+}
+
+func writeInterestRun(w http.ResponseWriter, run interestRun) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(run)
+}
+
+// backfillInterestAccruals accrues interest for each day in [from, to] (YYYY-MM-DD). Days which were
+// already accrued are only adjusted if their balance changed, so backfills can be rerun.
+func backfillInterestAccruals(logger log.Logger, accountRepo accountRepository, repo interestRepository, products interestProducts) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, fmt.Sprintf("unsupported HTTP verb %s", r.Method), http.StatusBadRequest)
+			return
+		}
+		q := r.URL.Query()
+		from, err := time.Parse("2006-01-02", q.Get("from"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid from %q", q.Get("from")), http.StatusBadRequest)
+			return
+		}
+		to, err := time.Parse("2006-01-02", q.Get("to"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid to %q", q.Get("to")), http.StatusBadRequest)
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+		if !from.Before(to) || to.After(startOfDay(time.Now())) {
+			http.Error(w, "from must not be after to, and to must be before today", http.StatusBadRequest)
+			return
+		}
+
+		n, err := accrueInterest(logger, accountRepo, repo, products, q.Get("accountId"), from, to)
+		if err != nil {
+			logger.Log("interest", fmt.Sprintf("problem backfilling interest accruals: %v", err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		logger.Log("interest", fmt.Sprintf("backfilled %d days of interest from %s to %s", n, q.Get("from"), q.Get("to")))
+		writeInterestRun(w, interestRun{Accrued: n})
+	}
+}
+
+// postMonthlyInterest posts the unposted accruals up to the end of month (YYYY-MM), which must have ended.
+func postMonthlyInterest(logger log.Logger, accountRepo accountRepository, repo interestRepository, products interestProducts) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, fmt.Sprintf("unsupported HTTP verb %s", r.Method), http.StatusBadRequest)
+			return
+		}
+		q := r.URL.Query()
+		period, err := readAccountingPeriod(MonthlyPeriod, q.Get("month"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if period.End.After(time.Now()) {
+			http.Error(w, errPeriodNotEnded.Error(), http.StatusBadRequest)
+			return
+		}
+
+		n, err := postInterestBefore(logger, accountRepo, repo, products, q.Get("accountId"), period.End)
+		if err != nil {
+			logger.Log("interest", fmt.Sprintf("problem posting interest for %s: %v", period.ID, err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeInterestRun(w, interestRun{Posted: n})
+	}
+}
+
+func getInterestAccruals(logger log.Logger, repo interestRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			http.Error(w, fmt.Sprintf("unsupported HTTP verb %s", r.Method), http.StatusBadRequest)
+			return
+		}
+		q := r.URL.Query()
+		accountID := q.Get("accountId")
+		if accountID == "" {
+			http.Error(w, "missing accountId", http.StatusBadRequest)
+			return
+		}
+		from, err := time.Parse("2006-01-02", q.Get("from"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid from %q", q.Get("from")), http.StatusBadRequest)
+			return
+		}
+		to, err := time.Parse("2006-01-02", q.Get("to"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid to %q", q.Get("to")), http.StatusBadRequest)
+			return
+		}
+
+		accruals, err := repo.getInterestAccruals(accountID, from, to.AddDate(0, 0, 1))
+		if err != nil {
+			logger.Log("interest", fmt.Sprintf("problem reading account=%s interest accruals: %v", accountID, err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(accruals)
+	}
+}
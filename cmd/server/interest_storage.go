@@ -0,0 +1,40 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+)
+
+type interestRepository interface {
+	// getDailyBalances returns the balance history of an account for each day in [from, to)
+	getDailyBalances(accountID string, from, to time.Time) ([]dailyBalance, error)
+	// getAccountProducts returns every product in the catalog so accounts can use their product's interest plan
+	getAccountProducts() ([]accountProduct, error)
+
+	// lastInterestAccrual returns the date of the latest accrual for accountID, or a zero time if it has none
+	lastInterestAccrual(accountID string) (time.Time, error)
+	// earliestUnaccruedLine returns the effective date of the earliest line of accountID before before which
+	// was posted after its day was accrued, such as a backdated posting, or a zero time if there isn't one.
+	earliestUnaccruedLine(accountID string, before time.Time) (time.Time, error)
+	// saveInterestAccruals stores the accruals of one account for consecutive days and returns how many were
+	// saved. Days which have already been accrued get an adjusting accrual when their amount changed, and are
+	// skipped otherwise so backfills can be rerun.
+	saveInterestAccruals(accruals []interestAccrual) (int, error)
+	// getInterestAccruals returns the accruals of accountID for each day in [from, to), oldest first. Adjusting
+	// accruals follow the accrual they adjust.
+	getInterestAccruals(accountID string, from, to time.Time) ([]interestAccrual, error)
+
+	// postInterest credits account with its unposted accruals from before end, drawn from the expenseAccountID
+	// GL account, and marks them as posted. A nil transaction is returned when there's nothing to post.
+	postInterest(account *accounts.Account, expenseAccountID string, end time.Time) (*transaction, error)
+}
+
+var (
+	errInterestAccrualsChanged = errors.New("interest accruals or balances changed while saving")
+)
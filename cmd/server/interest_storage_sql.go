@@ -0,0 +1,257 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+)
+
+type sqlInterestRepository struct {
+	db     *sql.DB
+	logger log.Logger
+
+	transactionRepo *sqlTransactionRepository
+	productRepo     *sqlProductRepository
+}
+
+func setupSqlInterestStorage(logger log.Logger, transactionRepo *sqlTransactionRepository) *sqlInterestRepository {
+	return &sqlInterestRepository{
+		db:              transactionRepo.db,
+		logger:          logger,
+		transactionRepo: transactionRepo,
+		productRepo:     setupSqlProductStorage(logger, transactionRepo.db),
+	}
+}
+
+func (r *sqlInterestRepository) getDailyBalances(accountID string, from, to time.Time) ([]dailyBalance, error) {
+	return r.transactionRepo.getDailyBalances(accountID, from, to)
+}
+
+func (r *sqlInterestRepository) getAccountProducts() ([]accountProduct, error) {
+	return r.productRepo.getAccountProducts()
+}
+
+func (r *sqlInterestRepository) lastInterestAccrual(accountID string) (time.Time, error) {
+	stmt, err := r.db.Prepare(`select accrual_date from interest_accruals where account_id = ? order by accrual_date desc limit 1;`)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("lastInterestAccrual: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	var last time.Time
+	if err := stmt.QueryRow(accountID).Scan(&last); err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("lastInterestAccrual: account=%s: %w", accountID, err)
+	}
+	return last.UTC(), nil
+}
+
+// earliestUnaccruedLine returns the effective date of the earliest line of accountID before before which was
+// posted after its day was accrued, or a zero time if there isn't one.
+func (r *sqlInterestRepository) earliestUnaccruedLine(accountID string, before time.Time) (time.Time, error) {
+	query := `select t.effective_date from transaction_lines l inner join transactions t on t.transaction_id = l.transaction_id
+where l.account_id = ? and t.effective_date < ? and l.interest_accrued_at is null and l.deleted_at is null and t.deleted_at is null
+order by t.effective_date asc limit 1;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("earliestUnaccruedLine: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	var effective time.Time
+	if err := stmt.QueryRow(accountID, before.UTC()).Scan(&effective); err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("earliestUnaccruedLine: account=%s: %w", accountID, err)
+	}
+	return effective.UTC(), nil
+}
+
+// saveInterestAccruals stores the accruals of one account for consecutive days. Days which were accrued already
+// are given an adjusting accrual of the difference when their amount changed, so posted accruals are never
+// rewritten. Lines of the account effective before the last day ends are marked as accrued, and must sum to its
+// balance or else something was posted since the accruals were computed and errInterestAccrualsChanged is returned.
+func (r *sqlInterestRepository) saveInterestAccruals(accruals []interestAccrual) (int, error) {
+	if len(accruals) == 0 {
+		return 0, nil
+	}
+	accountID, end := accruals[0].AccountID, accruals[len(accruals)-1].Date.AddDate(0, 0, 1)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("saveInterestAccruals: tx.Begin: %w", err)
+	}
+	existing, err := tx.Prepare(`select count(*), coalesce(sum(amount_micros), 0) from interest_accruals where account_id = ? and accrual_date = ?;`)
+	if err != nil {
+		return 0, fmt.Errorf("saveInterestAccruals: prepare: error=%w rollback=%v", err, tx.Rollback())
+	}
+	defer existing.Close()
+	stmt, err := tx.Prepare(`insert into interest_accruals(account_id, accrual_date, revision, balance, apy, amount_micros, created_at) values (?, ?, ?, ?, ?, ?, ?);`)
+	if err != nil {
+		return 0, fmt.Errorf("saveInterestAccruals: prepare insert: error=%w rollback=%v", err, tx.Rollback())
+	}
+	defer stmt.Close()
+
+	saved := 0
+	for i := range accruals {
+		a := accruals[i]
+		var revisions, micros int64
+		if err := existing.QueryRow(a.AccountID, a.Date.UTC()).Scan(&revisions, &micros); err != nil {
+			return 0, fmt.Errorf("saveInterestAccruals: account=%s date=%s: error=%w rollback=%v", a.AccountID, a.Date.Format("2006-01-02"), err, tx.Rollback())
+		}
+		if revisions > 0 && micros == a.AmountMicros {
+			continue // already accrued
+		}
+		if _, err := stmt.Exec(a.AccountID, a.Date.UTC(), revisions, a.Balance, a.APY, a.AmountMicros-micros, time.Now()); err != nil {
+			if database.UniqueViolation(err) {
+				err = errInterestAccrualsChanged // accrued concurrently
+			}
+			return 0, fmt.Errorf("saveInterestAccruals: account=%s date=%s: %w rollback=%v", a.AccountID, a.Date.Format("2006-01-02"), err, tx.Rollback())
+		}
+		saved++
+	}
+
+	query := `update transaction_lines set interest_accrued_at = ? where account_id = ? and interest_accrued_at is null and deleted_at is null
+and transaction_id in (select transaction_id from transactions where effective_date < ? and deleted_at is null);`
+	update, err := tx.Prepare(query)
+	if err != nil {
+		return 0, fmt.Errorf("saveInterestAccruals: prepare update: error=%w rollback=%v", err, tx.Rollback())
+	}
+	_, err = update.Exec(time.Now(), accountID, end)
+	update.Close()
+	if err != nil {
+		return 0, fmt.Errorf("saveInterestAccruals: account=%s update: error=%w rollback=%v", accountID, err, tx.Rollback())
+	}
+	balance, err := readBalanceAsOf(tx, accountID, end)
+	if err != nil {
+		return 0, fmt.Errorf("saveInterestAccruals: account=%s balance: error=%w rollback=%v", accountID, err, tx.Rollback())
+	}
+	if last := accruals[len(accruals)-1]; balance != last.Balance {
+		return 0, fmt.Errorf("saveInterestAccruals: account=%s balance=%d accrued=%d: %w rollback=%v", accountID, balance, last.Balance, errInterestAccrualsChanged, tx.Rollback())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("saveInterestAccruals: commit: %w", err)
+	}
+	return saved, nil
+}
+
+func (r *sqlInterestRepository) getInterestAccruals(accountID string, from, to time.Time) ([]interestAccrual, error) {
+	query := `select account_id, accrual_date, balance, apy, amount_micros, transaction_id from interest_accruals
+where account_id = ? and accrual_date >= ? and accrual_date < ? order by accrual_date asc, revision asc;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("getInterestAccruals: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(accountID, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("getInterestAccruals: query: %w", err)
+	}
+	defer rows.Close()
+
+	var accruals []interestAccrual
+	for rows.Next() {
+		var a interestAccrual
+		var transactionID sql.NullString
+		if err := rows.Scan(&a.AccountID, &a.Date, &a.Balance, &a.APY, &a.AmountMicros, &transactionID); err != nil {
+			return nil, fmt.Errorf("getInterestAccruals: scan account=%s: %w", accountID, err)
+		}
+		a.Date = a.Date.UTC()
+		a.TransactionID = transactionID.String
+		accruals = append(accruals, a)
+	}
+	return accruals, rows.Err()
+}
+
+// postInterest sums the unposted accruals of account before end and posts them as one Interest transaction
+// effective on the last second before end, or now when openPostingDate says that second can't be posted to.
+// Sums which round to less than a cent are left unposted so they carry forward into the next posting.
+func (r *sqlInterestRepository) postInterest(account *accounts.Account, expenseAccountID string, end time.Time) (*transaction, error) {
+	opts := createTransactionOpts{AllowGLDebits: true}
+	var out *transaction
+	err := withPostingRetries(func() error {
+		out = nil
+
+		tx, err := r.db.Begin()
+		if err != nil {
+			return fmt.Errorf("postInterest: tx.Begin: %w", err)
+		}
+		stmt, err := tx.Prepare(`select count(*), coalesce(sum(amount_micros), 0) from interest_accruals where account_id = ? and accrual_date < ? and transaction_id is null;`)
+		if err != nil {
+			return fmt.Errorf("postInterest: prepare: error=%w rollback=%v", err, tx.Rollback())
+		}
+		var count, micros int64
+		err = stmt.QueryRow(account.ID, end.UTC()).Scan(&count, &micros)
+		stmt.Close()
+		if err != nil {
+			return fmt.Errorf("postInterest: account=%s: error=%w rollback=%v", account.ID, err, tx.Rollback())
+		}
+		amount := microsToCents(micros)
+		if count == 0 || amount <= 0 {
+			return tx.Rollback()
+		}
+		if err := checkJournalGLAccount(tx, expenseAccountID); err != nil {
+			return fmt.Errorf("postInterest: account=%s: error=%w rollback=%v", account.ID, err, tx.Rollback())
+		}
+
+		now := time.Now()
+		effectiveDate, err := openPostingDate(tx, end.Add(-1*time.Second), now)
+		if err != nil {
+			return fmt.Errorf("postInterest: account=%s: error=%w rollback=%v", account.ID, err, tx.Rollback())
+		}
+		t := transaction{
+			ID:            base.ID(),
+			Timestamp:     now,
+			EffectiveDate: effectiveDate,
+			Lines: []transactionLine{
+				{AccountID: expenseAccountID, Purpose: Interest, Direction: Debit, Amount: amount},
+				{AccountID: account.ID, Purpose: Interest, Direction: Credit, Amount: amount},
+			},
+		}
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("postInterest: account=%s: error=%w rollback=%v", account.ID, err, tx.Rollback())
+		}
+		if err := checkAccountStatuses([]*accounts.Account{account}, t.Lines, opts); err != nil {
+			return fmt.Errorf("postInterest: account=%s: error=%w rollback=%v", account.ID, err, tx.Rollback())
+		}
+		if err := r.transactionRepo.insertTransaction(tx, t, opts, []*accounts.Account{account}); err != nil {
+			return fmt.Errorf("postInterest: account=%s: %w rollback=%v", account.ID, err, tx.Rollback())
+		}
+
+		// Mark the accruals we summed as posted, unless they changed since being read
+		stmt, err = tx.Prepare(`update interest_accruals set transaction_id = ? where account_id = ? and accrual_date < ? and transaction_id is null;`)
+		if err != nil {
+			return fmt.Errorf("postInterest: prepare update: error=%w rollback=%v", err, tx.Rollback())
+		}
+		res, err := stmt.Exec(t.ID, account.ID, end.UTC())
+		stmt.Close()
+		if err != nil {
+			return fmt.Errorf("postInterest: account=%s update: error=%w rollback=%v", account.ID, err, tx.Rollback())
+		}
+		if n, _ := res.RowsAffected(); n != count {
+			return fmt.Errorf("postInterest: account=%s: %w rollback=%v", account.ID, errInterestAccrualsChanged, tx.Rollback())
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("postInterest: commit: %w", err)
+		}
+		out = &t
+		return nil
+	})
+	return out, err
+}
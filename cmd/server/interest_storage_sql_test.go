@@ -0,0 +1,176 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestSqlInterestRepository__interest(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, db *sql.DB) {
+		repo := createTestSqlTransactionRepository(t, db)
+		glRepo := setupSqlGLAccountStorage(log.NewNopLogger(), db, repo.accountRepo)
+		defer repo.Close()
+		interestRepo := setupSqlInterestStorage(log.NewNopLogger(), repo)
+
+		routingNumber := "121042882"
+		expense, err := createGLAccountRequest{Code: "0093", Name: "Interest on savings deposits", Category: GLExpense}.asGLAccount(routingNumber, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := glRepo.createGLAccount(expense); err != nil {
+			t.Fatal(err)
+		}
+
+		created := time.Date(2020, time.January, 1, 10, 0, 0, 0, time.UTC)
+		savings := &accounts.Account{ID: base.ID(), Name: "Savings", AccountNumber: "123", RoutingNumber: routingNumber, Status: "open", Type: "Savings", CreatedAt: created}
+		checking := &accounts.Account{ID: base.ID(), Name: "Checking", AccountNumber: "456", RoutingNumber: routingNumber, Status: "open", Type: "Checking", CreatedAt: created}
+		accountRepo := &testAccountRepository{accounts: []*accounts.Account{savings, checking}}
+		repo.accountRepo = accountRepo
+
+		err = repo.createTransaction(transaction{
+			ID:            base.ID(),
+			Timestamp:     time.Now(),
+			EffectiveDate: created.Add(2 * time.Hour),
+			Lines: []transactionLine{
+				{AccountID: savings.ID, Purpose: ACHCredit, Direction: Credit, Amount: 1000000},
+				{AccountID: base.ID(), Purpose: ACHCredit, Direction: Debit, Amount: 1000000},
+			},
+		}, createTransactionOpts{AllowOverdraft: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		products := interestProducts{
+			routingNumber: []interestProduct{{Name: "Savings", AccountType: "savings", DayCount: Actual365, Expense: "0093", Tiers: []interestTier{{APY: 0.01}}}},
+		}
+		february := time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)
+		n, err := accrueInterest(log.NewNopLogger(), accountRepo, interestRepo, products, "", time.Time{}, february)
+		if err != nil || n != 31 {
+			t.Fatalf("accrued %d days: %v", n, err)
+		}
+		if n, err := accrueInterest(log.NewNopLogger(), accountRepo, interestRepo, products, "", time.Time{}, february); err != nil || n != 0 {
+			t.Errorf("accrued %d days again: %v", n, err)
+		}
+		if n, err := accrueInterest(log.NewNopLogger(), accountRepo, interestRepo, products, "", created, february); err != nil || n != 0 {
+			t.Errorf("backfilled %d days: %v", n, err)
+		}
+		if last, err := interestRepo.lastInterestAccrual(savings.ID); err != nil || !last.Equal(february.AddDate(0, 0, -1)) {
+			t.Errorf("last accrual %v: %v", last, err)
+		}
+
+		accruals, err := interestRepo.getInterestAccruals(savings.ID, created.Add(-10*time.Hour), february)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var micros int64
+		for i := range accruals {
+			micros += accruals[i].AmountMicros
+		}
+		if len(accruals) != 31 || accruals[0].Balance != 1000000 || accruals[0].APY != 0.01 || micros == 0 {
+			t.Fatalf("unexpected accruals: %#v", accruals)
+		}
+
+		// January is closed, so its interest posts into the current month instead
+		periodRepo := setupSqlPeriodStorage(log.NewNopLogger(), db)
+		if _, err := periodRepo.closePeriod(newAccountingPeriod(MonthlyPeriod, created), "auditor"); err != nil {
+			t.Fatal(err)
+		}
+		n, err = postInterestBefore(log.NewNopLogger(), accountRepo, interestRepo, products, "", february)
+		if err != nil || n != 1 {
+			t.Fatalf("posted %d: %v", n, err)
+		}
+		if n, err := postInterestBefore(log.NewNopLogger(), accountRepo, interestRepo, products, "", february); err != nil || n != 0 {
+			t.Errorf("posted %d again: %v", n, err)
+		}
+
+		interest := microsToCents(micros)
+		if balance, err := repo.getAccountBalanceAsOf(savings.ID, february); err != nil || balance != 1000000 {
+			t.Errorf("closed period balance=%d: %v", balance, err)
+		}
+		if balance, err := repo.getAccountBalanceAsOf(savings.ID, time.Now()); err != nil || balance != 1000000+interest {
+			t.Errorf("savings balance=%d interest=%d: %v", balance, interest, err)
+		}
+		if balance, err := repo.getAccountBalanceAsOf(glAccountID(routingNumber, "0093"), time.Now()); err != nil || balance != -interest {
+			t.Errorf("expense balance=%d: %v", balance, err)
+		}
+		accruals, err = interestRepo.getInterestAccruals(savings.ID, created.Add(-10*time.Hour), february)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(accruals) != 31 || accruals[0].TransactionID == "" {
+			t.Errorf("accruals weren't marked as posted: %#v", accruals[0])
+		}
+		posted, err := repo.getTransaction(accruals[0].TransactionID)
+		if err != nil || posted == nil {
+			t.Fatalf("transaction=%v: %v", posted, err)
+		}
+		if posted.EffectiveDate.Before(time.Now().Add(-1*time.Minute)) || posted.Lines[0].Purpose != Interest {
+			t.Errorf("unexpected interest transaction: %#v", posted)
+		}
+
+		// Deposits backdated into days which were accrued already adjust their accruals
+		march := february.AddDate(0, 1, 0)
+		if n, err := accrueInterest(log.NewNopLogger(), accountRepo, interestRepo, products, "", time.Time{}, march); err != nil || n != 29 {
+			t.Fatalf("accrued %d days: %v", n, err)
+		}
+		err = repo.createTransaction(transaction{
+			ID:            base.ID(),
+			Timestamp:     time.Now(),
+			EffectiveDate: time.Date(2020, time.February, 10, 12, 0, 0, 0, time.UTC),
+			Lines: []transactionLine{
+				{AccountID: savings.ID, Purpose: ACHCredit, Direction: Credit, Amount: 500000},
+				{AccountID: base.ID(), Purpose: ACHCredit, Direction: Debit, Amount: 500000},
+			},
+		}, createTransactionOpts{AllowOverdraft: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, err := accrueInterest(log.NewNopLogger(), accountRepo, interestRepo, products, "", time.Time{}, march); err != nil || n != 20 {
+			t.Fatalf("adjusted %d days: %v", n, err)
+		}
+		if n, err := accrueInterest(log.NewNopLogger(), accountRepo, interestRepo, products, "", time.Time{}, march); err != nil || n != 0 {
+			t.Errorf("adjusted %d days again: %v", n, err)
+		}
+		accruals, err = interestRepo.getInterestAccruals(savings.ID, february, march)
+		if err != nil {
+			t.Fatal(err)
+		}
+		daily := make(map[time.Time]int64)
+		for i := range accruals {
+			daily[accruals[i].Date] += accruals[i].AmountMicros
+		}
+		if len(accruals) != 29+20 || len(daily) != 29 {
+			t.Fatalf("unexpected accruals: %#v", accruals)
+		}
+		for day, micros := range daily {
+			balance := int64(1000000)
+			if !day.Before(time.Date(2020, time.February, 10, 0, 0, 0, 0, time.UTC)) {
+				balance += 500000
+			}
+			if expected := products[routingNumber][0].accrue(balance, day).AmountMicros; micros != expected {
+				t.Errorf("%s accrued %d micros, expected %d", day.Format("2006-01-02"), micros, expected)
+			}
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, sqliteDB.DB)
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, mysqlDB.DB)
+}
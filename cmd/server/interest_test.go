@@ -0,0 +1,149 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+func TestInterest__readInterestProducts(t *testing.T) {
+	products, err := readInterestProducts(strings.NewReader(`
+"121042882":
+  - name: High yield savings
+    accountType: Savings
+    dayCount: 30/360
+    expense: RIAD0093
+    tiers:
+      - minBalance: 0
+        apy: 0.005
+      - minBalance: 1000000
+        apy: 0.015
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	product := products.productFor(&accounts.Account{RoutingNumber: "121042882", Type: "savings"}, "")
+	if product == nil || product.Expense != "0093" || product.DayCount != Thirty360 || product.AccountType != "savings" {
+		t.Fatalf("unexpected product: %#v", product)
+	}
+	if p := products.productFor(&accounts.Account{RoutingNumber: "121042882", Type: "checking"}, ""); p != nil {
+		t.Errorf("unexpected product: %#v", p)
+	}
+	// Account products can reference an interest product by name
+	if p := products.productFor(&accounts.Account{RoutingNumber: "121042882", Type: "checking"}, "high yield savings"); p != product {
+		t.Errorf("unexpected product: %#v", p)
+	}
+	if p := products.productFor(&accounts.Account{RoutingNumber: "121042882", Type: "savings"}, "Premier"); p != nil {
+		t.Errorf("unexpected product: %#v", p)
+	}
+	for balance, expected := range map[int64]float64{-100: 0, 0: 0, 500: 0.005, 1000000: 0.015} {
+		if apy := product.apy(balance); apy != expected {
+			t.Errorf("balance=%d: got APY %v", balance, apy)
+		}
+	}
+
+	for _, raw := range []string{
+		`"12345": []`,
+		`"121042882": [{name: Savings, accountType: savings, expense: "0093", tiers: []}]`,
+		`"121042882": [{name: Savings, accountType: "home loan", expense: "0093", tiers: [{apy: 0.01}]}]`,
+		`"121042882": [{name: Savings, accountType: savings, dayCount: actual/actual, expense: "0093", tiers: [{apy: 0.01}]}]`,
+		`"121042882": [{name: Savings, accountType: savings, expense: "0093", tiers: [{apy: 1.5}]}]`,
+		`"121042882": [{name: Savings, accountType: savings, expense: "0093", tiers: [{minBalance: 10, apy: 0.01}, {minBalance: 10, apy: 0.02}]}]`,
+		`"121042882": [{name: Savings, accountType: savings, expense: "0093", rate: 0.01}]`,
+	} {
+		if _, err := readInterestProducts(strings.NewReader(raw)); err == nil {
+			t.Errorf("expected error: %s", raw)
+		}
+	}
+	if products, err := readInterestProductsFile(""); products != nil || err != nil {
+		t.Errorf("products=%v error=%v", products, err)
+	}
+}
+
+func TestInterest__accrue(t *testing.T) {
+	product := interestProduct{DayCount: Actual365, Tiers: []interestTier{{APY: 0.02}}}
+
+	// A year of daily accruals without compounding earns ln(1 + APY)
+	start := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var micros int64
+	for day := start; day.Year() == 2019; day = day.AddDate(0, 0, 1) {
+		micros += product.accrue(1000000, day).AmountMicros
+	}
+	if cents := microsToCents(micros); math.Abs(float64(cents)-1000000*math.Log(1.02)) > 1 {
+		t.Errorf("earned %d cents", cents)
+	}
+	if a := product.accrue(0, start); a.AmountMicros != 0 || a.APY != 0 {
+		t.Errorf("unexpected accrual: %#v", a)
+	}
+
+	// 30/360 accrues 30 days every month
+	product.DayCount = Thirty360
+	for _, month := range []time.Month{time.January, time.February} {
+		var fraction float64
+		for day := time.Date(2020, month, 1, 0, 0, 0, 0, time.UTC); day.Month() == month; day = day.AddDate(0, 0, 1) {
+			fraction += product.DayCount.yearFraction(day)
+		}
+		if math.Abs(fraction-30.0/360) > 1e-9 {
+			t.Errorf("%s: fraction=%v", month, fraction)
+		}
+	}
+	if f := Actual360.yearFraction(start); f != 1.0/360 {
+		t.Errorf("fraction=%v", f)
+	}
+
+	for micros, expected := range map[int64]int64{0: 0, 499999: 0, 500000: 1, 1499999: 1, -500000: -1} {
+		if cents := microsToCents(micros); cents != expected {
+			t.Errorf("%d micros: got %d cents", micros, cents)
+		}
+	}
+}
+
+func TestInterest__routes(t *testing.T) {
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+
+	repo := createTestSqlTransactionRepository(t, sqliteDB.DB)
+	router := mux.NewRouter()
+	addInterestRoutes(log.NewNopLogger(), func(path string, hf http.HandlerFunc) {
+		router.HandleFunc(path, hf)
+	}, &testAccountRepository{}, setupSqlInterestStorage(log.NewNopLogger(), repo), nil)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/interest/accrue?from=2020-01-01&to=2020-01-31", nil),
+		httptest.NewRequest("POST", "/interest/accrue?from=2020-01-01", nil),
+		httptest.NewRequest("POST", "/interest/accrue?from=2020-02-01&to=2020-01-31", nil),
+		httptest.NewRequest("POST", "/interest/accrue?from=2020-01-01&to="+time.Now().Format("2006-01-02"), nil),
+		httptest.NewRequest("POST", "/interest/post?month=2020-13", nil),
+		httptest.NewRequest("POST", "/interest/post?month="+time.Now().Format("2006-01"), nil),
+		httptest.NewRequest("GET", "/interest/accruals?from=2020-01-01&to=2020-01-31", nil),
+		httptest.NewRequest("GET", "/interest/accruals?accountId=foo&from=2020-01-01", nil),
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		w.Flush()
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: bogus HTTP status: %d", req.Method, req.URL, w.Code)
+		}
+	}
+
+	// Without any products there's nothing to accrue or post
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/interest/post?month=2020-01", nil))
+	w.Flush()
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"posted":0`) {
+		t.Errorf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -31,6 +31,9 @@ var (
 	adminAddr = flag.String("admin.addr", bind.Admin("accounts"), "Admin HTTP listen address")
 
 	flagLogFormat = flag.String("log.format", "", "Format for log lines (Options: json, plain")
+
+	flagCallReport    = flag.String("call-report", "", "Write the FFIEC 051 call report for a quarter-end date (YYYY-MM-DD) of DEFAULT_ROUTING_NUMBER and exit")
+	flagCallReportDir = flag.String("call-report.dir", ".", "Directory call reports are written into")
 )
 
 func main() {
@@ -60,6 +63,15 @@ func main() {
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	defer cancelFunc()
 
+	// Generate a call report offline from the ledger if requested
+	if *flagCallReport != "" {
+		if err := writeCallReportFromLedger(ctx, logger, defaultRoutingNumber, *flagCallReport, *flagCallReportDir); err != nil {
+			logger.Log("call-report", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Channel for errors
 	errs := make(chan error)
 	go func() {
@@ -79,7 +91,7 @@ func main() {
 	go func() {
 		logger.Log("admin", fmt.Sprintf("listening on %s", adminServer.BindAddr()))
 		if err := adminServer.Listen(); err != nil {
-			err = fmt.Errorf("problem starting admin http: %v", err)
+			err = fmt.Errorf("problem starting admin http: %w", err)
 			logger.Log("admin", err)
 			errs <- err
 		}
@@ -109,15 +121,92 @@ func main() {
 		panic(fmt.Sprintf("transaction storage: %v", err))
 	}
 	defer transactionRepo.Close()
+	transactionRepo.accountRepo = accountRepo // accounts can be stored in another database than transactions
 	logger.Log("main", fmt.Sprintf("using %T for transaction storage", transactionRepo))
 	adminServer.AddLivenessCheck("transactions", transactionRepo.Ping)
+	adminServer.AddHandler("/balances/reconcile", reconcileBalances(logger, transactionRepo))
+	periodRepo := setupSqlPeriodStorage(logger, transactionsDB)
+	addPeriodRoutes(logger, adminServer.AddHandler, periodRepo, readPeriodReopenUsers(os.Getenv("PERIOD_REOPEN_USERS")))
+	productRepo := setupSqlProductStorage(logger, transactionsDB)
+	addProductRoutes(logger, adminServer.AddHandler, productRepo)
+
+	// Read GL posting rules so customer transactions are journaled
+	if transactionRepo.glRules, err = readGLRulesFile(os.Getenv("GL_RULES_PATH")); err != nil {
+		panic(fmt.Sprintf("invalid GL_RULES_PATH=%q: %v", os.Getenv("GL_RULES_PATH"), err))
+	}
+	glAccountRepo := setupSqlGLAccountStorage(logger, transactionsDB, accountRepo)
+	if err := transactionRepo.glRules.checkGLAccounts(glAccountRepo); err != nil {
+		panic(fmt.Sprintf("invalid GL_RULES_PATH=%q: %v", os.Getenv("GL_RULES_PATH"), err))
+	}
+
+	// Expire authorization holds in the background
+	holdExpirationInterval := readInterval("HOLD_EXPIRATION_INTERVAL", time.Minute)
+	holdRepo := setupSqlHoldStorage(logger, transactionRepo)
+	go expireHoldsEvery(ctx, logger, holdRepo, holdExpirationInterval)
+
+	// Generate last month's account statements in the background
+	statementInterval := readInterval("STATEMENT_INTERVAL", time.Hour)
+	go generateStatementsEvery(ctx, logger, accountRepo, transactionRepo, statementInterval)
+
+	// Accrue and post interest on accounts with an interest product
+	interestProducts, err := readInterestProductsFile(os.Getenv("INTEREST_PRODUCTS_PATH"))
+	if err != nil {
+		panic(fmt.Sprintf("invalid INTEREST_PRODUCTS_PATH=%q: %v", os.Getenv("INTEREST_PRODUCTS_PATH"), err))
+	}
+	interestInterval := readInterval("INTEREST_INTERVAL", time.Hour)
+	interestRepo := setupSqlInterestStorage(logger, transactionRepo)
+	if len(interestProducts) > 0 {
+		go accrueInterestEvery(ctx, logger, accountRepo, interestRepo, interestProducts, interestInterval)
+	}
+	addInterestRoutes(logger, adminServer.AddHandler, accountRepo, interestRepo, interestProducts)
+
+	// Assess fees on accounts with a fee schedule
+	feeSchedules, err := readFeeSchedulesFile(os.Getenv("FEE_SCHEDULES_PATH"))
+	if err != nil {
+		panic(fmt.Sprintf("invalid FEE_SCHEDULES_PATH=%q: %v", os.Getenv("FEE_SCHEDULES_PATH"), err))
+	}
+	feeInterval := readInterval("FEE_INTERVAL", time.Hour)
+	feeRepo := setupSqlFeeStorage(logger, transactionRepo)
+	if len(feeSchedules) > 0 {
+		go assessFeesEvery(ctx, logger, accountRepo, feeRepo, feeSchedules, feeInterval)
+	}
+	addFeeRoutes(logger, adminServer.AddHandler, accountRepo, feeRepo, feeSchedules)
+
+	// Export outbound ACH lines as NACHA files
+	achConfig := achExportConfig{
+		Dir:                      os.Getenv("ACH_EXPORT_DIR"),
+		ImmediateDestination:     os.Getenv("ACH_IMMEDIATE_DESTINATION"),
+		ImmediateDestinationName: or(os.Getenv("ACH_IMMEDIATE_DESTINATION_NAME"), "Federal Reserve Bank"),
+		ImmediateOriginName:      or(os.Getenv("ACH_IMMEDIATE_ORIGIN_NAME"), "Moov"),
+	}
+	achInterval := readInterval("ACH_EXPORT_INTERVAL", time.Hour)
+	achExportRepo := setupSqlACHExportStorage(logger, transactionsDB)
+	if achConfig.Dir != "" {
+		if err := achConfig.validate(); err != nil {
+			panic(fmt.Sprintf("invalid ACH export config: %v", err))
+		}
+		go exportACHFilesEvery(ctx, logger, accountRepo, achExportRepo, achConfig, achInterval)
+	}
+	addACHExportRoutes(logger, adminServer.AddHandler, accountRepo, achExportRepo, achConfig)
+
+	// Delete idempotency keys once retries are no longer expected
+	idempotencyKeyRetention := readInterval("IDEMPOTENCY_KEY_RETENTION", 24*time.Hour)
 
 	// Setup business HTTP routes
 	router := mux.NewRouter()
 	moovhttp.AddCORSHandler(router)
+	idempotencyRepo := setupSqlIdempotencyStorage(logger, transactionsDB)
+	addIdempotencyMiddleware(logger, router, idempotencyRepo)
+	go deleteIdempotencyKeysEvery(ctx, logger, idempotencyRepo, idempotencyKeyRetention, time.Hour)
 	addPingRoute(logger, router)
-	addAccountRoutes(logger, router, accountRepo, transactionRepo)
+	addAccountRoutes(logger, router, accountRepo, transactionRepo, productRepo)
 	addTransactionRoutes(logger, router, accountRepo, transactionRepo)
+	addHoldRoutes(logger, router, holdRepo)
+	addBalanceRoutes(logger, router, accountRepo, transactionRepo)
+	addOverdraftRoutes(logger, router, accountRepo, setupSqlOverdraftStorage(logger, transactionsDB, accountRepo))
+	addStatementRoutes(logger, router, transactionRepo)
+	addGLAccountRoutes(logger, router, glAccountRepo)
+	addReportRoutes(logger, router, glAccountRepo)
 
 	// Start business HTTP server
 	readTimeout, _ := time.ParseDuration("30s")
@@ -181,6 +270,19 @@ func addPingRoute(logger log.Logger, r *mux.Router) {
 	})
 }
 
+// readInterval returns the positive duration set in the environment variable name, or def if it's unset
+func readInterval(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	interval, err := time.ParseDuration(v)
+	if err != nil || interval <= 0 {
+		panic(fmt.Sprintf("invalid %s=%q: %v", name, v, err))
+	}
+	return interval
+}
+
 // or returns primary if non-empty and backup otherwise
 func or(primary, backup string) string {
 	primary = strings.TrimSpace(primary)
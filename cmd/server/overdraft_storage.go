@@ -0,0 +1,27 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+type overdraftRepository interface {
+	// getOverdraft returns the overdraft line of accountID, including revoked lines, or nil if it never opted in
+	getOverdraft(accountID string) (*overdraftLine, error)
+	// saveOverdraft opts an account into an overdraft line, replacing any existing line
+	saveOverdraft(line overdraftLine) error
+	// revokeOverdraft ends the overdraft line of accountID. errOverdraftNotFound is returned if it doesn't have an active line.
+	revokeOverdraft(accountID string, when time.Time) (*overdraftLine, error)
+
+	// getOverdrawnAccounts returns each customer account with a negative balance, its overdraft limit and
+	// when its balance last went negative
+	getOverdrawnAccounts(asOf time.Time) ([]overdrawnAccount, error)
+}
+
+var (
+	errOverdraftNotFound = errors.New("account doesn't have an overdraft line")
+)
@@ -0,0 +1,160 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+type sqlOverdraftRepository struct {
+	db     *sql.DB
+	logger log.Logger
+
+	// accountRepo finds the customer accounts at our routing number
+	accountRepo accountRepository
+}
+
+func setupSqlOverdraftStorage(logger log.Logger, db *sql.DB, accountRepo accountRepository) *sqlOverdraftRepository {
+	return &sqlOverdraftRepository{db: db, logger: logger, accountRepo: accountRepo}
+}
+
+// readOverdraftLimit returns the overdraft limit of accountID, or zero if it doesn't have an active overdraft line
+func readOverdraftLimit(tx *sql.Tx, accountID string) (int64, error) {
+	stmt, err := tx.Prepare(`select overdraft_limit from account_overdrafts where account_id = ? and revoked_at is null limit 1;`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var limit int64
+	if err := stmt.QueryRow(accountID).Scan(&limit); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return limit, nil
+}
+
+func (r *sqlOverdraftRepository) getOverdraft(accountID string) (*overdraftLine, error) {
+	query := `select account_id, overdraft_limit, opted_in_at, disclosure, created_at, last_modified, revoked_at from account_overdrafts where account_id = ? limit 1;`
+	stmt, err := r.db.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("getOverdraft: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	var line overdraftLine
+	var revokedAt *time.Time
+	err = stmt.QueryRow(accountID).Scan(&line.AccountID, &line.Limit, &line.OptedInAt, &line.Disclosure, &line.CreatedAt, &line.LastModified, &revokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getOverdraft: account=%s: %w", accountID, err)
+	}
+	line.RevokedAt = revokedAt
+	return &line, nil
+}
+
+func (r *sqlOverdraftRepository) saveOverdraft(line overdraftLine) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("saveOverdraft: tx.Begin: %w", err)
+	}
+
+	query := `update account_overdrafts set overdraft_limit = ?, opted_in_at = ?, disclosure = ?, last_modified = ?, revoked_at = null where account_id = ?;`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("saveOverdraft: prepare update: error=%w rollback=%v", err, tx.Rollback())
+	}
+	res, err := stmt.Exec(line.Limit, line.OptedInAt, line.Disclosure, line.LastModified, line.AccountID)
+	stmt.Close()
+	if err != nil {
+		return fmt.Errorf("saveOverdraft: account=%s update: error=%w rollback=%v", line.AccountID, err, tx.Rollback())
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		query = `insert into account_overdrafts(account_id, overdraft_limit, opted_in_at, disclosure, created_at, last_modified) values (?, ?, ?, ?, ?, ?);`
+		stmt, err = tx.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("saveOverdraft: prepare insert: error=%w rollback=%v", err, tx.Rollback())
+		}
+		_, err = stmt.Exec(line.AccountID, line.Limit, line.OptedInAt, line.Disclosure, line.CreatedAt, line.LastModified)
+		stmt.Close()
+		if err != nil {
+			return fmt.Errorf("saveOverdraft: account=%s insert: error=%w rollback=%v", line.AccountID, err, tx.Rollback())
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("saveOverdraft: commit: %w", err)
+	}
+	return nil
+}
+
+func (r *sqlOverdraftRepository) revokeOverdraft(accountID string, when time.Time) (*overdraftLine, error) {
+	stmt, err := r.db.Prepare(`update account_overdrafts set revoked_at = ?, last_modified = ? where account_id = ? and revoked_at is null;`)
+	if err != nil {
+		return nil, fmt.Errorf("revokeOverdraft: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(when, when, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("revokeOverdraft: account=%s: %w", accountID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, errOverdraftNotFound
+	}
+	return r.getOverdraft(accountID)
+}
+
+// getOverdrawnAccounts finds customer accounts at our routing number with a negative checkpointed balance and then
+// walks each of their transactions by effective date to find when the balance last went from non-negative to negative.
+// Accounts at other financial institutions are overdrawn on their own books, not ours.
+func (r *sqlOverdraftRepository) getOverdrawnAccounts(asOf time.Time) ([]overdrawnAccount, error) {
+	customers, err := r.accountRepo.GetAccountTypes(defaultRoutingNumber)
+	if err != nil {
+		return nil, fmt.Errorf("getOverdrawnAccounts: accounts: %w", err)
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("getOverdrawnAccounts: tx.Begin: %w", err)
+	}
+	negative, err := readBalances(tx, `select account_id, balance from account_balances where balance < 0;`)
+	if err != nil {
+		return nil, fmt.Errorf("getOverdrawnAccounts: balances: error=%w rollback=%v", err, tx.Rollback())
+	}
+
+	var out []overdrawnAccount
+	for accountID := range negative {
+		if _, exists := customers[accountID]; !exists {
+			continue // GL accounts and accounts at other financial institutions
+		}
+		changes, err := readBalanceChanges(tx, accountID, time.Time{}, asOf)
+		if err != nil {
+			return nil, fmt.Errorf("getOverdrawnAccounts: account=%s: error=%w rollback=%v", accountID, err, tx.Rollback())
+		}
+		acct := overdrawnAccount{AccountID: accountID}
+		for i := range changes {
+			previous := acct.Balance
+			acct.Balance += changes[i].Amount
+			if previous >= 0 && acct.Balance < 0 {
+				acct.OverdrawnSince = changes[i].EffectiveDate.UTC()
+			}
+		}
+		if acct.Balance >= 0 {
+			continue // not overdrawn as of asOf
+		}
+		if acct.Limit, err = readOverdraftLimit(tx, accountID); err != nil {
+			return nil, fmt.Errorf("getOverdrawnAccounts: account=%s limit: error=%w rollback=%v", accountID, err, tx.Rollback())
+		}
+		out = append(out, acct)
+	}
+	return out, tx.Commit()
+}
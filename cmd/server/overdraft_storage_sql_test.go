@@ -0,0 +1,121 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestSqlOverdraftRepository__overdrafts(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+		// Accounts are only read through the account repository, which can be in another database
+		account := &accounts.Account{ID: base.ID(), AccountNumber: "123", Status: "open", RoutingNumber: defaultRoutingNumber}
+		external := &accounts.Account{ID: base.ID(), AccountNumber: "456", Status: "open", RoutingNumber: "121042882"}
+		repo.accountRepo = &testAccountRepository{accounts: []*accounts.Account{account, external}}
+		overdraftRepo := setupSqlOverdraftStorage(log.NewNopLogger(), repo.db, repo.accountRepo)
+		createTestGLAccount(t, repo.db, defaultRoutingNumber, createGLAccountRequest{Code: "0010", Name: "Cash", Category: GLAsset})
+		cash := glAccountID(defaultRoutingNumber, "0010")
+
+		post := func(direction LineDirection, amount int64, effective time.Time) error {
+			return repo.createTransaction(transaction{
+				ID:            base.ID(),
+				Timestamp:     time.Now(),
+				EffectiveDate: effective,
+				Lines: []transactionLine{
+					{AccountID: account.ID, Purpose: Transfer, Direction: direction, Amount: amount},
+					{AccountID: cash, Purpose: Transfer, Direction: direction.opposite(), Amount: amount},
+				},
+			}, createTransactionOpts{AllowGLDebits: true})
+		}
+		now := time.Now()
+		if err := post(Credit, 1000, now.AddDate(0, 0, -10)); err != nil {
+			t.Fatal(err)
+		}
+		// Accounts without an overdraft line can be debited to zero, but not below
+		if err := post(Debit, 1001, now); err == nil || !strings.Contains(err.Error(), errInsufficientFunds.Error()) {
+			t.Errorf("expected insufficient funds: %v", err)
+		}
+
+		if line, err := overdraftRepo.getOverdraft(account.ID); line != nil || err != nil {
+			t.Fatalf("line=%#v error=%v", line, err)
+		}
+		line := overdraftLine{AccountID: account.ID, Limit: 500, OptedInAt: now, Disclosure: "OD-2020-01", CreatedAt: now, LastModified: now}
+		if err := overdraftRepo.saveOverdraft(line); err != nil {
+			t.Fatal(err)
+		}
+		if err := post(Debit, 1600, now.AddDate(0, 0, -5)); err == nil || !strings.Contains(err.Error(), errInsufficientFunds.Error()) {
+			t.Errorf("expected insufficient funds: %v", err)
+		}
+		if err := post(Debit, 1500, now.AddDate(0, 0, -5)); err != nil {
+			t.Fatal(err)
+		}
+
+		// Raise the limit and then revoke it
+		line.Limit = 1000
+		if err := overdraftRepo.saveOverdraft(line); err != nil {
+			t.Fatal(err)
+		}
+		if found, err := overdraftRepo.getOverdraft(account.ID); err != nil || found.Limit != 1000 || found.Disclosure != "OD-2020-01" || found.RevokedAt != nil {
+			t.Fatalf("line=%#v error=%v", found, err)
+		}
+		revoked, err := overdraftRepo.revokeOverdraft(account.ID, now)
+		if err != nil || revoked.RevokedAt == nil {
+			t.Fatalf("line=%#v error=%v", revoked, err)
+		}
+		if _, err := overdraftRepo.revokeOverdraft(account.ID, now); err != errOverdraftNotFound {
+			t.Errorf("expected errOverdraftNotFound: %v", err)
+		}
+		if err := post(Debit, 1, now); err == nil {
+			t.Error("expected insufficient funds after revoking overdraft")
+		}
+		// Credits are always allowed, even when overdrawn
+		if err := post(Credit, 100, now); err != nil {
+			t.Fatal(err)
+		}
+
+		// External accounts can be overdrawn on our books, but aren't ours to report
+		err = repo.createTransaction(transaction{
+			ID:        base.ID(),
+			Timestamp: time.Now(),
+			Lines: []transactionLine{
+				{AccountID: external.ID, Purpose: Transfer, Direction: Debit, Amount: 500},
+				{AccountID: cash, Purpose: Transfer, Direction: Credit, Amount: 500},
+			},
+		}, createTransactionOpts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		accts, err := overdraftRepo.getOverdrawnAccounts(time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(accts) != 1 || accts[0].AccountID != account.ID || accts[0].Balance != -400 || accts[0].Limit != 0 {
+			t.Fatalf("unexpected overdrawn accounts: %#v", accts)
+		}
+		if report := buildOverdraftReport(accts, now.Add(time.Minute), 5); report.Accounts[0].DaysOverdrawn != 5 || !report.Accounts[0].ChargeOffCandidate || !report.Accounts[0].OverLimit {
+			t.Errorf("unexpected report: %#v", report)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
@@ -0,0 +1,263 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	moovhttp "github.com/moov-io/base/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+// overdraftLine lets an account's available balance go as low as -Limit. Accounts opt in at OptedInAt after
+// being given the disclosure identified by Disclosure. Revoked lines are kept so the opt in can be audited.
+type overdraftLine struct {
+	AccountID  string     `json:"accountId"`
+	Limit      int64      `json:"limit"`
+	OptedInAt  time.Time  `json:"optedInAt"`
+	Disclosure string     `json:"disclosure"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+
+	CreatedAt    time.Time `json:"createdAt"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+const maxOverdraftDisclosureLength = 200
+
+type updateOverdraftRequest struct {
+	Limit      int64     `json:"limit"`
+	OptedInAt  time.Time `json:"optedInAt"`
+	Disclosure string    `json:"disclosure"`
+}
+
+func (req updateOverdraftRequest) validate(now time.Time) error {
+	if req.Limit <= 0 {
+		return errors.New("overdraft limit must be positive")
+	}
+	if req.Disclosure == "" || len(req.Disclosure) > maxOverdraftDisclosureLength {
+		return fmt.Errorf("overdraft disclosure must be between 1 and %d characters", maxOverdraftDisclosureLength)
+	}
+	if req.OptedInAt.After(now) {
+		return errors.New("overdraft optedInAt can't be in the future")
+	}
+	return nil
+}
+
+// overdrawnAccount is a customer account with a negative balance. DaysOverdrawn counts the days since the
+// balance last went negative.
+type overdrawnAccount struct {
+	AccountID          string    `json:"accountId"`
+	Balance            int64     `json:"balance"`
+	Limit              int64     `json:"limit"`
+	OverLimit          bool      `json:"overLimit"`
+	OverdrawnSince     time.Time `json:"overdrawnSince"`
+	DaysOverdrawn      int       `json:"daysOverdrawn"`
+	ChargeOffCandidate bool      `json:"chargeOffCandidate"`
+}
+
+// overdraftReport lists overdrawn accounts, longest overdrawn first. Accounts overdrawn for at least
+// ChargeOffDays are candidates to be charged off.
+type overdraftReport struct {
+	AsOf           time.Time          `json:"asOf"`
+	ChargeOffDays  int                `json:"chargeOffDays"`
+	TotalOverdrawn int64              `json:"totalOverdrawn"`
+	Accounts       []overdrawnAccount `json:"accounts"`
+}
+
+// defaultChargeOffDays is how long an account can be overdrawn before it's a charge-off candidate
+const defaultChargeOffDays = 60
+
+func buildOverdraftReport(accts []overdrawnAccount, asOf time.Time, chargeOffDays int) overdraftReport {
+	report := overdraftReport{
+		AsOf:          asOf,
+		ChargeOffDays: chargeOffDays,
+		Accounts:      make([]overdrawnAccount, 0, len(accts)),
+	}
+	for _, acct := range accts {
+		acct.OverLimit = acct.Balance < -acct.Limit
+		acct.DaysOverdrawn = int(asOf.Sub(acct.OverdrawnSince).Hours() / 24)
+		acct.ChargeOffCandidate = acct.DaysOverdrawn >= chargeOffDays
+		report.TotalOverdrawn -= acct.Balance
+		report.Accounts = append(report.Accounts, acct)
+	}
+	sort.SliceStable(report.Accounts, func(i, j int) bool {
+		if report.Accounts[i].DaysOverdrawn == report.Accounts[j].DaysOverdrawn {
+			return report.Accounts[i].AccountID < report.Accounts[j].AccountID
+		}
+		return report.Accounts[i].DaysOverdrawn > report.Accounts[j].DaysOverdrawn
+	})
+	return report
+}
+
+func addOverdraftRoutes(logger log.Logger, r *mux.Router, accountRepo accountRepository, repo overdraftRepository) {
+	r.Methods("GET").Path("/accounts/{accountId}/overdraft").HandlerFunc(getOverdraft(logger, accountRepo, repo))
+	r.Methods("PUT").Path("/accounts/{accountId}/overdraft").HandlerFunc(updateOverdraft(logger, accountRepo, repo))
+	r.Methods("DELETE").Path("/accounts/{accountId}/overdraft").HandlerFunc(revokeOverdraft(logger, accountRepo, repo))
+	r.Methods("GET").Path("/reports/overdrafts").HandlerFunc(getOverdraftReport(logger, repo))
+}
+
+func writeOverdraftLine(w http.ResponseWriter, line *overdraftLine) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(line)
+}
+
+func getOverdraft(logger log.Logger, accountRepo accountRepository, repo overdraftRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		accountID := readBalanceAccountID(logger, w, r, accountRepo)
+		if accountID == "" {
+			return
+		}
+		line, err := repo.getOverdraft(accountID)
+		if err != nil {
+			logger.Log("overdrafts", fmt.Sprintf("problem reading account=%s overdraft: %v", accountID, err), "requestID", moovhttp.GetRequestID(r))
+			moovhttp.Problem(w, err)
+			return
+		}
+		if line == nil {
+			http.NotFound(w, r)
+			return
+		}
+		writeOverdraftLine(w, line)
+	}
+}
+
+func updateOverdraft(logger log.Logger, accountRepo accountRepository, repo overdraftRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		accountID := readBalanceAccountID(logger, w, r, accountRepo)
+		if accountID == "" {
+			return
+		}
+		requestID := moovhttp.GetRequestID(r)
+
+		var req updateOverdraftRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.Log("overdrafts", fmt.Sprintf("error reading JSON request: %v", err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		now := time.Now()
+		req.Disclosure = strings.TrimSpace(req.Disclosure)
+		if req.OptedInAt.IsZero() {
+			req.OptedInAt = now
+		}
+		if err := req.validate(now); err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		line := overdraftLine{
+			AccountID:    accountID,
+			Limit:        req.Limit,
+			OptedInAt:    req.OptedInAt,
+			Disclosure:   req.Disclosure,
+			CreatedAt:    now,
+			LastModified: now,
+		}
+		if err := repo.saveOverdraft(line); err != nil {
+			logger.Log("overdrafts", fmt.Sprintf("problem saving account=%s overdraft: %v", accountID, err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		logger.Log("overdrafts", fmt.Sprintf("account=%s opted into overdraft limit=%d disclosure=%s", accountID, line.Limit, line.Disclosure), "requestID", requestID, "userID", moovhttp.GetUserID(r))
+
+		saved, err := repo.getOverdraft(accountID)
+		if err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+		writeOverdraftLine(w, saved)
+	}
+}
+
+func revokeOverdraft(logger log.Logger, accountRepo accountRepository, repo overdraftRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		accountID := readBalanceAccountID(logger, w, r, accountRepo)
+		if accountID == "" {
+			return
+		}
+		requestID := moovhttp.GetRequestID(r)
+
+		line, err := repo.revokeOverdraft(accountID, time.Now())
+		if err != nil {
+			if err == errOverdraftNotFound {
+				http.NotFound(w, r)
+				return
+			}
+			logger.Log("overdrafts", fmt.Sprintf("problem revoking account=%s overdraft: %v", accountID, err), "requestID", requestID)
+			moovhttp.Problem(w, err)
+			return
+		}
+		logger.Log("overdrafts", fmt.Sprintf("revoked account=%s overdraft", accountID), "requestID", requestID, "userID", moovhttp.GetUserID(r))
+		writeOverdraftLine(w, line)
+	}
+}
+
+// getOverdraftReport lists the accounts which are overdrawn now. Passing ?chargeOffCandidates=true only
+// lists the accounts overdrawn for at least chargeOffDays.
+func getOverdraftReport(logger log.Logger, repo overdraftRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		q := r.URL.Query()
+		chargeOffDays := defaultChargeOffDays
+		if v := q.Get("chargeOffDays"); v != "" {
+			if chargeOffDays, err = strconv.Atoi(v); err != nil || chargeOffDays <= 0 {
+				moovhttp.Problem(w, fmt.Errorf("invalid chargeOffDays %q", v))
+				return
+			}
+		}
+		candidatesOnly, _ := strconv.ParseBool(q.Get("chargeOffCandidates"))
+
+		asOf := time.Now()
+		accts, err := repo.getOverdrawnAccounts(asOf)
+		if err != nil {
+			logger.Log("overdrafts", fmt.Sprintf("problem reading overdrawn accounts: %v", err), "requestID", moovhttp.GetRequestID(r))
+			moovhttp.Problem(w, err)
+			return
+		}
+		report := buildOverdraftReport(accts, asOf, chargeOffDays)
+		if candidatesOnly {
+			var candidates []overdrawnAccount
+			for i := range report.Accounts {
+				if report.Accounts[i].ChargeOffCandidate {
+					candidates = append(candidates, report.Accounts[i])
+				}
+			}
+			report = buildOverdraftReport(candidates, asOf, chargeOffDays)
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(report)
+	}
+}
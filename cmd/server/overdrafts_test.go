@@ -0,0 +1,126 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+func TestOverdrafts__validate(t *testing.T) {
+	now := time.Now()
+	req := updateOverdraftRequest{Limit: 500, OptedInAt: now, Disclosure: "OD-2020-01"}
+	if err := req.validate(now); err != nil {
+		t.Error(err)
+	}
+	for _, bad := range []updateOverdraftRequest{
+		{Limit: 0, OptedInAt: now, Disclosure: "OD-2020-01"},
+		{Limit: 500, OptedInAt: now},
+		{Limit: 500, OptedInAt: now, Disclosure: strings.Repeat("a", maxOverdraftDisclosureLength+1)},
+		{Limit: 500, OptedInAt: now.Add(time.Hour), Disclosure: "OD-2020-01"},
+	} {
+		if err := bad.validate(now); err == nil {
+			t.Errorf("expected error: %#v", bad)
+		}
+	}
+}
+
+func TestOverdrafts__buildOverdraftReport(t *testing.T) {
+	now := time.Now()
+	report := buildOverdraftReport([]overdrawnAccount{
+		{AccountID: "a", Balance: -100, Limit: 500, OverdrawnSince: now.AddDate(0, 0, -3)},
+		{AccountID: "b", Balance: -700, Limit: 500, OverdrawnSince: now.AddDate(0, 0, -90)},
+	}, now, defaultChargeOffDays)
+
+	if report.TotalOverdrawn != 800 || len(report.Accounts) != 2 {
+		t.Fatalf("unexpected report: %#v", report)
+	}
+	if a := report.Accounts[0]; a.AccountID != "b" || a.DaysOverdrawn != 90 || !a.OverLimit || !a.ChargeOffCandidate {
+		t.Errorf("unexpected account: %#v", a)
+	}
+	if a := report.Accounts[1]; a.AccountID != "a" || a.DaysOverdrawn != 3 || a.OverLimit || a.ChargeOffCandidate {
+		t.Errorf("unexpected account: %#v", a)
+	}
+}
+
+func TestOverdrafts__routes(t *testing.T) {
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+
+	repo := createTestSqlTransactionRepository(t, sqliteDB.DB)
+	accountRepo := &testAccountRepository{
+		accounts: []*accounts.Account{{ID: base.ID(), Status: "open", RoutingNumber: defaultRoutingNumber}},
+	}
+	accountID := accountRepo.accounts[0].ID
+
+	router := mux.NewRouter()
+	addOverdraftRoutes(log.NewNopLogger(), router, accountRepo, setupSqlOverdraftStorage(log.NewNopLogger(), repo.db, repo.accountRepo))
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("x-user-id", "test")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		w.Flush()
+		return w
+	}
+
+	if w := call("GET", "/accounts/"+accountID+"/overdraft", ""); w.Code != http.StatusNotFound {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	if w := call("PUT", "/accounts/"+accountID+"/overdraft", `{"limit": -5, "disclosure": "OD-2020-01"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	w := call("PUT", "/accounts/"+accountID+"/overdraft", `{"limit": 5000, "disclosure": "OD-2020-01"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+	var line overdraftLine
+	if err := json.NewDecoder(w.Body).Decode(&line); err != nil {
+		t.Fatal(err)
+	}
+	if line.AccountID != accountID || line.Limit != 5000 || line.OptedInAt.IsZero() || line.RevokedAt != nil {
+		t.Errorf("unexpected overdraft line: %#v", line)
+	}
+	if w := call("GET", "/accounts/"+accountID+"/overdraft", ""); w.Code != http.StatusOK {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	if w := call("DELETE", "/accounts/"+accountID+"/overdraft", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "revokedAt") {
+		t.Errorf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+	if w := call("DELETE", "/accounts/"+accountID+"/overdraft", ""); w.Code != http.StatusNotFound {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+
+	accountRepo.accounts = nil
+	if w := call("PUT", "/accounts/"+base.ID()+"/overdraft", `{"limit": 5000, "disclosure": "OD-2020-01"}`); w.Code != http.StatusNotFound {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+
+	if w := call("GET", "/reports/overdrafts?chargeOffDays=zero", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	w = call("GET", "/reports/overdrafts?chargeOffCandidates=true", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+	var report overdraftReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.ChargeOffDays != defaultChargeOffDays || len(report.Accounts) != 0 {
+		t.Errorf("unexpected report: %#v", report)
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+)
+
+type periodRepository interface {
+	// closePeriod snapshots the balance of every account at the end of the period and rejects postings
+	// dated inside of it until the period is reopened.
+	closePeriod(period accountingPeriod, actor string) (*accountingPeriod, error)
+
+	// reopenPeriod allows postings dated inside of a closed period again. The closing balances are kept
+	// until the period is closed again.
+	reopenPeriod(periodID, actor, reason string) (*accountingPeriod, error)
+
+	// getPeriod returns nil if the period has never been closed
+	getPeriod(periodID string) (*accountingPeriod, error)
+	getPeriodBalances(periodID string) (map[string]int64, error)
+	getPeriodEvents(periodID string) ([]periodEvent, error)
+}
+
+var (
+	errPeriodClosed        = errors.New("transaction is dated inside a closed accounting period")
+	errPeriodNotFound      = errors.New("accounting period not found")
+	errPeriodAlreadyClosed = errors.New("accounting period is already closed")
+	errPeriodNotClosed     = errors.New("accounting period isn't closed")
+	errPeriodNotEnded      = errors.New("accounting period hasn't ended")
+	errPeriodsChanged      = errors.New("accounting periods changed while posting")
+)
@@ -0,0 +1,340 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+type sqlPeriodRepository struct {
+	db     *sql.DB
+	logger log.Logger
+}
+
+func setupSqlPeriodStorage(logger log.Logger, db *sql.DB) *sqlPeriodRepository {
+	return &sqlPeriodRepository{db: db, logger: logger}
+}
+
+const periodColumns = `period_id, period_type, start_date, end_date, status, closed_by, closed_at`
+
+func scanPeriod(row rowScanner) (*accountingPeriod, error) {
+	var p accountingPeriod
+	var closedBy sql.NullString
+	var closedAt *time.Time
+	if err := row.Scan(&p.ID, &p.Type, &p.Start, &p.End, &p.Status, &closedBy, &closedAt); err != nil {
+		return nil, err
+	}
+	p.Start, p.End = p.Start.UTC(), p.End.UTC()
+	p.ClosedBy = closedBy.String
+	p.ClosedAt = closedAt
+	return &p, nil
+}
+
+func readPeriod(tx *sql.Tx, periodID string) (*accountingPeriod, error) {
+	stmt, err := tx.Prepare(fmt.Sprintf(`select %s from accounting_periods where period_id = ? limit 1;`, periodColumns))
+	if err != nil {
+		return nil, fmt.Errorf("prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	p, err := scanPeriod(stmt.QueryRow(periodID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("period=%q: %w", periodID, err)
+	}
+	return p, nil
+}
+
+func insertPeriodEvent(tx *sql.Tx, periodID string, event periodEvent) error {
+	query := `insert into accounting_period_events(period_id, action, actor, reason, created_at) values (?, ?, ?, ?, ?);`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("prepare event: %w", err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(periodID, event.Action, event.Actor, event.Reason, event.CreatedAt); err != nil {
+		return fmt.Errorf("period=%q event: %w", periodID, err)
+	}
+	return nil
+}
+
+// checkPeriodOpen returns errPeriodClosed if timestamp falls inside of any closed accounting period
+func checkPeriodOpen(tx *sql.Tx, timestamp time.Time) error {
+	query := `select period_id from accounting_periods where status = ? and start_date <= ? and end_date > ? limit 1;`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("checkPeriodOpen: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	var periodID string
+	timestamp = timestamp.UTC()
+	if err := stmt.QueryRow(PeriodClosed, timestamp, timestamp).Scan(&periodID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return fmt.Errorf("checkPeriodOpen: %w", err)
+	}
+	return fmt.Errorf("period=%s: %w", periodID, errPeriodClosed)
+}
+
+// periodLockWindow is how long before the end of a day postings effective on it start locking periods, so
+// postings which are still in flight when the day ends can't miss a daily period being closed.
+const periodLockWindow = 5 * time.Minute
+
+// postingLocksPeriods returns true if a posting effective on effective could fall inside a period which is closed
+// while it's posting. Periods end at midnight UTC and can be closed once they've ended, so postings effective today
+// only need to lock periods shortly before midnight.
+func postingLocksPeriods(effective, now time.Time) bool {
+	return effective.Before(startOfDay(now.Add(periodLockWindow)))
+}
+
+// checkPeriodVersion makes a posting and closePeriod exclude each other. The version read from tx's snapshot,
+// which checkPeriodOpen also reads from, must still be the latest version, otherwise a period was closed since the
+// snapshot was taken and errPeriodsChanged is returned. Updating the version row then blocks closePeriod until tx
+// commits or rolls back.
+func checkPeriodVersion(tx *sql.Tx) error {
+	stmt, err := tx.Prepare(`select version from accounting_period_version where lock_id = 'periods' limit 1;`)
+	if err != nil {
+		return fmt.Errorf("checkPeriodVersion: prepare: %w", err)
+	}
+	var version int64
+	err = stmt.QueryRow().Scan(&version)
+	stmt.Close()
+	if err != nil {
+		return fmt.Errorf("checkPeriodVersion: %w", err)
+	}
+
+	stmt, err = tx.Prepare(`update accounting_period_version set postings = postings + 1 where lock_id = 'periods' and version = ?;`)
+	if err != nil {
+		return fmt.Errorf("checkPeriodVersion: prepare update: %w", err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(version)
+	if err != nil {
+		return fmt.Errorf("checkPeriodVersion: update: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("checkPeriodVersion: version=%d: %w", version, errPeriodsChanged)
+	}
+	return nil
+}
+
+// lockPeriods increments the period version, which waits for postings which checked it to finish and makes those
+// which haven't retry. It must be called before anything else is read in tx, so tx sees every posting which
+// finished before it.
+func lockPeriods(tx *sql.Tx) error {
+	stmt, err := tx.Prepare(`update accounting_period_version set version = version + 1 where lock_id = 'periods';`)
+	if err != nil {
+		return fmt.Errorf("lockPeriods: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(); err != nil {
+		return fmt.Errorf("lockPeriods: %w", err)
+	}
+	return nil
+}
+
+// openPostingDate returns when a fee or interest dated when is posted. They post on when, unless it's in a month
+// which ended before now or inside a closed period, and then they post at now instead. This keeps them out of
+// statements which were already generated and periods which can no longer change.
+func openPostingDate(tx *sql.Tx, when, now time.Time) (time.Time, error) {
+	now = now.UTC()
+	if when.IsZero() || when.After(now) || when.Before(time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)) {
+		return now, nil
+	}
+	if err := checkPeriodOpen(tx, when); err != nil {
+		if errors.Is(err, errPeriodClosed) {
+			return now, nil
+		}
+		return when, err
+	}
+	return when, nil
+}
+
+func (r *sqlPeriodRepository) closePeriod(period accountingPeriod, actor string) (*accountingPeriod, error) {
+	now := time.Now()
+	if period.End.After(now) {
+		return nil, fmt.Errorf("closePeriod: period=%s: %w", period.ID, errPeriodNotEnded)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("closePeriod: tx.Begin: %w", err)
+	}
+	if err := lockPeriods(tx); err != nil {
+		return nil, fmt.Errorf("closePeriod: period=%s: %w rollback=%v", period.ID, err, tx.Rollback())
+	}
+	existing, err := readPeriod(tx, period.ID)
+	if err != nil {
+		return nil, fmt.Errorf("closePeriod: %w rollback=%v", err, tx.Rollback())
+	}
+	if existing != nil && existing.Status == PeriodClosed {
+		return nil, fmt.Errorf("closePeriod: period=%s: %w rollback=%v", period.ID, errPeriodAlreadyClosed, tx.Rollback())
+	}
+
+	period.Start, period.End = period.Start.UTC(), period.End.UTC()
+	period.Status = PeriodClosed
+	period.ClosedBy = actor
+	period.ClosedAt = &now
+
+	var query string
+	if existing == nil {
+		query = `insert into accounting_periods(period_type, start_date, end_date, status, closed_by, closed_at, created_at, period_id) values (?, ?, ?, ?, ?, ?, ?, ?);`
+	} else {
+		query = `update accounting_periods set period_type = ?, start_date = ?, end_date = ?, status = ?, closed_by = ?, closed_at = ?, created_at = coalesce(created_at, ?) where period_id = ?;`
+	}
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("closePeriod: prepare: error=%w rollback=%v", err, tx.Rollback())
+	}
+	_, err = stmt.Exec(period.Type, period.Start, period.End, period.Status, period.ClosedBy, now, now, period.ID)
+	stmt.Close()
+	if err != nil {
+		return nil, fmt.Errorf("closePeriod: period=%s: error=%w rollback=%v", period.ID, err, tx.Rollback())
+	}
+
+	// Snapshot closing balances from the posted lines since account_balances only holds current balances
+	balances, err := readBalances(tx, `select l.account_id, sum(case when l.direction = 'debit' then -l.amount else l.amount end)
+from transaction_lines l inner join transactions t on t.transaction_id = l.transaction_id
+where t.effective_date < ? and l.deleted_at is null and t.deleted_at is null
+group by l.account_id;`, period.End)
+	if err != nil {
+		return nil, fmt.Errorf("closePeriod: period=%s balances: %w rollback=%v", period.ID, err, tx.Rollback())
+	}
+	if err := writePeriodBalances(tx, period.ID, balances); err != nil {
+		return nil, fmt.Errorf("closePeriod: %w rollback=%v", err, tx.Rollback())
+	}
+
+	if err := insertPeriodEvent(tx, period.ID, periodEvent{Action: "close", Actor: actor, CreatedAt: now}); err != nil {
+		return nil, fmt.Errorf("closePeriod: %w rollback=%v", err, tx.Rollback())
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("closePeriod: commit: %w", err)
+	}
+	return &period, nil
+}
+
+// writePeriodBalances replaces the closing balances of a period
+func writePeriodBalances(tx *sql.Tx, periodID string, balances map[string]int64) error {
+	stmt, err := tx.Prepare(`delete from accounting_period_balances where period_id = ?;`)
+	if err != nil {
+		return fmt.Errorf("prepare delete balances: %w", err)
+	}
+	_, err = stmt.Exec(periodID)
+	stmt.Close()
+	if err != nil {
+		return fmt.Errorf("period=%q delete balances: %w", periodID, err)
+	}
+
+	stmt, err = tx.Prepare(`insert into accounting_period_balances(period_id, account_id, balance) values (?, ?, ?);`)
+	if err != nil {
+		return fmt.Errorf("prepare insert balances: %w", err)
+	}
+	defer stmt.Close()
+
+	for accountID, balance := range balances {
+		if _, err := stmt.Exec(periodID, accountID, balance); err != nil {
+			return fmt.Errorf("period=%q account=%q balance: %w", periodID, accountID, err)
+		}
+	}
+	return nil
+}
+
+func (r *sqlPeriodRepository) reopenPeriod(periodID, actor, reason string) (*accountingPeriod, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("reopenPeriod: tx.Begin: %w", err)
+	}
+	period, err := readPeriod(tx, periodID)
+	if err != nil {
+		return nil, fmt.Errorf("reopenPeriod: %w rollback=%v", err, tx.Rollback())
+	}
+	if period == nil {
+		return nil, fmt.Errorf("reopenPeriod: period=%s: %w rollback=%v", periodID, errPeriodNotFound, tx.Rollback())
+	}
+	if period.Status != PeriodClosed {
+		return nil, fmt.Errorf("reopenPeriod: period=%s: %w rollback=%v", periodID, errPeriodNotClosed, tx.Rollback())
+	}
+
+	stmt, err := tx.Prepare(`update accounting_periods set status = ? where period_id = ? and status = ?;`)
+	if err != nil {
+		return nil, fmt.Errorf("reopenPeriod: prepare: error=%w rollback=%v", err, tx.Rollback())
+	}
+	_, err = stmt.Exec(PeriodOpen, periodID, PeriodClosed)
+	stmt.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reopenPeriod: period=%s: error=%w rollback=%v", periodID, err, tx.Rollback())
+	}
+
+	if err := insertPeriodEvent(tx, periodID, periodEvent{Action: "reopen", Actor: actor, Reason: reason, CreatedAt: time.Now()}); err != nil {
+		return nil, fmt.Errorf("reopenPeriod: %w rollback=%v", err, tx.Rollback())
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("reopenPeriod: commit: %w", err)
+	}
+	period.Status = PeriodOpen
+	return period, nil
+}
+
+func (r *sqlPeriodRepository) getPeriod(periodID string) (*accountingPeriod, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("getPeriod: tx.Begin: %w", err)
+	}
+	period, err := readPeriod(tx, periodID)
+	if err != nil {
+		return nil, fmt.Errorf("getPeriod: %w rollback=%v", err, tx.Rollback())
+	}
+	return period, tx.Commit()
+}
+
+func (r *sqlPeriodRepository) getPeriodBalances(periodID string) (map[string]int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("getPeriodBalances: tx.Begin: %w", err)
+	}
+	balances, err := readBalances(tx, `select account_id, balance from accounting_period_balances where period_id = ?;`, periodID)
+	if err != nil {
+		return nil, fmt.Errorf("getPeriodBalances: period=%s: %w rollback=%v", periodID, err, tx.Rollback())
+	}
+	return balances, tx.Commit()
+}
+
+func (r *sqlPeriodRepository) getPeriodEvents(periodID string) ([]periodEvent, error) {
+	stmt, err := r.db.Prepare(`select action, actor, reason, created_at from accounting_period_events where period_id = ? order by created_at asc;`)
+	if err != nil {
+		return nil, fmt.Errorf("getPeriodEvents: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(periodID)
+	if err != nil {
+		return nil, fmt.Errorf("getPeriodEvents: query: %w", err)
+	}
+	defer rows.Close()
+
+	var events []periodEvent
+	for rows.Next() {
+		var event periodEvent
+		var reason sql.NullString
+		if err := rows.Scan(&event.Action, &event.Actor, &reason, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("getPeriodEvents: scan period=%s: %w", periodID, err)
+		}
+		event.Reason = reason.String
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,254 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestSqlPeriodRepository__periods(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+		periodRepo := setupSqlPeriodStorage(log.NewNopLogger(), repo.db)
+
+		account1, account2 := base.ID(), base.ID()
+		repo.accountRepo = &testAccountRepository{
+			accounts: []*accounts.Account{
+				{ID: account1, Status: "open", RoutingNumber: defaultRoutingNumber},
+				{ID: account2, Status: "open", RoutingNumber: defaultRoutingNumber},
+			},
+		}
+		transfer := func(when time.Time, amount int64) error {
+			return repo.createTransaction(transaction{
+				ID:        base.ID(),
+				Timestamp: when,
+				Lines: []transactionLine{
+					{AccountID: account1, Purpose: ACHDebit, Direction: Debit, Amount: amount},
+					{AccountID: account2, Purpose: ACHCredit, Direction: Credit, Amount: amount},
+				},
+			}, createTransactionOpts{AllowOverdraft: true})
+		}
+		if err := transfer(time.Date(2020, time.March, 10, 12, 0, 0, 0, time.UTC), 500); err != nil {
+			t.Fatal(err)
+		}
+		if err := transfer(time.Date(2020, time.April, 2, 12, 0, 0, 0, time.UTC), 200); err != nil {
+			t.Fatal(err)
+		}
+		// Closing balances must hold more than fits in an int32 of cents
+		if err := transfer(time.Date(2020, time.March, 11, 12, 0, 0, 0, time.UTC), 5000000000000); err != nil {
+			t.Fatal(err)
+		}
+
+		period, _ := readAccountingPeriod(MonthlyPeriod, "2020-03")
+		closed, err := periodRepo.closePeriod(period, "auditor")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if closed.Status != PeriodClosed || closed.ClosedBy != "auditor" || closed.ClosedAt == nil {
+			t.Errorf("unexpected period: %#v", closed)
+		}
+		if _, err := periodRepo.closePeriod(period, "auditor"); err == nil || !strings.Contains(err.Error(), errPeriodAlreadyClosed.Error()) {
+			t.Errorf("expected already closed: %v", err)
+		}
+
+		// Closing balances only include March
+		balances, err := periodRepo.getPeriodBalances(period.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(balances) != 2 || balances[account1] != -5000000000500 || balances[account2] != 5000000000500 {
+			t.Errorf("unexpected closing balances: %#v", balances)
+		}
+
+		// Postings inside the closed period are rejected, but not after it
+		if err := transfer(time.Date(2020, time.March, 31, 23, 0, 0, 0, time.UTC), 100); err == nil || !strings.Contains(err.Error(), errPeriodClosed.Error()) {
+			t.Errorf("expected closed period error: %v", err)
+		}
+		if err := transfer(time.Date(2020, time.April, 1, 0, 0, 0, 0, time.UTC), 100); err != nil {
+			t.Error(err)
+		}
+
+		// Reopen and post the late transaction
+		reopened, err := periodRepo.reopenPeriod(period.ID, "controller", "late return")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reopened.Status != PeriodOpen || !reopened.Start.Equal(period.Start) {
+			t.Errorf("unexpected period: %#v", reopened)
+		}
+		if _, err := periodRepo.reopenPeriod(period.ID, "controller", "again"); err == nil || !strings.Contains(err.Error(), errPeriodNotClosed.Error()) {
+			t.Errorf("expected not closed: %v", err)
+		}
+		if err := transfer(time.Date(2020, time.March, 31, 23, 0, 0, 0, time.UTC), 100); err != nil {
+			t.Error(err)
+		}
+
+		// Closing again takes a new snapshot
+		if _, err := periodRepo.closePeriod(period, "auditor"); err != nil {
+			t.Fatal(err)
+		}
+		if balances, _ := periodRepo.getPeriodBalances(period.ID); balances[account2] != 5000000000600 {
+			t.Errorf("unexpected closing balances: %#v", balances)
+		}
+		events, err := periodRepo.getPeriodEvents(period.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 3 || events[1].Action != "reopen" || events[1].Actor != "controller" || events[1].Reason != "late return" {
+			t.Errorf("unexpected events: %#v", events)
+		}
+
+		if _, err := periodRepo.reopenPeriod("daily-2020-01-01", "controller", "missing"); err == nil || !strings.Contains(err.Error(), errPeriodNotFound.Error()) {
+			t.Errorf("expected not found: %v", err)
+		}
+		if p, err := periodRepo.getPeriod("daily-2020-01-01"); p != nil || err != nil {
+			t.Errorf("period=%#v error=%v", p, err)
+		}
+
+		// Periods which haven't ended can't be closed
+		today, _ := readAccountingPeriod(DailyPeriod, time.Now().Format("2006-01-02"))
+		if _, err := periodRepo.closePeriod(today, "auditor"); err == nil || !strings.Contains(err.Error(), errPeriodNotEnded.Error()) {
+			t.Errorf("expected not ended: %v", err)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
+
+func TestSqlPeriodRepository__openPostingDate(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+		periodRepo := setupSqlPeriodStorage(log.NewNopLogger(), repo.db)
+
+		period, _ := readAccountingPeriod(DailyPeriod, "2020-03-10")
+		if _, err := periodRepo.closePeriod(period, "auditor"); err != nil {
+			t.Fatal(err)
+		}
+
+		tx, err := repo.db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+
+		now := time.Date(2020, time.March, 20, 12, 0, 0, 0, time.UTC)
+		cases := map[time.Time]time.Time{
+			time.Date(2020, time.March, 11, 12, 0, 0, 0, time.UTC):    time.Date(2020, time.March, 11, 12, 0, 0, 0, time.UTC),
+			time.Date(2020, time.March, 10, 12, 0, 0, 0, time.UTC):    now, // closed period
+			time.Date(2020, time.February, 29, 12, 0, 0, 0, time.UTC): now, // month has ended
+			now.Add(time.Hour): now,
+			{}:                 now,
+		}
+		for when, expected := range cases {
+			if got, err := openPostingDate(tx, when, now); err != nil || !got.Equal(expected) {
+				t.Errorf("%v: got %v error=%v", when, got, err)
+			}
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
+
+func TestSqlPeriodRepository__postingLocksPeriods(t *testing.T) {
+	now := time.Date(2020, time.March, 20, 12, 0, 0, 0, time.UTC)
+	if !postingLocksPeriods(time.Date(2020, time.March, 19, 23, 0, 0, 0, time.UTC), now) {
+		t.Error("postings before today can be in a period being closed")
+	}
+	if postingLocksPeriods(time.Date(2020, time.March, 20, 0, 0, 0, 0, time.UTC), now) {
+		t.Error("postings effective today can't be in an ended period")
+	}
+	if !postingLocksPeriods(time.Date(2020, time.March, 20, 23, 0, 0, 0, time.UTC), now.Add(11*time.Hour+57*time.Minute)) {
+		t.Error("postings into today lock periods shortly before midnight")
+	}
+}
+
+func TestSqlPeriodRepository__concurrentClose(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+		periodRepo := setupSqlPeriodStorage(log.NewNopLogger(), repo.db)
+
+		account1, account2 := base.ID(), base.ID()
+		repo.accountRepo = &testAccountRepository{
+			accounts: []*accounts.Account{
+				{ID: account1, Status: "open", RoutingNumber: defaultRoutingNumber},
+				{ID: account2, Status: "open", RoutingNumber: defaultRoutingNumber},
+			},
+		}
+
+		// Post into March while it's being closed. Each posting either lands before the close, and is in the
+		// closing balances, or is rejected.
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs <- repo.createTransaction(transaction{
+					ID:        base.ID(),
+					Timestamp: time.Date(2020, time.March, 10+i, 12, 0, 0, 0, time.UTC),
+					Lines: []transactionLine{
+						{AccountID: account1, Purpose: ACHDebit, Direction: Debit, Amount: 100},
+						{AccountID: account2, Purpose: ACHCredit, Direction: Credit, Amount: 100},
+					},
+				}, createTransactionOpts{AllowOverdraft: true})
+			}(i)
+		}
+		period, _ := readAccountingPeriod(MonthlyPeriod, "2020-03")
+		if _, err := periodRepo.closePeriod(period, "auditor"); err != nil {
+			t.Fatal(err)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil && !errors.Is(err, errPeriodClosed) && !database.LockConflict(err) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+
+		balances, err := periodRepo.getPeriodBalances(period.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if balance, err := repo.getAccountBalanceAsOf(account2, period.End); err != nil || balances[account2] != balance {
+			t.Errorf("closing balance=%d posted=%d: %v", balances[account2], balance, err)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
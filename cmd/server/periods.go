@@ -0,0 +1,274 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	moovhttp "github.com/moov-io/base/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+// PeriodType is how long an accounting period lasts. Periods start at midnight UTC.
+type PeriodType string
+
+var (
+	DailyPeriod   PeriodType = "daily"
+	MonthlyPeriod PeriodType = "monthly"
+)
+
+type PeriodStatus string
+
+var (
+	PeriodOpen   PeriodStatus = "open"
+	PeriodClosed PeriodStatus = "closed"
+)
+
+// accountingPeriod is a span of time [Start, End) which can be closed so that reported balances stop changing.
+// Periods are identified by their type and start date, such as monthly-2020-03 or daily-2020-03-31.
+type accountingPeriod struct {
+	ID     string       `json:"id"`
+	Type   PeriodType   `json:"type"`
+	Start  time.Time    `json:"start"`
+	End    time.Time    `json:"end"`
+	Status PeriodStatus `json:"status"`
+
+	ClosedBy string     `json:"closedBy,omitempty"`
+	ClosedAt *time.Time `json:"closedAt,omitempty"`
+}
+
+// readAccountingPeriod returns the period of type which includes date. Monthly periods accept YYYY-MM or
+// any YYYY-MM-DD date in the month.
+func readAccountingPeriod(periodType PeriodType, date string) (accountingPeriod, error) {
+	date = strings.TrimSpace(date)
+	var start time.Time
+	var err error
+	switch periodType {
+	case DailyPeriod:
+		start, err = time.Parse("2006-01-02", date)
+	case MonthlyPeriod:
+		if start, err = time.Parse("2006-01", date); err != nil {
+			start, err = time.Parse("2006-01-02", date)
+		}
+		start = time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return accountingPeriod{}, fmt.Errorf("unknown period type %q", periodType)
+	}
+	if err != nil {
+		return accountingPeriod{}, fmt.Errorf("invalid %s period date %q", periodType, date)
+	}
+	return newAccountingPeriod(periodType, start), nil
+}
+
+func newAccountingPeriod(periodType PeriodType, start time.Time) accountingPeriod {
+	p := accountingPeriod{
+		Type:   periodType,
+		Start:  start,
+		Status: PeriodOpen,
+	}
+	if periodType == DailyPeriod {
+		p.ID = fmt.Sprintf("%s-%s", periodType, start.Format("2006-01-02"))
+		p.End = start.AddDate(0, 0, 1)
+	} else {
+		p.ID = fmt.Sprintf("%s-%s", periodType, start.Format("2006-01"))
+		p.End = start.AddDate(0, 1, 0)
+	}
+	return p
+}
+
+// readAccountingPeriodID returns the period from an ID such as monthly-2020-03
+func readAccountingPeriodID(periodID string) (accountingPeriod, error) {
+	idx := strings.Index(periodID, "-")
+	if idx < 0 {
+		return accountingPeriod{}, fmt.Errorf("invalid period %q", periodID)
+	}
+	p, err := readAccountingPeriod(PeriodType(periodID[:idx]), periodID[idx+1:])
+	if err != nil || p.ID != periodID {
+		return p, fmt.Errorf("invalid period %q", periodID)
+	}
+	return p, nil
+}
+
+// periodEvent is an audit record of a period being closed or reopened
+type periodEvent struct {
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type accountingPeriodDetails struct {
+	accountingPeriod
+
+	// ClosingBalances are the balance of each account at the end of the period, keyed by account ID
+	ClosingBalances map[string]int64 `json:"closingBalances"`
+	Events          []periodEvent    `json:"events"`
+}
+
+type reopenPeriodRequest struct {
+	Reason string `json:"reason"`
+}
+
+// addPeriodRoutes registers the admin endpoints for closing and reopening accounting periods. Only the
+// users in reopenUsers can reopen a closed period.
+func addPeriodRoutes(logger log.Logger, handle func(string, http.HandlerFunc), repo periodRepository, reopenUsers []string) {
+	handle("/periods/close", closePeriod(logger, repo))
+	handle("/periods/{periodID}/reopen", reopenPeriod(logger, repo, reopenUsers))
+	handle("/periods/{periodID}", getPeriod(logger, repo))
+}
+
+// readPeriodReopenUsers parses a comma separated list of user IDs
+func readPeriodReopenUsers(v string) []string {
+	var out []string
+	for _, user := range strings.Split(v, ",") {
+		if user = strings.TrimSpace(user); user != "" {
+			out = append(out, user)
+		}
+	}
+	return out
+}
+
+func periodProblem(w http.ResponseWriter, err error, status int) {
+	http.Error(w, err.Error(), status)
+}
+
+func closePeriod(logger log.Logger, repo periodRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, fmt.Sprintf("unsupported HTTP verb %s", r.Method), http.StatusBadRequest)
+			return
+		}
+		actor := moovhttp.GetUserID(r)
+		if actor == "" {
+			periodProblem(w, errors.New("missing X-User-ID header"), http.StatusForbidden)
+			return
+		}
+		q := r.URL.Query()
+		period, err := readAccountingPeriod(PeriodType(strings.ToLower(q.Get("type"))), q.Get("date"))
+		if err != nil {
+			periodProblem(w, err, http.StatusBadRequest)
+			return
+		}
+		if period.End.After(time.Now()) {
+			periodProblem(w, errPeriodNotEnded, http.StatusBadRequest)
+			return
+		}
+
+		closed, err := repo.closePeriod(period, actor)
+		if err != nil {
+			logger.Log("periods", fmt.Sprintf("problem closing period=%s: %v", period.ID, err), "userID", actor)
+			status := http.StatusInternalServerError
+			if errors.Is(err, errPeriodAlreadyClosed) {
+				status = http.StatusConflict
+			}
+			periodProblem(w, err, status)
+			return
+		}
+		logger.Log("periods", fmt.Sprintf("closed period=%s", period.ID), "userID", actor)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(closed)
+	}
+}
+
+func reopenPeriod(logger log.Logger, repo periodRepository, reopenUsers []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, fmt.Sprintf("unsupported HTTP verb %s", r.Method), http.StatusBadRequest)
+			return
+		}
+		actor := moovhttp.GetUserID(r)
+		if !canReopenPeriods(actor, reopenUsers) {
+			logger.Log("periods", fmt.Sprintf("user=%q isn't allowed to reopen periods", actor))
+			periodProblem(w, fmt.Errorf("user %q can't reopen accounting periods", actor), http.StatusForbidden)
+			return
+		}
+		var req reopenPeriodRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			periodProblem(w, err, http.StatusBadRequest)
+			return
+		}
+		if req.Reason = strings.TrimSpace(req.Reason); req.Reason == "" {
+			periodProblem(w, errors.New("a reason is required to reopen a period"), http.StatusBadRequest)
+			return
+		}
+
+		periodID := mux.Vars(r)["periodID"]
+		period, err := repo.reopenPeriod(periodID, actor, req.Reason)
+		if err != nil {
+			logger.Log("periods", fmt.Sprintf("problem reopening period=%s: %v", periodID, err), "userID", actor)
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, errPeriodNotFound):
+				status = http.StatusNotFound
+			case errors.Is(err, errPeriodNotClosed):
+				status = http.StatusConflict
+			}
+			periodProblem(w, err, status)
+			return
+		}
+		logger.Log("periods", fmt.Sprintf("reopened period=%s: %s", periodID, req.Reason), "userID", actor)
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(period)
+	}
+}
+
+func canReopenPeriods(userID string, reopenUsers []string) bool {
+	for i := range reopenUsers {
+		if userID != "" && userID == reopenUsers[i] {
+			return true
+		}
+	}
+	return false
+}
+
+func getPeriod(logger log.Logger, repo periodRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			http.Error(w, fmt.Sprintf("unsupported HTTP verb %s", r.Method), http.StatusBadRequest)
+			return
+		}
+		periodID := mux.Vars(r)["periodID"]
+		period, err := readAccountingPeriodID(periodID)
+		if err != nil {
+			periodProblem(w, err, http.StatusBadRequest)
+			return
+		}
+		found, err := repo.getPeriod(periodID)
+		if err != nil {
+			logger.Log("periods", fmt.Sprintf("problem reading period=%s: %v", periodID, err))
+			periodProblem(w, err, http.StatusInternalServerError)
+			return
+		}
+		if found != nil {
+			period = *found
+		}
+		details := accountingPeriodDetails{accountingPeriod: period}
+		if details.ClosingBalances, err = repo.getPeriodBalances(periodID); err != nil {
+			logger.Log("periods", fmt.Sprintf("problem reading period=%s balances: %v", periodID, err))
+			periodProblem(w, err, http.StatusInternalServerError)
+			return
+		}
+		if details.Events, err = repo.getPeriodEvents(periodID); err != nil {
+			logger.Log("periods", fmt.Sprintf("problem reading period=%s events: %v", periodID, err))
+			periodProblem(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(details)
+	}
+}
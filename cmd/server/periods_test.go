@@ -0,0 +1,114 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moov-io/accounts/cmd/server/database"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+func TestPeriods__readAccountingPeriod(t *testing.T) {
+	p, err := readAccountingPeriod(MonthlyPeriod, "2020-02-14")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "monthly-2020-02" || !p.Start.Equal(time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)) || !p.End.Equal(time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected period: %#v", p)
+	}
+	if p, err = readAccountingPeriodID("daily-2020-12-31"); err != nil || !p.End.Equal(time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("period=%#v error=%v", p, err)
+	}
+
+	for _, id := range []string{"daily", "weekly-2020-01", "daily-2020-01", "monthly-2020-01-01"} {
+		if _, err := readAccountingPeriodID(id); err == nil {
+			t.Errorf("%s: expected error", id)
+		}
+	}
+	if users := readPeriodReopenUsers(" a, ,b"); len(users) != 2 || users[1] != "b" {
+		t.Errorf("unexpected users: %v", users)
+	}
+}
+
+func TestPeriods__routes(t *testing.T) {
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+
+	repo := createTestSqlTransactionRepository(t, sqliteDB.DB)
+	router := mux.NewRouter()
+	addPeriodRoutes(log.NewNopLogger(), func(path string, hf http.HandlerFunc) { router.HandleFunc(path, hf) }, setupSqlPeriodStorage(log.NewNopLogger(), repo.db), []string{"controller"})
+
+	call := func(method, path, userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if userID != "" {
+			req.Header.Set("x-user-id", userID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		w.Flush()
+		return w
+	}
+
+	if w := call("POST", "/periods/close?type=monthly&date=2020-03", "", ""); w.Code != http.StatusForbidden {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	if w := call("POST", "/periods/close?type=yearly&date=2020", "auditor", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	if w := call("POST", "/periods/close?type=daily&date="+time.Now().Format("2006-01-02"), "auditor", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	if w := call("POST", "/periods/close?type=monthly&date=2020-03", "auditor", ""); w.Code != http.StatusOK {
+		t.Errorf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+	if w := call("POST", "/periods/close?type=monthly&date=2020-03", "auditor", ""); w.Code != http.StatusConflict {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+
+	// Only permitted users can reopen, and must give a reason
+	if w := call("POST", "/periods/monthly-2020-03/reopen", "auditor", `{"reason": "late return"}`); w.Code != http.StatusForbidden {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	if w := call("POST", "/periods/monthly-2020-03/reopen", "controller", `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	if w := call("POST", "/periods/monthly-2020-02/reopen", "controller", `{"reason": "late return"}`); w.Code != http.StatusNotFound {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	if w := call("POST", "/periods/monthly-2020-03/reopen", "controller", `{"reason": "late return"}`); w.Code != http.StatusOK {
+		t.Errorf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+
+	w := call("GET", "/periods/monthly-2020-03", "auditor", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+	var details accountingPeriodDetails
+	if err := json.NewDecoder(w.Body).Decode(&details); err != nil {
+		t.Fatal(err)
+	}
+	if details.Status != PeriodOpen || details.ClosedBy != "auditor" || len(details.Events) != 2 {
+		t.Errorf("unexpected period: %#v", details)
+	}
+
+	// Periods which were never closed are open
+	if w := call("GET", "/periods/daily-2020-01-01", "auditor", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"open"`) {
+		t.Errorf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+	if w := call("GET", "/periods/daily-2020-01", "auditor", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	if w := call("GET", "/periods/close", "auditor", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+)
+
+type productRepository interface {
+	// getAccountProducts returns every product in the catalog ordered by code
+	getAccountProducts() ([]accountProduct, error)
+	// getAccountProduct returns the product whose code matches accountType, or nil if there isn't one
+	getAccountProduct(accountType string) (*accountProduct, error)
+	// saveAccountProduct adds a product to the catalog or replaces the product with the same code
+	saveAccountProduct(product accountProduct) error
+}
+
+var (
+	errPurposeNotAllowed         = errors.New("account product doesn't allow purpose")
+	errWithdrawalLimitExceeded   = errors.New("exceeds the withdrawal limit of its account product")
+	errDailyDebitLimitExceeded   = errors.New("exceeds the daily debit limit of its account product")
+	errMonthlyDebitLimitExceeded = errors.New("exceeds the monthly debit limit of its account product")
+	errPurposeLimitExceeded      = errors.New("exceeds the purpose limit of its account product")
+
+	errWithdrawalCounterConflict = errors.New("withdrawal counter was modified concurrently")
+)
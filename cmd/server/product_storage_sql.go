@@ -0,0 +1,201 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	accounts "github.com/moov-io/accounts/client"
+
+	"github.com/go-kit/kit/log"
+)
+
+type sqlProductRepository struct {
+	db     *sql.DB
+	logger log.Logger
+}
+
+func setupSqlProductStorage(logger log.Logger, db *sql.DB) *sqlProductRepository {
+	return &sqlProductRepository{db: db, logger: logger}
+}
+
+const accountProductColumns = `product_code, name, minimum_opening_deposit, allowed_purposes, interest_plan, fee_plan, max_withdrawals, daily_debit_limit, monthly_debit_limit, purpose_limits, created_at, last_modified`
+
+func scanAccountProduct(row rowScanner) (*accountProduct, error) {
+	var product accountProduct
+	var purposes, limits string
+	err := row.Scan(&product.Code, &product.Name, &product.MinimumOpeningDeposit, &purposes, &product.InterestPlan, &product.FeePlan,
+		&product.WithdrawalLimit, &product.DailyDebitLimit, &product.MonthlyDebitLimit, &limits, &product.CreatedAt, &product.LastModified)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range strings.Split(purposes, ",") {
+		if p != "" {
+			product.AllowedPurposes = append(product.AllowedPurposes, TransactionPurpose(p))
+		}
+	}
+	if product.PurposeLimits, err = readPurposeLimits(limits); err != nil {
+		return nil, fmt.Errorf("product=%s: %w", product.Code, err)
+	}
+	return &product, nil
+}
+
+// readPurposeLimits parses purpose limits stored as a comma separated list of purpose:monthlyAmount pairs
+func readPurposeLimits(v string) ([]purposeLimit, error) {
+	var out []purposeLimit
+	for _, pair := range strings.Split(v, ",") {
+		if pair == "" {
+			continue
+		}
+		idx := strings.LastIndex(pair, ":")
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid purpose limit %q", pair)
+		}
+		amount, err := strconv.ParseInt(pair[idx+1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid purpose limit %q: %w", pair, err)
+		}
+		out = append(out, purposeLimit{Purpose: TransactionPurpose(pair[:idx]), MonthlyAmount: amount})
+	}
+	return out, nil
+}
+
+func formatPurposeLimits(limits []purposeLimit) string {
+	pairs := make([]string, len(limits))
+	for i := range limits {
+		pairs[i] = fmt.Sprintf("%s:%d", limits[i].Purpose, limits[i].MonthlyAmount)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// readAccountProduct returns the product whose code matches accountType, or nil if there isn't one
+func readAccountProduct(tx *sql.Tx, accountType string) (*accountProduct, error) {
+	stmt, err := tx.Prepare(fmt.Sprintf(`select %s from account_products where product_code = ? limit 1;`, accountProductColumns))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	product, err := scanAccountProduct(stmt.QueryRow(strings.ToLower(accountType)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return product, err
+}
+
+// checkAccountProducts rejects lines whose purpose isn't allowed by the product of their account and debits past
+// the product's withdrawal limits, recording the withdrawal in the account's counters. Accounts whose type isn't
+// in the catalog aren't limited.
+func checkAccountProducts(tx *sql.Tx, t transaction, accts []*accounts.Account) error {
+	for i := range accts {
+		product, err := readAccountProduct(tx, accts[i].Type)
+		if err != nil {
+			return fmt.Errorf("account=%q product: %w", accts[i].ID, err)
+		}
+		if product == nil {
+			continue
+		}
+		for j := range t.Lines {
+			if t.Lines[j].AccountID == accts[i].ID && !product.allowsPurpose(t.Lines[j].Purpose) {
+				return fmt.Errorf("account=%q %w %s", accts[i].ID, errPurposeNotAllowed, t.Lines[j].Purpose)
+			}
+		}
+		w := readWithdrawal(accts[i].ID, t.Lines)
+		if w.Amount == 0 {
+			continue
+		}
+		if err := applyWithdrawal(tx, *product, accts[i].ID, effectiveDateOr(t.EffectiveDate, t.Timestamp), w); err != nil {
+			return fmt.Errorf("account=%q %w", accts[i].ID, err)
+		}
+	}
+	return nil
+}
+
+func (r *sqlProductRepository) getAccountProducts() ([]accountProduct, error) {
+	stmt, err := r.db.Prepare(fmt.Sprintf(`select %s from account_products order by product_code;`, accountProductColumns))
+	if err != nil {
+		return nil, fmt.Errorf("getAccountProducts: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query()
+	if err != nil {
+		return nil, fmt.Errorf("getAccountProducts: query: %w", err)
+	}
+	defer rows.Close()
+
+	var out []accountProduct
+	for rows.Next() {
+		product, err := scanAccountProduct(rows)
+		if err != nil {
+			return nil, fmt.Errorf("getAccountProducts: scan: %w", err)
+		}
+		out = append(out, *product)
+	}
+	return out, rows.Err()
+}
+
+func (r *sqlProductRepository) getAccountProduct(accountType string) (*accountProduct, error) {
+	stmt, err := r.db.Prepare(fmt.Sprintf(`select %s from account_products where product_code = ? limit 1;`, accountProductColumns))
+	if err != nil {
+		return nil, fmt.Errorf("getAccountProduct: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	product, err := scanAccountProduct(stmt.QueryRow(strings.ToLower(accountType)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getAccountProduct: code=%s: %w", accountType, err)
+	}
+	return product, nil
+}
+
+func (r *sqlProductRepository) saveAccountProduct(product accountProduct) error {
+	purposes := make([]string, len(product.AllowedPurposes))
+	for i := range product.AllowedPurposes {
+		purposes[i] = string(product.AllowedPurposes[i])
+	}
+	allowed, limits := strings.Join(purposes, ","), formatPurposeLimits(product.PurposeLimits)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("saveAccountProduct: tx.Begin: %w", err)
+	}
+
+	query := `update account_products set name = ?, minimum_opening_deposit = ?, allowed_purposes = ?, interest_plan = ?, fee_plan = ?, max_withdrawals = ?,
+daily_debit_limit = ?, monthly_debit_limit = ?, purpose_limits = ?, last_modified = ? where product_code = ?;`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("saveAccountProduct: prepare update: error=%w rollback=%v", err, tx.Rollback())
+	}
+	res, err := stmt.Exec(product.Name, product.MinimumOpeningDeposit, allowed, product.InterestPlan, product.FeePlan, product.WithdrawalLimit,
+		product.DailyDebitLimit, product.MonthlyDebitLimit, limits, product.LastModified, product.Code)
+	stmt.Close()
+	if err != nil {
+		return fmt.Errorf("saveAccountProduct: code=%s update: error=%w rollback=%v", product.Code, err, tx.Rollback())
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		query = fmt.Sprintf(`insert into account_products(%s) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`, accountProductColumns)
+		stmt, err = tx.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("saveAccountProduct: prepare insert: error=%w rollback=%v", err, tx.Rollback())
+		}
+		_, err = stmt.Exec(product.Code, product.Name, product.MinimumOpeningDeposit, allowed, product.InterestPlan, product.FeePlan, product.WithdrawalLimit,
+			product.DailyDebitLimit, product.MonthlyDebitLimit, limits, product.CreatedAt, product.LastModified)
+		stmt.Close()
+		if err != nil {
+			return fmt.Errorf("saveAccountProduct: code=%s insert: error=%w rollback=%v", product.Code, err, tx.Rollback())
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("saveAccountProduct: commit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestSqlProductRepository__accountProducts(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+		productRepo := setupSqlProductStorage(log.NewNopLogger(), repo.db)
+
+		// checking and savings are in the catalog to start
+		products, err := productRepo.getAccountProducts()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(products) != 2 || products[0].Code != "checking" || products[1].Code != "savings" || products[1].MinimumOpeningDeposit != 100 {
+			t.Fatalf("unexpected products: %#v", products)
+		}
+		if p, err := productRepo.getAccountProduct("Savings"); err != nil || p == nil || p.Name != "Savings" {
+			t.Fatalf("product=%#v error=%v", p, err)
+		}
+		if p, err := productRepo.getAccountProduct("other"); p != nil || err != nil {
+			t.Fatalf("product=%#v error=%v", p, err)
+		}
+
+		now := time.Now()
+		product := accountProduct{
+			Code:                  "money-market",
+			Name:                  "Money market",
+			MinimumOpeningDeposit: 250000,
+			AllowedPurposes:       []TransactionPurpose{ACHCredit, ACHDebit, Transfer},
+			InterestPlan:          "High yield",
+			FeePlan:               "Premier",
+			WithdrawalLimit:       2,
+			CreatedAt:             now,
+			LastModified:          now,
+		}
+		if err := productRepo.saveAccountProduct(product); err != nil {
+			t.Fatal(err)
+		}
+		product.Name, product.WithdrawalLimit = "Money market plus", 1
+		if err := productRepo.saveAccountProduct(product); err != nil {
+			t.Fatal(err)
+		}
+		found, err := productRepo.getAccountProduct("money-market")
+		if err != nil || found == nil {
+			t.Fatalf("product=%#v error=%v", found, err)
+		}
+		if found.Name != "Money market plus" || found.WithdrawalLimit != 1 || len(found.AllowedPurposes) != 3 || found.AllowedPurposes[2] != Transfer || found.FeePlan != "Premier" {
+			t.Errorf("unexpected product: %#v", found)
+		}
+
+		// Transactions are checked against the product of each account
+		account := &accounts.Account{ID: base.ID(), Status: "open", RoutingNumber: defaultRoutingNumber, Type: "Money-Market"}
+		repo.accountRepo = &testAccountRepository{accounts: []*accounts.Account{account}}
+		createTestGLAccount(t, repo.db, defaultRoutingNumber, createGLAccountRequest{Code: "0010", Name: "Cash", Category: GLAsset})
+		cash := glAccountID(defaultRoutingNumber, "0010")
+		post := func(purpose TransactionPurpose, direction LineDirection, amount int64, effective time.Time) error {
+			return repo.createTransaction(transaction{
+				ID:            base.ID(),
+				Timestamp:     time.Now(),
+				EffectiveDate: effective,
+				Lines: []transactionLine{
+					{AccountID: account.ID, Purpose: purpose, Direction: direction, Amount: amount},
+					{AccountID: cash, Purpose: purpose, Direction: direction.opposite(), Amount: amount},
+				},
+			}, createTransactionOpts{AllowGLDebits: true})
+		}
+		if err := post(ACHCredit, Credit, 10000, now); err != nil {
+			t.Fatal(err)
+		}
+		if err := post(Wire, Debit, 100, now); err == nil || !strings.Contains(err.Error(), errPurposeNotAllowed.Error()) {
+			t.Errorf("expected purpose to be rejected: %v", err)
+		}
+		if err := post(Transfer, Debit, 100, now); err != nil {
+			t.Fatal(err)
+		}
+		if err := post(ACHDebit, Debit, 100, now); err == nil || !strings.Contains(err.Error(), errWithdrawalLimitExceeded.Error()) {
+			t.Errorf("expected withdrawal limit: %v", err)
+		}
+		// Credits and debits in other months aren't limited
+		if err := post(ACHCredit, Credit, 100, now); err != nil {
+			t.Fatal(err)
+		}
+		if err := post(ACHDebit, Debit, 100, now.AddDate(0, -1, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
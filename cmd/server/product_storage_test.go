@@ -0,0 +1,59 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+)
+
+var (
+	mockProductRepo = &testProductRepository{
+		products: []accountProduct{
+			{Code: "checking", Name: "Checking", MinimumOpeningDeposit: 100},
+			{Code: "savings", Name: "Savings", MinimumOpeningDeposit: 100},
+		},
+	}
+)
+
+// testProductRepository represents a mocked productRepository where products or err are
+// returned if set. Tests are fully responsible for managing state.
+type testProductRepository struct {
+	products []accountProduct
+
+	err error
+}
+
+func (r *testProductRepository) getAccountProducts() ([]accountProduct, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.products, nil
+}
+
+func (r *testProductRepository) getAccountProduct(accountType string) (*accountProduct, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	for i := range r.products {
+		if strings.EqualFold(r.products[i].Code, accountType) {
+			return &r.products[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *testProductRepository) saveAccountProduct(product accountProduct) error {
+	if r.err != nil {
+		return r.err
+	}
+	for i := range r.products {
+		if r.products[i].Code == product.Code {
+			r.products[i] = product
+			return nil
+		}
+	}
+	r.products = append(r.products, product)
+	return nil
+}
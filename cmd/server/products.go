@@ -0,0 +1,217 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	moovhttp "github.com/moov-io/base/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+// accountProduct is an offering customers open accounts with. Its Code is the type of those accounts.
+//
+// InterestPlan and FeePlan are the names of the interest product and fee schedule the accounts use. Without them
+// the interest product and fee schedule for the account type apply.
+//
+// Withdrawals are limited per statement cycle (calendar month) and day. WithdrawalLimit is the most transactions
+// which can debit an account each statement cycle, DailyDebitLimit and MonthlyDebitLimit cap the amount debited and
+// PurposeLimits cap the amount debited by lines of a purpose. Limits of zero are unlimited.
+type accountProduct struct {
+	Code                  string               `json:"code"`
+	Name                  string               `json:"name"`
+	MinimumOpeningDeposit int64                `json:"minimumOpeningDeposit"`
+	AllowedPurposes       []TransactionPurpose `json:"allowedPurposes,omitempty"`
+	InterestPlan          string               `json:"interestPlan,omitempty"`
+	FeePlan               string               `json:"feePlan,omitempty"`
+	WithdrawalLimit       int                  `json:"withdrawalLimit,omitempty"`
+	DailyDebitLimit       int64                `json:"dailyDebitLimit,omitempty"`
+	MonthlyDebitLimit     int64                `json:"monthlyDebitLimit,omitempty"`
+	PurposeLimits         []purposeLimit       `json:"purposeLimits,omitempty"`
+
+	CreatedAt    time.Time `json:"createdAt"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+const maxProductNameLength = 100
+
+var productCodeRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,39}$`)
+
+// normalize lowercases and trims values read from requests
+func (p *accountProduct) normalize() {
+	p.Code = strings.ToLower(strings.TrimSpace(p.Code))
+	p.Name = strings.TrimSpace(p.Name)
+	for i := range p.AllowedPurposes {
+		p.AllowedPurposes[i] = TransactionPurpose(strings.ToLower(strings.TrimSpace(string(p.AllowedPurposes[i]))))
+	}
+	for i := range p.PurposeLimits {
+		p.PurposeLimits[i].Purpose = TransactionPurpose(strings.ToLower(strings.TrimSpace(string(p.PurposeLimits[i].Purpose))))
+	}
+	p.InterestPlan = strings.TrimSpace(p.InterestPlan)
+	p.FeePlan = strings.TrimSpace(p.FeePlan)
+}
+
+func (p accountProduct) validate() error {
+	if !productCodeRegex.MatchString(p.Code) {
+		return fmt.Errorf("accountProduct: invalid code %q", p.Code)
+	}
+	if p.Name == "" || len(p.Name) > maxProductNameLength {
+		return fmt.Errorf("accountProduct: name must be between 1 and %d characters", maxProductNameLength)
+	}
+	if p.MinimumOpeningDeposit < 0 {
+		return errors.New("accountProduct: minimumOpeningDeposit can't be negative")
+	}
+	for i := range p.AllowedPurposes {
+		if err := p.AllowedPurposes[i].validate(); err != nil {
+			return fmt.Errorf("accountProduct: allowedPurposes[%d]: %w", i, err)
+		}
+	}
+	if p.WithdrawalLimit < 0 || p.DailyDebitLimit < 0 || p.MonthlyDebitLimit < 0 {
+		return errors.New("accountProduct: withdrawal limits can't be negative")
+	}
+	seen := make(map[TransactionPurpose]bool)
+	for i, limit := range p.PurposeLimits {
+		if err := limit.Purpose.validate(); err != nil {
+			return fmt.Errorf("accountProduct: purposeLimits[%d]: %w", i, err)
+		}
+		if limit.Purpose == Fee || seen[limit.Purpose] {
+			return fmt.Errorf("accountProduct: purposeLimits[%d]: %s can't be limited", i, limit.Purpose)
+		}
+		if limit.MonthlyAmount <= 0 {
+			return fmt.Errorf("accountProduct: purposeLimits[%d]: monthlyAmount must be positive", i)
+		}
+		seen[limit.Purpose] = true
+	}
+	return nil
+}
+
+// allowsPurpose returns true if accounts of the product can post lines of purpose. Products without
+// AllowedPurposes allow every purpose.
+func (p accountProduct) allowsPurpose(purpose TransactionPurpose) bool {
+	if len(p.AllowedPurposes) == 0 {
+		return true
+	}
+	for i := range p.AllowedPurposes {
+		if strings.EqualFold(string(p.AllowedPurposes[i]), string(purpose)) {
+			return true
+		}
+	}
+	return false
+}
+
+// productCatalog is every account product keyed by code
+type productCatalog map[string]accountProduct
+
+func newProductCatalog(products []accountProduct) productCatalog {
+	catalog := make(productCatalog)
+	for i := range products {
+		catalog[products[i].Code] = products[i]
+	}
+	return catalog
+}
+
+// interestPlan returns the interest plan of the product for accountType, or an empty string if it doesn't have one
+func (c productCatalog) interestPlan(accountType string) string {
+	return c[strings.ToLower(accountType)].InterestPlan
+}
+
+// feePlan returns the fee plan of the product for accountType, or an empty string if it doesn't have one
+func (c productCatalog) feePlan(accountType string) string {
+	return c[strings.ToLower(accountType)].FeePlan
+}
+
+// addProductRoutes registers the admin endpoints for managing the account product catalog
+func addProductRoutes(logger log.Logger, handle func(string, http.HandlerFunc), repo productRepository) {
+	handle("/products", getAccountProducts(logger, repo))
+	handle("/products/{productCode}", accountProductHandler(logger, repo))
+}
+
+func getAccountProducts(logger log.Logger, repo productRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			http.Error(w, fmt.Sprintf("unsupported HTTP verb %s", r.Method), http.StatusBadRequest)
+			return
+		}
+		products, err := repo.getAccountProducts()
+		if err != nil {
+			logger.Log("products", fmt.Sprintf("problem reading account products: %v", err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if products == nil {
+			products = []accountProduct{}
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(products)
+	}
+}
+
+// accountProductHandler reads (GET) or creates and replaces (PUT) the product whose code is in the path
+func accountProductHandler(logger log.Logger, repo productRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := strings.ToLower(mux.Vars(r)["productCode"])
+		switch r.Method {
+		case "GET":
+			product, err := repo.getAccountProduct(code)
+			if err != nil {
+				logger.Log("products", fmt.Sprintf("problem reading product=%s: %v", code, err))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if product == nil {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(product)
+
+		case "PUT":
+			var product accountProduct
+			if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			product.Code = code
+			product.normalize()
+			if err := product.validate(); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			existing, err := repo.getAccountProduct(code)
+			if err != nil {
+				logger.Log("products", fmt.Sprintf("problem reading product=%s: %v", code, err))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			product.CreatedAt, product.LastModified = time.Now(), time.Now()
+			if existing != nil {
+				product.CreatedAt = existing.CreatedAt
+			}
+			if err := repo.saveAccountProduct(product); err != nil {
+				logger.Log("products", fmt.Sprintf("problem saving product=%s: %v", code, err))
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			logger.Log("products", fmt.Sprintf("saved product=%s", code), "userID", moovhttp.GetUserID(r))
+
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(product)
+
+		default:
+			http.Error(w, fmt.Sprintf("unsupported HTTP verb %s", r.Method), http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+func TestProducts__validate(t *testing.T) {
+	product := accountProduct{Code: " Student ", Name: " Student checking ", AllowedPurposes: []TransactionPurpose{" ACHCredit", "transfer"}}
+	product.normalize()
+	if err := product.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if product.Code != "student" || product.Name != "Student checking" || product.AllowedPurposes[0] != ACHCredit {
+		t.Errorf("unexpected product: %#v", product)
+	}
+	if !product.allowsPurpose(Transfer) || product.allowsPurpose(Wire) {
+		t.Error("unexpected allowed purposes")
+	}
+	if !(accountProduct{}).allowsPurpose(Wire) {
+		t.Error("products without allowed purposes allow every purpose")
+	}
+
+	for _, bad := range []accountProduct{
+		{Code: "student checking", Name: "Student"},
+		{Code: "student"},
+		{Code: "student", Name: "Student", MinimumOpeningDeposit: -1},
+		{Code: "student", Name: "Student", AllowedPurposes: []TransactionPurpose{"other"}},
+		{Code: "student", Name: "Student", WithdrawalLimit: -6},
+		{Code: "student", Name: "Student", DailyDebitLimit: -1},
+		{Code: "student", Name: "Student", MonthlyDebitLimit: -1},
+		{Code: "student", Name: "Student", PurposeLimits: []purposeLimit{{Purpose: Wire, MonthlyAmount: 0}}},
+		{Code: "student", Name: "Student", PurposeLimits: []purposeLimit{{Purpose: Fee, MonthlyAmount: 100}}},
+		{Code: "student", Name: "Student", PurposeLimits: []purposeLimit{{Purpose: Wire, MonthlyAmount: 100}, {Purpose: "wire", MonthlyAmount: 200}}},
+	} {
+		if err := bad.validate(); err == nil {
+			t.Errorf("expected error: %#v", bad)
+		}
+	}
+
+	catalog := newProductCatalog([]accountProduct{{Code: "student", InterestPlan: "Savings", FeePlan: "Student"}})
+	if catalog.interestPlan("Student") != "Savings" || catalog.feePlan("student") != "Student" || catalog.feePlan("checking") != "" {
+		t.Errorf("unexpected catalog: %#v", catalog)
+	}
+}
+
+func TestProducts__routes(t *testing.T) {
+	repo := &testProductRepository{}
+	router := mux.NewRouter()
+	addProductRoutes(log.NewNopLogger(), func(path string, hf http.HandlerFunc) {
+		router.HandleFunc(path, hf)
+	}, repo)
+	call := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		w.Flush()
+		return w
+	}
+
+	if w := call("GET", "/products", ""); w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+	if w := call("GET", "/products/student", ""); w.Code != http.StatusNotFound {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	if w := call("PUT", "/products/student", `{"name": "Student", "allowedPurposes": ["bitcoin"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	if w := call("POST", "/products/student", `{"name": "Student"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+
+	w := call("PUT", "/products/Student", `{"name": "Student checking", "minimumOpeningDeposit": 0, "feePlan": "Student", "withdrawalLimit": 6}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+	var product accountProduct
+	if err := json.NewDecoder(w.Body).Decode(&product); err != nil {
+		t.Fatal(err)
+	}
+	if product.Code != "student" || product.FeePlan != "Student" || product.WithdrawalLimit != 6 || product.CreatedAt.IsZero() {
+		t.Errorf("unexpected product: %#v", product)
+	}
+	if w := call("GET", "/products/student", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Student checking") {
+		t.Errorf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+}
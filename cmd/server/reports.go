@@ -0,0 +1,369 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	moovhttp "github.com/moov-io/base/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+// trialBalance lists the balance of every GL account as of a date in the debit or credit column. Customer
+// accounts are included in the GL account their type rolls up into.
+type trialBalance struct {
+	RoutingNumber string                `json:"routingNumber"`
+	Date          string                `json:"date"`
+	Accounts      []trialBalanceAccount `json:"accounts"`
+	TotalDebits   int64                 `json:"totalDebits"`
+	TotalCredits  int64                 `json:"totalCredits"`
+
+	// Balanced is false when debits and credits differ, which happens when transactions post to customer
+	// accounts whose type doesn't roll up into a GL account or were posted without a balancing line.
+	Balanced bool `json:"balanced"`
+}
+
+var errReportOutOfBalance = errors.New("is out of balance")
+
+// check returns an error when debits don't equal credits
+func (tb trialBalance) check() error {
+	if tb.Balanced {
+		return nil
+	}
+	return fmt.Errorf("trial balance for routingNumber=%s on %s %w: debits=%d credits=%d", tb.RoutingNumber, tb.Date, errReportOutOfBalance, tb.TotalDebits, tb.TotalCredits)
+}
+
+type trialBalanceAccount struct {
+	Code     string     `json:"code"`
+	Name     string     `json:"name"`
+	Category GLCategory `json:"category"`
+	Debit    int64      `json:"debit"`
+	Credit   int64      `json:"credit"`
+}
+
+// financialStatementSection is every GL account of a category with balances rolled up. Total is the sum of
+// the accounts which don't roll up into another account.
+type financialStatementSection struct {
+	Category GLCategory                `json:"category"`
+	Accounts []financialStatementEntry `json:"accounts"`
+	Total    int64                     `json:"total"`
+}
+
+type financialStatementEntry struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	ParentCode string `json:"parentCode,omitempty"`
+	Balance    int64  `json:"balance"`
+}
+
+type balanceSheet struct {
+	RoutingNumber string                    `json:"routingNumber"`
+	Date          string                    `json:"date"`
+	Assets        financialStatementSection `json:"assets"`
+	Liabilities   financialStatementSection `json:"liabilities"`
+	Equity        financialStatementSection `json:"equity"`
+
+	// UndistributedIncome is income less expenses which haven't been closed into equity
+	UndistributedIncome int64 `json:"undistributedIncome"`
+
+	// Balanced is true when assets equal liabilities, equity and undistributed income
+	Balanced bool `json:"balanced"`
+}
+
+// check returns an error when assets don't equal liabilities, equity and undistributed income
+func (bs balanceSheet) check() error {
+	if bs.Balanced {
+		return nil
+	}
+	return fmt.Errorf("balance sheet for routingNumber=%s on %s %w: assets=%d liabilities=%d equity=%d undistributedIncome=%d", bs.RoutingNumber, bs.Date, errReportOutOfBalance, bs.Assets.Total, bs.Liabilities.Total, bs.Equity.Total, bs.UndistributedIncome)
+}
+
+type incomeStatement struct {
+	RoutingNumber string                    `json:"routingNumber"`
+	StartDate     string                    `json:"startDate"`
+	EndDate       string                    `json:"endDate"`
+	Income        financialStatementSection `json:"income"`
+	Expenses      financialStatementSection `json:"expenses"`
+	NetIncome     int64                     `json:"netIncome"`
+}
+
+// buildTrialBalance places the balance posted directly to each GL account (rather than rolled up) into the
+// column of its sign.
+func buildTrialBalance(routingNumber string, date time.Time, chart []glAccount) trialBalance {
+	out := trialBalance{
+		RoutingNumber: routingNumber,
+		Date:          date.Format("2006-01-02"),
+	}
+	rolledUp := make(map[string]int64)
+	for i := range chart {
+		if chart[i].ParentCode != "" {
+			rolledUp[chart[i].ParentCode] += chart[i].Balance
+		}
+	}
+	for i := range chart {
+		own := chart[i].Balance - rolledUp[chart[i].Code]
+		if chart[i].Category.normalDirection() == Debit {
+			own = -1 * own // credits less debits
+		}
+		acct := trialBalanceAccount{
+			Code:     chart[i].Code,
+			Name:     chart[i].Name,
+			Category: chart[i].Category,
+		}
+		if own > 0 {
+			acct.Credit = own
+		} else {
+			acct.Debit = -1 * own
+		}
+		out.TotalDebits += acct.Debit
+		out.TotalCredits += acct.Credit
+		out.Accounts = append(out.Accounts, acct)
+	}
+	out.Balanced = out.TotalDebits == out.TotalCredits
+	return out
+}
+
+func buildFinancialStatementSection(chart []glAccount, category GLCategory) financialStatementSection {
+	section := financialStatementSection{
+		Category: category,
+		Accounts: []financialStatementEntry{},
+	}
+	for i := range chart {
+		if chart[i].Category != category {
+			continue
+		}
+		section.Accounts = append(section.Accounts, financialStatementEntry{
+			Code:       chart[i].Code,
+			Name:       chart[i].Name,
+			ParentCode: chart[i].ParentCode,
+			Balance:    chart[i].Balance,
+		})
+		if chart[i].ParentCode == "" {
+			section.Total += chart[i].Balance
+		}
+	}
+	return section
+}
+
+func buildBalanceSheet(routingNumber string, date time.Time, chart []glAccount) balanceSheet {
+	out := balanceSheet{
+		RoutingNumber:       routingNumber,
+		Date:                date.Format("2006-01-02"),
+		Assets:              buildFinancialStatementSection(chart, GLAsset),
+		Liabilities:         buildFinancialStatementSection(chart, GLLiability),
+		Equity:              buildFinancialStatementSection(chart, GLEquity),
+		UndistributedIncome: undistributedIncome(chart),
+	}
+	out.Balanced = out.Assets.Total == out.Liabilities.Total+out.Equity.Total+out.UndistributedIncome
+	return out
+}
+
+func buildIncomeStatement(routingNumber string, start, end time.Time, chart []glAccount) incomeStatement {
+	out := incomeStatement{
+		RoutingNumber: routingNumber,
+		StartDate:     start.Format("2006-01-02"),
+		EndDate:       end.Format("2006-01-02"),
+		Income:        buildFinancialStatementSection(chart, GLIncome),
+		Expenses:      buildFinancialStatementSection(chart, GLExpense),
+	}
+	out.NetIncome = out.Income.Total - out.Expenses.Total
+	return out
+}
+
+func addReportRoutes(logger log.Logger, r *mux.Router, glRepo glAccountRepository) {
+	r.Methods("GET").Path("/reports/trial-balance").HandlerFunc(getTrialBalance(logger, glRepo))
+	r.Methods("GET").Path("/reports/balance-sheet").HandlerFunc(getBalanceSheet(logger, glRepo))
+	r.Methods("GET").Path("/reports/income-statement").HandlerFunc(getIncomeStatement(logger, glRepo))
+}
+
+// reportParams are the query parameters of report endpoints. End is exclusive, so it's the day after the
+// last day reported on.
+type reportParams struct {
+	RoutingNumber string
+	Start, End    time.Time
+	CSV           bool
+}
+
+// readReportParams reads the routingNumber and either the as-of date or the startDate and endDate of a period.
+// The as-of and end dates default to today and periods default to starting on January 1st of the end date's year.
+func readReportParams(r *http.Request, period bool) (reportParams, error) {
+	q := r.URL.Query()
+	params := reportParams{
+		RoutingNumber: q.Get("routingNumber"),
+		CSV:           strings.EqualFold(q.Get("format"), "csv") || strings.Contains(r.Header.Get("Accept"), "text/csv"),
+	}
+	if !routingNumberRegex.MatchString(params.RoutingNumber) {
+		return params, fmt.Errorf("invalid routing number %q", params.RoutingNumber)
+	}
+	if v := q.Get("format"); v != "" && !strings.EqualFold(v, "csv") && !strings.EqualFold(v, "json") {
+		return params, fmt.Errorf("unknown format %q", v)
+	}
+
+	endParam := "date"
+	if period {
+		endParam = "endDate"
+	}
+	end, err := readDateParam(q.Get(endParam), true)
+	if err != nil {
+		return params, fmt.Errorf("invalid %s: %w", endParam, err)
+	}
+	if end.IsZero() {
+		end = time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	}
+	params.End = end
+
+	if period {
+		if params.Start, err = readDateParam(q.Get("startDate"), false); err != nil {
+			return params, fmt.Errorf("invalid startDate: %w", err)
+		}
+		if params.Start.IsZero() {
+			last := end.AddDate(0, 0, -1)
+			params.Start = time.Date(last.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		}
+		if !params.Start.Before(params.End) {
+			return params, errors.New("startDate must be before endDate")
+		}
+	}
+	return params, nil
+}
+
+// lastDay returns the last day included in a report, which ends before params.End
+func (params reportParams) lastDay() time.Time {
+	return params.End.Add(-1 * time.Nanosecond)
+}
+
+func getTrialBalance(logger log.Logger, glRepo glAccountRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+		params, err := readReportParams(r, false)
+		if err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+		chart, err := glRepo.getGLActivity(params.RoutingNumber, time.Time{}, params.End)
+		if err != nil {
+			logger.Log("reports", fmt.Sprintf("problem reading trial balance for routingNumber=%s: %v", params.RoutingNumber, err), "requestID", moovhttp.GetRequestID(r))
+			moovhttp.Problem(w, err)
+			return
+		}
+		report := buildTrialBalance(params.RoutingNumber, params.lastDay(), chart)
+		if err := report.check(); err != nil {
+			logger.Log("reports", err.Error(), "requestID", moovhttp.GetRequestID(r))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeReport(w, params, report, report.writeCSV)
+	}
+}
+
+func getBalanceSheet(logger log.Logger, glRepo glAccountRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+		params, err := readReportParams(r, false)
+		if err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+		chart, err := glRepo.getGLActivity(params.RoutingNumber, time.Time{}, params.End)
+		if err != nil {
+			logger.Log("reports", fmt.Sprintf("problem reading balance sheet for routingNumber=%s: %v", params.RoutingNumber, err), "requestID", moovhttp.GetRequestID(r))
+			moovhttp.Problem(w, err)
+			return
+		}
+		report := buildBalanceSheet(params.RoutingNumber, params.lastDay(), chart)
+		if err := report.check(); err != nil {
+			logger.Log("reports", err.Error(), "requestID", moovhttp.GetRequestID(r))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeReport(w, params, report, report.writeCSV)
+	}
+}
+
+func getIncomeStatement(logger log.Logger, glRepo glAccountRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+		params, err := readReportParams(r, true)
+		if err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+		chart, err := glRepo.getGLActivity(params.RoutingNumber, params.Start, params.End)
+		if err != nil {
+			logger.Log("reports", fmt.Sprintf("problem reading income statement for routingNumber=%s: %v", params.RoutingNumber, err), "requestID", moovhttp.GetRequestID(r))
+			moovhttp.Problem(w, err)
+			return
+		}
+		report := buildIncomeStatement(params.RoutingNumber, params.Start, params.lastDay(), chart)
+		writeReport(w, params, report, report.writeCSV)
+	}
+}
+
+// writeReport responds with the report as JSON, or as CSV when it was requested
+func writeReport(w http.ResponseWriter, params reportParams, report interface{}, writeCSV func(*csv.Writer)) {
+	if params.CSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		cw := csv.NewWriter(w)
+		writeCSV(cw)
+		cw.Flush()
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+func formatAmount(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
+func (tb trialBalance) writeCSV(w *csv.Writer) {
+	w.Write([]string{"code", "name", "category", "debit", "credit"})
+	for _, acct := range tb.Accounts {
+		w.Write([]string{acct.Code, acct.Name, string(acct.Category), formatAmount(acct.Debit), formatAmount(acct.Credit)})
+	}
+	w.Write([]string{"", "Total", "", formatAmount(tb.TotalDebits), formatAmount(tb.TotalCredits)})
+}
+
+func (section financialStatementSection) writeCSV(w *csv.Writer) {
+	for _, acct := range section.Accounts {
+		w.Write([]string{string(section.Category), acct.Code, acct.Name, acct.ParentCode, formatAmount(acct.Balance)})
+	}
+	w.Write([]string{string(section.Category), "", "Total", "", formatAmount(section.Total)})
+}
+
+func (bs balanceSheet) writeCSV(w *csv.Writer) {
+	w.Write([]string{"category", "code", "name", "parentCode", "balance"})
+	bs.Assets.writeCSV(w)
+	bs.Liabilities.writeCSV(w)
+	bs.Equity.writeCSV(w)
+	w.Write([]string{string(GLEquity), "", "Undistributed income", "", formatAmount(bs.UndistributedIncome)})
+}
+
+func (is incomeStatement) writeCSV(w *csv.Writer) {
+	w.Write([]string{"category", "code", "name", "parentCode", "balance"})
+	is.Income.writeCSV(w)
+	is.Expenses.writeCSV(w)
+	w.Write([]string{"", "", "Net income", "", formatAmount(is.NetIncome)})
+}
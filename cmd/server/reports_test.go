@@ -0,0 +1,212 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+func TestReports__readReportParams(t *testing.T) {
+	req := httptest.NewRequest("GET", "/reports/income-statement?routingNumber=121042882&endDate=2020-03-31", nil)
+	params, err := readReportParams(req, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !params.Start.Equal(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)) || !params.End.Equal(time.Date(2020, time.April, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected params: %#v", params)
+	}
+	if params.CSV || params.lastDay().Format("2006-01-02") != "2020-03-31" {
+		t.Errorf("unexpected params: %#v", params)
+	}
+
+	req = httptest.NewRequest("GET", "/reports/trial-balance?routingNumber=121042882", nil)
+	req.Header.Set("Accept", "text/csv")
+	if params, err = readReportParams(req, false); err != nil || !params.CSV || !params.Start.IsZero() || params.End.Before(time.Now()) {
+		t.Errorf("params=%#v error=%v", params, err)
+	}
+
+	for _, query := range []string{
+		"routingNumber=1210",
+		"routingNumber=121042882&endDate=03/31/2020",
+		"routingNumber=121042882&startDate=2020-04-01&endDate=2020-03-31",
+		"routingNumber=121042882&format=xml",
+	} {
+		req := httptest.NewRequest("GET", "/reports/income-statement?"+query, nil)
+		if _, err := readReportParams(req, true); err == nil {
+			t.Errorf("%s: expected error", query)
+		}
+	}
+}
+
+func TestReports__build(t *testing.T) {
+	chart := []glAccount{
+		{Code: "0081", Name: "Cash", Category: GLAsset, Balance: 1500},
+		{Code: "2200", Name: "Deposits", Category: GLLiability, Balance: 1000},
+		{Code: "2215", Name: "Checking", Category: GLLiability, ParentCode: "2200", Balance: 1000},
+		{Code: "3230", Name: "Common stock", Category: GLEquity, Balance: 400},
+		{Code: "4080", Name: "Fees", Category: GLIncome, Balance: 150},
+		{Code: "4135", Name: "Salaries", Category: GLExpense, Balance: 50},
+	}
+	date := time.Date(2020, time.March, 31, 0, 0, 0, 0, time.UTC)
+
+	tb := buildTrialBalance("121042882", date, chart)
+	if !tb.Balanced || tb.TotalDebits != 1550 || tb.TotalCredits != 1550 {
+		t.Errorf("unexpected trial balance: %#v", tb)
+	}
+	if tb.Accounts[1].Credit != 0 || tb.Accounts[2].Credit != 1000 || tb.Accounts[5].Debit != 50 {
+		t.Errorf("unexpected accounts: %#v", tb.Accounts)
+	}
+
+	bs := buildBalanceSheet("121042882", date, chart)
+	if !bs.Balanced || bs.Assets.Total != 1500 || bs.Liabilities.Total != 1000 || bs.UndistributedIncome != 100 || len(bs.Liabilities.Accounts) != 2 {
+		t.Errorf("unexpected balance sheet: %#v", bs)
+	}
+
+	is := buildIncomeStatement("121042882", time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), date, chart)
+	if is.NetIncome != 100 || is.StartDate != "2020-01-01" || is.EndDate != "2020-03-31" {
+		t.Errorf("unexpected income statement: %#v", is)
+	}
+
+	// Missing a balancing line
+	chart[0].Balance = 1600
+	if tb := buildTrialBalance("121042882", date, chart); tb.Balanced || !errors.Is(tb.check(), errReportOutOfBalance) {
+		t.Errorf("expected trial balance to be out of balance: %#v", tb)
+	}
+	if bs := buildBalanceSheet("121042882", date, chart); bs.Balanced || !errors.Is(bs.check(), errReportOutOfBalance) {
+		t.Errorf("expected balance sheet to be out of balance: %#v", bs)
+	}
+}
+
+func TestReports__routes(t *testing.T) {
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+
+	repo := createTestSqlTransactionRepository(t, sqliteDB.DB)
+	glRepo := setupSqlGLAccountStorage(log.NewNopLogger(), sqliteDB.DB, repo.accountRepo)
+	routingNumber := "121042882"
+
+	now := time.Now()
+	for _, req := range []createGLAccountRequest{
+		{Code: "RCON0081"},
+		{Code: "RCON2215", AccountType: "checking"},
+		{Code: "RIAD4080"},
+	} {
+		acct, err := req.asGLAccount(routingNumber, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := glRepo.createGLAccount(acct); err != nil {
+			t.Fatal(err)
+		}
+	}
+	customer := &accounts.Account{ID: base.ID(), CustomerID: base.ID(), Name: "Checking", AccountNumber: "123", RoutingNumber: routingNumber, Status: "open", Type: "Checking", CreatedAt: now, LastModified: now}
+	if err := repo.accountRepo.CreateAccount(customer.CustomerID, customer); err != nil {
+		t.Fatal(err)
+	}
+	post := func(when time.Time, lines ...transactionLine) {
+		t.Helper()
+		if err := repo.createTransaction(transaction{ID: base.ID(), Timestamp: when, Lines: lines}, createTransactionOpts{AllowGLDebits: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	post(time.Date(2020, time.January, 10, 12, 0, 0, 0, time.UTC),
+		transactionLine{AccountID: glAccountID(routingNumber, "0081"), Purpose: ACHCredit, Direction: Debit, Amount: 10000},
+		transactionLine{AccountID: customer.ID, Purpose: ACHCredit, Direction: Credit, Amount: 10000})
+	post(time.Date(2020, time.February, 1, 12, 0, 0, 0, time.UTC),
+		transactionLine{AccountID: customer.ID, Purpose: Fee, Direction: Debit, Amount: 300},
+		transactionLine{AccountID: glAccountID(routingNumber, "4080"), Purpose: Fee, Direction: Credit, Amount: 300})
+	post(time.Date(2020, time.April, 2, 12, 0, 0, 0, time.UTC),
+		transactionLine{AccountID: customer.ID, Purpose: Fee, Direction: Debit, Amount: 200},
+		transactionLine{AccountID: glAccountID(routingNumber, "4080"), Purpose: Fee, Direction: Credit, Amount: 200})
+
+	router := mux.NewRouter()
+	addReportRoutes(log.NewNopLogger(), router, glRepo)
+	call := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("x-user-id", "test")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		w.Flush()
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: bogus status code: %d: %s", path, w.Code, w.Body.String())
+		}
+		return w
+	}
+
+	var tb trialBalance
+	if err := json.NewDecoder(call("/reports/trial-balance?routingNumber=121042882&date=2020-03-31").Body).Decode(&tb); err != nil {
+		t.Fatal(err)
+	}
+	if !tb.Balanced || tb.TotalDebits != 10000 || len(tb.Accounts) != 3 || tb.Accounts[1].Credit != 9700 {
+		t.Errorf("unexpected trial balance: %#v", tb)
+	}
+
+	var bs balanceSheet
+	if err := json.NewDecoder(call("/reports/balance-sheet?routingNumber=121042882&date=2020-04-30").Body).Decode(&bs); err != nil {
+		t.Fatal(err)
+	}
+	if !bs.Balanced || bs.Assets.Total != 10000 || bs.Liabilities.Total != 9500 || bs.UndistributedIncome != 500 {
+		t.Errorf("unexpected balance sheet: %#v", bs)
+	}
+
+	var is incomeStatement
+	if err := json.NewDecoder(call("/reports/income-statement?routingNumber=121042882&startDate=2020-04-01&endDate=2020-06-30").Body).Decode(&is); err != nil {
+		t.Fatal(err)
+	}
+	if is.NetIncome != 200 || is.Income.Accounts[0].Balance != 200 {
+		t.Errorf("unexpected income statement: %#v", is)
+	}
+
+	w := call("/reports/income-statement?routingNumber=121042882&endDate=2020-03-31&format=csv")
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type: %s", ct)
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := records[len(records)-1]
+	if len(records) != 5 || last[2] != "Net income" || last[4] != "300" {
+		t.Errorf("unexpected CSV: %v", records)
+	}
+
+	// Savings accounts don't roll up into a GL account, so their deposits leave the books out of balance
+	savings := &accounts.Account{ID: base.ID(), CustomerID: base.ID(), Name: "Savings", AccountNumber: "456", RoutingNumber: routingNumber, Status: "open", Type: "Savings", CreatedAt: now, LastModified: now}
+	if err := repo.accountRepo.CreateAccount(savings.CustomerID, savings); err != nil {
+		t.Fatal(err)
+	}
+	post(time.Date(2020, time.May, 1, 12, 0, 0, 0, time.UTC),
+		transactionLine{AccountID: glAccountID(routingNumber, "0081"), Purpose: ACHCredit, Direction: Debit, Amount: 500},
+		transactionLine{AccountID: savings.ID, Purpose: ACHCredit, Direction: Credit, Amount: 500})
+	for _, path := range []string{
+		"/reports/trial-balance?routingNumber=121042882&date=2020-05-31",
+		"/reports/balance-sheet?routingNumber=121042882&date=2020-05-31",
+	} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("x-user-id", "test")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		w.Flush()
+		if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), errReportOutOfBalance.Error()) {
+			t.Errorf("%s: bogus status code: %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+}
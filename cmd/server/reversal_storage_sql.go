@@ -0,0 +1,142 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/moov-io/base"
+)
+
+func (r *sqlTransactionRepository) reverseTransaction(transactionID string, req reversalRequest, opts createTransactionOpts) (*transaction, error) {
+	found, err := r.getTransaction(transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("reverseTransaction: %w", err)
+	}
+	var out *transaction
+	err = withPostingRetries(func() error {
+		accounts, err := r.accountRepo.GetAccounts(grabAccountIDs(found.Lines))
+		if err != nil {
+			return fmt.Errorf("reverseTransaction: problem reading accounts for transaction=%q: %w", transactionID, err)
+		}
+
+		tx, err := r.db.Begin()
+		if err != nil {
+			return fmt.Errorf("reverseTransaction: tx.Begin: %w", err)
+		}
+
+		// Read the original and what's been reversed inside our database transaction so concurrent reversals conflict
+		original, err := r.loadTransaction(tx, transactionID)
+		if err != nil {
+			return fmt.Errorf("reverseTransaction: error=%w rollback=%v", err, tx.Rollback())
+		}
+		reversed, total, err := r.reversedAmounts(tx, transactionID)
+		if err != nil {
+			return fmt.Errorf("reverseTransaction: transaction=%q: error=%w rollback=%v", transactionID, err, tx.Rollback())
+		}
+		lines, err := buildReversalLines(*original, reversed, req)
+		if err != nil {
+			return fmt.Errorf("reverseTransaction: transaction=%q: %w rollback=%v", transactionID, err, tx.Rollback())
+		}
+		now := time.Now()
+		t := transaction{
+			ID:            base.ID(),
+			Timestamp:     now,
+			EffectiveDate: effectiveDateOr(req.EffectiveDate, now),
+			Lines:         lines,
+			Status:        TransactionPosted,
+			ReversalOf:    transactionID,
+		}
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("reverseTransaction: reversal of transaction=%q is invalid: %w rollback=%v", transactionID, err, tx.Rollback())
+		}
+		if err := checkAccountStatuses(accounts, t.Lines, opts); err != nil {
+			return fmt.Errorf("reverseTransaction: transaction=%q: %w rollback=%v", transactionID, err, tx.Rollback())
+		}
+		if err := r.insertTransaction(tx, t, opts, accounts); err != nil {
+			return fmt.Errorf("reverseTransaction: %w rollback=%v", err, tx.Rollback())
+		}
+
+		// Update the original's status, but only if no other reversal was posted since we read it
+		for i := range lines {
+			reversed[lines[i].AccountID] += lines[i].Amount
+		}
+		if err := refundWithdrawals(tx, *original, lines, reversed); err != nil {
+			return fmt.Errorf("reverseTransaction: transaction=%q: %w rollback=%v", transactionID, err, tx.Rollback())
+		}
+		status := reversedStatus(*original, reversed)
+		query := `update transactions set status = ?, reversed_amount = ? where transaction_id = ? and reversed_amount = ?;`
+		stmt, err := tx.Prepare(query)
+		if err != nil {
+			return fmt.Errorf("reverseTransaction: prepare: error=%w rollback=%v", err, tx.Rollback())
+		}
+		res, err := stmt.Exec(status, total+sumAmounts(lines), transactionID, total)
+		stmt.Close()
+		if err != nil {
+			return fmt.Errorf("reverseTransaction: transaction=%q update: error=%w rollback=%v", transactionID, err, tx.Rollback())
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return fmt.Errorf("reverseTransaction: transaction=%q: %w rollback=%v", transactionID, errBalanceConflict, tx.Rollback())
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("reverseTransaction: commit: %w", err)
+		}
+		out = &t
+		return nil
+	})
+	return out, err
+}
+
+// reversedAmounts returns how much of each line of a transaction has been reversed, keyed by AccountID, along with
+// the reversed_amount recorded on the transaction.
+func (r *sqlTransactionRepository) reversedAmounts(tx *sql.Tx, transactionID string) (map[string]int64, int64, error) {
+	query := `select reversed_amount from transactions where transaction_id = ? limit 1;`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return nil, 0, err
+	}
+	var total int64
+	err = stmt.QueryRow(transactionID).Scan(&total)
+	stmt.Close()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query = `select l.account_id, sum(l.amount) from transactions t inner join transaction_lines l on l.transaction_id = t.transaction_id
+where t.reversal_of = ? and t.deleted_at is null and l.deleted_at is null group by l.account_id;`
+	stmt, err = tx.Prepare(query)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(transactionID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	reversed := make(map[string]int64)
+	for rows.Next() {
+		var accountID string
+		var amount int64
+		if err := rows.Scan(&accountID, &amount); err != nil {
+			return nil, 0, err
+		}
+		reversed[accountID] = amount
+	}
+	return reversed, total, rows.Err()
+}
+
+func sumAmounts(lines []transactionLine) int64 {
+	var sum int64
+	for i := range lines {
+		sum += lines[i].Amount
+	}
+	return sum
+}
@@ -0,0 +1,136 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+)
+
+func TestSqlTransactionRepository__reverseTransaction(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+
+		account1, account2, account3 := base.ID(), base.ID(), base.ID()
+		repo.accountRepo = &testAccountRepository{}
+
+		// Fund each account so reversals don't leave any of them with insufficient funds
+		for _, id := range []string{account1, account2, account3} {
+			deposit := transaction{
+				ID:        base.ID(),
+				Timestamp: time.Now(),
+				Lines:     []transactionLine{{AccountID: id, Purpose: ACHCredit, Direction: Credit, Amount: 5000}},
+			}
+			if err := repo.createTransaction(deposit, createTransactionOpts{InitialDeposit: true}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		original := transaction{
+			ID:        base.ID(),
+			Timestamp: time.Now(),
+			Lines: []transactionLine{
+				{AccountID: account1, Purpose: ACHDebit, Direction: Debit, Amount: 1000},
+				{AccountID: account2, Purpose: Transfer, Direction: Credit, Amount: 900},
+				{AccountID: account3, Purpose: Fee, Direction: Credit, Amount: 100},
+			},
+		}
+		if err := repo.createTransaction(original, createTransactionOpts{}); err != nil {
+			t.Fatal(err)
+		}
+		balanceOf := func(accountID string) int64 {
+			t.Helper()
+			dbtx, _ := repo.db.Begin()
+			defer dbtx.Rollback()
+			balance, err := repo.getAccountBalance(dbtx, accountID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return balance
+		}
+
+		// Refund the fee
+		reversal, err := repo.reverseTransaction(original.ID, reversalRequest{
+			Lines: []reversalLine{{AccountID: account1, Amount: 100}, {AccountID: account3}},
+		}, createTransactionOpts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reversal.ReversalOf != original.ID {
+			t.Errorf("unexpected reversal: %#v", reversal)
+		}
+		if b := balanceOf(account1); b != 4100 {
+			t.Errorf("account1 balance=%d", b)
+		}
+		if b := balanceOf(account3); b != 5000 {
+			t.Errorf("account3 balance=%d", b)
+		}
+		found, err := repo.getTransaction(original.ID)
+		if err != nil || found.Status != TransactionPartiallyReversed {
+			t.Errorf("transaction=%#v error=%v", found, err)
+		}
+		found, err = repo.getTransaction(reversal.ID)
+		if err != nil || found.Status != TransactionPosted || found.ReversalOf != original.ID {
+			t.Errorf("reversal=%#v error=%v", found, err)
+		}
+
+		// Reverse everything else
+		reversal, err = repo.reverseTransaction(original.ID, reversalRequest{}, createTransactionOpts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(reversal.Lines) != 2 {
+			t.Errorf("unexpected reversal: %#v", reversal)
+		}
+		for _, id := range []string{account1, account2, account3} {
+			if b := balanceOf(id); b != 5000 {
+				t.Errorf("account=%s balance=%d", id, b)
+			}
+		}
+		if found, err := repo.getTransaction(original.ID); err != nil || found.Status != TransactionReversed {
+			t.Errorf("transaction=%#v error=%v", found, err)
+		}
+
+		// Block double reversals and reversing a reversal
+		if _, err := repo.reverseTransaction(original.ID, reversalRequest{}, createTransactionOpts{}); err == nil || !strings.Contains(err.Error(), errTransactionReversed.Error()) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if _, err := repo.reverseTransaction(reversal.ID, reversalRequest{}, createTransactionOpts{}); err == nil || !strings.Contains(err.Error(), errReverseReversal.Error()) {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		// Reversals show up in the account's transactions
+		transactions, _, err := repo.getAccountTransactions(account1, transactionSearchParams{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(transactions) != 4 {
+			t.Fatalf("got %d transactions", len(transactions))
+		}
+		var reversals int
+		for i := range transactions {
+			if transactions[i].ReversalOf == original.ID {
+				reversals++
+			}
+		}
+		if reversals != 2 {
+			t.Errorf("found %d reversals", reversals)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
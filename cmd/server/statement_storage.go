@@ -0,0 +1,34 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+type statementRepository interface {
+	// getStatementActivity returns the opening balance of accountID at start and each of its lines effective before
+	// end which isn't on a statement yet. The opening balance is the closing balance of the previous statement, so
+	// lines backdated into a month which already has a statement are carried onto the next one. An account's first
+	// statement opens with its balance at start and only has lines effective in [start, end).
+	getStatementActivity(accountID string, start, end time.Time) (int64, []statementLine, error)
+
+	// saveStatement stores a generated statement along with its rendered PDF. errStatementExists is
+	// returned if the account already has a statement for the period, and errStatementActivityChanged if
+	// lines were posted to the account since getStatementActivity was read.
+	saveStatement(s statement, pdf []byte) error
+	statementExists(accountID, period string) (bool, error)
+
+	// getAccountStatements returns the statements of an account, newest first, without their lines
+	getAccountStatements(accountID string) ([]statement, error)
+	getStatement(accountID, statementID string) (*statement, error)
+	getStatementPDF(accountID, statementID string) ([]byte, error)
+}
+
+var (
+	errStatementExists          = errors.New("statement already exists for period")
+	errStatementActivityChanged = errors.New("account activity changed while generating statement")
+)
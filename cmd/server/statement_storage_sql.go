@@ -0,0 +1,230 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/moov-io/accounts/cmd/server/database"
+)
+
+func (r *sqlTransactionRepository) getStatementActivity(accountID string, start, end time.Time) (int64, []statementLine, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, nil, fmt.Errorf("getStatementActivity: tx.Begin: %w", err)
+	}
+	opening, previous, err := readPreviousClosingBalance(tx, accountID, start)
+	if err != nil {
+		return 0, nil, fmt.Errorf("getStatementActivity: account=%s previous statement: %w rollback=%v", accountID, err, tx.Rollback())
+	}
+	// The first statement opens with everything effective before start. Later statements open with the previous
+	// closing balance and carry every line not on a statement yet, including lines backdated into earlier months.
+	from := time.Time{}
+	if !previous {
+		from = start
+		opening, err = readBalanceAsOf(tx, accountID, start)
+		if err != nil {
+			return 0, nil, fmt.Errorf("getStatementActivity: account=%s opening balance: %w rollback=%v", accountID, err, tx.Rollback())
+		}
+	}
+
+	query := `select t.transaction_id, t.effective_date, l.purpose, l.direction, l.amount
+from transaction_lines l inner join transactions t on t.transaction_id = l.transaction_id
+where l.account_id = ? and t.effective_date >= ? and t.effective_date < ? and l.statement_id is null and l.deleted_at is null and t.deleted_at is null
+order by t.effective_date asc, t.transaction_id asc;`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return 0, nil, fmt.Errorf("getStatementActivity: prepare: error=%w rollback=%v", err, tx.Rollback())
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(accountID, from.UTC(), end.UTC())
+	if err != nil {
+		return 0, nil, fmt.Errorf("getStatementActivity: query: error=%w rollback=%v", err, tx.Rollback())
+	}
+	defer rows.Close()
+
+	var lines []statementLine
+	for rows.Next() {
+		var line statementLine
+		var effectiveDate time.Time
+		if err := rows.Scan(&line.TransactionID, &effectiveDate, &line.Purpose, &line.Direction, &line.Amount); err != nil {
+			return 0, nil, fmt.Errorf("getStatementActivity: scan account=%s: error=%w rollback=%v", accountID, err, tx.Rollback())
+		}
+		line.Date = effectiveDate.UTC().Format("2006-01-02")
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, nil, fmt.Errorf("getStatementActivity: rows: error=%w rollback=%v", err, tx.Rollback())
+	}
+	return opening, lines, tx.Commit()
+}
+
+// readPreviousClosingBalance returns the closing balance of the latest statement of accountID which started
+// before start, and false if there isn't one.
+func readPreviousClosingBalance(tx *sql.Tx, accountID string, start time.Time) (int64, bool, error) {
+	stmt, err := tx.Prepare(`select closing_balance from account_statements where account_id = ? and start_date < ? order by start_date desc limit 1;`)
+	if err != nil {
+		return 0, false, err
+	}
+	defer stmt.Close()
+
+	var closing int64
+	if err := stmt.QueryRow(accountID, start.UTC()).Scan(&closing); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return closing, true, nil
+}
+
+// saveStatement stores s and marks each line of the account effective before its end, and not on an earlier
+// statement, as being on s. Those lines must sum to the closing balance of s, otherwise something was posted
+// since s was built and errStatementActivityChanged is returned.
+func (r *sqlTransactionRepository) saveStatement(s statement, pdf []byte) error {
+	bs, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("saveStatement: statement=%s: %w", s.ID, err)
+	}
+	start, _ := time.Parse("2006-01-02", s.StartDate)
+	end, _ := time.Parse("2006-01-02", s.EndDate)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("saveStatement: tx.Begin: %w", err)
+	}
+	query := `insert into account_statements(statement_id, account_id, period, start_date, end_date, opening_balance, closing_balance, statement, pdf, created_at) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("saveStatement: prepare: error=%w rollback=%v", err, tx.Rollback())
+	}
+	_, err = stmt.Exec(s.ID, s.AccountID, s.Period, start, end, s.OpeningBalance, s.ClosingBalance, string(bs), pdf, s.CreatedAt)
+	stmt.Close()
+	if err != nil {
+		if database.UniqueViolation(err) {
+			return fmt.Errorf("saveStatement: account=%s period=%s: %w rollback=%v", s.AccountID, s.Period, errStatementExists, tx.Rollback())
+		}
+		return fmt.Errorf("saveStatement: statement=%s: error=%w rollback=%v", s.ID, err, tx.Rollback())
+	}
+
+	query = `update transaction_lines set statement_id = ? where account_id = ? and statement_id is null and deleted_at is null
+and transaction_id in (select transaction_id from transactions where effective_date < ? and deleted_at is null);`
+	stmt, err = tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("saveStatement: prepare update: error=%w rollback=%v", err, tx.Rollback())
+	}
+	_, err = stmt.Exec(s.ID, s.AccountID, end.AddDate(0, 0, 1))
+	stmt.Close()
+	if err != nil {
+		return fmt.Errorf("saveStatement: statement=%s update: error=%w rollback=%v", s.ID, err, tx.Rollback())
+	}
+
+	// Every statement closes with the sum of the lines on it and on earlier statements
+	query = `select coalesce(sum(case when direction = 'debit' then -amount else amount end), 0) from transaction_lines
+where account_id = ? and statement_id is not null and deleted_at is null;`
+	stmt, err = tx.Prepare(query)
+	if err != nil {
+		return fmt.Errorf("saveStatement: prepare balance: error=%w rollback=%v", err, tx.Rollback())
+	}
+	var balance int64
+	err = stmt.QueryRow(s.AccountID).Scan(&balance)
+	stmt.Close()
+	if err != nil {
+		return fmt.Errorf("saveStatement: statement=%s balance: error=%w rollback=%v", s.ID, err, tx.Rollback())
+	}
+	if balance != s.ClosingBalance {
+		return fmt.Errorf("saveStatement: statement=%s closing balance=%d stated lines=%d: %w rollback=%v", s.ID, s.ClosingBalance, balance, errStatementActivityChanged, tx.Rollback())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("saveStatement: commit: %w", err)
+	}
+	return nil
+}
+
+func (r *sqlTransactionRepository) statementExists(accountID, period string) (bool, error) {
+	stmt, err := r.db.Prepare(`select count(*) from account_statements where account_id = ? and period = ?;`)
+	if err != nil {
+		return false, fmt.Errorf("statementExists: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	var n int
+	if err := stmt.QueryRow(accountID, period).Scan(&n); err != nil {
+		return false, fmt.Errorf("statementExists: account=%s period=%s: %w", accountID, period, err)
+	}
+	return n > 0, nil
+}
+
+func (r *sqlTransactionRepository) getAccountStatements(accountID string) ([]statement, error) {
+	stmt, err := r.db.Prepare(`select statement from account_statements where account_id = ? order by period desc;`)
+	if err != nil {
+		return nil, fmt.Errorf("getAccountStatements: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("getAccountStatements: query: %w", err)
+	}
+	defer rows.Close()
+
+	var statements []statement
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, fmt.Errorf("getAccountStatements: scan account=%s: %w", accountID, err)
+		}
+		var s statement
+		if err := json.Unmarshal([]byte(raw), &s); err != nil {
+			return nil, fmt.Errorf("getAccountStatements: account=%s: %w", accountID, err)
+		}
+		s.Lines = nil
+		statements = append(statements, s)
+	}
+	return statements, rows.Err()
+}
+
+func (r *sqlTransactionRepository) getStatement(accountID, statementID string) (*statement, error) {
+	stmt, err := r.db.Prepare(`select statement from account_statements where account_id = ? and statement_id = ? limit 1;`)
+	if err != nil {
+		return nil, fmt.Errorf("getStatement: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	var raw string
+	if err := stmt.QueryRow(accountID, statementID).Scan(&raw); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getStatement: statement=%s: %w", statementID, err)
+	}
+	var s statement
+	if err := json.Unmarshal([]byte(raw), &s); err != nil {
+		return nil, fmt.Errorf("getStatement: statement=%s: %w", statementID, err)
+	}
+	return &s, nil
+}
+
+func (r *sqlTransactionRepository) getStatementPDF(accountID, statementID string) ([]byte, error) {
+	stmt, err := r.db.Prepare(`select pdf from account_statements where account_id = ? and statement_id = ? limit 1;`)
+	if err != nil {
+		return nil, fmt.Errorf("getStatementPDF: prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	var pdf []byte
+	if err := stmt.QueryRow(accountID, statementID).Scan(&pdf); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getStatementPDF: statement=%s: %w", statementID, err)
+	}
+	return pdf, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+)
+
+func TestSqlTransactionRepository__statements(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+
+		account := &accounts.Account{ID: base.ID(), Name: "Checking", AccountNumber: "987654321", RoutingNumber: "121042882", Status: "open"}
+		other := base.ID()
+		repo.accountRepo = &testAccountRepository{}
+
+		post := func(effective time.Time, purpose TransactionPurpose, direction LineDirection, amount int64) {
+			t.Helper()
+			err := repo.createTransaction(transaction{
+				ID:            base.ID(),
+				Timestamp:     time.Now(),
+				EffectiveDate: effective,
+				Lines: []transactionLine{
+					{AccountID: account.ID, Purpose: purpose, Direction: direction, Amount: amount},
+					{AccountID: other, Purpose: purpose, Direction: direction.opposite(), Amount: amount},
+				},
+			}, createTransactionOpts{AllowOverdraft: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		post(time.Date(2020, time.February, 20, 12, 0, 0, 0, time.UTC), ACHCredit, Credit, 10000)
+		post(time.Date(2020, time.March, 5, 12, 0, 0, 0, time.UTC), Fee, Debit, 500)
+		post(time.Date(2020, time.March, 31, 23, 0, 0, 0, time.UTC), Interest, Credit, 25)
+		post(time.Date(2020, time.April, 1, 0, 0, 0, 0, time.UTC), ACHDebit, Debit, 1000)
+
+		period, _ := readAccountingPeriod(MonthlyPeriod, "2020-03")
+		opening, lines, err := repo.getStatementActivity(account.ID, period.Start, period.End)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opening != 10000 || len(lines) != 2 || lines[0].Date != "2020-03-05" || lines[1].Purpose != Interest {
+			t.Errorf("opening=%d lines=%#v", opening, lines)
+		}
+
+		s, err := generateStatement(repo, account, period, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := generateStatement(repo, account, period, time.Now()); err == nil || !strings.Contains(err.Error(), errStatementExists.Error()) {
+			t.Errorf("expected duplicate statement: %v", err)
+		}
+		if exists, err := repo.statementExists(account.ID, "2020-03"); !exists || err != nil {
+			t.Errorf("exists=%v error=%v", exists, err)
+		}
+
+		statements, err := repo.getAccountStatements(account.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(statements) != 1 || statements[0].ID != s.ID || statements[0].ClosingBalance != 9525 || len(statements[0].Lines) != 0 {
+			t.Errorf("unexpected statements: %#v", statements)
+		}
+		found, err := repo.getStatement(account.ID, s.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found == nil || len(found.Lines) != 2 || found.Lines[1].RunningBalance != 9525 || found.MaskedAccountNumber != "*****4321" {
+			t.Errorf("unexpected statement: %#v", found)
+		}
+		pdf, err := repo.getStatementPDF(account.ID, s.ID)
+		if err != nil || !bytes.HasPrefix(pdf, []byte("%PDF")) {
+			t.Errorf("unexpected PDF (%d bytes): %v", len(pdf), err)
+		}
+
+		// Lines backdated into March after its statement was generated are carried onto April's statement
+		post(time.Date(2020, time.March, 15, 12, 0, 0, 0, time.UTC), ACHCredit, Credit, 300)
+		april, _ := readAccountingPeriod(MonthlyPeriod, "2020-04")
+		opening, lines, err = repo.getStatementActivity(account.ID, april.Start, april.End)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opening != s.ClosingBalance || len(lines) != 2 || lines[0].Date != "2020-03-15" || lines[1].Date != "2020-04-01" {
+			t.Errorf("opening=%d lines=%#v", opening, lines)
+		}
+
+		// Statements aren't saved if lines were posted since their activity was read
+		stale := buildStatement(account, april, opening, lines, time.Now())
+		post(time.Date(2020, time.March, 20, 12, 0, 0, 0, time.UTC), ACHCredit, Credit, 50)
+		if err := repo.saveStatement(stale, nil); !errors.Is(err, errStatementActivityChanged) {
+			t.Errorf("expected errStatementActivityChanged: %v", err)
+		}
+		next, err := generateStatement(repo, account, april, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next.OpeningBalance != s.ClosingBalance || len(next.Lines) != 3 || next.ClosingBalance != 9525+300+50-1000 {
+			t.Errorf("unexpected statement: %#v", next)
+		}
+
+		if found, err := repo.getStatement(base.ID(), s.ID); found != nil || err != nil {
+			t.Errorf("statement=%#v error=%v", found, err)
+		}
+		if pdf, err := repo.getStatementPDF(base.ID(), s.ID); pdf != nil || err != nil {
+			t.Errorf("PDF=%d bytes error=%v", len(pdf), err)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
@@ -0,0 +1,384 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/base"
+	moovhttp "github.com/moov-io/base/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// statement is a monthly summary of an account's activity. Lines are ordered by their effective date and
+// each carries the account balance after it's applied. Lines backdated into a month which already had its
+// statement are on the next statement, so each statement opens with the previous statement's closing balance.
+type statement struct {
+	ID                  string `json:"id"`
+	AccountID           string `json:"accountId"`
+	AccountName         string `json:"accountName"`
+	MaskedAccountNumber string `json:"maskedAccountNumber"`
+	RoutingNumber       string `json:"routingNumber"`
+
+	// Period is the month of the statement (e.g. 2020-03) and StartDate and EndDate are its first and last days
+	Period    string `json:"period"`
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate"`
+
+	OpeningBalance int64            `json:"openingBalance"`
+	ClosingBalance int64            `json:"closingBalance"`
+	Summary        statementSummary `json:"summary"`
+	Lines          []statementLine  `json:"lines,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type statementLine struct {
+	TransactionID  string             `json:"transactionId"`
+	Date           string             `json:"date"`
+	Purpose        TransactionPurpose `json:"purpose"`
+	Direction      LineDirection      `json:"direction"`
+	Amount         int64              `json:"amount"`
+	RunningBalance int64              `json:"runningBalance"`
+}
+
+// statementSummary totals a statement's lines. Fees are net of refunded fees and Interest is net of
+// reversed interest.
+type statementSummary struct {
+	Credits  int64 `json:"credits"`
+	Debits   int64 `json:"debits"`
+	Fees     int64 `json:"fees"`
+	Interest int64 `json:"interest"`
+}
+
+// maskAccountNumber hides all but the last four digits of an account number
+func maskAccountNumber(v string) string {
+	if len(v) <= 4 {
+		return v
+	}
+	return strings.Repeat("*", len(v)-4) + v[len(v)-4:]
+}
+
+// buildStatement returns the statement of account over period from its opening balance and lines
+func buildStatement(account *accounts.Account, period accountingPeriod, opening int64, lines []statementLine, now time.Time) statement {
+	s := statement{
+		ID:                  base.ID(),
+		AccountID:           account.ID,
+		AccountName:         account.Name,
+		MaskedAccountNumber: maskAccountNumber(account.AccountNumber),
+		RoutingNumber:       account.RoutingNumber,
+		Period:              period.Start.Format("2006-01"),
+		StartDate:           period.Start.Format("2006-01-02"),
+		EndDate:             period.End.AddDate(0, 0, -1).Format("2006-01-02"),
+		OpeningBalance:      opening,
+		Lines:               lines,
+		CreatedAt:           now,
+	}
+	balance := opening
+	for i := range s.Lines {
+		line := transactionLine{Direction: s.Lines[i].Direction, Amount: s.Lines[i].Amount}
+		balance += line.balanceChange()
+		s.Lines[i].RunningBalance = balance
+
+		if line.isDebit() {
+			s.Summary.Debits += line.Amount
+		} else {
+			s.Summary.Credits += line.Amount
+		}
+		switch s.Lines[i].Purpose {
+		case Fee:
+			s.Summary.Fees -= line.balanceChange()
+		case Interest:
+			s.Summary.Interest += line.balanceChange()
+		}
+	}
+	s.ClosingBalance = balance
+	return s
+}
+
+// generateStatement builds, renders and stores the statement of account for period
+func generateStatement(repo statementRepository, account *accounts.Account, period accountingPeriod, now time.Time) (*statement, error) {
+	opening, lines, err := repo.getStatementActivity(account.ID, period.Start, period.End)
+	if err != nil {
+		return nil, err
+	}
+	s := buildStatement(account, period, opening, lines, now)
+
+	var buf bytes.Buffer
+	if err := renderStatementPDF(&buf, s); err != nil {
+		return nil, fmt.Errorf("statement=%s PDF: %w", s.ID, err)
+	}
+	if err := repo.saveStatement(s, buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// generateMonthlyStatements generates the statement for the month before now of every customer account at our
+// routing number which doesn't have one yet. Accounts closed before the month started or opened after it ended
+// are skipped, as are accounts at other financial institutions.
+//
+// Fees and interest for a month which has ended post into the current month (see openPostingDate), so they're
+// on the next statement rather than changing one which was already generated. Other postings backdated into a month
+// which has a statement are carried onto the next statement. Statements which fail, such as when the account's
+// activity changed while generating them, are generated on the next run.
+func generateMonthlyStatements(logger log.Logger, accountRepo accountRepository, repo statementRepository, now time.Time) (int, error) {
+	lastMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+	period := newAccountingPeriod(MonthlyPeriod, lastMonth)
+
+	generated := 0
+	err := forEachAccount(accountRepo, period.End, func(acct *accounts.Account) error {
+		if acct.RoutingNumber != defaultRoutingNumber {
+			return nil
+		}
+		if !acct.ClosedAt.IsZero() && acct.ClosedAt.Before(period.Start) {
+			return nil
+		}
+		exists, err := repo.statementExists(acct.ID, lastMonth.Format("2006-01"))
+		if err != nil || exists {
+			return err
+		}
+		s, err := generateStatement(repo, acct, period, now)
+		if err != nil {
+			if !errors.Is(err, errStatementExists) { // otherwise generated concurrently
+				logger.Log("statements", fmt.Sprintf("problem generating account=%s statement for %s: %v", acct.ID, lastMonth.Format("2006-01"), err))
+			}
+			return nil
+		}
+		logger.Log("statements", fmt.Sprintf("generated statement=%s for account=%s period=%s", s.ID, s.AccountID, s.Period))
+		generated++
+		return nil
+	})
+	return generated, err
+}
+
+// generateStatementsEvery periodically generates last month's statements until ctx is done.
+func generateStatementsEvery(ctx context.Context, logger log.Logger, accountRepo accountRepository, repo statementRepository, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			n, err := generateMonthlyStatements(logger, accountRepo, repo, now.UTC())
+			if err != nil {
+				logger.Log("statements", fmt.Sprintf("problem generating statements: %v", err))
+				continue
+			}
+			if n > 0 {
+				logger.Log("statements", fmt.Sprintf("generated %d statements", n))
+			}
+		}
+	}
+}
+
+// formatCents formats an amount of cents as dollars with separators (e.g. 123456 is 1,234.56)
+func formatCents(cents int64) string {
+	neg := cents < 0
+	if neg {
+		cents = -1 * cents
+	}
+	s := strconv.FormatInt(cents/100, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	s = fmt.Sprintf("%s.%02d", s, cents%100)
+	if neg {
+		return "-" + s
+	}
+	return s
+}
+
+func renderStatementPDF(w io.Writer, s statement) error {
+	pdf := gofpdf.New("P", "pt", "Letter", "")
+	pdf.SetCreationDate(s.CreatedAt)
+	pdf.SetModificationDate(s.CreatedAt)
+	pdf.SetCatalogSort(true)
+	pdf.SetTitle(fmt.Sprintf("Statement for %s from %s to %s", s.MaskedAccountNumber, s.StartDate, s.EndDate), false)
+	pdf.SetMargins(36, 36, 36)
+	pdf.AddPage()
+
+	const (
+		dateWidth    = 70.0
+		purposeWidth = 170.0
+		amountWidth  = 100.0
+		rowHeight    = 16.0
+	)
+
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(540, 20, "Account Statement", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(540, 13, s.AccountName, "", 1, "L", false, 0, "")
+	pdf.CellFormat(540, 13, fmt.Sprintf("Account %s, routing number %s", s.MaskedAccountNumber, s.RoutingNumber), "", 1, "L", false, 0, "")
+	pdf.CellFormat(540, 13, fmt.Sprintf("Statement period %s to %s", s.StartDate, s.EndDate), "", 1, "L", false, 0, "")
+	pdf.Ln(10)
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.SetFillColor(230, 230, 230)
+	pdf.CellFormat(540, rowHeight, "Summary", "1", 1, "L", true, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	for _, row := range []struct {
+		caption string
+		amount  int64
+	}{
+		{"Opening balance", s.OpeningBalance},
+		{"Credits", s.Summary.Credits},
+		{"Debits", s.Summary.Debits},
+		{"Fees charged", s.Summary.Fees},
+		{"Interest earned", s.Summary.Interest},
+		{"Closing balance", s.ClosingBalance},
+	} {
+		pdf.CellFormat(440, rowHeight, row.caption, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(amountWidth, rowHeight, formatCents(row.amount), "1", 1, "R", false, 0, "")
+	}
+	pdf.Ln(10)
+
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(dateWidth, rowHeight, "Date", "1", 0, "L", true, 0, "")
+	pdf.CellFormat(purposeWidth, rowHeight, "Description", "1", 0, "L", true, 0, "")
+	pdf.CellFormat(amountWidth, rowHeight, "Debit", "1", 0, "R", true, 0, "")
+	pdf.CellFormat(amountWidth, rowHeight, "Credit", "1", 0, "R", true, 0, "")
+	pdf.CellFormat(amountWidth, rowHeight, "Balance", "1", 1, "R", true, 0, "")
+
+	pdf.SetFont("Helvetica", "", 9)
+	if len(s.Lines) == 0 {
+		pdf.CellFormat(540, rowHeight, "No activity this period", "1", 1, "C", false, 0, "")
+	}
+	for _, line := range s.Lines {
+		debit, credit := "", formatCents(line.Amount)
+		if line.Direction == Debit {
+			debit, credit = credit, ""
+		}
+		pdf.CellFormat(dateWidth, rowHeight, line.Date, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(purposeWidth, rowHeight, statementLineDescription(line.Purpose), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(amountWidth, rowHeight, debit, "1", 0, "R", false, 0, "")
+		pdf.CellFormat(amountWidth, rowHeight, credit, "1", 0, "R", false, 0, "")
+		pdf.CellFormat(amountWidth, rowHeight, formatCents(line.RunningBalance), "1", 1, "R", false, 0, "")
+	}
+	return pdf.Output(w)
+}
+
+func statementLineDescription(purpose TransactionPurpose) string {
+	switch purpose {
+	case ACHCredit:
+		return "ACH credit"
+	case ACHDebit:
+		return "ACH debit"
+	case Fee:
+		return "Fee"
+	case Interest:
+		return "Interest"
+	case Transfer:
+		return "Transfer"
+	case Wire:
+		return "Wire"
+	}
+	return string(purpose)
+}
+
+var errNoStatementID = errors.New("no statementId found")
+
+func addStatementRoutes(logger log.Logger, r *mux.Router, repo statementRepository) {
+	r.Methods("GET").Path("/accounts/{accountId}/statements").HandlerFunc(getAccountStatements(logger, repo))
+	r.Methods("GET").Path("/accounts/{accountId}/statements/{statementId}").HandlerFunc(getStatement(logger, repo))
+}
+
+func getAccountStatements(logger log.Logger, repo statementRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		accountID := getAccountID(w, r)
+		if accountID == "" {
+			return
+		}
+		statements, err := repo.getAccountStatements(accountID)
+		if err != nil {
+			logger.Log("statements", fmt.Sprintf("problem reading account=%s statements: %v", accountID, err), "requestID", moovhttp.GetRequestID(r))
+			moovhttp.Problem(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(statements)
+	}
+}
+
+// wantsStatementPDF returns true if the request asks for a PDF with ?format=pdf or an Accept header
+func wantsStatementPDF(r *http.Request) bool {
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		return format == "pdf"
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/pdf")
+}
+
+func getStatement(logger log.Logger, repo statementRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w, err := wrapResponseWriter(logger, w, r)
+		if err != nil {
+			return
+		}
+
+		accountID := getAccountID(w, r)
+		if accountID == "" {
+			return
+		}
+		statementID := mux.Vars(r)["statementId"]
+		if statementID == "" {
+			moovhttp.Problem(w, errNoStatementID)
+			return
+		}
+
+		if wantsStatementPDF(r) {
+			pdf, err := repo.getStatementPDF(accountID, statementID)
+			if err != nil {
+				logger.Log("statements", fmt.Sprintf("problem reading statement=%s PDF: %v", statementID, err), "requestID", moovhttp.GetRequestID(r))
+				moovhttp.Problem(w, err)
+				return
+			}
+			if pdf == nil {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="statement-%s.pdf"`, statementID))
+			w.WriteHeader(http.StatusOK)
+			w.Write(pdf)
+			return
+		}
+
+		s, err := repo.getStatement(accountID, statementID)
+		if err != nil {
+			logger.Log("statements", fmt.Sprintf("problem reading statement=%s: %v", statementID, err), "requestID", moovhttp.GetRequestID(r))
+			moovhttp.Problem(w, err)
+			return
+		}
+		if s == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(s)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+)
+
+func TestStatements__buildStatement(t *testing.T) {
+	account := &accounts.Account{ID: base.ID(), Name: "Savings", AccountNumber: "1234567", RoutingNumber: "121042882"}
+	period, _ := readAccountingPeriod(MonthlyPeriod, "2020-02")
+	s := buildStatement(account, period, 1000, []statementLine{
+		{Date: "2020-02-03", Purpose: ACHCredit, Direction: Credit, Amount: 5000},
+		{Date: "2020-02-10", Purpose: Fee, Direction: Debit, Amount: 300},
+		{Date: "2020-02-11", Purpose: Fee, Direction: Credit, Amount: 100},
+		{Date: "2020-02-29", Purpose: Interest, Direction: Credit, Amount: 12},
+		{Date: "2020-02-29", Purpose: ACHDebit, Direction: Debit, Amount: 2000},
+	}, time.Now())
+
+	if s.Period != "2020-02" || s.StartDate != "2020-02-01" || s.EndDate != "2020-02-29" || s.MaskedAccountNumber != "***4567" {
+		t.Errorf("unexpected statement: %#v", s)
+	}
+	if s.ClosingBalance != 3812 || s.Lines[1].RunningBalance != 5700 || s.Lines[4].RunningBalance != 3812 {
+		t.Errorf("unexpected balances: %#v", s)
+	}
+	if s.Summary.Credits != 5112 || s.Summary.Debits != 2300 || s.Summary.Fees != 200 || s.Summary.Interest != 12 {
+		t.Errorf("unexpected summary: %#v", s.Summary)
+	}
+
+	var buf bytes.Buffer
+	if err := renderStatementPDF(&buf, s); err != nil || !bytes.HasPrefix(buf.Bytes(), []byte("%PDF")) {
+		t.Errorf("unexpected PDF: %v", err)
+	}
+}
+
+func TestStatements__format(t *testing.T) {
+	if v := maskAccountNumber("123"); v != "123" {
+		t.Errorf("got %q", v)
+	}
+	for cents, expected := range map[int64]string{0: "0.00", 5: "0.05", 123456: "1,234.56", -100000000: "-1,000,000.00"} {
+		if v := formatCents(cents); v != expected {
+			t.Errorf("%d: got %q", cents, v)
+		}
+	}
+}
+
+func TestStatements__generateMonthlyStatements(t *testing.T) {
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+
+	repo := createTestSqlTransactionRepository(t, sqliteDB.DB)
+	now := time.Date(2020, time.April, 2, 6, 0, 0, 0, time.UTC)
+	accountRepo := &testAccountRepository{
+		accounts: []*accounts.Account{
+			{ID: base.ID(), Name: "Checking", AccountNumber: "1234567", RoutingNumber: defaultRoutingNumber, Status: "open", CreatedAt: now.AddDate(0, -2, 0)},
+			{ID: base.ID(), Name: "Closed", AccountNumber: "7654321", RoutingNumber: defaultRoutingNumber, Status: "closed", CreatedAt: now.AddDate(0, -3, 0), ClosedAt: now.AddDate(0, -2, 0)},
+			{ID: base.ID(), Name: "New", AccountNumber: "1111111", RoutingNumber: defaultRoutingNumber, Status: "open", CreatedAt: now},
+			{ID: base.ID(), Name: "External", AccountNumber: "2222222", RoutingNumber: "121042882", Status: "open", CreatedAt: now.AddDate(0, -2, 0)},
+		},
+	}
+	n, err := generateMonthlyStatements(log.NewNopLogger(), accountRepo, repo, now)
+	if err != nil || n != 1 {
+		t.Fatalf("generated %d statements: %v", n, err)
+	}
+	if n, err := generateMonthlyStatements(log.NewNopLogger(), accountRepo, repo, now); err != nil || n != 0 {
+		t.Errorf("generated %d statements again: %v", n, err)
+	}
+
+	router := mux.NewRouter()
+	addStatementRoutes(log.NewNopLogger(), router, repo)
+	call := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("x-user-id", "test")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		w.Flush()
+		return w
+	}
+
+	accountID := accountRepo.accounts[0].ID
+	w := call("/accounts/"+accountID+"/statements", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+	var statements []statement
+	if err := json.NewDecoder(w.Body).Decode(&statements); err != nil {
+		t.Fatal(err)
+	}
+	if len(statements) != 1 || statements[0].Period != "2020-03" {
+		t.Fatalf("unexpected statements: %#v", statements)
+	}
+
+	if w := call("/accounts/"+accountID+"/statements/"+statements[0].ID, ""); w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Errorf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+	w = call("/accounts/"+accountID+"/statements/"+statements[0].ID, "application/pdf")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/pdf" || !bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF")) {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	if w := call("/accounts/"+accountID+"/statements/"+statements[0].ID+"?format=pdf", ""); w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/pdf" {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	if w := call("/accounts/"+accountID+"/statements/"+base.ID(), ""); w.Code != http.StatusNotFound {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+	if w := call("/accounts/"+accountID+"/statements/"+base.ID()+"?format=pdf", ""); w.Code != http.StatusNotFound {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+}
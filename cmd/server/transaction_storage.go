@@ -4,13 +4,35 @@
 
 package main
 
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
 type transactionRepository interface {
 	Ping() error
 	Close() error
 
 	createTransaction(tx transaction, opts createTransactionOpts) error
-	getAccountTransactions(accountID string) ([]transaction, error) // TODO(adam): limit and/or pagination params
+	// getAccountTransactions returns a page of transactions with lines posted against accountID, newest first.
+	// The returned cursor is non-nil when more transactions match params.
+	getAccountTransactions(accountID string, params transactionSearchParams) ([]transaction, *transactionCursor, error)
 	getTransaction(transactionID string) (*transaction, error)
+
+	// reverseTransaction posts a transaction which undoes some or all of the original transaction and links them.
+	// errTransactionReversed is returned if the original has already been fully reversed.
+	reverseTransaction(transactionID string, req reversalRequest, opts createTransactionOpts) (*transaction, error)
+
+	// reconcileBalances recomputes balances from posted lines and returns accounts whose checkpointed balance differs
+	reconcileBalances(repair bool) ([]balanceDrift, error)
+
+	// getAccountBalanceAsOf returns the balance of an account from lines effective before asOf
+	getAccountBalanceAsOf(accountID string, asOf time.Time) (int64, error)
+	// getDailyBalances returns the balance history of an account for each day in [from, to)
+	getDailyBalances(accountID string, from, to time.Time) ([]dailyBalance, error)
 }
 
 type createTransactionOpts struct {
@@ -22,6 +44,64 @@ type createTransactionOpts struct {
 	// to onboard on account. This is done to initially add funds into an account, but we don't track where the
 	// funds come from on the transaction level.
 	InitialDeposit bool
+
+	// AllowGLDebits lets internal postings to the FI's own books, such as GL journals and interest, debit GL accounts
+	// without checking their balance. It must never be set for transactions submitted through the HTTP API.
+	AllowGLDebits bool
+
+	// IdempotencyKey, when set, is the reserved key of the request posting the transaction. The transaction is linked
+	// to it in the same database transaction, so retries with the key can't post again.
+	IdempotencyKey *idempotencyKey
+}
+
+// transactionSearchParams filters and pages transactions returned from getAccountTransactions.
+type transactionSearchParams struct {
+	// Limit is the maximum number of transactions to return
+	Limit int
+
+	// Cursor, when set, only returns transactions which come after the cursor
+	Cursor *transactionCursor
+
+	// StartDate and EndDate, if non-zero, bound transaction timestamps as [StartDate, EndDate)
+	StartDate time.Time
+	EndDate   time.Time
+
+	// Purposes, if non-empty, limits transactions to those with a line for the account with any of the purposes
+	Purposes []TransactionPurpose
+}
+
+// transactionCursor marks the last transaction of a page. Transactions are ordered by their
+// timestamp and then ID, so the pair is enough to resume from.
+type transactionCursor struct {
+	Timestamp     time.Time
+	TransactionID string
+}
+
+var errInvalidCursor = errors.New("invalid transaction cursor")
+
+// String returns an opaque representation of the cursor for callers to send back
+func (c *transactionCursor) String() string {
+	if c == nil {
+		return ""
+	}
+	raw := fmt.Sprintf("%s|%s", c.Timestamp.Format(time.RFC3339Nano), c.TransactionID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeTransactionCursor(v string) (*transactionCursor, error) {
+	bs, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	parts := strings.SplitN(string(bs), "|", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, errInvalidCursor
+	}
+	ts, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	return &transactionCursor{Timestamp: ts, TransactionID: parts[1]}, nil
 }
 
 // grabAccountIDs returns an []string of each accountID from an array of transactionLines.
@@ -7,11 +7,13 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
 
 	"github.com/go-kit/kit/log"
 )
@@ -21,6 +23,9 @@ type sqlTransactionRepository struct {
 	logger log.Logger
 
 	accountRepo accountRepository
+
+	// glRules post GL journal entries for customer transactions
+	glRules glRules
 }
 
 func setupSqlTransactionStorage(ctx context.Context, logger log.Logger, db *sql.DB) (*sqlTransactionRepository, error) {
@@ -46,8 +51,7 @@ func isInternalDebit(accounts []*accounts.Account, lines []transactionLine, rout
 	for i := range accounts {
 		for j := range lines {
 			if accounts[i].ID == lines[j].AccountID {
-				switch lines[j].Purpose {
-				case ACHDebit:
+				if lines[j].isDebit() {
 					return accounts[i].RoutingNumber == routingNumber
 				}
 			}
@@ -56,55 +60,181 @@ func isInternalDebit(accounts []*accounts.Account, lines []transactionLine, rout
 	return true // default to assuming we need to check/prevent an overdraft
 }
 
+// checkAccountStatuses returns an error if any line can't be posted due to its account's status.
+// Lines for accounts we don't have a record of are not checked.
+func checkAccountStatuses(accounts []*accounts.Account, lines []transactionLine, opts createTransactionOpts) error {
+	for i := range accounts {
+		for j := range lines {
+			if accounts[i].ID == lines[j].AccountID {
+				if err := readAccountStatus(accounts[i].Status).allowsLine(lines[j], opts); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readPostingAccounts reads the account of each line through the account repository, which can be stored in another
+// database than transactions, and returns an error if any line can't be posted due to its account's status. Posting
+// calls it on each attempt before beginning its database transaction, so status changes committed since an earlier
+// attempt are enforced. Like checkAccountStatuses lines for accounts we don't have a record of are not checked.
+func (r *sqlTransactionRepository) readPostingAccounts(lines []transactionLine, opts createTransactionOpts) ([]*accounts.Account, error) {
+	accounts, err := r.accountRepo.GetAccounts(grabAccountIDs(lines))
+	if err != nil {
+		return nil, fmt.Errorf("problem reading accounts: %w", err)
+	}
+	if err := checkAccountStatuses(accounts, lines, opts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
 func (r *sqlTransactionRepository) createTransaction(t transaction, opts createTransactionOpts) error {
 	if err := t.validate(); err != nil && !opts.InitialDeposit {
-		return fmt.Errorf("transaction=%q is invalid: %v", t.ID, err)
+		return fmt.Errorf("transaction=%q is invalid: %w", t.ID, err)
 	}
 
-	accounts, err := r.accountRepo.GetAccounts(grabAccountIDs(t.Lines))
-	if err != nil {
-		return fmt.Errorf("createTransaction: problem reading accounts for transaction=%q: %v", t.ID, err)
+	var posted []*accounts.Account
+	err := withPostingRetries(func() error {
+		accounts, err := r.readPostingAccounts(t.Lines, opts)
+		if err != nil {
+			return fmt.Errorf("createTransaction: transaction=%q: %w", t.ID, err)
+		}
+		posted = accounts
+		return r.postTransaction(t, opts, accounts)
+	})
+	if errors.Is(err, errInsufficientFunds) {
+		// Record the rejected debits so NSF fees can be assessed
+		if nsfErr := r.recordNSFEvents(t, posted); nsfErr != nil && r.logger != nil {
+			r.logger.Log("transactions", fmt.Sprintf("problem recording NSF for transaction=%q: %v", t.ID, nsfErr))
+		}
+	}
+	return err
+}
+
+const (
+	maxPostingAttempts  = 10
+	postingRetryBackoff = 10 * time.Millisecond
+)
+
+// withPostingRetries calls post until it succeeds or fails for a reason other than a concurrent modification.
+//
+// Balances are updated with optimistic locking, so when another transaction modifies the same
+// account balance concurrently we retry with the freshly committed balances.
+func withPostingRetries(post func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := post()
+		if err == nil || !retryablePostingError(err) || attempt >= maxPostingAttempts {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * postingRetryBackoff)
 	}
+}
+
+// retryablePostingError returns true if posting a transaction failed due to a concurrent modification
+// of an account balance, withdrawal counter or accounting period, rather than anything wrong with the transaction itself.
+func retryablePostingError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, errBalanceConflict) || errors.Is(err, errWithdrawalCounterConflict) ||
+		errors.Is(err, errPeriodsChanged) || database.LockConflict(err)
+}
+
+var errInsufficientFunds = errors.New("has insufficient funds")
+
+// hasSufficientFunds checks the available balance of an account after line has been applied.
+//
+// The available balance is the posted balance less any active holds. Debits can't take it below the negative
+// of the account's overdraft limit, which is zero for accounts without an overdraft line. Credits are always allowed.
+func hasSufficientFunds(available, overdraftLimit int64, line transactionLine) bool {
+	return !line.isDebit() || available >= -overdraftLimit
+}
 
+func (r *sqlTransactionRepository) postTransaction(t transaction, opts createTransactionOpts, accounts []*accounts.Account) error {
 	tx, err := r.db.Begin()
 	if err != nil {
-		return fmt.Errorf("createTransaction: tx.Begin error=%v rollback=%v", err, tx.Rollback())
+		return fmt.Errorf("createTransaction: tx.Begin: %w", err)
+	}
+	if !opts.InitialDeposit {
+		if err := checkAccountProducts(tx, t, accounts); err != nil {
+			return fmt.Errorf("createTransaction: transaction=%q: %w rollback=%v", t.ID, err, tx.Rollback())
+		}
+	}
+	if err := r.insertTransaction(tx, t, opts, accounts); err != nil {
+		return fmt.Errorf("%w rollback=%v", err, tx.Rollback())
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("createTransaction: commit: %w", err)
+	}
+	return nil
+}
+
+// insertTransaction writes t and each of its lines inside of tx and updates account balances. Callers are
+// responsible for committing or rolling back tx.
+func (r *sqlTransactionRepository) insertTransaction(tx *sql.Tx, t transaction, opts createTransactionOpts, accounts []*accounts.Account) error {
+	t.EffectiveDate = effectiveDateOr(t.EffectiveDate, t.Timestamp).UTC()
+	if postingLocksPeriods(t.EffectiveDate, time.Now()) {
+		if err := checkPeriodVersion(tx); err != nil {
+			return fmt.Errorf("createTransaction: transaction=%q: %w", t.ID, err)
+		}
+	}
+	if err := checkPeriodOpen(tx, t.EffectiveDate); err != nil {
+		return fmt.Errorf("createTransaction: transaction=%q: %w", t.ID, err)
 	}
 
 	// insert transaction
-	query := `insert into transactions(transaction_id, timestamp, created_at) values (?, ?, ?);`
+	query := `insert into transactions(transaction_id, timestamp, effective_date, reversal_of, journal_of, status, created_at) values (?, ?, ?, ?, ?, ?, ?);`
 	stmt, err := tx.Prepare(query)
 	if err != nil {
-		return fmt.Errorf("createTransaction: prepare: error=%v rollback=%v", err, tx.Rollback())
+		return fmt.Errorf("createTransaction: prepare: %w", err)
 	}
-	if _, err := stmt.Exec(t.ID, t.Timestamp, time.Now()); err != nil {
+	if _, err := stmt.Exec(t.ID, t.Timestamp, t.EffectiveDate, nullString(t.ReversalOf), nullString(t.JournalOf), TransactionPosted, time.Now()); err != nil {
 		stmt.Close()
-		return fmt.Errorf("createTransaction: insert: error=%v rollback=%v", err, tx.Rollback())
+		return fmt.Errorf("createTransaction: insert: %w", err)
 	}
 	stmt.Close()
 
+	if opts.IdempotencyKey != nil {
+		if err := linkIdempotencyKey(tx, opts.IdempotencyKey, t.ID); err != nil {
+			return fmt.Errorf("createTransaction: %w", err)
+		}
+	}
+
 	// insert each transactionLine
 	for i := range t.Lines {
-		query = `insert into transaction_lines(transaction_id, account_id, purpose, amount, created_at) values (?, ?, ?, ?, ?);`
+		query = `insert into transaction_lines(transaction_id, account_id, purpose, direction, amount, created_at) values (?, ?, ?, ?, ?, ?);`
 		stmt, err = tx.Prepare(query)
 		if err != nil {
-			stmt.Close()
-			return fmt.Errorf("createTransaction: transaction=%q account=%q prepare: error=%v rollback=%v", t.ID, t.Lines[i].AccountID, err, tx.Rollback())
+			return fmt.Errorf("createTransaction: transaction=%q account=%q prepare: %w", t.ID, t.Lines[i].AccountID, err)
 		}
-		if _, err := stmt.Exec(t.ID, t.Lines[i].AccountID, t.Lines[i].Purpose, t.Lines[i].Amount, time.Now()); err != nil {
+		if _, err := stmt.Exec(t.ID, t.Lines[i].AccountID, t.Lines[i].Purpose, t.Lines[i].Direction, t.Lines[i].Amount, time.Now()); err != nil {
 			stmt.Close()
-			return fmt.Errorf("createTransaction: transaction=%q account=%q insert: error=%v rollback=%v", t.ID, t.Lines[i].AccountID, err, tx.Rollback())
+			return fmt.Errorf("createTransaction: transaction=%q account=%q insert: %w", t.ID, t.Lines[i].AccountID, err)
 		}
 		stmt.Close()
 
-		// Check account balance, and if we're negative by less than t.Lines[i].Amount then we need to rollback as that account
+		if _, err := r.applyBalanceChange(tx, t.Lines[i].AccountID, t.Lines[i].balanceChange()); err != nil {
+			return fmt.Errorf("createTransaction: transaction=%q account=%q: %w", t.ID, t.Lines[i].AccountID, err)
+		}
+
+		// GL accounts track the FI's own books, so their lines must be for an account in the chart of accounts.
+		glAccount := isGLAccountID(t.Lines[i].AccountID)
+		if glAccount {
+			if err := checkJournalGLAccount(tx, t.Lines[i].AccountID); err != nil {
+				return fmt.Errorf("createTransaction: transaction=%q: %w", t.ID, err)
+			}
+		}
+
+		// Check account balance, and if a debit took the account below its overdraft limit then we need to rollback as that account
 		// didn't have sufficient funds to post the transaction.
 		//
 		// From Wade: Allowing overdrafts is similar to offering credit to customers, which requires additional disclosures and would need
-		// to be done on an account-by-account basis.
+		// to be done on an account-by-account basis. Accounts opt into an overdraft line with its own limit and disclosure.
 		if opts.InitialDeposit {
-			if t.Lines[0].Purpose != ACHCredit {
-				return fmt.Errorf("createTransaction: InitialDeposit must be ACHCredit rollback=%v", tx.Rollback())
+			if t.Lines[0].Purpose != ACHCredit || t.Lines[0].isDebit() {
+				return errors.New("createTransaction: InitialDeposit must be an ACHCredit credit")
 			}
 			if len(t.Lines) == 1 && t.Lines[0].Amount > 100 {
 				// Ignore all other checks and just allow the deposit
@@ -113,163 +243,168 @@ func (r *sqlTransactionRepository) createTransaction(t transaction, opts createT
 		}
 		// TODO(adam): I think we need to add a check (to bypass further validation) on external accounts
 		// since we won't have an accurate way to confirm their balance.
-		balance, err := r.getAccountBalance(tx, t.Lines[i].AccountID)
-		if err != nil {
-			return fmt.Errorf("createTransaction: getAccountBalance: transaction=%q account=%q: err=%v rollback=%v", t.ID, t.Lines[i].AccountID, err, tx.Rollback())
-		}
-		// The current account balance is negative, so if that balance is less negative than the transaction amount that means the
-		// account was overdrawn (i.e. insufficient funds). If the balances are equal then we also ran out of funds.
 		//
 		// If the debited account is external then allow the transfer. (That accounts system will send back a returned file on an insufficient balance.)
 		if opts.AllowOverdraft || !isInternalDebit(accounts, t.Lines, defaultRoutingNumber) {
 			continue
 		}
-		if balance <= 0 || (balance <= int32(t.Lines[i].Amount) && t.Lines[i].Purpose == ACHDebit) {
-			return fmt.Errorf("acocunt=%q has insufficient funds: rollback=%v", t.Lines[i].AccountID, tx.Rollback())
+		// Only internal postings can debit GL accounts (e.g. increasing an asset) without a funds check,
+		// otherwise callers could credit customer accounts with money debited from the FI's books.
+		if glAccount && opts.AllowGLDebits {
+			continue
+		}
+		balances, err := r.getAccountBalances(tx, t.Lines[i].AccountID)
+		if err != nil {
+			return fmt.Errorf("createTransaction: transaction=%q account=%q: %w", t.ID, t.Lines[i].AccountID, err)
+		}
+		limit, err := readOverdraftLimit(tx, t.Lines[i].AccountID)
+		if err != nil {
+			return fmt.Errorf("createTransaction: transaction=%q account=%q: %w", t.ID, t.Lines[i].AccountID, err)
+		}
+		if !hasSufficientFunds(balances.Available, limit, t.Lines[i]) {
+			return fmt.Errorf("account=%q %w", t.Lines[i].AccountID, errInsufficientFunds)
 		}
 	}
-
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("createTransaction: commit: %v", err)
-	}
-	return nil
+	return r.insertGLJournal(tx, t, accounts)
 }
 
-func (r *sqlTransactionRepository) getAccountTransactions(accountID string) ([]transaction, error) {
-	tx, err := r.db.Begin()
-	if err != nil {
-		return nil, fmt.Errorf("getAccountTransactions: %v", err)
+func (r *sqlTransactionRepository) getAccountTransactions(accountID string, params transactionSearchParams) ([]transaction, *transactionCursor, error) {
+	// Find the page of transactions posted against accountID and then join all of their lines in one query.
+	// We read one extra transaction to know if another page exists.
+	var where []string
+	args := []interface{}{accountID}
+	if !params.StartDate.IsZero() {
+		where = append(where, "t.timestamp >= ?")
+		args = append(args, params.StartDate)
 	}
+	if !params.EndDate.IsZero() {
+		where = append(where, "t.timestamp < ?")
+		args = append(args, params.EndDate)
+	}
+	if len(params.Purposes) > 0 {
+		where = append(where, fmt.Sprintf("al.purpose in (?%s)", strings.Repeat(",?", len(params.Purposes)-1)))
+		for i := range params.Purposes {
+			args = append(args, params.Purposes[i])
+		}
+	}
+	if params.Cursor != nil {
+		where = append(where, "(t.timestamp < ? or (t.timestamp = ? and t.transaction_id < ?))")
+		args = append(args, params.Cursor.Timestamp, params.Cursor.Timestamp, params.Cursor.TransactionID)
+	}
+	args = append(args, params.Limit+1)
 
-	query := `select distinct transaction_id from transaction_lines where account_id = ? order by created_at desc;`
-	stmt, err := tx.Prepare(query)
+	filters := ""
+	if len(where) > 0 {
+		filters = " and " + strings.Join(where, " and ")
+	}
+	query := fmt.Sprintf(`select page.transaction_id, page.timestamp, page.effective_date, page.reversal_of, page.journal_of, page.status, l.account_id, l.purpose, l.direction, l.amount from (
+select t.transaction_id, t.timestamp, t.effective_date, t.reversal_of, t.journal_of, t.status from transactions t inner join transaction_lines al on al.transaction_id = t.transaction_id
+where al.account_id = ? and al.deleted_at is null and t.deleted_at is null%s
+order by t.timestamp desc, t.transaction_id desc limit ?
+) page inner join transaction_lines l on l.transaction_id = page.transaction_id and l.deleted_at is null
+order by page.timestamp desc, page.transaction_id desc;`, filters)
+
+	stmt, err := r.db.Prepare(query)
 	if err != nil {
-		return nil, fmt.Errorf("getAccountTransactions: prepare: error=%v rollback=%v", err, tx.Rollback())
+		return nil, nil, fmt.Errorf("getAccountTransactions: prepare: %w", err)
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.Query(accountID)
+	rows, err := stmt.Query(args...)
 	if err != nil {
-		return nil, fmt.Errorf("getAccountTransactions: query: error=%v rollback=%v", err, tx.Rollback())
+		return nil, nil, fmt.Errorf("getAccountTransactions: query: %w", err)
 	}
 	defer rows.Close()
 
-	var transactionIDs []string
+	var transactions []transaction
 	for rows.Next() {
-		var id string
-		if err := rows.Scan(&id); err != nil {
-			return nil, fmt.Errorf("getAccountTransactions: scan: error=%v rollback=%v", err, tx.Rollback())
+		var transactionID string
+		var timestamp, effectiveDate time.Time
+		var reversalOf, journalOf, status sql.NullString
+		var line transactionLine
+		if err := rows.Scan(&transactionID, &timestamp, &effectiveDate, &reversalOf, &journalOf, &status, &line.AccountID, &line.Purpose, &line.Direction, &line.Amount); err != nil {
+			return nil, nil, fmt.Errorf("getAccountTransactions: scan: %w", err)
 		}
-		transactionIDs = append(transactionIDs, id)
+		if n := len(transactions); n == 0 || transactions[n-1].ID != transactionID {
+			transactions = append(transactions, transaction{
+				ID:            transactionID,
+				Timestamp:     timestamp,
+				EffectiveDate: effectiveDate,
+				Status:        readTransactionStatus(status.String),
+				ReversalOf:    reversalOf.String,
+				JournalOf:     journalOf.String,
+			})
+		}
+		n := len(transactions) - 1
+		transactions[n].Lines = append(transactions[n].Lines, line)
 	}
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("getAccountTransactions: err: error=%v rollback=%v", err, tx.Rollback())
+		return nil, nil, fmt.Errorf("getAccountTransactions: rows: %w", err)
 	}
 
-	var transactions []transaction
-	for i := range transactionIDs {
-		t, err := r.loadTransaction(tx, transactionIDs[i])
-		if err != nil {
-			return nil, fmt.Errorf("getAccountTransactions: looping: error=%v rollback=%v", err, tx.Rollback())
-		}
-		transactions = append(transactions, *t)
-	}
-
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("getAccountTransactions: commit: error=%v rollback=%v", err, tx.Rollback())
+	var next *transactionCursor
+	if len(transactions) > params.Limit {
+		transactions = transactions[:params.Limit]
+		last := transactions[len(transactions)-1]
+		next = &transactionCursor{Timestamp: last.Timestamp, TransactionID: last.ID}
 	}
-	return transactions, nil
+	return transactions, next, nil
 }
 
 func (r *sqlTransactionRepository) getTransaction(transactionID string) (*transaction, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
-		return nil, fmt.Errorf("getTransaction: %v", err)
+		return nil, fmt.Errorf("getTransaction: %w", err)
 	}
 	transaction, err := r.loadTransaction(tx, transactionID)
 	if err != nil {
-		return nil, fmt.Errorf("getTransaction: error=%v rollback=%v", err, tx.Rollback())
+		return nil, fmt.Errorf("getTransaction: error=%w rollback=%v", err, tx.Rollback())
 	}
 	return transaction, tx.Commit()
 }
 
 func (r *sqlTransactionRepository) loadTransaction(tx *sql.Tx, transactionID string) (*transaction, error) {
-	query := `select timestamp from transactions where transaction_id = ? and deleted_at is null limit 1;`
+	query := `select timestamp, effective_date, reversal_of, journal_of, status from transactions where transaction_id = ? and deleted_at is null limit 1;`
 	stmt, err := tx.Prepare(query)
 	if err != nil {
-		return nil, fmt.Errorf("loadTransaction: timestamp: %v", err)
+		return nil, fmt.Errorf("loadTransaction: timestamp: %w", err)
 	}
-	var timestamp time.Time
-	if err := stmt.QueryRow(transactionID).Scan(&timestamp); err != nil {
+	var timestamp, effectiveDate time.Time
+	var reversalOf, journalOf, status sql.NullString
+	if err := stmt.QueryRow(transactionID).Scan(&timestamp, &effectiveDate, &reversalOf, &journalOf, &status); err != nil {
 		stmt.Close()
-		return nil, fmt.Errorf("loadTransaction: timestamp query: %v", err)
+		return nil, fmt.Errorf("loadTransaction: timestamp query: %w", err)
 	}
 	stmt.Close() // close to prevent leaks
 
-	query = `select account_id, purpose, amount from transaction_lines where transaction_id = ? and deleted_at is null`
+	query = `select account_id, purpose, direction, amount from transaction_lines where transaction_id = ? and deleted_at is null`
 	stmt, err = tx.Prepare(query)
 	if err != nil {
-		return nil, fmt.Errorf("loadTransaction: %v", err)
+		return nil, fmt.Errorf("loadTransaction: %w", err)
 	}
 	defer stmt.Close()
 
 	rows, err := stmt.Query(transactionID)
 	if err != nil {
-		return nil, fmt.Errorf("loadTransaction: query: %v", err)
+		return nil, fmt.Errorf("loadTransaction: query: %w", err)
 	}
 	defer rows.Close()
 
 	var lines []transactionLine
 	for rows.Next() {
 		var line transactionLine
-		if err := rows.Scan(&line.AccountID, &line.Purpose, &line.Amount); err != nil {
-			return nil, fmt.Errorf("loadTransaction: scan transaction=%q account=%q: %v", transactionID, line.AccountID, err)
+		if err := rows.Scan(&line.AccountID, &line.Purpose, &line.Direction, &line.Amount); err != nil {
+			return nil, fmt.Errorf("loadTransaction: scan transaction=%q account=%q: %w", transactionID, line.AccountID, err)
 		}
 		lines = append(lines, line)
 	}
 	return &transaction{
-		ID:        transactionID,
-		Timestamp: timestamp,
-		Lines:     lines,
+		ID:            transactionID,
+		Timestamp:     timestamp,
+		EffectiveDate: effectiveDate,
+		Lines:         lines,
+		Status:        readTransactionStatus(status.String),
+		ReversalOf:    reversalOf.String,
+		JournalOf:     journalOf.String,
 	}, rows.Err()
 }
-
-func (r *sqlTransactionRepository) getAccountBalance(tx *sql.Tx, accountID string) (int32, error) {
-	if accountID == "" {
-		return 0, nil
-	}
-
-	// TODO(adam): At some point we should probably checkpoint balances so we avoid an entire index scan on an account_id
-	query := `select amount, purpose from transaction_lines where account_id = ? and deleted_at is null;`
-	stmt, err := tx.Prepare(query)
-	if err != nil {
-		return 0, err
-	}
-	defer stmt.Close()
-
-	rows, err := stmt.Query(accountID)
-	if err != nil {
-		return 0, fmt.Errorf("problem querying account=%s balance: %v", accountID, err)
-	}
-	defer rows.Close()
-
-	var amount int32
-	for rows.Next() {
-		var amt int32
-		var purpose string
-		if err := rows.Scan(&amt, &purpose); err != nil {
-			return 0, fmt.Errorf("error reading amount/purpose: %v", err)
-		}
-		if strings.EqualFold(purpose, "achdebit") {
-			amount -= amt
-		} else {
-			amount += amt
-		}
-	}
-
-	if err := rows.Err(); err != nil {
-		return 0, fmt.Errorf("problem getting account=%s balance: %v", accountID, err)
-	}
-
-	return amount, nil
-}
@@ -7,6 +7,8 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -71,15 +73,15 @@ func TestSqlTransactionRepository(t *testing.T) {
 			ID:        base.ID(),
 			Timestamp: time.Now(),
 			Lines: []transactionLine{
-				{AccountID: account1, Purpose: ACHDebit, Amount: 500},
-				{AccountID: account2, Purpose: ACHCredit, Amount: 500},
+				{AccountID: account1, Purpose: ACHDebit, Direction: Debit, Amount: 500},
+				{AccountID: account2, Purpose: ACHCredit, Direction: Credit, Amount: 500},
 			},
 		}
 		if err := repo.createTransaction(tx, createTransactionOpts{AllowOverdraft: false}); err != nil {
 			t.Fatal(err)
 		}
 
-		transactions, err := repo.getAccountTransactions(account1)
+		transactions, _, err := repo.getAccountTransactions(account1, transactionSearchParams{Limit: 10})
 		if err != nil {
 			t.Error(err)
 		}
@@ -122,6 +124,68 @@ func TestSqlTransactionRepository(t *testing.T) {
 }
 
 // TestSqlTransactionRepository__Internal will create an internal transfer
+func TestSqlTransactionRepository__retryablePostingError(t *testing.T) {
+	if retryablePostingError(nil) {
+		t.Error("nil isn't retryable")
+	}
+	if !retryablePostingError(fmt.Errorf("createTransaction: account=%q: %w", base.ID(), errBalanceConflict)) {
+		t.Error("balance conflicts are retryable")
+	}
+	if !retryablePostingError(fmt.Errorf("createTransaction: %w rollback=<nil>", errWithdrawalCounterConflict)) {
+		t.Error("withdrawal counter conflicts are retryable")
+	}
+	if retryablePostingError(fmt.Errorf("createTransaction: account=%q %w", base.ID(), errInsufficientFunds)) {
+		t.Error("insufficient funds isn't retryable")
+	}
+	if retryablePostingError(errors.New(errBalanceConflict.Error())) {
+		t.Error("only wrapped balance conflicts are retryable")
+	}
+}
+
+func TestSqlTransactionRepository__separateAccountsDatabase(t *testing.T) {
+	accountsDB := database.CreateTestSqliteDB(t)
+	defer accountsDB.Close()
+	transactionsDB := database.CreateTestSqliteDB(t)
+	defer transactionsDB.Close()
+
+	accountRepo, err := setupSqlAccountStorage(context.Background(), log.NewNopLogger(), accountsDB.DB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := createTestSqlTransactionRepository(t, transactionsDB.DB)
+	repo.accountRepo = accountRepo
+
+	account := &accounts.Account{ID: base.ID(), CustomerID: base.ID(), Name: "Checking", AccountNumber: "123", RoutingNumber: defaultRoutingNumber, Status: "open", Type: "Checking", CreatedAt: time.Now()}
+	if err := accountRepo.CreateAccount(account.CustomerID, account); err != nil {
+		t.Fatal(err)
+	}
+	freeze := accountStatusChange{AccountID: account.ID, Status: AccountFrozen, ReasonCode: "investigation", Actor: "compliance", CreatedAt: time.Now()}
+	if err := accountRepo.UpdateAccountStatus(freeze); err != nil {
+		t.Fatal(err)
+	}
+
+	// Statuses are read from the accounts database, which the transactions database has no copy of
+	tx := transaction{
+		ID:        base.ID(),
+		Timestamp: time.Now(),
+		Lines: []transactionLine{
+			{AccountID: account.ID, Purpose: ACHCredit, Direction: Credit, Amount: 1000},
+			{AccountID: base.ID(), Purpose: ACHDebit, Direction: Debit, Amount: 1000},
+		},
+	}
+	if err := repo.createTransaction(tx, createTransactionOpts{AllowOverdraft: true}); err == nil || !strings.Contains(err.Error(), "is frozen and can't be credited") {
+		t.Errorf("expected error: %v", err)
+	}
+
+	restrict := accountStatusChange{AccountID: account.ID, Status: AccountDebitRestricted, ReasonCode: "investigation-cleared", Actor: "compliance", CreatedAt: time.Now()}
+	if err := accountRepo.UpdateAccountStatus(restrict); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.createTransaction(tx, createTransactionOpts{AllowOverdraft: true}); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestSqlTransactionRepository__Internal(t *testing.T) {
 	t.Parallel()
 
@@ -143,7 +207,7 @@ func TestSqlTransactionRepository__Internal(t *testing.T) {
 			ID:        base.ID(),
 			Timestamp: time.Now(),
 			Lines: []transactionLine{
-				{AccountID: account1, Purpose: ACHCredit, Amount: 1000},
+				{AccountID: account1, Purpose: ACHCredit, Direction: Credit, Amount: 1000},
 			},
 		}
 		if err := repo.createTransaction(tx, createTransactionOpts{InitialDeposit: true}); err != nil {
@@ -165,8 +229,8 @@ func TestSqlTransactionRepository__Internal(t *testing.T) {
 			ID:        base.ID(),
 			Timestamp: time.Now(),
 			Lines: []transactionLine{
-				{AccountID: account1, Purpose: ACHDebit, Amount: 400},
-				{AccountID: account2, Purpose: ACHCredit, Amount: 400},
+				{AccountID: account1, Purpose: ACHDebit, Direction: Debit, Amount: 400},
+				{AccountID: account2, Purpose: ACHCredit, Direction: Credit, Amount: 400},
 			},
 		}
 		// Create the transaction and allow it to overdraft
@@ -176,7 +240,7 @@ func TestSqlTransactionRepository__Internal(t *testing.T) {
 		}
 		t.Logf("created transaction=%s", tx.ID)
 
-		transactions, err := repo.getAccountTransactions(account1)
+		transactions, _, err := repo.getAccountTransactions(account1, transactionSearchParams{Limit: 10})
 		if err != nil {
 			t.Error(err)
 		}
@@ -243,8 +307,8 @@ func TestSqlTransactionRepository__AllowOverdraft(t *testing.T) {
 			ID:        base.ID(),
 			Timestamp: time.Now(),
 			Lines: []transactionLine{
-				{AccountID: account1, Purpose: ACHDebit, Amount: 500},
-				{AccountID: account2, Purpose: ACHCredit, Amount: 500},
+				{AccountID: account1, Purpose: ACHDebit, Direction: Debit, Amount: 500},
+				{AccountID: account2, Purpose: ACHCredit, Direction: Credit, Amount: 500},
 			},
 		}
 		// Create the transaction and allow it to overdraft
@@ -252,7 +316,7 @@ func TestSqlTransactionRepository__AllowOverdraft(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		transactions, err := repo.getAccountTransactions(account1)
+		transactions, _, err := repo.getAccountTransactions(account1, transactionSearchParams{Limit: 10})
 		if err != nil {
 			t.Error(err)
 		}
@@ -307,8 +371,8 @@ func TestSqlTransactionRepository__DisallowOverdraft(t *testing.T) {
 			ID:        base.ID(),
 			Timestamp: time.Now(),
 			Lines: []transactionLine{
-				{AccountID: account1, Purpose: ACHDebit, Amount: 500},
-				{AccountID: account2, Purpose: ACHCredit, Amount: 500},
+				{AccountID: account1, Purpose: ACHDebit, Direction: Debit, Amount: 500},
+				{AccountID: account2, Purpose: ACHCredit, Direction: Credit, Amount: 500},
 			},
 		}
 
@@ -340,8 +404,8 @@ func TestTransactions__isInternalDebit(t *testing.T) {
 		{ID: account2, AccountNumber: "432", RoutingNumber: defaultRoutingNumber},
 	}
 	lines := []transactionLine{
-		{AccountID: account1, Purpose: ACHDebit, Amount: 500},
-		{AccountID: account2, Purpose: ACHCredit, Amount: 500},
+		{AccountID: account1, Purpose: ACHDebit, Direction: Debit, Amount: 500},
+		{AccountID: account2, Purpose: ACHCredit, Direction: Credit, Amount: 500},
 	}
 	if isInternalDebit(accounts, lines, defaultRoutingNumber) {
 		t.Errorf("account1 is external")
@@ -371,9 +435,9 @@ func TestSqlTransactions_unique(t *testing.T) {
 		account1, account2 := base.ID(), base.ID()
 		lines := []transactionLine{
 			// Valid transaction, but has multiple lines for the same accountID
-			{AccountID: account1, Purpose: ACHDebit, Amount: 500},
-			{AccountID: account1, Purpose: ACHDebit, Amount: 100},
-			{AccountID: account2, Purpose: ACHCredit, Amount: 600},
+			{AccountID: account1, Purpose: ACHDebit, Direction: Debit, Amount: 500},
+			{AccountID: account1, Purpose: ACHDebit, Direction: Debit, Amount: 100},
+			{AccountID: account2, Purpose: ACHCredit, Direction: Credit, Amount: 600},
 		}
 		tx := transaction{
 			ID:        base.ID(),
@@ -399,3 +463,243 @@ func TestSqlTransactions_unique(t *testing.T) {
 	defer mysqlDB.Close()
 	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
 }
+
+func TestSqlTransactionRepository__getAccountTransactionsPaging(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+
+		accountID := base.ID()
+		repo.accountRepo = &testAccountRepository{}
+
+		// Post five transactions a day apart, the last being a fee
+		start := time.Date(2020, time.March, 1, 12, 0, 0, 0, time.UTC)
+		var ids []string
+		for i := 0; i < 5; i++ {
+			purpose := ACHCredit
+			if i == 4 {
+				purpose = Fee
+			}
+			tx := transaction{
+				ID:        base.ID(),
+				Timestamp: start.AddDate(0, 0, i),
+				Lines: []transactionLine{
+					{AccountID: accountID, Purpose: purpose, Direction: Credit, Amount: 100},
+					{AccountID: base.ID(), Purpose: ACHDebit, Direction: Debit, Amount: 100},
+				},
+			}
+			if err := repo.createTransaction(tx, createTransactionOpts{AllowOverdraft: true}); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, tx.ID)
+		}
+
+		// Page through two at a time
+		var found []string
+		params := transactionSearchParams{Limit: 2}
+		for page := 0; ; page++ {
+			transactions, next, err := repo.getAccountTransactions(accountID, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range transactions {
+				if n := len(transactions[i].Lines); n != 2 {
+					t.Errorf("transaction=%s has %d lines", transactions[i].ID, n)
+				}
+				found = append(found, transactions[i].ID)
+			}
+			if next == nil {
+				break
+			}
+			if page > 3 {
+				t.Fatal("too many pages")
+			}
+			params.Cursor, err = decodeTransactionCursor(next.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if len(found) != 5 {
+			t.Fatalf("found %d transactions: %v", len(found), found)
+		}
+		for i := range found {
+			if found[i] != ids[4-i] {
+				t.Errorf("transaction[%d]=%s expected %s", i, found[i], ids[4-i])
+			}
+		}
+
+		// Filter by dates
+		transactions, next, err := repo.getAccountTransactions(accountID, transactionSearchParams{
+			Limit:     10,
+			StartDate: start.AddDate(0, 0, 1),
+			EndDate:   start.AddDate(0, 0, 3),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(transactions) != 2 || next != nil {
+			t.Errorf("got %d transactions (next=%v)", len(transactions), next)
+		}
+
+		// Filter by purpose
+		transactions, _, err = repo.getAccountTransactions(accountID, transactionSearchParams{
+			Limit:    10,
+			Purposes: []TransactionPurpose{Fee},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(transactions) != 1 || transactions[0].ID != ids[4] {
+			t.Errorf("unexpected transactions: %#v", transactions)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
+
+func TestSqlTransactionRepository__feeDebit(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+
+		customer, income := base.ID(), base.ID()
+		repo.accountRepo = &testAccountRepository{}
+
+		deposit := transaction{
+			ID:        base.ID(),
+			Timestamp: time.Now(),
+			Lines:     []transactionLine{{AccountID: customer, Purpose: ACHCredit, Direction: Credit, Amount: 1000}},
+		}
+		if err := repo.createTransaction(deposit, createTransactionOpts{InitialDeposit: true}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Charge the customer a fee
+		fee := transaction{
+			ID:        base.ID(),
+			Timestamp: time.Now(),
+			Lines: []transactionLine{
+				{AccountID: customer, Purpose: Fee, Direction: Debit, Amount: 250},
+				{AccountID: income, Purpose: Fee, Direction: Credit, Amount: 250},
+			},
+		}
+		if err := repo.createTransaction(fee, createTransactionOpts{}); err != nil {
+			t.Fatal(err)
+		}
+
+		dbtx, err := repo.db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer dbtx.Rollback()
+		if balance, err := repo.getAccountBalance(dbtx, customer); err != nil || balance != 750 {
+			t.Errorf("customer balance=%d error=%v", balance, err)
+		}
+		if balance, err := repo.getAccountBalance(dbtx, income); err != nil || balance != 250 {
+			t.Errorf("income balance=%d error=%v", balance, err)
+		}
+		dbtx.Rollback()
+
+		found, err := repo.getTransaction(fee.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range found.Lines {
+			if found.Lines[i].AccountID == customer && found.Lines[i].Direction != Debit {
+				t.Errorf("unexpected line: %#v", found.Lines[i])
+			}
+		}
+
+		// Balances computed from lines agree with the checkpointed balances
+		if drifts, err := repo.reconcileBalances(false); err != nil || len(drifts) != 0 {
+			t.Errorf("drifts=%#v error=%v", drifts, err)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
+
+func TestSqlTransactionRepository__effectiveDate(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+
+		customer, other := base.ID(), base.ID()
+		repo.accountRepo = &testAccountRepository{}
+
+		// Transactions without an EffectiveDate take value when they're posted
+		now := time.Now().Truncate(time.Second)
+		deposit := transaction{
+			ID:        base.ID(),
+			Timestamp: now,
+			Lines:     []transactionLine{{AccountID: customer, Purpose: ACHCredit, Direction: Credit, Amount: 1000}},
+		}
+		if err := repo.createTransaction(deposit, createTransactionOpts{InitialDeposit: true}); err != nil {
+			t.Fatal(err)
+		}
+		found, err := repo.getTransaction(deposit.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !found.EffectiveDate.Equal(now) {
+			t.Errorf("unexpected effectiveDate=%v timestamp=%v", found.EffectiveDate, found.Timestamp)
+		}
+
+		// Backdate an ACH return
+		effective := time.Date(2020, time.March, 31, 0, 0, 0, 0, time.UTC)
+		ret := transaction{
+			ID:            base.ID(),
+			Timestamp:     now,
+			EffectiveDate: effective,
+			Lines: []transactionLine{
+				{AccountID: customer, Purpose: ACHDebit, Direction: Debit, Amount: 300},
+				{AccountID: other, Purpose: ACHCredit, Direction: Credit, Amount: 300},
+			},
+		}
+		if err := repo.createTransaction(ret, createTransactionOpts{}); err != nil {
+			t.Fatal(err)
+		}
+		transactions, _, err := repo.getAccountTransactions(other, transactionSearchParams{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(transactions) != 1 || !transactions[0].EffectiveDate.Equal(effective) || !transactions[0].Timestamp.Equal(now) {
+			t.Errorf("unexpected transactions: %#v", transactions)
+		}
+
+		// Reversals can't take value before the original
+		if _, err := repo.reverseTransaction(ret.ID, reversalRequest{Amount: 100, EffectiveDate: effective.Add(-time.Hour)}, createTransactionOpts{}); err == nil {
+			t.Error("expected error")
+		}
+		reversal, err := repo.reverseTransaction(ret.ID, reversalRequest{Amount: 100, EffectiveDate: effective.Add(time.Hour)}, createTransactionOpts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found, err := repo.getTransaction(reversal.ID); err != nil || !found.EffectiveDate.Equal(effective.Add(time.Hour)) {
+			t.Errorf("reversal=%#v error=%v", found, err)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
@@ -8,7 +8,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -56,35 +58,163 @@ func (p TransactionPurpose) validate() error {
 	}
 }
 
+// LineDirection is the side of the ledger a transactionLine is posted to. Debits decrease an
+// account's balance and credits increase it, regardless of the line's TransactionPurpose.
+type LineDirection string
+
+var (
+	Debit  LineDirection = "debit"
+	Credit LineDirection = "credit"
+)
+
+func (d *LineDirection) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*d = LineDirection(strings.ToLower(strings.TrimSpace(s)))
+	if *d == "" {
+		return nil // filled in from the line's purpose
+	}
+	if err := d.validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d LineDirection) validate() error {
+	switch d {
+	case Debit, Credit:
+		return nil
+	default:
+		return fmt.Errorf("unknown LineDirection %q", d)
+	}
+}
+
+// defaultDirection is the direction of lines sent without one. Before lines had a direction
+// only ACHDebit lines were debits.
+func defaultDirection(purpose TransactionPurpose) LineDirection {
+	if purpose == ACHDebit {
+		return Debit
+	}
+	return Credit
+}
+
+// opposite returns the direction which undoes d
+func (d LineDirection) opposite() LineDirection {
+	if d == Debit {
+		return Credit
+	}
+	return Debit
+}
+
 type transactionLine struct {
 	AccountID string             `json:"accountId"`
 	Purpose   TransactionPurpose `json:"purpose"`
-	Amount    int                `json:"amount"`
+	Direction LineDirection      `json:"direction"`
+	Amount    int64              `json:"amount"`
+}
+
+func (line transactionLine) isDebit() bool {
+	return line.Direction == Debit
+}
+
+// balanceChange returns how much the line changes its account's balance by.
+func (line transactionLine) balanceChange() int64 {
+	if line.isDebit() {
+		return -1 * line.Amount
+	}
+	return line.Amount
 }
 
 func (line transactionLine) validate() error {
 	if line.AccountID == "" || line.Amount == 0 {
 		return fmt.Errorf("transactionLine: AccountID=%s Amount=%d is invalid", line.AccountID, line.Amount)
 	}
+	if err := line.Direction.validate(); err != nil {
+		return err
+	}
 	return line.Purpose.validate()
 }
 
+var errAmountOverflow = errors.New("amount overflows 64-bit minor units")
+
+// addAmounts returns a + b or errAmountOverflow if the result doesn't fit in an int64.
+func addAmounts(a, b int64) (int64, error) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, errAmountOverflow
+	}
+	return sum, nil
+}
+
 type createTransactionRequest struct {
 	Lines []transactionLine `json:"lines"`
+
+	// EffectiveDate backdates the transaction, and defaults to when it's posted
+	EffectiveDate time.Time `json:"effectiveDate"`
 }
 
 func (r *createTransactionRequest) asTransaction(id string) transaction {
+	for i := range r.Lines {
+		if r.Lines[i].Direction == "" {
+			r.Lines[i].Direction = defaultDirection(r.Lines[i].Purpose)
+		}
+	}
+	now := time.Now()
 	return transaction{
-		ID:        id,
-		Lines:     r.Lines,
-		Timestamp: time.Now(),
+		ID:            id,
+		Lines:         r.Lines,
+		Timestamp:     now,
+		EffectiveDate: effectiveDateOr(r.EffectiveDate, now),
+	}
+}
+
+// effectiveDateOr returns when, or timestamp if when is zero
+func effectiveDateOr(when, timestamp time.Time) time.Time {
+	if when.IsZero() {
+		return timestamp
+	}
+	return when
+}
+
+type transactionStatus string
+
+var (
+	TransactionPosted            transactionStatus = "posted"
+	TransactionPartiallyReversed transactionStatus = "partially-reversed"
+	TransactionReversed          transactionStatus = "reversed"
+)
+
+// readTransactionStatus returns the status stored for a transaction. Transactions posted before
+// statuses were recorded have none and are read as posted.
+func readTransactionStatus(v string) transactionStatus {
+	if v == "" {
+		return TransactionPosted
 	}
+	return transactionStatus(v)
 }
 
 type transaction struct {
-	ID        string            `json:"id"`
-	Timestamp time.Time         `json:"timestamp"`
-	Lines     []transactionLine `json:"lines"`
+	ID string `json:"id"`
+
+	// Timestamp is when the transaction was posted
+	Timestamp time.Time `json:"timestamp"`
+
+	// EffectiveDate is when the transaction takes value. Backdated transactions (e.g. ACH returns and late
+	// corrections) have an EffectiveDate before their Timestamp. Balances as of a date use EffectiveDate.
+	EffectiveDate time.Time `json:"effectiveDate"`
+
+	Lines []transactionLine `json:"lines"`
+
+	// Status shows if the transaction has been reversed
+	Status transactionStatus `json:"status,omitempty"`
+
+	// ReversalOf is the ID of the transaction this transaction reverses
+	ReversalOf string `json:"reversalOf,omitempty"`
+
+	// JournalOf is the ID of the customer transaction this GL journal entry was posted for
+	JournalOf string `json:"journalOf,omitempty"`
 }
 
 func (t transaction) validate() error {
@@ -97,25 +227,32 @@ func (t transaction) validate() error {
 	if t.Timestamp.IsZero() {
 		return fmt.Errorf("transaction=%s has no Timestamp", t.ID)
 	}
+	if t.EffectiveDate.After(t.Timestamp) {
+		return fmt.Errorf("transaction=%s EffectiveDate=%v is after Timestamp=%v", t.ID, t.EffectiveDate.Format(time.RFC3339), t.Timestamp.Format(time.RFC3339))
+	}
 
-	sum := 0
+	var debits, credits int64
 	for i := range t.Lines {
-		if t.Lines[0].Amount < 0 {
-			return fmt.Errorf("transaction=%s has negative amount=%d", t.ID, t.Lines[0].Amount)
+		if t.Lines[i].Amount < 0 {
+			return fmt.Errorf("transaction=%s has negative amount=%d", t.ID, t.Lines[i].Amount)
 		}
-		if t.Lines[i].Purpose == ACHDebit {
-			sum += -1 * t.Lines[i].Amount
+		if err := t.Lines[i].validate(); err != nil {
+			return fmt.Errorf("transaction=%s has invalid line[%d]: %w", t.ID, i, err)
+		}
+		var err error
+		if t.Lines[i].isDebit() {
+			debits, err = addAmounts(debits, t.Lines[i].Amount)
 		} else {
-			sum += t.Lines[i].Amount
+			credits, err = addAmounts(credits, t.Lines[i].Amount)
 		}
-		if err := t.Lines[i].validate(); err != nil {
-			return fmt.Errorf("transaction=%s has invalid line[%d]: %v", t.ID, i, err)
+		if err != nil {
+			return fmt.Errorf("transaction=%s line[%d]: %w", t.ID, i, err)
 		}
 	}
-	if sum == 0 {
+	if debits == credits {
 		return nil
 	}
-	return fmt.Errorf("transaction=%s has %d invalid lines sum=%d", t.ID, len(t.Lines), sum)
+	return fmt.Errorf("transaction=%s has %d invalid lines debits=%d credits=%d", t.ID, len(t.Lines), debits, credits)
 }
 
 func addTransactionRoutes(logger log.Logger, router *mux.Router, accountRepo accountRepository, transactionRepo transactionRepository) {
@@ -135,6 +272,65 @@ func getAccountID(w http.ResponseWriter, r *http.Request) string {
 	return v
 }
 
+const (
+	defaultTransactionLimit = 100
+	maxTransactionLimit     = 1000
+)
+
+// readTransactionSearchParams parses the query parameters accepted by getAccountTransactions
+func readTransactionSearchParams(r *http.Request) (transactionSearchParams, error) {
+	q := r.URL.Query()
+	params := transactionSearchParams{
+		Limit: defaultTransactionLimit,
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return params, fmt.Errorf("invalid limit %q", v)
+		}
+		if n > maxTransactionLimit {
+			n = maxTransactionLimit
+		}
+		params.Limit = n
+	}
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := decodeTransactionCursor(v)
+		if err != nil {
+			return params, err
+		}
+		params.Cursor = cursor
+	}
+	var err error
+	if params.StartDate, err = readDateParam(q.Get("startDate"), false); err != nil {
+		return params, fmt.Errorf("invalid startDate: %w", err)
+	}
+	if params.EndDate, err = readDateParam(q.Get("endDate"), true); err != nil {
+		return params, fmt.Errorf("invalid endDate: %w", err)
+	}
+	if !params.StartDate.IsZero() && !params.EndDate.IsZero() && !params.StartDate.Before(params.EndDate) {
+		return params, errors.New("startDate must be before endDate")
+	}
+	for _, v := range q["purpose"] {
+		for _, p := range strings.Split(v, ",") {
+			purpose := TransactionPurpose(strings.ToLower(strings.TrimSpace(p)))
+			if err := purpose.validate(); err != nil {
+				return params, err
+			}
+			params.Purposes = append(params.Purposes, purpose)
+		}
+	}
+	return params, nil
+}
+
+// nextPageLink returns the URL for the page of results after cursor, keeping all other query parameters.
+func nextPageLink(r *http.Request, cursor *transactionCursor) string {
+	u := *r.URL
+	q := u.Query()
+	q.Set("cursor", cursor.String())
+	u.RawQuery = q.Encode()
+	return u.RequestURI()
+}
+
 func getAccountTransactions(logger log.Logger, transactionRepo transactionRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w, err := wrapResponseWriter(logger, w, r)
@@ -148,12 +344,21 @@ func getAccountTransactions(logger log.Logger, transactionRepo transactionReposi
 			return
 		}
 
-		transactions, err := transactionRepo.getAccountTransactions(accountID)
+		params, err := readTransactionSearchParams(r)
 		if err != nil {
 			moovhttp.Problem(w, err)
 			return
 		}
 
+		transactions, next, err := transactionRepo.getAccountTransactions(accountID, params)
+		if err != nil {
+			moovhttp.Problem(w, err)
+			return
+		}
+		if next != nil {
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, nextPageLink(r, next)))
+		}
+
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(transactions)
@@ -179,8 +384,17 @@ func createTransaction(logger log.Logger, accountRepo accountRepository, transac
 
 		// Post the transaction
 		tx := req.asTransaction(base.ID())
-		if err := transactionRepo.createTransaction(tx, createTransactionOpts{AllowOverdraft: false}); err != nil {
-			logger.Log("transactions", fmt.Errorf("problem creating transaction: %v", err), "requestID", requestID)
+		opts := createTransactionOpts{AllowOverdraft: false, IdempotencyKey: readReservedIdempotencyKey(r)}
+		if err := transactionRepo.createTransaction(tx, opts); err != nil {
+			logger.Log("transactions", fmt.Errorf("problem creating transaction: %w", err), "requestID", requestID)
+			if code := readProductLimitCode(err); code != "" {
+				writeProductLimitProblem(w, code, err)
+				return
+			}
+			if errors.Is(err, errIdempotencyKeyInFlight) {
+				writeConflict(w, err)
+				return
+			}
 			moovhttp.Problem(w, err)
 			return
 		}
@@ -191,6 +405,139 @@ func createTransaction(logger log.Logger, accountRepo accountRepository, transac
 	}
 }
 
+var (
+	errTransactionReversed = errors.New("transaction has already been reversed")
+	errReverseReversal     = errors.New("reversals can't be reversed")
+	errReverseJournal      = errors.New("GL journal entries are reversed with their customer transaction")
+)
+
+// reversalRequest optionally limits a reversal to part of the original transaction. An empty
+// reversalRequest reverses everything not reversed already.
+type reversalRequest struct {
+	// Amount reverses this much of each line on a transaction with one debit and one credit line
+	Amount int64 `json:"amount"`
+
+	// Lines reverses Amount of the original line for AccountID, or everything remaining if Amount is zero
+	Lines []reversalLine `json:"lines"`
+
+	// EffectiveDate backdates the reversal (e.g. an ACH return), but not before the original transaction
+	EffectiveDate time.Time `json:"effectiveDate"`
+}
+
+type reversalLine struct {
+	AccountID string `json:"accountId"`
+	Amount    int64  `json:"amount"`
+}
+
+// reverseLine returns a transactionLine which undoes amount of line's change to its account balance.
+// The reversal keeps the purpose of the original line and is posted in the opposite direction.
+func reverseLine(line transactionLine, amount int64) transactionLine {
+	return transactionLine{
+		AccountID: line.AccountID,
+		Purpose:   line.Purpose,
+		Direction: line.Direction.opposite(),
+		Amount:    amount,
+	}
+}
+
+// buildReversalLines returns the lines which reverse the original transaction according to req. The amount
+// of each original line already reversed is keyed by its AccountID in reversed.
+func buildReversalLines(original transaction, reversed map[string]int64, req reversalRequest) ([]transactionLine, error) {
+	if original.ReversalOf != "" {
+		return nil, errReverseReversal
+	}
+	if original.JournalOf != "" {
+		return nil, errReverseJournal
+	}
+	if req.Amount < 0 {
+		return nil, fmt.Errorf("invalid reversal amount %d", req.Amount)
+	}
+	if !req.EffectiveDate.IsZero() && req.EffectiveDate.Before(original.EffectiveDate) {
+		return nil, fmt.Errorf("reversal effectiveDate can't be before the original's %s", original.EffectiveDate.Format(time.RFC3339))
+	}
+	if req.Amount > 0 && len(req.Lines) > 0 {
+		return nil, errors.New("reversal can have an amount or lines, but not both")
+	}
+
+	remaining := make(map[string]int64)
+	for i := range original.Lines {
+		id := original.Lines[i].AccountID
+		if _, exists := remaining[id]; exists {
+			return nil, fmt.Errorf("transaction=%s has multiple lines for account=%s", original.ID, id)
+		}
+		remaining[id] = original.Lines[i].Amount - reversed[id]
+	}
+
+	var lines []transactionLine
+	switch {
+	case req.Amount > 0:
+		if len(original.Lines) != 2 {
+			return nil, fmt.Errorf("transaction=%s has %d lines, partial reversals by amount need exactly 2", original.ID, len(original.Lines))
+		}
+		for i := range original.Lines {
+			if req.Amount > remaining[original.Lines[i].AccountID] {
+				return nil, fmt.Errorf("reversal amount %d is more than the %d remaining for account=%s", req.Amount, remaining[original.Lines[i].AccountID], original.Lines[i].AccountID)
+			}
+			lines = append(lines, reverseLine(original.Lines[i], req.Amount))
+		}
+
+	case len(req.Lines) > 0:
+		seen := make(map[string]bool)
+		for _, rl := range req.Lines {
+			var line *transactionLine
+			for i := range original.Lines {
+				if original.Lines[i].AccountID == rl.AccountID {
+					line = &original.Lines[i]
+				}
+			}
+			if line == nil {
+				return nil, fmt.Errorf("transaction=%s has no line for account=%s", original.ID, rl.AccountID)
+			}
+			if seen[rl.AccountID] {
+				return nil, fmt.Errorf("account=%s is reversed more than once", rl.AccountID)
+			}
+			seen[rl.AccountID] = true
+
+			amount := rl.Amount
+			if amount == 0 {
+				amount = remaining[rl.AccountID]
+			}
+			if amount <= 0 || amount > remaining[rl.AccountID] {
+				return nil, fmt.Errorf("reversal amount %d is invalid with %d remaining for account=%s", amount, remaining[rl.AccountID], rl.AccountID)
+			}
+			lines = append(lines, reverseLine(*line, amount))
+		}
+
+	default:
+		for i := range original.Lines {
+			if amount := remaining[original.Lines[i].AccountID]; amount > 0 {
+				lines = append(lines, reverseLine(original.Lines[i], amount))
+			}
+		}
+		if len(lines) == 0 {
+			return nil, errTransactionReversed
+		}
+	}
+	return lines, nil
+}
+
+// reversedStatus returns the status of the original transaction once the amounts in reversed have been reversed
+func reversedStatus(original transaction, reversed map[string]int64) transactionStatus {
+	some, all := false, true
+	for i := range original.Lines {
+		n := reversed[original.Lines[i].AccountID]
+		some = some || n > 0
+		all = all && n >= original.Lines[i].Amount
+	}
+	switch {
+	case all:
+		return TransactionReversed
+	case some:
+		return TransactionPartiallyReversed
+	}
+	return TransactionPosted
+}
+
 func getTransactionID(w http.ResponseWriter, r *http.Request) string {
 	v := mux.Vars(r)["transactionId"]
 	if v == "" {
@@ -217,26 +564,21 @@ func createTransactionReversal(logger log.Logger, accountRepo accountRepository,
 		}
 		logger.Log("transaction", fmt.Sprintf("reversing transaction %s", transactionID), "requestID", requestID)
 
-		// reverse the transaction (after reading it from our database)
-		transaction, err := transactionRepo.getTransaction(transactionID)
-		if err != nil {
+		var req reversalRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
 			moovhttp.Problem(w, err)
 			return
 		}
-		transaction.ID = base.ID()
-		transaction.Timestamp = time.Now()
-		for i := range transaction.Lines {
-			// Swap Purpose back if Debit vs Credit
-			switch {
-			case transaction.Lines[i].Purpose == ACHCredit:
-				transaction.Lines[i].Purpose = ACHDebit
-			case transaction.Lines[i].Purpose == ACHDebit:
-				transaction.Lines[i].Purpose = ACHCredit
+
+		// reverse the transaction (after reading it from our database)
+		transaction, err := transactionRepo.reverseTransaction(transactionID, req, createTransactionOpts{IdempotencyKey: readReservedIdempotencyKey(r)})
+		if err != nil {
+			logger.Log("transactions", fmt.Errorf("problem reversing transaction: %w", err), "requestID", requestID)
+			if errors.Is(err, errTransactionReversed) || errors.Is(err, errIdempotencyKeyInFlight) {
+				writeConflict(w, err)
+			} else {
+				moovhttp.Problem(w, err)
 			}
-		}
-		if err := transactionRepo.createTransaction(*transaction, createTransactionOpts{AllowOverdraft: false}); err != nil {
-			logger.Log("transactions", fmt.Errorf("problem creating transaction: %v", err), "requestID", requestID)
-			moovhttp.Problem(w, err)
 			return
 		}
 		logger.Log("transactions", fmt.Sprintf("reversed (original transaction=%s) transaction=%s", transactionID, transaction.ID), "requestID", requestID)
@@ -9,8 +9,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -26,6 +28,13 @@ type mockTransactionRepository struct {
 
 	transactions []transaction
 	created      transaction
+
+	params transactionSearchParams
+	cursor *transactionCursor
+	drifts []balanceDrift
+
+	balance int64
+	daily   []dailyBalance
 }
 
 func (r *mockTransactionRepository) Ping() error {
@@ -44,11 +53,44 @@ func (r *mockTransactionRepository) createTransaction(tx transaction, opts creat
 	return r.err
 }
 
-func (r *mockTransactionRepository) getAccountTransactions(accountID string) ([]transaction, error) {
+func (r *mockTransactionRepository) getAccountTransactions(accountID string, params transactionSearchParams) ([]transaction, *transactionCursor, error) {
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	r.params = params
+	return r.transactions, r.cursor, nil
+}
+
+func (r *mockTransactionRepository) reverseTransaction(transactionID string, req reversalRequest, opts createTransactionOpts) (*transaction, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	lines, err := buildReversalLines(r.transactions[0], nil, req)
+	if err != nil {
+		return nil, err
+	}
+	r.created = transaction{
+		ID:         base.ID(),
+		Timestamp:  time.Now(),
+		Lines:      lines,
+		ReversalOf: transactionID,
+	}
+	return &r.created, nil
+}
+
+func (r *mockTransactionRepository) reconcileBalances(repair bool) ([]balanceDrift, error) {
 	if r.err != nil {
 		return nil, r.err
 	}
-	return r.transactions, nil
+	return r.drifts, nil
+}
+
+func (r *mockTransactionRepository) getAccountBalanceAsOf(accountID string, asOf time.Time) (int64, error) {
+	return r.balance, r.err
+}
+
+func (r *mockTransactionRepository) getDailyBalances(accountID string, from, to time.Time) ([]dailyBalance, error) {
+	return r.daily, r.err
 }
 
 func (r *mockTransactionRepository) getTransaction(transactionID string) (*transaction, error) {
@@ -96,16 +138,39 @@ func TestTransactionPurpose(t *testing.T) {
 	}
 }
 
+func TestTransactions__LineDirection(t *testing.T) {
+	var req createTransactionRequest
+	body := `{"lines": [{"accountId": "a", "purpose": "fee", "direction": "Debit", "amount": 100}, {"accountId": "b", "purpose": "fee", "amount": 100}, {"accountId": "c", "purpose": "achdebit", "amount": 100}]}`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatal(err)
+	}
+	tx := req.asTransaction(base.ID())
+	if tx.Lines[0].Direction != Debit || tx.Lines[1].Direction != Credit || tx.Lines[2].Direction != Debit {
+		t.Errorf("unexpected lines: %#v", tx.Lines)
+	}
+	if n := tx.Lines[0].balanceChange(); n != -100 {
+		t.Errorf("fee debit changed balance by %d", n)
+	}
+
+	var direction LineDirection
+	if err := json.Unmarshal([]byte(`"sideways"`), &direction); err == nil {
+		t.Error("expected error")
+	}
+	if Debit.opposite() != Credit || Credit.opposite() != Debit {
+		t.Error("unexpected opposite directions")
+	}
+}
+
 func TestTransaction__validate(t *testing.T) {
 	tx := transaction{
 		ID:        base.ID(),
 		Timestamp: time.Now(),
 		Lines: []transactionLine{
 			{
-				AccountID: base.ID(), Purpose: ACHDebit, Amount: 500,
+				AccountID: base.ID(), Purpose: ACHDebit, Direction: Debit, Amount: 500,
 			},
 			{
-				AccountID: base.ID(), Purpose: ACHCredit, Amount: 500,
+				AccountID: base.ID(), Purpose: ACHCredit, Direction: Credit, Amount: 500,
 			},
 		},
 	}
@@ -127,12 +192,38 @@ func TestTransaction__validate(t *testing.T) {
 	}
 	tx.Timestamp = time.Now()
 
+	// backdated transactions are valid, but not ones effective in the future
+	tx.EffectiveDate = tx.Timestamp.Add(-48 * time.Hour)
+	if err := tx.validate(); err != nil {
+		t.Error(err)
+	}
+	tx.EffectiveDate = tx.Timestamp.Add(time.Hour)
+	if err := tx.validate(); err == nil {
+		t.Error("expected error")
+	}
+	tx.EffectiveDate = empty
+
 	tx.Lines[0].Amount = 1
 	if err := tx.validate(); err == nil {
 		t.Error("expected error")
 	}
 	tx.Lines[0].Amount = 500
 
+	// debits are summed against credits regardless of purpose
+	tx.Lines[0].Purpose, tx.Lines[1].Purpose = Fee, Fee
+	if err := tx.validate(); err != nil {
+		t.Error(err)
+	}
+	tx.Lines[1].Direction = Debit
+	if err := tx.validate(); err == nil {
+		t.Error("expected error")
+	}
+	tx.Lines[1].Direction = ""
+	if err := tx.validate(); err == nil {
+		t.Error("expected error")
+	}
+	tx.Lines[1].Direction = Credit
+
 	tx.Lines[0].Purpose = TransactionPurpose("other")
 	if err := tx.validate(); err == nil {
 		t.Error("expected error")
@@ -145,6 +236,47 @@ func TestTransaction__validate(t *testing.T) {
 
 }
 
+func TestTransaction__validateOverflow(t *testing.T) {
+	tx := transaction{
+		ID:        base.ID(),
+		Timestamp: time.Now(),
+		Lines: []transactionLine{
+			{AccountID: base.ID(), Purpose: ACHCredit, Direction: Credit, Amount: math.MaxInt64},
+			{AccountID: base.ID(), Purpose: ACHCredit, Direction: Credit, Amount: 1},
+			{AccountID: base.ID(), Purpose: ACHDebit, Direction: Debit, Amount: math.MaxInt64},
+		},
+	}
+	if err := tx.validate(); err == nil {
+		t.Error("expected error")
+	} else if !strings.Contains(err.Error(), errAmountOverflow.Error()) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Large, but balanced amounts are fine
+	tx.Lines = []transactionLine{
+		{AccountID: base.ID(), Purpose: ACHCredit, Direction: Credit, Amount: 5000000000000},
+		{AccountID: base.ID(), Purpose: ACHDebit, Direction: Debit, Amount: 5000000000000},
+	}
+	if err := tx.validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTransactions__addAmounts(t *testing.T) {
+	if n, err := addAmounts(math.MaxInt64-1, 1); err != nil || n != math.MaxInt64 {
+		t.Errorf("n=%d error=%v", n, err)
+	}
+	if _, err := addAmounts(math.MaxInt64, 1); err != errAmountOverflow {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := addAmounts(math.MinInt64, -1); err != errAmountOverflow {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if n, err := addAmounts(-5, 3); err != nil || n != -2 {
+		t.Errorf("n=%d error=%v", n, err)
+	}
+}
+
 func TestTransactions_getAccountID(t *testing.T) {
 	w := httptest.NewRecorder()
 	req := httptest.NewRequest("GET", "/foo", nil)
@@ -192,6 +324,7 @@ func TestTransactions_Get(t *testing.T) {
 					{
 						AccountID: accountID,
 						Purpose:   Transfer,
+						Direction: Credit,
 						Amount:    13412,
 					},
 				},
@@ -203,6 +336,7 @@ func TestTransactions_Get(t *testing.T) {
 					{
 						AccountID: accountID,
 						Purpose:   Transfer,
+						Direction: Credit,
 						Amount:    5331,
 					},
 				},
@@ -244,6 +378,76 @@ func TestTransactions_Get(t *testing.T) {
 	}
 }
 
+func TestTransactions_GetPaging(t *testing.T) {
+	accountID := base.ID()
+	transactionRepo := &mockTransactionRepository{
+		transactions: []transaction{
+			{ID: base.ID(), Timestamp: time.Now()},
+		},
+		cursor: &transactionCursor{Timestamp: time.Now(), TransactionID: base.ID()},
+	}
+
+	router := mux.NewRouter()
+	addTransactionRoutes(log.NewNopLogger(), router, &testAccountRepository{}, transactionRepo)
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/accounts/%s/transactions?limit=1&startDate=2020-01-01&endDate=2020-01-31&purpose=fee,Interest", accountID), nil)
+	req.Header.Set("x-user-id", base.ID())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", w.Code, w.Body.String())
+	}
+	params := transactionRepo.params
+	if params.Limit != 1 || len(params.Purposes) != 2 || params.Purposes[1] != Interest {
+		t.Errorf("unexpected params: %#v", params)
+	}
+	if !params.StartDate.Equal(time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)) || !params.EndDate.Equal(time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected dates: %v to %v", params.StartDate, params.EndDate)
+	}
+	link := w.Header().Get("Link")
+	if !strings.Contains(link, "cursor="+transactionRepo.cursor.String()) || !strings.Contains(link, `rel="next"`) || !strings.Contains(link, "limit=1") {
+		t.Errorf("unexpected Link: %q", link)
+	}
+
+	// invalid params
+	for _, query := range []string{"limit=-1", "cursor=bad", "startDate=2020-02-01&endDate=2020-01-01", "purpose=other", "endDate=yesterday"} {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/accounts/%s/transactions?%s", accountID, query), nil)
+		req.Header.Set("x-user-id", base.ID())
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		w.Flush()
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d", query, w.Code)
+		}
+	}
+}
+
+func TestTransactions__transactionCursor(t *testing.T) {
+	cursor := &transactionCursor{
+		Timestamp:     time.Date(2020, time.March, 1, 12, 30, 0, 1234, time.FixedZone("PDT", -7*60*60)),
+		TransactionID: base.ID(),
+	}
+	decoded, err := decodeTransactionCursor(cursor.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Timestamp.Equal(cursor.Timestamp) || decoded.TransactionID != cursor.TransactionID {
+		t.Errorf("unexpected cursor: %#v", decoded)
+	}
+	if _, err := decodeTransactionCursor("bm9waXBl"); err == nil {
+		t.Error("expected error")
+	}
+	var empty *transactionCursor
+	if v := empty.String(); v != "" {
+		t.Errorf("unexpected cursor: %q", v)
+	}
+}
+
 func TestTransactions_Create(t *testing.T) {
 	accountRepo := &testAccountRepository{
 		accounts: []*accounts.Account{
@@ -259,8 +463,8 @@ func TestTransactions_Create(t *testing.T) {
 	var body bytes.Buffer
 	json.NewEncoder(&body).Encode(createTransactionRequest{
 		Lines: []transactionLine{
-			{AccountID: accountRepo.accounts[0].ID, Purpose: ACHDebit, Amount: 4121},
-			{AccountID: accountRepo.accounts[1].ID, Purpose: ACHCredit, Amount: 4121},
+			{AccountID: accountRepo.accounts[0].ID, Purpose: ACHDebit, Direction: Debit, Amount: 4121},
+			{AccountID: accountRepo.accounts[1].ID, Purpose: ACHCredit, Direction: Credit, Amount: 4121},
 		},
 	})
 	req := httptest.NewRequest("POST", "/accounts/transactions", &body)
@@ -296,6 +500,31 @@ func TestTransactions_Create(t *testing.T) {
 	if w.Code != http.StatusBadRequest {
 		t.Errorf("got %d", w.Code)
 	}
+
+	// account product limits respond with their code
+	transactionRepo.err = fmt.Errorf("createTransaction: account=%q %w of 50000", accountRepo.accounts[0].ID, errDailyDebitLimitExceeded)
+	json.NewEncoder(&body).Encode(createTransactionRequest{
+		Lines: []transactionLine{
+			{AccountID: accountRepo.accounts[0].ID, Purpose: ACHDebit, Direction: Debit, Amount: 4121},
+			{AccountID: accountRepo.accounts[1].ID, Purpose: ACHCredit, Direction: Credit, Amount: 4121},
+		},
+	})
+	req = httptest.NewRequest("POST", "/accounts/transactions", &body)
+	req.Header.Set("x-user-id", base.ID())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	var problem struct {
+		Code ProductLimitCode `json:"code"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || problem.Code != DailyDebitLimitExceeded {
+		t.Errorf("got %d: %#v", w.Code, problem)
+	}
 }
 
 func TestTransactions_CreateInvalid(t *testing.T) {
@@ -309,8 +538,8 @@ func TestTransactions_CreateInvalid(t *testing.T) {
 	json.NewEncoder(&body).Encode(createTransactionRequest{
 		Lines: []transactionLine{
 			// Invalid Lines will force an error
-			{AccountID: base.ID(), Purpose: ACHDebit, Amount: -4121},
-			{AccountID: base.ID(), Purpose: ACHCredit, Amount: -121},
+			{AccountID: base.ID(), Purpose: ACHDebit, Direction: Debit, Amount: -4121},
+			{AccountID: base.ID(), Purpose: ACHCredit, Direction: Credit, Amount: -121},
 		},
 	})
 	req := httptest.NewRequest("POST", "/accounts/transactions", &body)
@@ -337,11 +566,13 @@ func TestTransactions__createTransactionReversal(t *testing.T) {
 					{
 						AccountID: base.ID(),
 						Purpose:   ACHDebit,
+						Direction: Debit,
 						Amount:    1000,
 					},
 					{
 						AccountID: base.ID(),
 						Purpose:   ACHCredit,
+						Direction: Credit,
 						Amount:    1000,
 					},
 				},
@@ -419,3 +650,150 @@ func TestTransactions_getTransactionID(t *testing.T) {
 		t.Errorf("got %q", transactionID)
 	}
 }
+
+func TestTransactions__buildReversalLines(t *testing.T) {
+	original := transaction{
+		ID:        base.ID(),
+		Timestamp: time.Now(),
+		Lines: []transactionLine{
+			{AccountID: "a", Purpose: ACHDebit, Direction: Debit, Amount: 1000},
+			{AccountID: "b", Purpose: Fee, Direction: Credit, Amount: 100},
+			{AccountID: "c", Purpose: Transfer, Direction: Credit, Amount: 900},
+		},
+	}
+
+	// full reversal swaps the direction of every line and keeps its purpose
+	lines, err := buildReversalLines(original, nil, reversalRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reversal := transaction{ID: base.ID(), Timestamp: time.Now(), Lines: lines}
+	if err := reversal.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if lines[0].Direction != Credit || lines[1].Direction != Debit || lines[2].Direction != Debit || lines[1].Purpose != Fee {
+		t.Errorf("unexpected lines: %#v", lines)
+	}
+
+	// partial reversal by line
+	lines, err = buildReversalLines(original, nil, reversalRequest{
+		Lines: []reversalLine{{AccountID: "a", Amount: 100}, {AccountID: "b"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reversal.Lines = lines
+	if err := reversal.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// what remains after that partial reversal
+	reversed := map[string]int64{"a": 100, "b": 100}
+	lines, err = buildReversalLines(original, reversed, reversalRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 || lines[0].AccountID != "a" || lines[0].Amount != 900 || lines[1].AccountID != "c" || lines[1].Amount != 900 {
+		t.Errorf("unexpected lines: %#v", lines)
+	}
+	if status := reversedStatus(original, reversed); status != TransactionPartiallyReversed {
+		t.Errorf("unexpected status: %s", status)
+	}
+
+	// nothing left to reverse
+	reversed = map[string]int64{"a": 1000, "b": 100, "c": 900}
+	if _, err := buildReversalLines(original, reversed, reversalRequest{}); err != errTransactionReversed {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if status := reversedStatus(original, reversed); status != TransactionReversed {
+		t.Errorf("unexpected status: %s", status)
+	}
+	if status := reversedStatus(original, nil); status != TransactionPosted {
+		t.Errorf("unexpected status: %s", status)
+	}
+
+	cases := []reversalRequest{
+		{Amount: 100},  // too many lines
+		{Amount: -100}, // negative
+		{Amount: 100, Lines: []reversalLine{{AccountID: "a"}}},
+		{Lines: []reversalLine{{AccountID: "d"}}},
+		{Lines: []reversalLine{{AccountID: "a", Amount: 1001}}},
+		{Lines: []reversalLine{{AccountID: "a", Amount: 1}, {AccountID: "a", Amount: 1}}},
+	}
+	for i := range cases {
+		if _, err := buildReversalLines(original, nil, cases[i]); err == nil {
+			t.Errorf("#%d expected error", i)
+		}
+	}
+
+	// partial reversal by amount
+	original.Lines = original.Lines[1:]
+	original.Lines[0].Purpose = ACHDebit
+	original.Lines[0].Direction = Debit
+	lines, err = buildReversalLines(original, map[string]int64{"b": 50, "c": 50}, reversalRequest{Amount: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 || lines[0].Direction != Credit || lines[0].Amount != 50 || lines[1].Direction != Debit {
+		t.Errorf("unexpected lines: %#v", lines)
+	}
+	if _, err := buildReversalLines(original, map[string]int64{"b": 50, "c": 50}, reversalRequest{Amount: 51}); err == nil {
+		t.Error("expected error")
+	}
+
+	// reversals can't be reversed
+	original.ReversalOf = base.ID()
+	if _, err := buildReversalLines(original, nil, reversalRequest{}); err != errReverseReversal {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTransactions__createTransactionReversalPartial(t *testing.T) {
+	transactionRepo := &mockTransactionRepository{
+		transactions: []transaction{
+			{
+				ID:        base.ID(),
+				Timestamp: time.Now(),
+				Lines: []transactionLine{
+					{AccountID: "a", Purpose: ACHDebit, Direction: Debit, Amount: 1000},
+					{AccountID: "b", Purpose: Wire, Direction: Credit, Amount: 1000},
+				},
+			},
+		},
+	}
+
+	router := mux.NewRouter()
+	addTransactionRoutes(log.NewNopLogger(), router, &testAccountRepository{}, transactionRepo)
+
+	body := strings.NewReader(`{"amount": 250}`)
+	req := httptest.NewRequest("POST", fmt.Sprintf("/accounts/transactions/%s/reversal", transactionRepo.transactions[0].ID), body)
+	req.Header.Set("x-user-id", base.ID())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("bogus HTTP status: %d: %s", w.Code, w.Body.String())
+	}
+	var tx transaction
+	if err := json.NewDecoder(w.Body).Decode(&tx); err != nil {
+		t.Fatal(err)
+	}
+	if tx.ReversalOf != transactionRepo.transactions[0].ID || len(tx.Lines) != 2 || tx.Lines[1].Purpose != Wire || tx.Lines[1].Direction != Debit || tx.Lines[1].Amount != 250 {
+		t.Errorf("unexpected reversal: %#v", tx)
+	}
+
+	// already reversed
+	transactionRepo.err = fmt.Errorf("reverseTransaction: %w", errTransactionReversed)
+	req = httptest.NewRequest("POST", fmt.Sprintf("/accounts/transactions/%s/reversal", transactionRepo.transactions[0].ID), nil)
+	req.Header.Set("x-user-id", base.ID())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	w.Flush()
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("bogus HTTP status: %d", w.Code)
+	}
+}
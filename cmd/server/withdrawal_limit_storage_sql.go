@@ -0,0 +1,203 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/moov-io/accounts/cmd/server/database"
+)
+
+// readWithdrawalCounter returns the counter of accountID for period and purpose. Counters which don't exist yet
+// are returned empty with a zero version.
+func readWithdrawalCounter(tx *sql.Tx, accountID, period string, purpose TransactionPurpose) (withdrawalCounter, error) {
+	counter := withdrawalCounter{AccountID: accountID, Period: period, Purpose: purpose}
+
+	query := `select withdrawals, amount, version from withdrawal_counters where account_id = ? and period = ? and purpose = ? limit 1;`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return counter, err
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRow(accountID, period, purpose).Scan(&counter.Withdrawals, &counter.Amount, &counter.version)
+	if err != nil && err != sql.ErrNoRows {
+		return counter, err
+	}
+	return counter, nil
+}
+
+// addToWithdrawalCounter adds one withdrawal of amount to counter. If the counter was modified after it was read
+// errWithdrawalCounterConflict is returned and the caller should retry with a new database transaction.
+func addToWithdrawalCounter(tx *sql.Tx, counter withdrawalCounter, amount int64) error {
+	if counter.version == 0 {
+		query := `insert into withdrawal_counters(account_id, period, purpose, withdrawals, amount, version, last_modified) values (?, ?, ?, 1, ?, 1, ?);`
+		stmt, err := tx.Prepare(query)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		if _, err := stmt.Exec(counter.AccountID, counter.Period, counter.Purpose, amount, time.Now()); err != nil {
+			if database.UniqueViolation(err) {
+				return errWithdrawalCounterConflict
+			}
+			return err
+		}
+		return nil
+	}
+
+	query := `update withdrawal_counters set withdrawals = withdrawals + 1, amount = amount + ?, version = version + 1, last_modified = ?
+where account_id = ? and period = ? and purpose = ? and version = ?;`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(amount, time.Now(), counter.AccountID, counter.Period, counter.Purpose, counter.version)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errWithdrawalCounterConflict
+	}
+	return nil
+}
+
+// applyWithdrawal checks w against the product's withdrawal limits using the counters of accountID for the statement
+// cycle and day of effective, and then adds w to them. Each transaction counts as one withdrawal.
+func applyWithdrawal(tx *sql.Tx, product accountProduct, accountID string, effective time.Time, w withdrawal) error {
+	month, day := monthlyWithdrawalPeriod(effective), dailyWithdrawalPeriod(effective)
+
+	monthly, err := readWithdrawalCounter(tx, accountID, month, "")
+	if err != nil {
+		return fmt.Errorf("reading monthly withdrawals: %w", err)
+	}
+	daily, err := readWithdrawalCounter(tx, accountID, day, "")
+	if err != nil {
+		return fmt.Errorf("reading daily withdrawals: %w", err)
+	}
+	purposes := make(map[TransactionPurpose]withdrawalCounter)
+	for purpose := range w.Purposes {
+		if purposes[purpose], err = readWithdrawalCounter(tx, accountID, month, purpose); err != nil {
+			return fmt.Errorf("reading %s withdrawals: %w", purpose, err)
+		}
+	}
+
+	if err := product.checkWithdrawal(w, monthly, daily, purposes); err != nil {
+		return err
+	}
+
+	if err := addToWithdrawalCounter(tx, monthly, w.Amount); err != nil {
+		return fmt.Errorf("updating monthly withdrawals: %w", err)
+	}
+	if err := addToWithdrawalCounter(tx, daily, w.Amount); err != nil {
+		return fmt.Errorf("updating daily withdrawals: %w", err)
+	}
+	for purpose, counter := range purposes {
+		if err := addToWithdrawalCounter(tx, counter, w.Purposes[purpose]); err != nil {
+			return fmt.Errorf("updating %s withdrawals: %w", purpose, err)
+		}
+	}
+	return nil
+}
+
+// subtractFromWithdrawalCounter takes withdrawals and amount back out of counter, never going below zero. Counters
+// which don't exist have nothing to take out. Like addToWithdrawalCounter errWithdrawalCounterConflict is returned
+// if the counter was modified after it was read.
+func subtractFromWithdrawalCounter(tx *sql.Tx, counter withdrawalCounter, withdrawals int, amount int64) error {
+	if counter.version == 0 {
+		return nil
+	}
+	if withdrawals > counter.Withdrawals {
+		withdrawals = counter.Withdrawals
+	}
+	if amount > counter.Amount {
+		amount = counter.Amount
+	}
+
+	query := `update withdrawal_counters set withdrawals = withdrawals - ?, amount = amount - ?, version = version + 1, last_modified = ?
+where account_id = ? and period = ? and purpose = ? and version = ?;`
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.Exec(withdrawals, amount, time.Now(), counter.AccountID, counter.Period, counter.Purpose, counter.version)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errWithdrawalCounterConflict
+	}
+	return nil
+}
+
+// refundWithdrawals takes the debits of original which lines reverse back out of the counters they were added to,
+// so reversed debits don't count towards an account's limits. An account's withdrawal is only uncounted once all
+// of its lines in original are reversed, which reversed (the amounts reversed so far by account) tells us.
+func refundWithdrawals(tx *sql.Tx, original transaction, lines []transactionLine, reversed map[string]int64) error {
+	effective := effectiveDateOr(original.EffectiveDate, original.Timestamp)
+	month, day := monthlyWithdrawalPeriod(effective), dailyWithdrawalPeriod(effective)
+
+	seen := make(map[string]bool)
+	for _, accountID := range grabAccountIDs(lines) {
+		if seen[accountID] {
+			continue
+		}
+		seen[accountID] = true
+
+		// Reversal lines flip the direction of the original's lines, so a refunded debit is a credit here
+		var refunded []transactionLine
+		for i := range lines {
+			if lines[i].AccountID == accountID {
+				line := lines[i]
+				line.Direction = line.Direction.opposite()
+				refunded = append(refunded, line)
+			}
+		}
+		w := readWithdrawal(accountID, refunded)
+		if w.Amount == 0 {
+			continue
+		}
+		var total int64
+		for i := range original.Lines {
+			if original.Lines[i].AccountID == accountID {
+				total += original.Lines[i].Amount
+			}
+		}
+		withdrawals := 0
+		if reversed[accountID] >= total {
+			withdrawals = 1
+		}
+
+		for _, period := range []string{month, day} {
+			if err := refundWithdrawal(tx, accountID, period, "", withdrawals, w.Amount); err != nil {
+				return fmt.Errorf("account=%q: %w", accountID, err)
+			}
+		}
+		for purpose, amount := range w.Purposes {
+			if err := refundWithdrawal(tx, accountID, month, purpose, withdrawals, amount); err != nil {
+				return fmt.Errorf("account=%q: %w", accountID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func refundWithdrawal(tx *sql.Tx, accountID, period string, purpose TransactionPurpose, withdrawals int, amount int64) error {
+	counter, err := readWithdrawalCounter(tx, accountID, period, purpose)
+	if err != nil {
+		return fmt.Errorf("reading %s withdrawals: %w", period, err)
+	}
+	if err := subtractFromWithdrawalCounter(tx, counter, withdrawals, amount); err != nil {
+		return fmt.Errorf("updating %s withdrawals: %w", period, err)
+	}
+	return nil
+}
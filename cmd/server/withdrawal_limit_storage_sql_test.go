@@ -0,0 +1,215 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	accounts "github.com/moov-io/accounts/client"
+	"github.com/moov-io/accounts/cmd/server/database"
+	"github.com/moov-io/base"
+
+	"github.com/go-kit/kit/log"
+)
+
+func TestSqlTransactionRepository__withdrawalLimits(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+		productRepo := setupSqlProductStorage(log.NewNopLogger(), repo.db)
+
+		now := time.Now()
+		product := accountProduct{
+			Code:              "savings",
+			Name:              "Savings",
+			WithdrawalLimit:   6,
+			DailyDebitLimit:   50000,
+			MonthlyDebitLimit: 60000,
+			PurposeLimits:     []purposeLimit{{Purpose: Wire, MonthlyAmount: 20000}},
+			CreatedAt:         now,
+			LastModified:      now,
+		}
+		if err := productRepo.saveAccountProduct(product); err != nil {
+			t.Fatal(err)
+		}
+		if found, err := productRepo.getAccountProduct("savings"); err != nil || len(found.PurposeLimits) != 1 || found.PurposeLimits[0].MonthlyAmount != 20000 || found.DailyDebitLimit != 50000 {
+			t.Fatalf("product=%#v error=%v", found, err)
+		}
+
+		account := &accounts.Account{ID: base.ID(), Status: "open", RoutingNumber: defaultRoutingNumber, Type: "Savings"}
+		repo.accountRepo = &testAccountRepository{accounts: []*accounts.Account{account}}
+		createTestGLAccount(t, repo.db, defaultRoutingNumber, createGLAccountRequest{Code: "0010", Name: "Cash", Category: GLAsset})
+		cash := glAccountID(defaultRoutingNumber, "0010")
+		post := func(purpose TransactionPurpose, direction LineDirection, amount int64, effective time.Time) error {
+			return repo.createTransaction(transaction{
+				ID:            base.ID(),
+				Timestamp:     time.Now(),
+				EffectiveDate: effective,
+				Lines: []transactionLine{
+					{AccountID: account.ID, Purpose: purpose, Direction: direction, Amount: amount},
+					{AccountID: cash, Purpose: purpose, Direction: direction.opposite(), Amount: amount},
+				},
+			}, createTransactionOpts{AllowGLDebits: true})
+		}
+		if err := post(ACHCredit, Credit, 500000, now); err != nil {
+			t.Fatal(err)
+		}
+
+		// Yesterday's debits count towards the month, but not today
+		yesterday := now.AddDate(0, 0, -1)
+		if monthlyWithdrawalPeriod(yesterday) != monthlyWithdrawalPeriod(now) {
+			yesterday = now.Add(-1 * time.Minute)
+		}
+		if err := post(Wire, Debit, 15000, yesterday); err != nil {
+			t.Fatal(err)
+		}
+		if err := post(Wire, Debit, 6000, now); readProductLimitCode(err) != PurposeLimitExceeded {
+			t.Errorf("expected %s: %v", PurposeLimitExceeded, err)
+		}
+		if err := post(Transfer, Debit, 40000, now); err != nil {
+			t.Fatal(err)
+		}
+		if err := post(Transfer, Debit, 10000, now); readProductLimitCode(err) != MonthlyDebitLimitExceeded && readProductLimitCode(err) != DailyDebitLimitExceeded {
+			t.Errorf("expected a debit limit: %v", err)
+		}
+
+		tx, err := repo.db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tx.Rollback()
+
+		monthly, err := readWithdrawalCounter(tx, account.ID, monthlyWithdrawalPeriod(now), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if monthly.Withdrawals != 2 || monthly.Amount != 55000 || monthly.version == 0 {
+			t.Errorf("unexpected counter: %#v", monthly)
+		}
+		wires, err := readWithdrawalCounter(tx, account.ID, monthlyWithdrawalPeriod(now), Wire)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wires.Withdrawals != 1 || wires.Amount != 15000 {
+			t.Errorf("unexpected counter: %#v", wires)
+		}
+
+		// Counters modified after they're read are retried
+		monthly.version--
+		if err := addToWithdrawalCounter(tx, monthly, 100); err != errWithdrawalCounterConflict {
+			t.Errorf("expected conflict: %v", err)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
+
+func TestSqlTransactionRepository__withdrawalLimitsReversalsAndHolds(t *testing.T) {
+	t.Parallel()
+
+	check := func(t *testing.T, repo *sqlTransactionRepository) {
+		defer repo.Close()
+		holdRepo := setupSqlHoldStorage(log.NewNopLogger(), repo)
+
+		now := time.Now()
+		product := accountProduct{Code: "savings", Name: "Savings", WithdrawalLimit: 2, CreatedAt: now, LastModified: now}
+		if err := setupSqlProductStorage(log.NewNopLogger(), repo.db).saveAccountProduct(product); err != nil {
+			t.Fatal(err)
+		}
+
+		savings := &accounts.Account{ID: base.ID(), Status: "open", RoutingNumber: defaultRoutingNumber, Type: "Savings"}
+		checking := &accounts.Account{ID: base.ID(), Status: "open", RoutingNumber: defaultRoutingNumber, Type: "Checking"}
+		repo.accountRepo = &testAccountRepository{accounts: []*accounts.Account{savings, checking}}
+		createTestGLAccount(t, repo.db, defaultRoutingNumber, createGLAccountRequest{Code: "0010", Name: "Cash", Category: GLAsset})
+
+		deposit := transaction{
+			ID:        base.ID(),
+			Timestamp: now,
+			Lines: []transactionLine{
+				{AccountID: savings.ID, Purpose: ACHCredit, Direction: Credit, Amount: 100000},
+				{AccountID: glAccountID(defaultRoutingNumber, "0010"), Purpose: ACHCredit, Direction: Debit, Amount: 100000},
+			},
+		}
+		if err := repo.createTransaction(deposit, createTransactionOpts{AllowGLDebits: true}); err != nil {
+			t.Fatal(err)
+		}
+		transfer := func() (transaction, error) {
+			tx := transaction{
+				ID:        base.ID(),
+				Timestamp: time.Now(),
+				Lines: []transactionLine{
+					{AccountID: savings.ID, Purpose: Transfer, Direction: Debit, Amount: 1000},
+					{AccountID: checking.ID, Purpose: Transfer, Direction: Credit, Amount: 1000},
+				},
+			}
+			return tx, repo.createTransaction(tx, createTransactionOpts{})
+		}
+		counter := func() withdrawalCounter {
+			tx, err := repo.db.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+			counter, err := readWithdrawalCounter(tx, savings.ID, monthlyWithdrawalPeriod(now), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			return counter
+		}
+
+		// Partially reversed debits keep their withdrawal, but not the reversed amount
+		first, err := transfer()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.reverseTransaction(first.ID, reversalRequest{Amount: 400}, createTransactionOpts{}); err != nil {
+			t.Fatal(err)
+		}
+		if c := counter(); c.Withdrawals != 1 || c.Amount != 600 {
+			t.Errorf("unexpected counter after partial reversal: %#v", c)
+		}
+		if _, err := repo.reverseTransaction(first.ID, reversalRequest{}, createTransactionOpts{}); err != nil {
+			t.Fatal(err)
+		}
+		if c := counter(); c.Withdrawals != 0 || c.Amount != 0 {
+			t.Errorf("unexpected counter after reversal: %#v", c)
+		}
+
+		// Both withdrawals are allowed since the first was reversed
+		for i := 0; i < 2; i++ {
+			if _, err := transfer(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Capturing a hold is a withdrawal too
+		h := hold{ID: base.ID(), AccountID: savings.ID, CreditAccountID: checking.ID, Purpose: Transfer, Amount: 500, Status: HoldPending, ExpiresAt: now.Add(time.Hour), CreatedAt: now, LastModified: now}
+		if err := holdRepo.placeHold(h, createTransactionOpts{}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := holdRepo.captureHold(h.ID, 0); readProductLimitCode(err) != WithdrawalCountExceeded {
+			t.Errorf("expected %s: %v", WithdrawalCountExceeded, err)
+		}
+		if found, err := holdRepo.getHold(h.ID); err != nil || found.Status != HoldPending || found.CapturedAmount != 0 {
+			t.Errorf("hold=%#v error=%v", found, err)
+		}
+	}
+
+	sqliteDB := database.CreateTestSqliteDB(t)
+	defer sqliteDB.Close()
+	check(t, createTestSqlTransactionRepository(t, sqliteDB.DB))
+
+	mysqlDB := database.CreateTestMySQLDB(t)
+	defer mysqlDB.Close()
+	check(t, createTestSqlTransactionRepository(t, mysqlDB.DB))
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ProductLimitCode identifies which rule of an account product rejected a transaction
+type ProductLimitCode string
+
+var (
+	PurposeNotAllowed         ProductLimitCode = "purpose_not_allowed"
+	WithdrawalCountExceeded   ProductLimitCode = "withdrawal_count_exceeded"
+	DailyDebitLimitExceeded   ProductLimitCode = "daily_debit_limit_exceeded"
+	MonthlyDebitLimitExceeded ProductLimitCode = "monthly_debit_limit_exceeded"
+	PurposeLimitExceeded      ProductLimitCode = "purpose_limit_exceeded"
+)
+
+// productLimitCodes maps the errors returned when posting breaks an account product's rules to their code
+var productLimitCodes = []struct {
+	err  error
+	code ProductLimitCode
+}{
+	{errPurposeNotAllowed, PurposeNotAllowed},
+	{errWithdrawalLimitExceeded, WithdrawalCountExceeded},
+	{errDailyDebitLimitExceeded, DailyDebitLimitExceeded},
+	{errMonthlyDebitLimitExceeded, MonthlyDebitLimitExceeded},
+	{errPurposeLimitExceeded, PurposeLimitExceeded},
+}
+
+// readProductLimitCode returns the code of the account product rule which err is from, or an empty string
+// if err isn't from one.
+func readProductLimitCode(err error) ProductLimitCode {
+	if err == nil {
+		return ""
+	}
+	for i := range productLimitCodes {
+		if errors.Is(err, productLimitCodes[i].err) {
+			return productLimitCodes[i].code
+		}
+	}
+	return ""
+}
+
+// writeProductLimitProblem writes err to w as a '400 Bad Request' response in the same format as moovhttp.Problem
+// with the code of the account product rule the transaction broke.
+func writeProductLimitProblem(w http.ResponseWriter, code ProductLimitCode, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": err.Error(),
+		"code":  code,
+	})
+}
+
+// purposeLimit caps how much lines of Purpose can debit an account each statement cycle (calendar month)
+type purposeLimit struct {
+	Purpose       TransactionPurpose `json:"purpose"`
+	MonthlyAmount int64              `json:"monthlyAmount"`
+}
+
+// withdrawal is what one transaction debits from an account. Fees aren't withdrawals.
+type withdrawal struct {
+	Amount   int64
+	Purposes map[TransactionPurpose]int64
+}
+
+func readWithdrawal(accountID string, lines []transactionLine) withdrawal {
+	w := withdrawal{Purposes: make(map[TransactionPurpose]int64)}
+	for i := range lines {
+		if lines[i].AccountID != accountID || !lines[i].isDebit() || lines[i].Purpose == Fee {
+			continue
+		}
+		w.Amount += lines[i].Amount
+		w.Purposes[lines[i].Purpose] += lines[i].Amount
+	}
+	return w
+}
+
+// withdrawalCounter is how many withdrawals debited an account in a period and their total. Periods are a statement
+// cycle (YYYY-MM) or day (YYYY-MM-DD) and counters for one Purpose only include the amount of its lines.
+type withdrawalCounter struct {
+	AccountID   string
+	Period      string
+	Purpose     TransactionPurpose
+	Withdrawals int
+	Amount      int64
+
+	version int64
+}
+
+func monthlyWithdrawalPeriod(when time.Time) string {
+	return when.UTC().Format("2006-01")
+}
+
+func dailyWithdrawalPeriod(when time.Time) string {
+	return when.UTC().Format("2006-01-02")
+}
+
+// checkWithdrawal returns an error if adding w to the monthly, daily and per-purpose monthly counters breaks one of
+// the product's limits. Limits of zero are unlimited.
+func (p accountProduct) checkWithdrawal(w withdrawal, monthly, daily withdrawalCounter, purposes map[TransactionPurpose]withdrawalCounter) error {
+	if p.WithdrawalLimit > 0 && monthly.Withdrawals+1 > p.WithdrawalLimit {
+		return fmt.Errorf("%w of %d per month", errWithdrawalLimitExceeded, p.WithdrawalLimit)
+	}
+	if p.DailyDebitLimit > 0 && daily.Amount+w.Amount > p.DailyDebitLimit {
+		return fmt.Errorf("%w of %d", errDailyDebitLimitExceeded, p.DailyDebitLimit)
+	}
+	if p.MonthlyDebitLimit > 0 && monthly.Amount+w.Amount > p.MonthlyDebitLimit {
+		return fmt.Errorf("%w of %d", errMonthlyDebitLimitExceeded, p.MonthlyDebitLimit)
+	}
+	for _, limit := range p.PurposeLimits {
+		amount, ok := w.Purposes[limit.Purpose]
+		if !ok {
+			continue
+		}
+		if purposes[limit.Purpose].Amount+amount > limit.MonthlyAmount {
+			return fmt.Errorf("%w of %d for %s", errPurposeLimitExceeded, limit.MonthlyAmount, limit.Purpose)
+		}
+	}
+	return nil
+}
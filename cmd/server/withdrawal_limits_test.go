@@ -0,0 +1,103 @@
+// Copyright 2020 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithdrawalLimits__readWithdrawal(t *testing.T) {
+	w := readWithdrawal("a", []transactionLine{
+		{AccountID: "a", Purpose: Wire, Direction: Debit, Amount: 2500},
+		{AccountID: "a", Purpose: Fee, Direction: Debit, Amount: 100},
+		{AccountID: "a", Purpose: Transfer, Direction: Credit, Amount: 1000},
+		{AccountID: "a", Purpose: Transfer, Direction: Debit, Amount: 500},
+		{AccountID: "b", Purpose: Wire, Direction: Debit, Amount: 9000},
+	})
+	if w.Amount != 3000 || w.Purposes[Wire] != 2500 || w.Purposes[Transfer] != 500 || len(w.Purposes) != 2 {
+		t.Errorf("unexpected withdrawal: %#v", w)
+	}
+	if w := readWithdrawal("c", nil); w.Amount != 0 {
+		t.Errorf("unexpected withdrawal: %#v", w)
+	}
+}
+
+func TestWithdrawalLimits__checkWithdrawal(t *testing.T) {
+	product := accountProduct{
+		WithdrawalLimit:   6,
+		DailyDebitLimit:   50000,
+		MonthlyDebitLimit: 200000,
+		PurposeLimits:     []purposeLimit{{Purpose: Wire, MonthlyAmount: 100000}},
+	}
+	w := withdrawal{Amount: 10000, Purposes: map[TransactionPurpose]int64{Wire: 10000}}
+
+	if err := product.checkWithdrawal(w, withdrawalCounter{Withdrawals: 5}, withdrawalCounter{}, nil); err != nil {
+		t.Error(err)
+	}
+	if err := (accountProduct{}).checkWithdrawal(w, withdrawalCounter{Withdrawals: 500, Amount: 1e9}, withdrawalCounter{Amount: 1e9}, nil); err != nil {
+		t.Errorf("products without limits: %v", err)
+	}
+
+	cases := map[ProductLimitCode]error{
+		WithdrawalCountExceeded:   product.checkWithdrawal(w, withdrawalCounter{Withdrawals: 6}, withdrawalCounter{}, nil),
+		DailyDebitLimitExceeded:   product.checkWithdrawal(w, withdrawalCounter{}, withdrawalCounter{Amount: 45000}, nil),
+		MonthlyDebitLimitExceeded: product.checkWithdrawal(w, withdrawalCounter{Amount: 190001}, withdrawalCounter{}, nil),
+		PurposeLimitExceeded: product.checkWithdrawal(w, withdrawalCounter{}, withdrawalCounter{}, map[TransactionPurpose]withdrawalCounter{
+			Wire: {Amount: 95000},
+		}),
+	}
+	for code, err := range cases {
+		if readProductLimitCode(err) != code {
+			t.Errorf("%s: unexpected error: %v", code, err)
+		}
+	}
+}
+
+func TestWithdrawalLimits__readProductLimitCode(t *testing.T) {
+	if code := readProductLimitCode(nil); code != "" {
+		t.Errorf("unexpected code: %q", code)
+	}
+	if code := readProductLimitCode(errors.New("other")); code != "" {
+		t.Errorf("unexpected code: %q", code)
+	}
+	err := fmt.Errorf("createTransaction: transaction=\"a\": account=\"b\" %w wire rollback=<nil>", errPurposeNotAllowed)
+	if code := readProductLimitCode(err); code != PurposeNotAllowed {
+		t.Errorf("unexpected code: %q", code)
+	}
+	if code := readProductLimitCode(errors.New(err.Error())); code != "" {
+		t.Errorf("errors with the same message aren't product limit errors: %q", code)
+	}
+
+	w := httptest.NewRecorder()
+	writeProductLimitProblem(w, DailyDebitLimitExceeded, errDailyDebitLimitExceeded)
+	w.Flush()
+
+	var problem struct {
+		Error string           `json:"error"`
+		Code  ProductLimitCode `json:"code"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || problem.Code != DailyDebitLimitExceeded || problem.Error == "" {
+		t.Errorf("unexpected problem: %d: %#v", w.Code, problem)
+	}
+}
+
+func TestWithdrawalLimits__periods(t *testing.T) {
+	when := time.Date(2020, time.March, 31, 23, 30, 0, 0, time.FixedZone("CST", -6*60*60))
+	if p := monthlyWithdrawalPeriod(when); p != "2020-04" {
+		t.Errorf("unexpected period: %s", p)
+	}
+	if p := dailyWithdrawalPeriod(when); p != "2020-04-01" {
+		t.Errorf("unexpected period: %s", p)
+	}
+}